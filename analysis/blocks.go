@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"slices"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Block is a set of delivery hours prices are averaged over, e.g. the peak hours of
+// working days. Blocks are defined in Spanish wall-clock time, in which OMIE numbers
+// the hours of both countries, so they follow DST changes.
+type Block struct {
+	Name string
+
+	// Contains reports whether the hour or quarter-hour starting at start, in
+	// Europe/Madrid, belongs to the block
+	Contains func(start time.Time) bool
+}
+
+// Standard blocks of the Iberian market, as traded on OMIP. Public holidays aren't
+// taken into account; define a custom block to leave them out of Peak.
+var (
+	// Base is every hour of every day
+	Base = Block{Name: "base", Contains: func(time.Time) bool { return true }}
+
+	// Peak is hours 9 to 20 of Monday to Friday as OMIE numbers them, 08:00-20:00
+	Peak = NewBlock("peak", 8, 20, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+
+	// OffPeak is every hour outside Peak, weekends included
+	OffPeak = Block{Name: "offpeak", Contains: func(start time.Time) bool { return !Peak.Contains(start) }}
+
+	// Weekend is every hour of Saturdays and Sundays
+	Weekend = NewBlock("weekend", 0, 24, time.Saturday, time.Sunday)
+)
+
+// NewBlock returns a block of the hours starting from the wall-clock hour from up to,
+// but excluding, to (0-24) on the given weekdays, or on every day when none is given.
+// from may be greater than to for blocks spanning midnight, e.g. 22 to 6.
+func NewBlock(name string, from, to int, weekdays ...time.Weekday) Block {
+	return Block{
+		Name: name,
+		Contains: func(start time.Time) bool {
+			if len(weekdays) > 0 && !slices.Contains(weekdays, start.Weekday()) {
+				return false
+			}
+			hour := start.Hour()
+			if from <= to {
+				return hour >= from && hour < to
+			}
+			return hour >= from || hour < to
+		},
+	}
+}
+
+// BlockPrice is the average price of a block over a day or a month
+type BlockPrice struct {
+	Block  string
+	Period time.Time // The day, or the first day of the month
+	Price  float64   // Time-weighted average price, EUR/MWh
+	Hours  float64   // Hours of the block with a price
+}
+
+// DailyBlockPrices returns the average price of every block on every day, in the order
+// of days and then of blocks. Prices are Portugal's for types.Portugal and Spain's
+// otherwise. Blocks without any price on a day are left out.
+func DailyBlockPrices(days []*types.MarginalPriceData, system types.SystemType, blocks ...Block) []BlockPrice {
+	var prices []BlockPrice
+	for _, day := range days {
+		prices = appendBlockPrices(prices, []*types.MarginalPriceData{day}, day.Date, system, blocks)
+	}
+	return prices
+}
+
+// MonthlyBlockPrices returns the average price of every block in every month the days
+// fall in, in the order of months and then of blocks. Averages are taken over every
+// hour of the month in the block, not over the daily averages, weighting quarter-hour
+// prices by their length so months crossing a change of resolution average correctly.
+func MonthlyBlockPrices(days []*types.MarginalPriceData, system types.SystemType, blocks ...Block) []BlockPrice {
+	order, months := groupByMonth(days)
+	var prices []BlockPrice
+	for _, month := range order {
+		prices = appendBlockPrices(prices, months[month], month, system, blocks)
+	}
+	return prices
+}
+
+// groupByMonth groups days by the first day of their month, returning the months in
+// ascending order
+func groupByMonth(days []*types.MarginalPriceData) ([]time.Time, map[time.Time][]*types.MarginalPriceData) {
+	months := make(map[time.Time][]*types.MarginalPriceData)
+	var order []time.Time
+	for _, day := range days {
+		month := time.Date(day.Date.Year(), day.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if _, ok := months[month]; !ok {
+			order = append(order, month)
+		}
+		months[month] = append(months[month], day)
+	}
+	slices.SortFunc(order, func(a, b time.Time) int { return a.Compare(b) })
+	return order, months
+}
+
+// appendBlockPrices appends the average price of every block over days to prices,
+// reported for period
+func appendBlockPrices(prices []BlockPrice, days []*types.MarginalPriceData, period time.Time, system types.SystemType, blocks []Block) []BlockPrice {
+	for _, block := range blocks {
+		var sum float64
+		var length time.Duration
+		for _, day := range days {
+			slot := day.Resolution.Duration()
+			systemPrices(day, system).ForEachHour(func(index int, price float64) {
+				if block.Contains(day.TimeForHour(index)) {
+					sum += price * slot.Hours()
+					length += slot
+				}
+			})
+		}
+		if length > 0 {
+			prices = append(prices, BlockPrice{Block: block.Name, Period: period, Price: sum / length.Hours(), Hours: length.Hours()})
+		}
+	}
+	return prices
+}
+
+// systemPrices returns the marginal prices of system: Portugal's for types.Portugal and
+// Spain's otherwise
+func systemPrices(day *types.MarginalPriceData, system types.SystemType) types.HourlyValues {
+	if system == types.Portugal {
+		return day.PortugalPrices
+	}
+	return day.SpainPrices
+}
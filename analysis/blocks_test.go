@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// pricesDay returns a day of Spanish prices set by price from the hour or period index
+func pricesDay(date time.Time, resolution types.Resolution, price func(index int) float64) *types.MarginalPriceData {
+	day := types.NewMarginalPriceData(date)
+	day.Resolution = resolution
+	slots := types.HoursInDay(date)
+	if resolution == types.QuarterHourly {
+		slots *= types.QuartersPerHour
+	}
+	for index := 1; index <= slots; index++ {
+		day.SpainPrices[index] = price(index)
+	}
+	return day
+}
+
+func TestDailyBlockPrices(t *testing.T) {
+	monday := pricesDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), types.Hourly, func(hour int) float64 { return float64(hour) })
+	saturday := pricesDay(time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 50 })
+	night := NewBlock("night", 22, 6)
+
+	prices := DailyBlockPrices([]*types.MarginalPriceData{monday, saturday}, types.Spain, Peak, OffPeak, Weekend, night)
+
+	want := []BlockPrice{
+		{Block: "peak", Period: monday.Date, Price: 14.5, Hours: 12},    // Hours 9-20
+		{Block: "offpeak", Period: monday.Date, Price: 10.5, Hours: 12}, // Hours 1-8 and 21-24
+		{Block: "night", Period: monday.Date, Price: 8.5, Hours: 8},     // Hours 1-6 and 23-24
+		{Block: "offpeak", Period: saturday.Date, Price: 50, Hours: 24},
+		{Block: "weekend", Period: saturday.Date, Price: 50, Hours: 24},
+		{Block: "night", Period: saturday.Date, Price: 50, Hours: 8},
+	}
+	if len(prices) != len(want) {
+		t.Fatalf("Expected %d block prices, got %+v", len(want), prices)
+	}
+	for i := range want {
+		if prices[i] != want[i] {
+			t.Errorf("Block price %d = %+v, want %+v", i, prices[i], want[i])
+		}
+	}
+}
+
+func TestMonthlyBlockPrices(t *testing.T) {
+	// Quarter-hour prices weigh a quarter of hourly ones, so both days weigh the same
+	days := []*types.MarginalPriceData{
+		pricesDay(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 40 }),
+		pricesDay(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), types.QuarterHourly, func(int) float64 { return 20 }),
+		pricesDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 10 }),
+	}
+
+	prices := MonthlyBlockPrices(days, types.Spain, Base)
+	if len(prices) != 2 {
+		t.Fatalf("Expected a price per month, got %+v", prices)
+	}
+	january := BlockPrice{Block: "base", Period: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Price: 15, Hours: 48}
+	if prices[0] != january {
+		t.Errorf("January = %+v, want %+v", prices[0], january)
+	}
+	if prices[1].Price != 40 || prices[1].Hours != 24 {
+		t.Errorf("Unexpected February price %+v", prices[1])
+	}
+}
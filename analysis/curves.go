@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Interpolation is how a resampled curve is read between the prices of its offers
+type Interpolation int
+
+const (
+	// Step reads the curve as the market clears it: the cumulative energy only changes
+	// at the price of an offer
+	Step Interpolation = iota
+
+	// Linear joins the offers of consecutive prices with straight lines, which reads
+	// better in charts of curves with few offers
+	Linear
+)
+
+// CurveSample is a point of a curve resampled onto a grid
+type CurveSample struct {
+	Price  float64 // EUR/MWh
+	Energy float64 // Cumulative energy offered at this price or better, MWh
+}
+
+// Grid returns the values from from to to, both included, every step, e.g. a price
+// grid of €1/MWh steps with Grid(0, 180, 1). It returns nil unless step is positive.
+func Grid(from, to, step float64) []float64 {
+	if step <= 0 || to < from {
+		return nil
+	}
+	n := int(math.Floor((to-from)/step+1e-9)) + 1
+	grid := make([]float64, n)
+	for i := range grid {
+		grid[i] = from + float64(i)*step
+	}
+	return grid
+}
+
+// ResampleByPrice returns the cumulative energy of a side of the curve at every price
+// of the grid: the energy offered at that price or cheaper for types.Sell, the supply
+// curve, and at that price or dearer for types.Buy, the demand curve. Every point of
+// the side is used; filter the curve first, e.g. with MatchedOnly, to resample part of
+// it. Day-over-day curves resampled onto the same grid can be compared point by point.
+func ResampleByPrice(curve types.MarketCurve, side types.OfferType, prices []float64, interpolation Interpolation) []CurveSample {
+	steps := cumulativeSteps(curve, side)
+	samples := make([]CurveSample, len(prices))
+	for i, price := range prices {
+		samples[i] = CurveSample{Price: price, Energy: steps.energyAt(price, interpolation)}
+	}
+	return samples
+}
+
+// ResampleByVolume returns the price a side of the curve reaches at every cumulative
+// energy of the grid, see ResampleByPrice. Energies beyond the whole side have a NaN
+// price.
+func ResampleByVolume(curve types.MarketCurve, side types.OfferType, energies []float64, interpolation Interpolation) []CurveSample {
+	steps := cumulativeSteps(curve, side)
+	samples := make([]CurveSample, len(energies))
+	for i, energy := range energies {
+		samples[i] = CurveSample{Price: steps.priceAt(energy, interpolation), Energy: energy}
+	}
+	return samples
+}
+
+// curveSteps is a side of a curve as the cumulative energy at every distinct price, in
+// merit order
+type curveSteps struct {
+	prices   []float64
+	energies []float64
+	sign     float64 // 1 for supply, -1 for demand, whose merit order is by falling price
+}
+
+// cumulativeSteps sorts a side of the curve in merit order and accumulates its energy
+func cumulativeSteps(curve types.MarketCurve, side types.OfferType) curveSteps {
+	steps := curveSteps{sign: 1}
+	points := curve.Supply
+	if side == types.Buy {
+		steps.sign, points = -1, curve.Demand
+	}
+
+	sorted := append([]types.MarketPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return steps.sign*sorted[i].Price < steps.sign*sorted[j].Price
+	})
+
+	total := 0.0
+	for _, point := range sorted {
+		total += point.Energy
+		if n := len(steps.prices); n > 0 && steps.prices[n-1] == point.Price {
+			steps.energies[n-1] = total
+			continue
+		}
+		steps.prices = append(steps.prices, point.Price)
+		steps.energies = append(steps.energies, total)
+	}
+	return steps
+}
+
+// energyAt returns the cumulative energy offered at price
+func (s curveSteps) energyAt(price float64, interpolation Interpolation) float64 {
+	// Number of steps at price or better
+	n := sort.Search(len(s.prices), func(i int) bool { return s.sign*s.prices[i] > s.sign*price })
+	switch {
+	case n == 0:
+		return 0
+	case n == len(s.prices) || interpolation == Step:
+		return s.energies[n-1]
+	}
+	fraction := (price - s.prices[n-1]) / (s.prices[n] - s.prices[n-1])
+	return s.energies[n-1] + fraction*(s.energies[n]-s.energies[n-1])
+}
+
+// priceAt returns the price at which the cumulative energy offered reaches energy, or
+// NaN when it never does
+func (s curveSteps) priceAt(energy float64, interpolation Interpolation) float64 {
+	n := sort.SearchFloat64s(s.energies, energy)
+	switch {
+	case n == len(s.energies):
+		return math.NaN()
+	case n == 0 || interpolation == Step:
+		return s.prices[n]
+	}
+	fraction := (energy - s.energies[n-1]) / (s.energies[n] - s.energies[n-1])
+	return s.prices[n-1] + fraction*(s.prices[n]-s.prices[n-1])
+}
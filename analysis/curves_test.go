@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestResampleByPrice(t *testing.T) {
+	curve := types.MarketCurve{
+		Hour: 1,
+		Supply: []types.MarketPoint{
+			{Energy: 300, Price: 60},
+			{Energy: 1000, Price: 0},
+			{Energy: 200, Price: 60},
+			{Energy: 500, Price: 40},
+		},
+		Demand: []types.MarketPoint{
+			{Energy: 800, Price: 0},
+			{Energy: 1200, Price: 180},
+		},
+	}
+
+	prices := Grid(0, 80, 20)
+	tests := []struct {
+		name          string
+		side          types.OfferType
+		interpolation Interpolation
+		want          []float64
+	}{
+		{"supply steps", types.Sell, Step, []float64{1000, 1000, 1500, 2000, 2000}},
+		{"supply linear", types.Sell, Linear, []float64{1000, 1250, 1500, 2000, 2000}},
+		{"demand steps", types.Buy, Step, []float64{2000, 1200, 1200, 1200, 1200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := ResampleByPrice(curve, tt.side, prices, tt.interpolation)
+			for i, sample := range samples {
+				if sample.Price != prices[i] || sample.Energy != tt.want[i] {
+					t.Errorf("Sample %d = %+v, want %v MWh at %v", i, sample, tt.want[i], prices[i])
+				}
+			}
+		})
+	}
+
+	volumes := ResampleByVolume(curve, types.Sell, []float64{500, 1250, 1750, 2500}, Step)
+	for i, want := range []float64{0, 40, 60} {
+		if volumes[i].Price != want {
+			t.Errorf("Price at %v MWh = %v, want %v", volumes[i].Energy, volumes[i].Price, want)
+		}
+	}
+	if !math.IsNaN(volumes[3].Price) {
+		t.Errorf("Expected no price beyond the whole supply, got %v", volumes[3].Price)
+	}
+	if linear := ResampleByVolume(curve, types.Sell, []float64{1250}, Linear); linear[0].Price != 20 {
+		t.Errorf("Expected 20 EUR/MWh halfway between the first two steps, got %v", linear[0].Price)
+	}
+}
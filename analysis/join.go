@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// JoinedHour is an hour of the technology mix of a system together with its marginal
+// price
+type JoinedHour struct {
+	types.TechnologyEnergy // Generation by technology, with the date, hour and system
+
+	Start time.Time // Instant the hour starts, in Europe/Madrid
+	Price float64   // Marginal price of the system, EUR/MWh
+	Total float64   // Energy over every technology, see TechnologyEnergy.Total, MWh
+}
+
+// Join pairs every hour of the technology mix with the marginal price of the same hour
+// and system: Portugal's for types.Portugal and Spain's otherwise. Both files number
+// hours from midnight in Spanish time, so hours are matched by index, which keeps the
+// 23 and 25 hours of DST days aligned. Quarter-hour prices are averaged into their
+// hour. Hours without a price, and prices without a mix, are left out; rows are in time
+// order, and by system within an hour.
+func Join(prices []*types.MarginalPriceData, mix []*types.TechnologyEnergyDay) []JoinedHour {
+	byDate := make(map[time.Time]*types.MarginalPriceData, len(prices))
+	for _, day := range prices {
+		byDate[dateOf(day.Date)] = day
+	}
+
+	var rows []JoinedHour
+	for _, day := range mix {
+		priceDay, ok := byDate[dateOf(day.Date)]
+		if !ok {
+			continue
+		}
+		for _, record := range day.Records {
+			price, ok := hourPrice(priceDay, day.System, record.Hour)
+			if !ok {
+				continue
+			}
+			rows = append(rows, JoinedHour{
+				TechnologyEnergy: record,
+				Start:            types.HourIndex(record.Hour).Start(record.Date),
+				Price:            price,
+				Total:            record.Total(),
+			})
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b JoinedHour) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.System, b.System)
+	})
+	return rows
+}
+
+// hourPrice returns the price of system in an hour of the day, averaging the periods of
+// quarter-hourly days. ok is false when the hour has no price.
+func hourPrice(day *types.MarginalPriceData, system types.SystemType, hour int) (price float64, ok bool) {
+	values := systemPrices(day, system)
+	if day.Resolution != types.QuarterHourly {
+		price, ok = values[hour]
+		return price, ok && !math.IsNaN(price)
+	}
+
+	sum, n := 0.0, 0
+	first := types.HourIndex(hour).FirstPeriod().Int()
+	for period := first; period < first+types.QuartersPerHour; period++ {
+		if value, ok := values[period]; ok && !math.IsNaN(value) {
+			sum += value
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// dateOf returns the calendar date of t as midnight UTC, like the dates of parsed files
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
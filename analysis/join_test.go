@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestJoin(t *testing.T) {
+	// DST ends on 2022-10-30, a 25-hour day, and the prices of the 31st are quarter-hourly
+	longDay := time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC)
+	nextDay := longDay.AddDate(0, 0, 1)
+	prices := []*types.MarginalPriceData{
+		pricesDay(nextDay, types.QuarterHourly, func(period int) float64 { return float64(period) }),
+		pricesDay(longDay, types.Hourly, func(hour int) float64 { return float64(100 + hour) }),
+	}
+	for _, day := range prices {
+		for index, price := range day.SpainPrices {
+			day.PortugalPrices[index] = price + 1
+		}
+	}
+
+	mixDay := func(date time.Time, system types.SystemType, hours int) *types.TechnologyEnergyDay {
+		day := &types.TechnologyEnergyDay{Date: date, System: system}
+		for hour := 1; hour <= hours; hour++ {
+			day.Records = append(day.Records, types.TechnologyEnergy{Date: date, Hour: hour, System: system, Wind: 100, Nuclear: 50})
+		}
+		return day
+	}
+	mix := []*types.TechnologyEnergyDay{
+		mixDay(nextDay, types.Spain, 1),
+		mixDay(longDay, types.Portugal, 25),
+		mixDay(longDay, types.Spain, 25),
+		mixDay(longDay.AddDate(0, 0, -1), types.Spain, 24), // No prices
+	}
+
+	rows := Join(prices, mix)
+	if len(rows) != 25*2+1 {
+		t.Fatalf("Expected 51 rows, got %d", len(rows))
+	}
+
+	// The two 02:00 hours of the long day, Spain first
+	third, fourth := rows[4], rows[6]
+	if third.Hour != 3 || third.System != types.Spain || third.Price != 103 || fourth.Hour != 4 || fourth.Price != 104 {
+		t.Errorf("Unexpected rows for hours 3 and 4: %+v, %+v", third, fourth)
+	}
+	if third.Start.Equal(fourth.Start) || fourth.Start.Sub(third.Start) != time.Hour {
+		t.Errorf("Expected hours 3 and 4 an hour apart, got %s and %s", third.Start, fourth.Start)
+	}
+	if portugal := rows[5]; portugal.System != types.Portugal || portugal.Price != 104 || portugal.Total != 150 || portugal.Wind != 100 {
+		t.Errorf("Unexpected Portugal row %+v", portugal)
+	}
+	if last := rows[50]; !last.Date.Equal(nextDay) || last.Price != 2.5 {
+		t.Errorf("Expected the quarter-hour prices of hour 1 to be averaged, got %+v", last)
+	}
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Point is a value of a time series of hours or quarter-hour periods
+type Point struct {
+	Start  time.Time     // Instant the hour or period starts, in Europe/Madrid
+	Length time.Duration // An hour, or 15 minutes for quarter-hour periods
+	Value  float64
+}
+
+// PriceSeries assembles the prices of system over days into a single series in time
+// order, Portugal's for types.Portugal and Spain's otherwise. Days may be in any order
+// and of either resolution; missing prices are left out of the series.
+func PriceSeries(days []*types.MarginalPriceData, system types.SystemType) []Point {
+	var series []Point
+	for _, day := range days {
+		length := day.Resolution.Duration()
+		systemPrices(day, system).ForEachHour(func(index int, price float64) {
+			if !math.IsNaN(price) {
+				series = append(series, Point{Start: day.TimeForHour(index), Length: length, Value: price})
+			}
+		})
+	}
+	slices.SortStableFunc(series, func(a, b Point) int { return a.Start.Compare(b.Start) })
+	return series
+}
+
+// Window is the span a rolling statistic looks back over, see HoursWindow and
+// DaysWindow
+type Window struct {
+	hours int
+	days  int
+}
+
+// HoursWindow returns a window of the last n hours, whatever the length of the days
+func HoursWindow(n int) Window {
+	return Window{hours: n}
+}
+
+// DaysWindow returns a window of the last n market days, from the same wall-clock time
+// n days before, so it is an hour shorter or longer when it spans a DST change
+func DaysWindow(n int) Window {
+	return Window{days: n}
+}
+
+// start returns the instant the window ending at end starts
+func (w Window) start(end time.Time) time.Time {
+	if w.days > 0 {
+		return end.In(types.Spain.Location()).AddDate(0, 0, -w.days)
+	}
+	return end.Add(-time.Duration(w.hours) * time.Hour)
+}
+
+// SMA returns the simple moving average of the series over the window ending with
+// every point, weighting quarter-hour periods by their length. The window of a point
+// covers the hours before it ends; points whose window starts before the series are
+// NaN. Gaps in the series are skipped, so the average is over the points present.
+func SMA(series []Point, window Window) []Point {
+	averages := make([]Point, len(series))
+	var sum float64
+	var length time.Duration
+	first := 0 // First point of the current window
+	for i, point := range series {
+		sum += point.Value * point.Length.Hours()
+		length += point.Length
+
+		end := point.Start.Add(point.Length)
+		from := window.start(end)
+		for ; first < i && series[first].Start.Before(from); first++ {
+			sum -= series[first].Value * series[first].Length.Hours()
+			length -= series[first].Length
+		}
+
+		averages[i] = Point{Start: point.Start, Length: point.Length, Value: math.NaN()}
+		if !series[0].Start.After(from) && length > 0 {
+			averages[i].Value = sum / length.Hours()
+		}
+	}
+	return averages
+}
+
+// EMA returns the exponential moving average of the series, with the weight of every
+// value decaying as exp(-2·age/window), the continuous form of the usual 2/(N+1)
+// smoothing of an N-point window. Decaying by time rather than by point, it handles
+// gaps, DST days and quarter-hour periods. The average starts at the first value.
+func EMA(series []Point, window Window) []Point {
+	averages := make([]Point, len(series))
+	for i, point := range series {
+		averages[i] = point
+		if i == 0 {
+			continue
+		}
+
+		end := point.Start.Add(point.Length)
+		span := end.Sub(window.start(end))
+		elapsed := end.Sub(series[i-1].Start.Add(series[i-1].Length))
+		alpha := 1 - math.Exp(-2*elapsed.Hours()/span.Hours())
+		averages[i].Value = averages[i-1].Value + alpha*(point.Value-averages[i-1].Value)
+	}
+	return averages
+}
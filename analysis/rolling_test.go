@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestSMA(t *testing.T) {
+	// DST starts on 2024-03-31, a 23-hour day
+	var days []*types.MarginalPriceData
+	for i, price := range []float64{10, 20, 30} {
+		days = append(days, pricesDay(time.Date(2024, 3, 30+i, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return price }))
+	}
+	series := PriceSeries(days, types.Spain)
+	if len(series) != 24+23+24 {
+		t.Fatalf("Expected 71 hours, got %d", len(series))
+	}
+
+	daily := SMA(series, DaysWindow(1))
+	if !math.IsNaN(daily[22].Value) || daily[23].Value != 10 {
+		t.Errorf("Expected the first full window to end with the first day, got %v and %v", daily[22].Value, daily[23].Value)
+	}
+	if last := daily[24+22]; last.Value != 20 {
+		t.Errorf("Expected the window of the DST day to cover its 23 hours only, got %v", last.Value)
+	}
+
+	hourly := SMA(series, HoursWindow(24))
+	if want := (23*20 + 10) / 24.0; math.Abs(hourly[24+22].Value-want) > 1e-9 {
+		t.Errorf("Expected 24 hours to reach back into the first day, got %v, want %v", hourly[24+22].Value, want)
+	}
+}
+
+func TestEMA(t *testing.T) {
+	var days []*types.MarginalPriceData
+	for i, price := range []float64{10, 10, 40} {
+		days = append(days, pricesDay(time.Date(2024, 1, 15+i, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return price }))
+	}
+	// Quarter-hour periods decay like the hours they make up
+	quarters := pricesDay(time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC), types.QuarterHourly, func(int) float64 { return 40 })
+
+	hourly := EMA(PriceSeries(days, types.Spain), HoursWindow(24))
+	quarterly := EMA(PriceSeries(append(days[:2:2], quarters), types.Spain), HoursWindow(24))
+
+	if hourly[47].Value != 10 {
+		t.Errorf("Expected a constant series to average to itself, got %v", hourly[47].Value)
+	}
+	last := hourly[len(hourly)-1].Value
+	if last <= 35 || last >= 40 {
+		t.Errorf("Expected the average to approach 40 after a day, got %v", last)
+	}
+	if got := quarterly[len(quarterly)-1].Value; math.Abs(got-last) > 1e-9 {
+		t.Errorf("Expected quarter-hour periods to decay like hours, got %v, want %v", got, last)
+	}
+}
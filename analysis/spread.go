@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// splitTolerance is the spread below which the markets are considered coupled. Prices
+// are published with two decimals, so anything under half a cent is rounding.
+const splitTolerance = 0.005
+
+// Spread is the Spain-Portugal price difference of an hour or quarter-hour period
+type Spread struct {
+	Date     time.Time
+	Index    int       // Hour, or quarter-hour period for quarter-hourly days
+	Start    time.Time // Instant the hour or period starts, in Europe/Madrid
+	Spain    float64   // EUR/MWh
+	Portugal float64   // EUR/MWh
+	Spread   float64   // Spain minus Portugal, EUR/MWh
+
+	// Split is set when the markets decoupled, i.e. the prices differ because the
+	// interconnection was congested
+	Split bool
+}
+
+// Spreads returns the spread of every hour, or period, of the day with a price in both
+// countries, in order
+func Spreads(day *types.MarginalPriceData) []Spread {
+	var spreads []Spread
+	day.SpainPrices.ForEachHour(func(index int, spain float64) {
+		portugal, ok := day.PortugalPrices[index]
+		if !ok || math.IsNaN(spain) || math.IsNaN(portugal) {
+			return
+		}
+		spread := spain - portugal
+		spreads = append(spreads, Spread{
+			Date:     day.Date,
+			Index:    index,
+			Start:    day.TimeForHour(index),
+			Spain:    spain,
+			Portugal: portugal,
+			Spread:   spread,
+			Split:    math.Abs(spread) >= splitTolerance,
+		})
+	})
+	return spreads
+}
+
+// SplitStats summarizes the market splitting between Spain and Portugal over a day or a
+// month. Averages are weighted by time, so quarter-hour periods count for a quarter of
+// an hour.
+type SplitStats struct {
+	Period     time.Time // The day, or the first day of the month
+	Hours      float64   // Hours with a price in both countries
+	SplitHours float64   // Hours the markets were decoupled
+
+	MeanSpread    float64 // Average spread, Spain minus Portugal, EUR/MWh
+	MeanAbsSpread float64 // Average absolute spread, EUR/MWh
+	MaxAbsSpread  float64 // Largest absolute spread, EUR/MWh
+}
+
+// SplitShare returns the share (0-1) of the hours the markets were decoupled, or NaN
+// when there are no hours
+func (s SplitStats) SplitShare() float64 {
+	if s.Hours == 0 {
+		return math.NaN()
+	}
+	return s.SplitHours / s.Hours
+}
+
+// DailySplitting returns the splitting statistics of every day with prices in both
+// countries, in the order of days
+func DailySplitting(days []*types.MarginalPriceData) []SplitStats {
+	var stats []SplitStats
+	for _, day := range days {
+		if s, ok := splitStats([]*types.MarginalPriceData{day}, day.Date); ok {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
+// MonthlySplitting returns the splitting statistics of every month the days fall in, in
+// ascending order
+func MonthlySplitting(days []*types.MarginalPriceData) []SplitStats {
+	order, months := groupByMonth(days)
+	var stats []SplitStats
+	for _, month := range order {
+		if s, ok := splitStats(months[month], month); ok {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
+// splitStats summarizes the splitting over days, reported for period. ok is false when
+// no hour has a price in both countries.
+func splitStats(days []*types.MarginalPriceData, period time.Time) (stats SplitStats, ok bool) {
+	stats.Period = period
+	var sum, absSum float64
+	for _, day := range days {
+		hours := day.Resolution.Duration().Hours()
+		for _, spread := range Spreads(day) {
+			stats.Hours += hours
+			if spread.Split {
+				stats.SplitHours += hours
+			}
+			sum += spread.Spread * hours
+			absSum += math.Abs(spread.Spread) * hours
+			stats.MaxAbsSpread = math.Max(stats.MaxAbsSpread, math.Abs(spread.Spread))
+		}
+	}
+	if stats.Hours == 0 {
+		return stats, false
+	}
+	stats.MeanSpread = sum / stats.Hours
+	stats.MeanAbsSpread = absSum / stats.Hours
+	return stats, true
+}
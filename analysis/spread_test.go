@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestSpreads(t *testing.T) {
+	day := pricesDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 50 })
+	for hour := range day.SpainPrices {
+		day.PortugalPrices[hour] = 50
+	}
+	day.PortugalPrices[10] = 60
+	day.PortugalPrices[11] = 40
+	day.PortugalPrices[12] = 50.001 // Rounding, not a split
+	delete(day.PortugalPrices, 24)
+
+	spreads := Spreads(day)
+	if len(spreads) != 23 {
+		t.Fatalf("Expected the 23 hours with both prices, got %d", len(spreads))
+	}
+	if s := spreads[9]; s.Index != 10 || s.Spread != -10 || !s.Split {
+		t.Errorf("Unexpected spread of hour 10: %+v", s)
+	}
+	if spreads[11].Split {
+		t.Errorf("Expected hour 12 to be coupled, got %+v", spreads[11])
+	}
+
+	daily := DailySplitting([]*types.MarginalPriceData{day})
+	if len(daily) != 1 {
+		t.Fatalf("Expected a day of statistics, got %+v", daily)
+	}
+	if s := daily[0]; s.Hours != 23 || s.SplitHours != 2 || s.MaxAbsSpread != 10 || math.Abs(s.MeanSpread) > 1e-3 {
+		t.Errorf("Unexpected daily statistics %+v", s)
+	}
+}
+
+func TestMonthlySplitting(t *testing.T) {
+	split := pricesDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 80 })
+	for hour := range split.SpainPrices {
+		split.PortugalPrices[hour] = 60
+	}
+	coupled := pricesDay(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), types.QuarterHourly, func(int) float64 { return 70 })
+	for period := range coupled.SpainPrices {
+		coupled.PortugalPrices[period] = 70
+	}
+	spainOnly := pricesDay(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), types.Hourly, func(int) float64 { return 70 })
+
+	stats := MonthlySplitting([]*types.MarginalPriceData{coupled, split, spainOnly})
+	if len(stats) != 1 {
+		t.Fatalf("Expected January only, got %+v", stats)
+	}
+	s := stats[0]
+	if s.Hours != 48 || s.SplitHours != 24 || s.SplitShare() != 0.5 || s.MeanSpread != 10 || s.MeanAbsSpread != 10 {
+		t.Errorf("Unexpected monthly statistics %+v", s)
+	}
+}
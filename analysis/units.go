@@ -0,0 +1,84 @@
+// Package analysis provides derived datasets built on top of parsed OMIE data
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Unit describes a bidding unit. The curve files only carry the unit code, so the rest
+// of the description comes from the caller's own unit data.
+type Unit struct {
+	Code       string
+	Name       string
+	Agent      string
+	Technology types.TechnologyType
+}
+
+// UnitRegistry maps unit codes to their description. The library doesn't import OMIE's
+// list of units, so the registry is built by the caller, e.g. from a list of the units
+// of interest kept alongside the application:
+//
+//	registry := analysis.UnitRegistry{
+//		"ACE3": {Code: "ACE3", Agent: "ENDESA", Technology: types.Nuclear},
+//	}
+type UnitRegistry map[string]Unit
+
+// ClassifiedPoint is a curve point labeled with the unit that submitted it
+type ClassifiedPoint struct {
+	types.MarketPoint
+	Date  time.Time
+	Hour  int
+	Side  types.OfferType // Sell for supply points, Buy for demand points
+	Unit  Unit            // Zero value when the unit isn't in the registry
+	Known bool            // Whether the unit was found in the registry
+}
+
+// Classify labels every supply and demand point of the curve with its unit's
+// technology and agent. Points whose unit code is empty or unknown to the registry
+// are kept with Known set to false.
+func Classify(curve types.MarketCurve, registry UnitRegistry) []ClassifiedPoint {
+	points := make([]ClassifiedPoint, 0, len(curve.Supply)+len(curve.Demand))
+	for _, point := range curve.Supply {
+		points = append(points, classifyPoint(curve, point, types.Sell, registry))
+	}
+	for _, point := range curve.Demand {
+		points = append(points, classifyPoint(curve, point, types.Buy, registry))
+	}
+	return points
+}
+
+// ClassifyDay labels the points of every curve of the day, in hour order
+func ClassifyDay(day *types.MarketCurveDay, registry UnitRegistry) []ClassifiedPoint {
+	var points []ClassifiedPoint
+	for _, curve := range day.Curves {
+		points = append(points, Classify(curve, registry)...)
+	}
+	return points
+}
+
+// MeritOrder returns the classified supply points of the curve sorted by ascending
+// price, the order in which the market dispatches them. Points with the same price
+// keep their order in the file.
+func MeritOrder(curve types.MarketCurve, registry UnitRegistry) []ClassifiedPoint {
+	points := Classify(types.MarketCurve{Date: curve.Date, Hour: curve.Hour, Aggregation: curve.Aggregation, Supply: curve.Supply}, registry)
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Price < points[j].Price
+	})
+	return points
+}
+
+// classifyPoint looks up the unit of a single point
+func classifyPoint(curve types.MarketCurve, point types.MarketPoint, side types.OfferType, registry UnitRegistry) ClassifiedPoint {
+	unit, known := registry[point.UnitCode]
+	return ClassifiedPoint{
+		MarketPoint: point,
+		Date:        curve.Date,
+		Hour:        curve.Hour,
+		Side:        side,
+		Unit:        unit,
+		Known:       known && point.UnitCode != "",
+	}
+}
@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestMeritOrder(t *testing.T) {
+	registry := UnitRegistry{
+		"ACE3": {Code: "ACE3", Agent: "ENDESA", Technology: types.Nuclear},
+		"CTN1": {Code: "CTN1", Agent: "NATURGY", Technology: types.CombinedCycle},
+	}
+
+	curve := types.MarketCurve{
+		Hour: 1,
+		Supply: []types.MarketPoint{
+			{Energy: 300, Price: 65, Matched: types.Offered, UnitCode: "CTN1"},
+			{Energy: 1000, Price: 0, Matched: types.Offered, UnitCode: "ACE3"},
+			{Energy: 50, Price: 40, Matched: types.Offered, UnitCode: "XXX1"},
+		},
+		Demand: []types.MarketPoint{
+			{Energy: 500, Price: 180, Matched: types.Offered, UnitCode: "ACE3"},
+		},
+	}
+
+	points := MeritOrder(curve, registry)
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 supply points, got %d", len(points))
+	}
+
+	if points[0].Unit.Technology != types.Nuclear || points[2].Unit.Technology != types.CombinedCycle {
+		t.Errorf("Unexpected merit order: %v", points)
+	}
+	if points[1].Known || points[1].Side != types.Sell {
+		t.Errorf("Expected unknown sell point, got %+v", points[1])
+	}
+
+	if all := Classify(curve, registry); len(all) != 4 || all[3].Side != types.Buy {
+		t.Errorf("Expected supply and demand points to be classified, got %v", all)
+	}
+}
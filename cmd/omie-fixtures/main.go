@@ -0,0 +1,275 @@
+// Command omie-fixtures adds OMIE files to the test fixtures and regenerates the values
+// the parser tests expect from them, so covering a new format era is a matter of picking
+// a date rather than copying values by hand.
+//
+// Usage, from the root of the repository:
+//
+//	omie-fixtures --prices 2014-05-13,2024-10-27 --tech 2024-10-27 --system iberian
+//	omie-fixtures --legacy  # The OMEL era files, see legacyPriceDates
+//	omie-fixtures   # Only regenerate parsers/golden_values_test.go
+//
+// Files are downloaded to --testdata under the names the downloaders give them. The
+// golden values are then regenerated from every marginal price and energy by technology
+// file under --testdata, whether just downloaded or not, so review the diff of the
+// generated file: values that changed for an existing fixture point to a parser change.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+const dateLayout = "2006-01-02"
+
+// legacyPriceDates are the marginal price files of the OMEL era downloaded by --legacy, a
+// day a year from the start of the market in 1998 to 2005, covering the peseta and the
+// early euro layouts
+var legacyPriceDates = []string{
+	"1998-01-01", "1999-06-01", "2000-06-01", "2001-06-01", "2001-12-31",
+	"2002-01-01", "2003-06-01", "2004-06-01", "2005-06-01",
+}
+
+// Names of the fixtures the golden values are generated from, as DownloadData names them
+var (
+	pricesFile     = regexp.MustCompile(`^PMD_\d{8}\.txt$`)
+	technologyFile = regexp.MustCompile(`^EnergyByTechnology_\d+_\d{8}\.TXT$`)
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run downloads the files selected by args and regenerates the golden values, returning
+// the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var prices, tech, system, testdata, golden string
+	var legacy, verbose bool
+	fs := flag.NewFlagSet("omie-fixtures", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&prices, "prices", "", "comma-separated dates whose marginal price files are downloaded")
+	fs.StringVar(&tech, "tech", "", "comma-separated dates whose energy by technology files are downloaded")
+	fs.BoolVar(&legacy, "legacy", false, "also download the marginal price files of the OMEL era, 1998 to 2005")
+	fs.StringVar(&system, "system", "iberian", "system of the energy by technology files: spain, portugal or iberian")
+	fs.StringVar(&testdata, "testdata", "testdata", "folder of the fixtures")
+	fs.StringVar(&golden, "golden", filepath.Join("parsers", "golden_values_test.go"), "Go file the golden values are written to")
+	fs.BoolVar(&verbose, "verbose", false, "log downloads to stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return 2 // The flag package printed the error or the usage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "omie-fixtures: unexpected arguments %q\n", fs.Args())
+		return 2
+	}
+
+	if legacy {
+		prices = strings.Join(append(legacyPriceDates, prices), ",")
+	}
+	if err := fetch(ctx, prices, tech, system, testdata, verbose); err != nil {
+		fmt.Fprintf(stderr, "omie-fixtures: %v\n", err)
+		return 1
+	}
+
+	source, err := generate(testdata, golden)
+	if err != nil {
+		fmt.Fprintf(stderr, "omie-fixtures: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(golden, source, 0644); err != nil {
+		fmt.Fprintf(stderr, "omie-fixtures: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", golden)
+	return 0
+}
+
+// dataDownloader saves the files of a date range to a folder
+type dataDownloader interface {
+	DownloadData(ctx context.Context, dateIni, dateEnd time.Time, outputFolder string, verbose bool) error
+}
+
+// fetch downloads the files of the dates listed in prices and tech to testdata
+func fetch(ctx context.Context, prices, tech, system, testdata string, verbose bool) error {
+	priceDates, err := parseDates(prices)
+	if err != nil {
+		return err
+	}
+	techDates, err := parseDates(tech)
+	if err != nil {
+		return err
+	}
+	var systemType types.SystemType
+	if err := systemType.UnmarshalText([]byte(strings.ToUpper(system))); err != nil {
+		return fmt.Errorf("unknown system %q", system)
+	}
+
+	if err := download(ctx, "marginal prices", downloaders.NewMarginalPriceDownloader(), priceDates, testdata, verbose); err != nil {
+		return err
+	}
+	return download(ctx, "energy by technology", downloaders.NewEnergyByTechnologyDownloader(systemType), techDates, testdata, verbose)
+}
+
+// download saves the file of every date in dates to testdata, naming what failed
+func download(ctx context.Context, what string, downloader dataDownloader, dates []time.Time, testdata string, verbose bool) error {
+	for _, date := range dates {
+		if err := downloader.DownloadData(ctx, date, date, testdata, verbose); err != nil {
+			return fmt.Errorf("%s of %s: %w", what, date.Format(dateLayout), err)
+		}
+	}
+	return nil
+}
+
+// parseDates parses a comma-separated list of YYYY-MM-DD dates
+func parseDates(value string) ([]time.Time, error) {
+	var dates []time.Time
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		date, err := time.Parse(dateLayout, field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", field)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// generate returns the source of the golden values of every fixture under testdata,
+// with the paths of the fixtures relative to the folder of golden
+func generate(testdata, golden string) ([]byte, error) {
+	entries, err := os.ReadDir(testdata)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by omie-fixtures from the files under testdata; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", filepath.Base(filepath.Dir(mustAbs(golden))))
+
+	b.WriteString("// goldenPrices are the values parsed from the marginal price fixtures\n")
+	b.WriteString("var goldenPrices = []goldenPriceFile{\n")
+	for _, name := range names {
+		if !pricesFile.MatchString(name) {
+			continue
+		}
+		path := filepath.Join(testdata, name)
+		parsed, err := parsers.NewMarginalPriceParser().ParseFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		day := parsed.(*types.MarginalPriceData)
+		fmt.Fprintf(&b, "{\nFile: %q,\nDate: %q,\nResolution: %q,\nSeries: map[string]map[int]float64{\n",
+			relative(golden, path), day.Date.Format(dateLayout), day.Resolution.String())
+		for _, series := range day.Series() {
+			if len(series.Values) > 0 {
+				writeValues(&b, strconv.Quote(series.Name)+": ", series.Values)
+			}
+		}
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// goldenTechnology are the values parsed from the energy by technology fixtures\n")
+	b.WriteString("var goldenTechnology = []goldenTechnologyFile{\n")
+	for _, name := range names {
+		if !technologyFile.MatchString(name) {
+			continue
+		}
+		path := filepath.Join(testdata, name)
+		parsed, err := parsers.NewEnergyByTechnologyParser().ParseFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		day := parsed.(*types.TechnologyEnergyDay)
+		totals := make(types.HourlyValues, len(day.Records))
+		daily := make(map[types.TechnologyType]float64)
+		for _, record := range day.Records {
+			totals[record.Hour] = record.Total()
+			for tech, value := range record.Values() {
+				daily[tech] += value
+			}
+		}
+		fmt.Fprintf(&b, "{\nFile: %q,\nDate: %q,\nSystem: %q,\n", relative(golden, path), day.Date.Format(dateLayout), day.System.String())
+		writeValues(&b, "Totals: map[int]float64", totals)
+		b.WriteString("Technologies: map[string]float64{\n")
+		for _, tech := range types.Technologies() {
+			if value, ok := daily[tech]; ok {
+				fmt.Fprintf(&b, "%q: %s,\n", tech, formatValue(value))
+			}
+		}
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// writeValues writes values as a map literal after head, e.g. a field name, leaving
+// out missing values
+func writeValues(b *bytes.Buffer, head string, values types.HourlyValues) {
+	b.WriteString(head + "{")
+	n := 0
+	values.ForEachHour(func(hour int, value float64) {
+		if math.IsNaN(value) {
+			return
+		}
+		if n%8 == 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "%d: %s, ", hour, formatValue(value))
+		n++
+	})
+	b.WriteString("\n},\n")
+}
+
+// formatValue formats a value to 12 significant digits, dropping the noise of decimal
+// parsing, e.g. 48.88 rather than 48.879999999999995
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', 12, 64)
+}
+
+// relative returns the path of a fixture relative to the folder of golden, with
+// forward slashes so the generated file is the same on every system
+func relative(golden, path string) string {
+	rel, err := filepath.Rel(filepath.Dir(mustAbs(golden)), mustAbs(path))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// mustAbs returns the absolute form of path, or path itself when it can't be resolved
+func mustAbs(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
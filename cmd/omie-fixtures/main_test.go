@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGoldenUpToDate fails when the golden values are stale, e.g. after a fixture was
+// added by hand or a parser changed; run omie-fixtures to regenerate them
+func TestGoldenUpToDate(t *testing.T) {
+	golden := filepath.Join("..", "..", "parsers", "golden_values_test.go")
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := generate(filepath.Join("..", "..", "testdata"), golden)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is stale, run go run ./cmd/omie-fixtures from the root of the repository", golden)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	fixture, err := os.ReadFile(filepath.Join("..", "..", "testdata", "PMD_20090601.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdata := filepath.Join(dir, "testdata")
+	if err := os.MkdirAll(testdata, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(testdata, "PMD_20090601.txt"), fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join(dir, "fixtures", "golden_values_test.go")
+	if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--testdata", testdata, "--golden", golden}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	source, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package fixtures", `"../testdata/PMD_20090601.txt"`, `"spain_prices": {`, "1: 39.97,"} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("expected the generated file to contain %q", want)
+		}
+	}
+
+	if code := run(context.Background(), []string{"--prices", "01/06/2009", "--testdata", testdata, "--golden", golden}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an invalid date, got %d", code)
+	}
+	if code := run(context.Background(), []string{"--tech", "2009-06-01", "--system", "mars", "--testdata", testdata, "--golden", golden}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown system, got %d", code)
+	}
+}
+
+func TestLegacyPriceDates(t *testing.T) {
+	dates, err := parseDates(strings.Join(legacyPriceDates, ","))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dates) != len(legacyPriceDates) {
+		t.Fatalf("parsed %d dates, want %d", len(dates), len(legacyPriceDates))
+	}
+	for _, date := range dates {
+		if date.Year() < 1998 || date.Year() > 2005 {
+			t.Errorf("%s is outside the OMEL era", date.Format(dateLayout))
+		}
+	}
+}
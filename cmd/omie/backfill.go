@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/export"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// backfillDatasets are the datasets a backfill downloads, in the order they're processed
+var backfillDatasets = []string{"prices", "tech-spain", "tech-portugal", "tech-iberian", "curves"}
+
+// dayFunc receives every imported day of a dataset, as the slice of results an export
+// format writes
+type dayFunc func(date time.Time, results interface{}) error
+
+// eachDay imports the range start..end of a dataset, calling fn with every day in date
+// order. Days that fail are skipped and reported in a *types.MultiError.
+type eachDay func(ctx context.Context, start, end time.Time, fn dayFunc) error
+
+func runBackfill(ctx context.Context, args []string, stdout, stderr io.Writer) (err error) {
+	var flags commonFlags
+	var notifications notifyFlags
+	var out, statePath, datasets string
+	var archives bool
+	fs := newFlagSet("backfill", "Downloads every day of a range of the selected datasets into a directory, one file per\n"+
+		"dataset and day. Progress is kept in a state file, so a run that is interrupted or that\n"+
+		"fails on some days resumes without downloading the completed days again. The notifiers\n"+
+		"given by --webhook, --slack and --smtp receive a summary when the backfill finishes.", stderr)
+	fs.StringVar(&flags.from, "from", "", "first day to download, YYYY-MM-DD (required)")
+	fs.StringVar(&flags.to, "to", "today", "last day to download, YYYY-MM-DD or today")
+	fs.StringVar(&out, "out", "", "directory the files are written to (required)")
+	fs.StringVar(&datasets, "datasets", strings.Join(backfillDatasets, ","), "comma-separated datasets to download")
+	fs.StringVar(&statePath, "state", "", "progress file (default <out>/.omie-backfill.json)")
+	fs.BoolVar(&archives, "archives", false, "download the curves from the monthly and yearly ZIP archives instead of\n"+
+		"one file per day")
+	flags.registerFormat(fs, "ndjson")
+	flags.registerImport(fs)
+	notifications.register(fs, "URL notified with a JSON summary when the backfill finishes")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	case flags.from == "":
+		return fmt.Errorf("--from is required")
+	case out == "":
+		return fmt.Errorf("--out is required")
+	}
+	format, err := flags.exportFormat()
+	if err != nil {
+		return err
+	}
+	dates, err := flags.dateRange(time.Now())
+	if err != nil {
+		return err
+	}
+	names, err := parseDatasets(datasets)
+	if err != nil {
+		return err
+	}
+	_, notifier, err := notifications.notifiers()
+	if err != nil {
+		return err
+	}
+
+	if statePath == "" {
+		statePath = filepath.Join(out, ".omie-backfill.json")
+	}
+	journal, err := importers.OpenFileJournal(statePath)
+	if err != nil {
+		return err
+	}
+
+	var report []string
+	defer func() {
+		notifyFinished(ctx, stderr, notifier, "backfill", strings.Join(report, "\n"), err)
+	}()
+
+	options := flags.importOptions(stderr)
+	options.BulkArchives = archives
+	failed := 0
+	for _, name := range names {
+		each := backfillImporter(name, options)
+		dataset := backfillDataset(name, format)
+		downloaded := 0
+
+		missing, err := journal.Missing(ctx, dataset, dates.Start, dates.End)
+		if err != nil {
+			return err
+		}
+		for _, gap := range missing {
+			err := each(ctx, gap.Start, gap.End, func(date time.Time, results interface{}) error {
+				if err := writeFile(dayPath(out, name, date, format), format, results); err != nil {
+					return err
+				}
+				downloaded++
+				return journal.Record(ctx, dataset, date)
+			})
+
+			var multi *types.MultiError
+			switch {
+			case errors.As(err, &multi):
+				failed += len(multi.Errors)
+				if flags.verbose {
+					for _, err := range multi.Errors {
+						fmt.Fprintf(stderr, "%s: %v\n", name, err)
+					}
+				}
+			case err != nil:
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		done, err := completedDays(ctx, journal, dataset, dates.Start, dates.End)
+		if err != nil {
+			return err
+		}
+		line := fmt.Sprintf("%s: %d days downloaded, %d of %d days done", name, downloaded, done, dates.Days())
+		report = append(report, line)
+		fmt.Fprintln(stdout, line)
+	}
+
+	if failed > 0 && options.CircuitBreaker.Open() {
+		return fmt.Errorf("omie.es looks unavailable, %d days could not be downloaded; run the backfill again later to retry them", failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d days could not be downloaded, run the backfill again to retry them", failed)
+	}
+	return nil
+}
+
+// parseDatasets parses the comma-separated list of the --datasets flag
+func parseDatasets(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, dataset := range backfillDatasets {
+			known = known || dataset == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown dataset %q, expected one of %s", name, strings.Join(backfillDatasets, ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no datasets selected")
+	}
+	return names, nil
+}
+
+// backfillImporter returns the import of a dataset named in backfillDatasets
+func backfillImporter(name string, options importers.ImportOptions) eachDay {
+	switch name {
+	case "prices":
+		importer := importers.NewMarginalPriceImporter(options)
+		return func(ctx context.Context, start, end time.Time, fn dayFunc) error {
+			return importer.ImportEach(ctx, start, end, func(day *types.MarginalPriceData, _ importers.ResumeToken) error {
+				return fn(day.Date, []*types.MarginalPriceData{day})
+			})
+		}
+	case "curves":
+		// The daily aggregated file needs one request per day instead of one per hour. With
+		// archives a month is imported at once, as a single download covers it.
+		importer := importers.NewSupplyDemandCurveDayImporter(options)
+		importer.SetSource(importers.AggregatedCurveFile)
+		return func(ctx context.Context, start, end time.Time, fn dayFunc) error {
+			var errs []error
+			for _, chunk := range curveChunks(start, end, options.BulkArchives) {
+				results, err := importer.Import(ctx, chunk.Start, chunk.End)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				days := make(map[time.Time]*types.MarketCurveDay)
+				if curves, ok := results.([]*types.MarketCurveDay); ok {
+					for _, day := range curves {
+						days[day.Date] = day
+					}
+				}
+				for date := range chunk.All() {
+					day, ok := days[date]
+					switch {
+					case ok:
+						if err := fn(date, []*types.MarketCurveDay{day}); err != nil {
+							return err
+						}
+					case err != nil:
+						errs = append(errs, importers.DateError{Date: date, Err: err})
+					default:
+						errs = append(errs, importers.DateError{Date: date,
+							Err: types.NewOMIEError(types.ErrCodeNotFound, "no curves found for date", nil)})
+					}
+				}
+			}
+			return types.JoinErrors("import completed", errs)
+		}
+	default:
+		var system types.SystemType
+		system.UnmarshalText([]byte(strings.ToUpper(strings.TrimPrefix(name, "tech-"))))
+		importer := importers.NewEnergyByTechnologyImporter(system, options)
+		return func(ctx context.Context, start, end time.Time, fn dayFunc) error {
+			return importer.ImportEach(ctx, start, end, func(day *types.TechnologyEnergyDay, _ importers.ResumeToken) error {
+				return fn(day.Date, []*types.TechnologyEnergyDay{day})
+			})
+		}
+	}
+}
+
+// curveChunks splits start..end into the ranges the curves are imported in: single days,
+// or calendar months when monthly is set
+func curveChunks(start, end time.Time, monthly bool) []types.DateRange {
+	var chunks []types.DateRange
+	for date := range (types.DateRange{Start: start, End: end}).All() {
+		if n := len(chunks); n > 0 && monthly && date.Month() == chunks[n-1].End.Month() {
+			chunks[n-1].End = date
+			continue
+		}
+		chunks = append(chunks, types.DateRange{Start: date, End: date})
+	}
+	return chunks
+}
+
+// dayPath returns the file of a dataset and day under out:
+// <out>/<dataset>/year=YYYY/month=MM/YYYY-MM-DD.<ext>
+func dayPath(out, dataset string, date time.Time, format export.Format) string {
+	partition := export.PartitionYearMonth.Split(date, date)[0]
+	return filepath.Join(out, dataset, partition.Dir, date.Format(dateLayout)+format.Extension())
+}
+
+// technologyDataset returns the dataset name of the energy by technology of system
+func technologyDataset(system types.SystemType) string {
+	return "tech-" + strings.ToLower(system.String())
+}
+
+// writeFile writes results to path through a temporary sibling that is renamed into
+// place once complete, so a file that exists is always whole
+func writeFile(path string, format export.Format, results interface{}) error {
+	return atomicfile.Write(path, func(w io.Writer) error {
+		return format.Write(w, results)
+	})
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/export"
+	"github.com/devuo/omiedata/export/csvexport"
+	"github.com/devuo/omiedata/export/ndjson"
+	"github.com/devuo/omiedata/export/parquet"
+	"github.com/devuo/omiedata/export/xlsx"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// dateLayout is the layout of the date flags
+const dateLayout = "2006-01-02"
+
+// commonFlags are the flags shared by every subcommand
+type commonFlags struct {
+	from, to, date string
+	format, output string
+	layout         string
+	separator      string
+	decimal        string
+	cache          string
+	rate           float64
+	retries        int
+	concurrency    int
+	parseWorkers   int
+	verbose        bool
+	proxy          *url.URL
+	tlsConfig      *tls.Config
+}
+
+// register adds the common flags to fs
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.from, "from", "", "first day to fetch, YYYY-MM-DD or today (default yesterday)")
+	fs.StringVar(&c.to, "to", "", "last day to fetch, YYYY-MM-DD or today (default --from)")
+	fs.StringVar(&c.date, "date", "", "single day to fetch, YYYY-MM-DD, instead of --from and --to")
+	fs.StringVar(&c.output, "output", "", "output file (default stdout)")
+	c.registerFormat(fs, "csv")
+	c.registerImport(fs)
+}
+
+// registerFormat adds the flags selecting the output format to fs
+func (c *commonFlags) registerFormat(fs *flag.FlagSet, format string) {
+	fs.StringVar(&c.format, "format", format, "output format: csv, json, ndjson, xlsx or parquet")
+	fs.StringVar(&c.layout, "layout", "long", "CSV layout: long (one value per row) or wide (one row per hour)")
+	fs.StringVar(&c.separator, "separator", ",", "CSV field separator")
+	fs.StringVar(&c.decimal, "decimal", ".", "CSV decimal mark")
+}
+
+// registerImport adds the flags tuning the downloads to fs
+func (c *commonFlags) registerImport(fs *flag.FlagSet) {
+	fs.IntVar(&c.retries, "retries", 3, "download attempts per file")
+	fs.IntVar(&c.concurrency, "concurrency", 5, "files downloaded in parallel, across every dataset")
+	fs.IntVar(&c.parseWorkers, "parse-workers", runtime.NumCPU(), "files parsed in parallel")
+	fs.Float64Var(&c.rate, "rate", 5, "maximum requests per second to omie.es, or 0 for no limit")
+	fs.BoolVar(&c.verbose, "verbose", false, "log downloads to stderr")
+	fs.StringVar(&c.cache, "cache", defaultCacheDir(), "folder caching downloaded files, or empty to disable it")
+	fs.Func("proxy", "proxy URL for the requests to omie.es, overriding HTTPS_PROXY", func(value string) error {
+		proxy, err := url.Parse(value)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", value)
+		}
+		c.proxy = proxy
+		return nil
+	})
+
+	var caFiles []string
+	fs.Func("ca-cert", "PEM file of a root certificate to trust on top of the system's, e.g. a corporate gateway's (repeatable)", func(file string) error {
+		caFiles = append(caFiles, file)
+		roots, err := downloaders.LoadRootCAs(caFiles...)
+		if err != nil {
+			return err
+		}
+		c.tlsConfig = &tls.Config{RootCAs: roots}
+		return nil
+	})
+}
+
+// defaultCacheDir returns the folder the downloaded files are cached in by default,
+// under the user's cache folder, or "" when the system has none
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "omiedata")
+}
+
+// dateRange returns the days selected by the date flags
+func (c *commonFlags) dateRange(now time.Time) (types.DateRange, error) {
+	if c.date != "" {
+		if c.from != "" || c.to != "" {
+			return types.DateRange{}, fmt.Errorf("--date can't be combined with --from or --to")
+		}
+		c.from, c.to = c.date, c.date
+	}
+
+	yesterday := today(now).AddDate(0, 0, -1)
+	start, err := parseDate("from", c.from, yesterday, now)
+	if err != nil {
+		return types.DateRange{}, err
+	}
+	end, err := parseDate("to", c.to, start, now)
+	if err != nil {
+		return types.DateRange{}, err
+	}
+
+	return types.NewDateRange(start, end)
+}
+
+// importOptions returns the import options selected by the flags. Download events are
+// logged to stderr, so they never mix with output written to stdout.
+func (c *commonFlags) importOptions(stderr io.Writer) importers.ImportOptions {
+	options := importers.ImportOptions{
+		MaxRetries:    c.retries,
+		RetryDelay:    time.Second,
+		MaxConcurrent: c.concurrency,
+		ParseWorkers:  c.parseWorkers,
+		CacheDir:      c.cache,
+		Proxy:         c.proxy,
+		TLSConfig:     c.tlsConfig,
+
+		// Bound the downloads of every importer created from the options together, e.g.
+		// prices and technologies in the daemon
+		Pool: downloaders.NewWorkerPool(c.concurrency),
+
+		// Give up on the remaining dates while omie.es is down instead of retrying each one
+		CircuitBreaker: downloaders.NewCircuitBreaker(10, time.Minute),
+	}
+	if c.rate > 0 {
+		options.RateLimiter = downloaders.NewRateLimiter(c.rate, c.concurrency)
+	}
+	if c.verbose {
+		options.Logger = slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return options
+}
+
+// exportFormat returns the output format selected by the flags
+func (c *commonFlags) exportFormat() (export.Format, error) {
+	switch strings.ToLower(c.format) {
+	case "csv":
+		options := csvexport.Options{}
+		switch strings.ToLower(c.layout) {
+		case "long":
+		case "wide":
+			options.Layout = csvexport.Wide
+		default:
+			return nil, fmt.Errorf("unknown layout %q", c.layout)
+		}
+		separator, err := singleRune("separator", c.separator)
+		if err != nil {
+			return nil, err
+		}
+		decimal, err := singleRune("decimal", c.decimal)
+		if err != nil {
+			return nil, err
+		}
+		options.Separator, options.Decimal = separator, decimal
+		return csvexport.Format{Options: options}, nil
+	case "json":
+		return export.JSONFormat{}, nil
+	case "ndjson":
+		return ndjson.Format{}, nil
+	case "xlsx":
+		return xlsx.Format{}, nil
+	case "parquet":
+		return parquet.Format{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", c.format)
+	}
+}
+
+// write writes results in the selected format to --output, or to stdout
+func (c *commonFlags) write(stdout io.Writer, results interface{}) error {
+	format, err := c.exportFormat()
+	if err != nil {
+		return err
+	}
+
+	if c.output == "" {
+		return format.Write(stdout, results)
+	}
+
+	file, err := os.Create(c.output)
+	if err != nil {
+		return err
+	}
+	if err := format.Write(file, results); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parse parses the flags of a subcommand, validating the format before anything is
+// downloaded
+func (c *commonFlags) parse(fs *flag.FlagSet, args []string) (types.DateRange, error) {
+	if err := fs.Parse(args); err != nil {
+		return types.DateRange{}, err
+	}
+	if fs.NArg() > 0 {
+		return types.DateRange{}, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if _, err := c.exportFormat(); err != nil {
+		return types.DateRange{}, err
+	}
+	return c.dateRange(time.Now())
+}
+
+func runPrices(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var flags commonFlags
+	fs := newFlagSet("prices", "Fetches the day-ahead marginal prices and energies of a range of days.", stderr)
+	flags.register(fs)
+
+	dates, err := flags.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	importer := importers.NewMarginalPriceImporter(flags.importOptions(stderr))
+	days, err := importer.ImportDays(ctx, dates.Start, dates.End)
+	if err != nil {
+		return err
+	}
+	return flags.write(stdout, days)
+}
+
+func runTechnology(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var flags commonFlags
+	var system string
+	fs := newFlagSet("tech", "Fetches the energy by technology of a system for a range of days.", stderr)
+	flags.register(fs)
+	fs.StringVar(&system, "system", "iberian", "system: spain, portugal or iberian")
+
+	dates, err := flags.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var systemType types.SystemType
+	if err := systemType.UnmarshalText([]byte(strings.ToUpper(system))); err != nil {
+		return fmt.Errorf("unknown system %q", system)
+	}
+
+	importer := importers.NewEnergyByTechnologyImporter(systemType, flags.importOptions(stderr))
+	days, err := importer.ImportDays(ctx, dates.Start, dates.End)
+	if err != nil {
+		return err
+	}
+	return flags.write(stdout, days)
+}
+
+func runCurves(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var flags commonFlags
+	var hour int
+	var source string
+	fs := newFlagSet("curves", "Fetches the supply and demand curves of an hour, or of every hour, of a range of days.", stderr)
+	flags.register(fs)
+	fs.IntVar(&hour, "hour", 0, "hour of the day (1-25), or 0 for every hour")
+	fs.StringVar(&source, "source", "hourly", "curve files of whole days: hourly, aggregated or unit")
+
+	dates, err := flags.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	options := flags.importOptions(stderr)
+	if hour != 0 {
+		index, err := types.NewHourIndex(hour)
+		if err != nil {
+			return err
+		}
+		results, err := importers.NewSupplyDemandCurveImporter(index, options).Import(ctx, dates.Start, dates.End)
+		if err != nil {
+			return err
+		}
+		return flags.write(stdout, results)
+	}
+
+	importer := importers.NewSupplyDemandCurveDayImporter(options)
+	switch strings.ToLower(source) {
+	case "hourly":
+		importer.SetSource(importers.HourlyCurveFiles)
+	case "aggregated":
+		importer.SetSource(importers.AggregatedCurveFile)
+	case "unit":
+		importer.SetSource(importers.UnitCurveFile)
+	default:
+		return fmt.Errorf("unknown curve source %q", source)
+	}
+
+	results, err := importer.Import(ctx, dates.Start, dates.End)
+	if err != nil {
+		return err
+	}
+	return flags.write(stdout, results)
+}
+
+// newFlagSet creates the flag set of a subcommand, printing errors and help to stderr
+func newFlagSet(name, description string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("omie "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: omie %s [flags]\n\n%s\n\nFlags:\n", name, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseDate parses the value of a date flag, returning fallback when it's empty
+func parseDate(name, value string, fallback, now time.Time) (time.Time, error) {
+	switch value {
+	case "":
+		return fallback, nil
+	case "today":
+		return today(now), nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q, expected YYYY-MM-DD", name, value)
+	}
+	return date, nil
+}
+
+// today returns the date of now in Spain, as midnight UTC like the dates of parsed files
+func today(now time.Time) time.Time {
+	local := now.In(types.Spain.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// singleRune returns the only character of the value of a flag
+func singleRune(name, value string) (rune, error) {
+	runes := []rune(value)
+	if value == `\t` {
+		return '\t', nil
+	}
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("--%s must be a single character, got %q", name, value)
+	}
+	return runes[0], nil
+}
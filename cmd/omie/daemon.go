@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/notify"
+	"github.com/devuo/omiedata/schedule"
+	"github.com/devuo/omiedata/store/sqlite"
+	"github.com/devuo/omiedata/types"
+)
+
+func runDaemon(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var flags commonFlags
+	var notifications notifyFlags
+	var out, sqlitePath, systems string
+	var failures int
+	fs := newFlagSet("daemon", "Runs until interrupted, importing the next day's prices and energy by technology when\n"+
+		"OMIE publishes them, retrying until they appear, and delivering them to the sinks given\n"+
+		"by --out and --sqlite. Started after a publication, it imports that day right away.\n"+
+		"Every --webhook receives a JSON summary of each imported day. The notifiers given by\n"+
+		"--webhook, --slack and --smtp are told when a job fails --failures times in a row.", stderr)
+	fs.StringVar(&out, "out", "", "directory the files are written to, laid out like backfill")
+	fs.StringVar(&sqlitePath, "sqlite", "", "SQLite database the data is saved to")
+	fs.StringVar(&systems, "tech", "iberian", "comma-separated systems whose energy by technology is imported, or none")
+	fs.IntVar(&failures, "failures", 1, "consecutive failed runs of a job, each retried until the publication is late, before notifying")
+	flags.registerFormat(fs, "ndjson")
+	flags.registerImport(fs)
+	notifications.register(fs, "URL notified with a JSON summary of every imported day and of failing jobs")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if out == "" && sqlitePath == "" {
+		return fmt.Errorf("at least one of --out and --sqlite is required")
+	}
+	format, err := flags.exportFormat()
+	if err != nil {
+		return err
+	}
+	techSystems, err := parseSystems(systems)
+	if err != nil {
+		return err
+	}
+	notifier, alerts, err := notifications.notifiers()
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	options := flags.importOptions(stderr)
+
+	var priceSinks []func(context.Context, *types.MarginalPriceData) error
+	var techSinks []func(context.Context, *types.TechnologyEnergyDay) error
+	if out != "" {
+		priceSinks = append(priceSinks, func(ctx context.Context, day *types.MarginalPriceData) error {
+			return writeFile(dayPath(out, "prices", day.Date, format), format, []*types.MarginalPriceData{day})
+		})
+		techSinks = append(techSinks, func(ctx context.Context, day *types.TechnologyEnergyDay) error {
+			return writeFile(dayPath(out, technologyDataset(day.System), day.Date, format), format, []*types.TechnologyEnergyDay{day})
+		})
+	}
+	if sqlitePath != "" {
+		store, err := sqlite.Open(ctx, sqlitePath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		priceSinks = append(priceSinks, func(ctx context.Context, day *types.MarginalPriceData) error {
+			return store.SavePrices(ctx, day)
+		})
+		techSinks = append(techSinks, func(ctx context.Context, day *types.TechnologyEnergyDay) error {
+			return store.SaveTechnology(ctx, day)
+		})
+	}
+
+	s := schedule.New()
+	trackers := make(map[string]*notify.FailureTracker)
+	add := func(job schedule.Job) {
+		job.CatchUp = true
+		trackers[job.Name] = notify.NewFailureTracker(alerts, job.Name, failures)
+		s.Add(tracked(job, trackers[job.Name]))
+	}
+
+	add(schedule.DayAheadPrices(importers.NewMarginalPriceImporter(options),
+		deliver(logger, notifier, notify.PricesImported, priceSinks...)))
+	for _, system := range techSystems {
+		job := schedule.Technology(importers.NewEnergyByTechnologyImporter(system, options),
+			deliver(logger, notifier, notify.TechnologyImported, techSinks...))
+		job.Name = fmt.Sprintf("energy by technology (%s)", strings.ToLower(system.String()))
+		add(job)
+	}
+	s.OnError(func(job schedule.Job, err error) {
+		logger.Error("job failed", "job", job.Name, "err", err)
+		if err := trackers[job.Name].Failure(ctx, err); err != nil {
+			logger.Error("notification failed", "job", job.Name, "err", err)
+		}
+	})
+
+	now := time.Now()
+	logger.Info("waiting for publications", "latest", schedule.DayAheadCalendar.Latest(now).Format(dateLayout),
+		"next", schedule.DayAheadCalendar.Next(now).Format(time.RFC3339))
+	if err := s.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// deliver returns a handler delivering data to every sink and, once all of them
+// succeeded, logging the day and notifying the webhooks. A failed notification is only
+// logged, so it doesn't make the job import and deliver the day again.
+func deliver[T any](logger *slog.Logger, notifier notify.Notifier, imported func(T) notify.Event, sinks ...func(context.Context, T) error) func(context.Context, T) error {
+	all := schedule.Multi(sinks...)
+	return func(ctx context.Context, data T) error {
+		if err := all(ctx, data); err != nil {
+			return err
+		}
+
+		event := imported(data)
+		logger.Info("imported", "job", event.Job, "system", event.System, "date", event.Date.Format(dateLayout))
+		if err := notifier.Notify(ctx, event); err != nil {
+			logger.Error("notification failed", "job", event.Job, "err", err)
+		}
+		return nil
+	}
+}
+
+// tracked wraps the run of job so every successful run resets tracker. Failed runs reach
+// the tracker through the scheduler's OnError, once their retries are exhausted.
+func tracked(job schedule.Job, tracker *notify.FailureTracker) schedule.Job {
+	run := job.Run
+	job.Run = func(ctx context.Context, at time.Time) error {
+		err := run(ctx, at)
+		if err == nil {
+			tracker.Success()
+		}
+		return err
+	}
+	return job
+}
+
+// parseSystems parses a comma-separated list of systems, where "none" selects none
+func parseSystems(value string) ([]types.SystemType, error) {
+	var systems []types.SystemType
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+		var system types.SystemType
+		if err := system.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+			return nil, fmt.Errorf("unknown system %q", name)
+		}
+		systems = append(systems, system)
+	}
+	return systems, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/compression"
+	"github.com/devuo/omiedata/export"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var flags commonFlags
+	var out, datasets, partition, compress string
+	var parallel int
+	fs := newFlagSet("export", "Exports a range of days of the selected datasets into a partitioned directory tree, one\n"+
+		"file per dataset and partition, e.g. <out>/year=2024/month=01/prices.parquet. Partitions\n"+
+		"whose file already exists are skipped, so an interrupted export resumes when run again.", stderr)
+	fs.StringVar(&flags.from, "from", "", "first day to export, YYYY-MM-DD (required)")
+	fs.StringVar(&flags.to, "to", "today", "last day to export, YYYY-MM-DD or today")
+	fs.StringVar(&out, "out", "", "directory the partitions are written to (required)")
+	fs.StringVar(&datasets, "datasets", "prices", "comma-separated datasets to export: "+strings.Join(backfillDatasets, ", "))
+	fs.StringVar(&partition, "partition", string(export.PartitionYearMonth), "partitioning: none, year, year/month or year/month/day")
+	fs.StringVar(&compress, "compress", "none", "compression of the output files: none, gzip or zstd")
+	fs.IntVar(&parallel, "parallel", 1, "partitions imported and written in parallel")
+	flags.registerFormat(fs, "parquet")
+	flags.registerImport(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	case flags.from == "":
+		return fmt.Errorf("--from is required")
+	case out == "":
+		return fmt.Errorf("--out is required")
+	}
+	format, err := flags.exportFormat()
+	if err != nil {
+		return err
+	}
+	partitioning, err := export.ParsePartitioning(partition)
+	if err != nil {
+		return err
+	}
+	codec, err := compression.Parse(compress)
+	if err != nil {
+		return err
+	}
+	dates, err := flags.dateRange(time.Now())
+	if err != nil {
+		return err
+	}
+	names, err := parseDatasets(datasets)
+	if err != nil {
+		return err
+	}
+
+	options := flags.importOptions(stderr)
+	for _, name := range names {
+		exporter := export.NewExporter(exportImporter(name, options), format)
+		exporter.SetName(name)
+		exporter.SetPartitioning(partitioning)
+		exporter.SetCompression(codec)
+		exporter.SetConcurrency(parallel)
+
+		summary, err := exporter.Export(ctx, out, dates.Start, dates.End)
+		if summary != nil {
+			fmt.Fprintf(stdout, "%s: %d files written, %d already existed, %d partitions without data\n",
+				name, len(summary.Written), len(summary.Skipped), summary.Empty)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// exportImporter returns the importer of a dataset named in backfillDatasets
+func exportImporter(name string, options importers.ImportOptions) importers.Importer {
+	switch name {
+	case "prices":
+		return importers.NewMarginalPriceImporter(options)
+	case "curves":
+		// The daily aggregated file needs one request per day instead of one per hour
+		importer := importers.NewSupplyDemandCurveDayImporter(options)
+		importer.SetSource(importers.AggregatedCurveFile)
+		return importer
+	default:
+		var system types.SystemType
+		system.UnmarshalText([]byte(strings.ToUpper(strings.TrimPrefix(name, "tech-"))))
+		return importers.NewEnergyByTechnologyImporter(system, options)
+	}
+}
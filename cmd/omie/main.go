@@ -0,0 +1,89 @@
+// Command omie downloads OMIE market data and writes it as CSV, JSON, NDJSON, XLSX or
+// Parquet, for users who want the data without writing Go.
+//
+// Usage:
+//
+//	omie prices --from 2024-01-01 --to 2024-01-31 --format csv
+//	omie tech --system iberian --date 2024-01-15 --layout wide
+//	omie curves --date 2024-01-15 --hour 12 --format json
+//	omie backfill --from 2010-01-01 --to today --out ./archive
+//	omie export --from 2010-01-01 --to 2024-12-31 --format parquet --partition year/month --out ./lake
+//	omie daemon --out ./archive --sqlite omie.db
+//
+// Dates default to yesterday. Output goes to stdout unless --output is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// command is a subcommand of omie
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+}
+
+// commands lists the subcommands in the order of the usage message
+var commands = []command{
+	{"prices", "Day-ahead marginal prices and energies", runPrices},
+	{"tech", "Energy by technology of a system", runTechnology},
+	{"curves", "Supply and demand curves", runCurves},
+	{"backfill", "Download every dataset of a range of days into a directory", runBackfill},
+	{"export", "Export a range of days into a partitioned dataset", runExport},
+	{"daemon", "Import new days as OMIE publishes them", runDaemon},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the subcommand named by args[0], returning the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(ctx, args[1:], stdout, stderr)
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 2
+		default:
+			fmt.Fprintf(stderr, "omie %s: %v\n", cmd.name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stderr, "omie: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("Usage: omie <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	b.WriteString("\nRun 'omie <command> -h' for the flags of a command.\n")
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/export/csvexport"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/notify"
+	"github.com/devuo/omiedata/schedule"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestRunUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"bogus"}, &bytes.Buffer{}, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for an unknown command, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") || !strings.Contains(stderr.String(), "prices") {
+		t.Errorf("expected the error and the command list, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run(context.Background(), []string{"prices", "--format", "yaml"}, &bytes.Buffer{}, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown format, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown format "yaml"`) {
+		t.Errorf("expected the format error, got %q", stderr.String())
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		flags      commonFlags
+		start, end string
+		wantErr    bool
+	}{
+		{"defaults to yesterday", commonFlags{}, "2024-02-29", "2024-02-29", false},
+		{"range", commonFlags{from: "2024-01-01", to: "2024-01-31"}, "2024-01-01", "2024-01-31", false},
+		{"to defaults to from", commonFlags{from: "2024-01-01"}, "2024-01-01", "2024-01-01", false},
+		{"single date", commonFlags{date: "2024-01-15"}, "2024-01-15", "2024-01-15", false},
+		{"date with from", commonFlags{date: "2024-01-15", from: "2024-01-01"}, "", "", true},
+		{"invalid date", commonFlags{from: "15/01/2024"}, "", "", true},
+		{"inverted range", commonFlags{from: "2024-01-31", to: "2024-01-01"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := tt.flags.dateRange(now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dateRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := dates.Start.Format(dateLayout); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := dates.End.Format(dateLayout); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	flags := commonFlags{format: "CSV", layout: "wide", separator: `\t`, decimal: ","}
+	format, err := flags.exportFormat()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	csv, ok := format.(csvexport.Format)
+	if !ok {
+		t.Fatalf("expected a CSV format, got %T", format)
+	}
+	if csv.Options.Layout != csvexport.Wide || csv.Options.Separator != '\t' || csv.Options.Decimal != ',' {
+		t.Errorf("unexpected CSV options %+v", csv.Options)
+	}
+
+	for _, name := range []string{"json", "ndjson", "xlsx", "parquet"} {
+		flags := commonFlags{format: name}
+		format, err := flags.exportFormat()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if format.Extension() != "."+name {
+			t.Errorf("%s: extension = %s", name, format.Extension())
+		}
+	}
+
+	for _, flags := range []commonFlags{
+		{format: "csv", layout: "tall", separator: ",", decimal: "."},
+		{format: "csv", layout: "long", separator: ";;", decimal: "."},
+	} {
+		if _, err := flags.exportFormat(); err == nil {
+			t.Errorf("expected an error for %+v", flags)
+		}
+	}
+}
+
+func TestParseSystems(t *testing.T) {
+	systems, err := parseSystems("Spain, iberian")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(systems) != 2 || systems[0] != types.Spain || systems[1] != types.Iberian {
+		t.Errorf("unexpected systems %v", systems)
+	}
+
+	if systems, err := parseSystems("none"); err != nil || len(systems) != 0 {
+		t.Errorf("expected no systems for none, got %v, %v", systems, err)
+	}
+	if _, err := parseSystems("france"); err == nil {
+		t.Error("expected an error for an unknown system")
+	}
+}
+
+func TestRunExportFlags(t *testing.T) {
+	out := t.TempDir()
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--out", out}, "--from is required"},
+		{[]string{"--from", "2024-01-01"}, "--out is required"},
+		{[]string{"--from", "2024-01-01", "--out", out, "--partition", "week"}, `unknown partitioning "week"`},
+		{[]string{"--from", "2024-01-01", "--out", out, "--compress", "brotli"}, `unknown compression "brotli"`},
+		{[]string{"--from", "2024-01-01", "--out", out, "--datasets", "offers"}, `unknown dataset "offers"`},
+		{[]string{"--from", "2024-01-01", "--out", out, "--format", "yaml"}, `unknown format "yaml"`},
+	} {
+		var stderr bytes.Buffer
+		if code := run(context.Background(), append([]string{"export"}, tc.args...), &bytes.Buffer{}, &stderr); code != 1 {
+			t.Errorf("%v: expected exit code 1, got %d", tc.args, code)
+		}
+		if !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%v: expected %q, got %q", tc.args, tc.want, stderr.String())
+		}
+	}
+}
+
+func TestExportImporter(t *testing.T) {
+	for name, want := range map[string]string{
+		"prices":       "*importers.MarginalPriceImporter",
+		"tech-iberian": "*importers.EnergyByTechnologyImporter",
+		"curves":       "*importers.SupplyDemandCurveDayImporter",
+	} {
+		if got := fmt.Sprintf("%T", exportImporter(name, importers.ImportOptions{})); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+// recorder is a notifier keeping the events it receives
+type recorder struct {
+	events []notify.Event
+}
+
+func (r *recorder) Notify(ctx context.Context, event notify.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestNotifyFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--smtp", "mail.example.com"},
+		{"--smtp", "mail.example.com:25", "--mail-to", "ops@example.com"},
+		{"--mail-from", "omie@example.com", "--mail-to", "ops@example.com"},
+	} {
+		var notifications notifyFlags
+		fs := newFlagSet("test", "", io.Discard)
+		notifications.register(fs, "URL")
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := notifications.notifiers(); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	var notifications notifyFlags
+	fs := newFlagSet("test", "", io.Discard)
+	notifications.register(fs, "URL")
+	args := []string{"--webhook", "http://localhost/hook", "--slack", "http://localhost/slack",
+		"--smtp", "localhost:25", "--mail-from", "omie@example.com", "--mail-to", "ops@example.com"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := notifications.notifiers(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNotifyFinished(t *testing.T) {
+	notifier := &recorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Interrupted runs are reported too
+	notifyFinished(ctx, io.Discard, notifier, "backfill", "prices: 2 days downloaded", context.Canceled)
+	if len(notifier.events) != 1 {
+		t.Fatalf("expected a notification, got %d", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.Kind != notify.JobFinished || event.Job != "backfill" || event.Message != "prices: 2 days downloaded" || event.Err != context.Canceled {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestTrackedJob(t *testing.T) {
+	notifier := &recorder{}
+	tracker := notify.NewFailureTracker(notifier, "day-ahead prices", 2)
+
+	var fail bool
+	job := tracked(schedule.Job{Name: "day-ahead prices", Run: func(ctx context.Context, at time.Time) error {
+		if fail {
+			return errors.New("not published")
+		}
+		return nil
+	}}, tracker)
+
+	ctx := context.Background()
+	// A success between two failures resets the streak, two failures in a row notify
+	for _, failing := range []bool{true, false, true, true} {
+		fail = failing
+		if err := job.Run(ctx, time.Now()); err != nil {
+			tracker.Failure(ctx, err)
+		}
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Kind != notify.FailureThreshold {
+		t.Errorf("expected a single failure notification, got %+v", notifier.events)
+	}
+}
+
+func TestCurveChunks(t *testing.T) {
+	start := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	if chunks := curveChunks(start, end, false); len(chunks) != 33 || !chunks[0].End.Equal(start) {
+		t.Errorf("expected a chunk per day, got %d", len(chunks))
+	}
+
+	chunks := curveChunks(start, end, true)
+	want := []types.DateRange{
+		{Start: start, End: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), End: end},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d monthly chunks, got %v", len(want), chunks)
+	}
+	for i := range want {
+		if !chunks[i].Start.Equal(want[i].Start) || !chunks[i].End.Equal(want[i].End) {
+			t.Errorf("chunk %d = %v, want %v", i, chunks[i], want[i])
+		}
+	}
+}
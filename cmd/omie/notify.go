@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/devuo/omiedata/notify"
+)
+
+// notifyFlags are the flags selecting where the notifications of long-running commands
+// are sent
+type notifyFlags struct {
+	webhooks []notify.Notifier
+	slack    []notify.Notifier
+	smtp     string
+	mailFrom string
+	mailTo   []string
+}
+
+// register adds the notification flags to fs, describing what --webhook receives
+func (n *notifyFlags) register(fs *flag.FlagSet, webhookUsage string) {
+	fs.Func("webhook", webhookUsage+" (repeatable)", func(url string) error {
+		n.webhooks = append(n.webhooks, notify.NewWebhookNotifier(url))
+		return nil
+	})
+	fs.Func("slack", "Slack incoming webhook URL notified with a summary (repeatable)", func(url string) error {
+		n.slack = append(n.slack, notify.NewSlackNotifier(url))
+		return nil
+	})
+	fs.StringVar(&n.smtp, "smtp", "", "SMTP server, host:port, emailing a summary to --mail-to; credentials are read from\n"+
+		"OMIE_SMTP_USERNAME and OMIE_SMTP_PASSWORD when set")
+	fs.StringVar(&n.mailFrom, "mail-from", "", "sender of the emails sent through --smtp")
+	fs.Func("mail-to", "recipient of the emails sent through --smtp (repeatable)", func(to string) error {
+		n.mailTo = append(n.mailTo, to)
+		return nil
+	})
+}
+
+// notifiers returns the notifier of every --webhook and the notifier of every
+// destination selected by the flags, webhooks included
+func (n *notifyFlags) notifiers() (webhooks, all notify.Notifier, err error) {
+	notifiers := append(append([]notify.Notifier{}, n.webhooks...), n.slack...)
+
+	switch {
+	case n.smtp == "" && (n.mailFrom != "" || len(n.mailTo) > 0):
+		return nil, nil, fmt.Errorf("--mail-from and --mail-to need --smtp")
+	case n.smtp != "":
+		host, _, err := net.SplitHostPort(n.smtp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --smtp %q, expected host:port", n.smtp)
+		}
+		if n.mailFrom == "" || len(n.mailTo) == 0 {
+			return nil, nil, fmt.Errorf("--smtp needs --mail-from and --mail-to")
+		}
+
+		var auth smtp.Auth
+		if username := os.Getenv("OMIE_SMTP_USERNAME"); username != "" {
+			auth = smtp.PlainAuth("", username, os.Getenv("OMIE_SMTP_PASSWORD"), host)
+		}
+		notifiers = append(notifiers, notify.NewSMTPNotifier(n.smtp, auth, n.mailFrom, n.mailTo...))
+	}
+
+	return notify.Multi(n.webhooks...), notify.Multi(notifiers...), nil
+}
+
+// notifyFinished sends a JobFinished event for job, with the outcome err. It's sent
+// even once ctx is cancelled, so interrupted runs are reported too, and a failed
+// notification is only reported on stderr.
+func notifyFinished(ctx context.Context, stderr io.Writer, notifier notify.Notifier, job, message string, err error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	event := notify.Event{Kind: notify.JobFinished, Job: job, Time: time.Now(), Message: message, Err: err}
+	if err := notifier.Notify(ctx, event); err != nil {
+		fmt.Fprintf(stderr, "notification failed: %v\n", err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/devuo/omiedata/export"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// backfillDataset returns the name a dataset's progress is kept under in the backfill
+// journal. Days are recorded per format, as the files of one format don't stand in for
+// another's, so resuming with another format downloads the days again.
+func backfillDataset(name string, format export.Format) string {
+	return name + format.Extension()
+}
+
+// completedDays returns how many days of start..end journal has recorded for dataset
+func completedDays(ctx context.Context, journal importers.Journal, dataset string, start, end time.Time) (int, error) {
+	missing, err := journal.Missing(ctx, dataset, start, end)
+	if err != nil {
+		return 0, err
+	}
+	days := (types.DateRange{Start: start, End: end}).Days()
+	for _, gap := range missing {
+		days -= gap.Days()
+	}
+	return days, nil
+}
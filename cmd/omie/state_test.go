@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/export/csvexport"
+	"github.com/devuo/omiedata/export/ndjson"
+	"github.com/devuo/omiedata/importers"
+)
+
+func TestBackfillJournal(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	journal, err := importers.OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prices := backfillDataset("prices", ndjson.Format{})
+	for _, d := range []int{3, 1, 2, 7, 8, 5} {
+		if err := journal.Record(ctx, prices, day(d)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got, _ := completedDays(ctx, journal, prices, day(2), day(7)); got != 4 {
+		t.Errorf("completedDays() = %d, want 4", got)
+	}
+
+	loaded, err := importers.OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := completedDays(ctx, loaded, prices, day(1), day(10)); got != 6 {
+		t.Errorf("expected 6 days done after loading, got %d", got)
+	}
+
+	// The days written in one format are still missing in another
+	csv := backfillDataset("prices", csvexport.Format{})
+	if got, _ := completedDays(ctx, loaded, csv, day(1), day(10)); got != 0 {
+		t.Errorf("expected no days done in another format, got %d", got)
+	}
+}
+
+func TestParseDatasets(t *testing.T) {
+	names, err := parseDatasets("prices, Tech-Spain,,curves")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 3 || names[1] != "tech-spain" {
+		t.Errorf("unexpected datasets %v", names)
+	}
+
+	for _, value := range []string{"prices,weather", ","} {
+		if _, err := parseDatasets(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package compression provides transparent gzip and zstd compression for the files
+// written by the downloaders and exporters.
+package compression
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Compression identifies a compression algorithm
+type Compression string
+
+const (
+	None Compression = ""
+	Gzip Compression = "gzip"
+	Zstd Compression = "zstd"
+)
+
+// Parse parses a compression name ("gzip"/"gz", "zstd"/"zst" or "none")
+func Parse(s string) (Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return None, nil
+	case "gzip", "gz":
+		return Gzip, nil
+	case "zstd", "zst":
+		return Zstd, nil
+	default:
+		return None, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("unknown compression %q", s), nil)
+	}
+}
+
+// FromFilename detects the compression of a file from its extension
+func FromFilename(name string) Compression {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".gz"), strings.HasSuffix(lower, ".tgz"):
+		return Gzip
+	case strings.HasSuffix(lower, ".zst"):
+		return Zstd
+	default:
+		return None
+	}
+}
+
+// Extension returns the file extension appended to compressed files, including the leading dot
+func (c Compression) Extension() string {
+	switch c {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// NewWriter returns a writer that compresses into w. Closing it flushes the compressed
+// stream but does not close w.
+func (c Compression) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("unknown compression %q", string(c)), nil)
+	}
+}
+
+// NewReader returns a reader that decompresses r
+func (c Compression) NewReader(r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case None:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("unknown compression %q", string(c)), nil)
+	}
+}
+
+// nopWriteCloser adds a no-op Close to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
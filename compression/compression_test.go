@@ -0,0 +1,92 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	data := strings.Repeat("Precio marginal en el sistema español (EUR/MWh);45,50;44,10;\n", 100)
+
+	for _, c := range []Compression{None, Gzip, Zstd} {
+		var buf bytes.Buffer
+		writer, err := c.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("%q: NewWriter() error: %v", c, err)
+		}
+		if _, err := io.WriteString(writer, data); err != nil {
+			t.Fatalf("%q: Write() error: %v", c, err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("%q: Close() error: %v", c, err)
+		}
+		if c != None && buf.Len() >= len(data) {
+			t.Errorf("%q: compressed %d bytes into %d", c, len(data), buf.Len())
+		}
+
+		reader, err := c.NewReader(&buf)
+		if err != nil {
+			t.Fatalf("%q: NewReader() error: %v", c, err)
+		}
+		decompressed, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("%q: Read() error: %v", c, err)
+		}
+		if string(decompressed) != data {
+			t.Errorf("%q: round trip changed the data", c)
+		}
+	}
+}
+
+func TestNewReaderCorrupt(t *testing.T) {
+	for _, c := range []Compression{Gzip, Zstd} {
+		reader, err := c.NewReader(strings.NewReader("not compressed at all"))
+		if err == nil {
+			_, err = io.ReadAll(reader)
+			reader.Close()
+		}
+		if err == nil {
+			t.Errorf("%q: expected an error reading uncompressed data", c)
+		}
+	}
+}
+
+func TestUnknownCompression(t *testing.T) {
+	if _, err := Compression("lz4").NewWriter(io.Discard); err == nil {
+		t.Error("expected an error creating a writer of an unknown compression")
+	}
+	if _, err := Compression("lz4").NewReader(strings.NewReader("")); err == nil {
+		t.Error("expected an error creating a reader of an unknown compression")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for input, want := range map[string]Compression{"": None, "none": None, "GZIP": Gzip, "gz": Gzip, " zstd ": Zstd, "zst": Zstd} {
+		if c, err := Parse(input); err != nil || c != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", input, c, err, want)
+		}
+	}
+	if _, err := Parse("lz4"); err == nil {
+		t.Error("expected an error parsing an unknown compression")
+	}
+}
+
+func TestFromFilename(t *testing.T) {
+	for name, want := range map[string]Compression{
+		"prices.tar":     None,
+		"prices.tar.gz":  Gzip,
+		"prices.TGZ":     Gzip,
+		"prices.tar.zst": Zstd,
+		"PMD_20240115":   None,
+	} {
+		if c := FromFilename(name); c != want {
+			t.Errorf("FromFilename(%q) = %q, want %q", name, c, want)
+		}
+		if c := FromFilename("data" + want.Extension()); c != want {
+			t.Errorf("FromFilename() of the extension of %q = %q", want, c)
+		}
+	}
+}
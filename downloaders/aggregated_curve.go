@@ -0,0 +1,18 @@
+package downloaders
+
+// AggregatedCurveDownloader downloads the daily aggregated supply/demand curves (curva_pbc).
+// The folder and file names follow those of the curva_pbc_uof files and haven't been
+// checked against a published file yet.
+type AggregatedCurveDownloader struct {
+	*GeneralDownloader
+}
+
+// NewAggregatedCurveDownloader creates a new aggregated curve downloader
+func NewAggregatedCurveDownloader() *AggregatedCurveDownloader {
+	urlMask := fileDownloadURL + "curva_pbc&filename=curva_pbc_YYYYMMDD.1"
+	outputMask := "curva_pbc_YYYYMMDD.1"
+
+	return &AggregatedCurveDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
@@ -0,0 +1,112 @@
+package downloaders
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/compression"
+)
+
+// filesTransport answers the requests for the files it holds by the end of their URL,
+// and 404 Not Found for any other
+type filesTransport map[string]string
+
+func (f filesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for suffix, body := range f {
+		if strings.HasSuffix(req.URL.String(), suffix) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		}
+	}
+	return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// newFilesDownloader returns a marginal price downloader fetching from files
+func newFilesDownloader(files filesTransport) *MarginalPriceDownloader {
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 0, MaxConcurrent: 1, RequestTimeout: time.Second})
+	d.client = &http.Client{Transport: files}
+	return d
+}
+
+func TestDownloadArchive(t *testing.T) {
+	files := filesTransport{
+		"_15_01_2024_15_01_2024.TXT": "prices of the 15th",
+		"_16_01_2024_16_01_2024.TXT": "prices of the 16th",
+	}
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, name := range []string{"prices.tar", "prices.tar.gz", "prices.tar.zst"} {
+		path := filepath.Join(t.TempDir(), "nested", name)
+		err := newFilesDownloader(files).DownloadArchive(context.Background(), start, start.AddDate(0, 0, 2), path, false)
+		if err == nil || !strings.Contains(err.Error(), "1 errors") {
+			t.Errorf("%s: expected the missing 17th reported, got %v", name, err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		reader, err := compression.FromFilename(path).NewReader(file)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		members := make(map[string]string)
+		archive := tar.NewReader(reader)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: invalid archive: %v", name, err)
+			}
+			body, _ := io.ReadAll(archive)
+			members[header.Name] = string(body)
+			if day, _ := time.Parse("20060102.txt", strings.TrimPrefix(header.Name, "PMD_")); !header.ModTime.Equal(day) {
+				t.Errorf("%s: %s dated %v, want the file's date", name, header.Name, header.ModTime)
+			}
+		}
+		reader.Close()
+		file.Close()
+
+		if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+			t.Errorf("%s: expected only the archive in its folder, got %d files", name, len(entries))
+		}
+		if len(members) != 2 || members["PMD_20240115.txt"] != "prices of the 15th" || members["PMD_20240116.txt"] != "prices of the 16th" {
+			t.Errorf("%s: unexpected members %v", name, members)
+		}
+	}
+}
+
+func TestDownloadDataCompressed(t *testing.T) {
+	d := newFilesDownloader(filesTransport{"_15_01_2024_15_01_2024.TXT": "prices of the 15th"})
+	d.config.Compression = compression.Zstd
+
+	dir := t.TempDir()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if err := d.DownloadData(context.Background(), date, date, dir, false); err != nil {
+		t.Fatalf("DownloadData() error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "PMD_20240115.txt.zst"))
+	if err != nil {
+		t.Fatalf("expected the file saved with the compression extension: %v", err)
+	}
+	defer file.Close()
+	reader, err := compression.Zstd.NewReader(file)
+	if err != nil {
+		t.Fatalf("NewReader() error: %v", err)
+	}
+	defer reader.Close()
+	if body, err := io.ReadAll(reader); err != nil || string(body) != "prices of the 15th" {
+		t.Errorf("unexpected contents %q, %v", body, err)
+	}
+}
@@ -0,0 +1,92 @@
+package downloaders
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// fixtureTransport serves local testdata files instead of hitting omie.es, choosing the
+// file by the dataset prefix found in the requested URL
+type fixtureTransport struct {
+	files map[string][]byte // URL substring -> file contents
+}
+
+func newFixtureTransport(b *testing.B, files map[string]string) *fixtureTransport {
+	b.Helper()
+	t := &fixtureTransport{files: make(map[string][]byte)}
+	for pattern, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			b.Fatalf("failed to read fixture %s: %v", filename, err)
+		}
+		t.files[pattern] = data
+	}
+	return t
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for pattern, data := range t.files {
+		if strings.Contains(req.URL.Path, pattern) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(data)),
+				Request:    req,
+			}, nil
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// benchmarkImport downloads a month through the fixture transport and parses every
+// response, mirroring the receive loop of the importers
+func benchmarkImport(b *testing.B, downloader *GeneralDownloader, parser parsers.Parser, start time.Time) {
+	end := start.AddDate(0, 0, 29)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		days := 0
+		for result := range downloader.URLResponses(context.Background(), start, end, false) {
+			if result.Error != nil {
+				b.Fatal(result.Error)
+			}
+
+			_, err := parser.ParseResponse(result.Response)
+			result.Response.Body.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+			days++
+		}
+
+		if days != 30 {
+			b.Fatalf("expected 30 days, got %d", days)
+		}
+	}
+}
+
+func BenchmarkMarginalPriceImport(b *testing.B) {
+	downloader := NewMarginalPriceDownloader()
+	downloader.client.Transport = newFixtureTransport(b, map[string]string{"INT_PBC_EV_H": "../testdata/PMD_20090601.txt"})
+
+	benchmarkImport(b, downloader.GeneralDownloader, parsers.NewMarginalPriceParser(), time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func BenchmarkEnergyByTechnologyImport(b *testing.B) {
+	downloader := NewEnergyByTechnologyDownloader(types.Iberian)
+	downloader.client.Transport = newFixtureTransport(b, map[string]string{"INT_PBC_TECNOLOGIAS_H_9": "../testdata/EnergyByTechnology_9_20201113.TXT"})
+
+	benchmarkImport(b, downloader.GeneralDownloader, parsers.NewEnergyByTechnologyParser(), time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC))
+}
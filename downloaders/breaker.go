@@ -0,0 +1,77 @@
+package downloaders
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// CircuitBreaker stops requesting files once omie.es looks down, so a multi-year import
+// doesn't try every remaining date with full retries. It trips after a number of
+// consecutive failed requests, network errors or 5xx responses, and then fails every
+// request fast with an ErrCodeUnavailable error. Once the cooldown has passed, a single
+// request is let through to probe the server; a success closes the breaker again.
+// Share a breaker between downloaders, or importers through ImportOptions, so they
+// learn from each other's failures.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int       // Consecutive failures
+	openedAt time.Time // When the breaker tripped or last let a probe through
+}
+
+// NewCircuitBreaker creates a breaker tripping after threshold consecutive failures and
+// probing the server again every cooldown while open
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Open reports whether the breaker has tripped
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+// allow returns an ErrCodeUnavailable error when a request must not be made, letting a
+// probe through once per cooldown while open
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if now := b.now(); now.Sub(b.openedAt) >= b.cooldown {
+		b.openedAt = now
+		return nil
+	}
+	return types.NewOMIEError(types.ErrCodeUnavailable,
+		fmt.Sprintf("omie.es unavailable, not requesting after %d consecutive failures", b.failures), nil)
+}
+
+// success records a request the server answered, closing the breaker
+func (b *CircuitBreaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+}
+
+// failure records a failed request, tripping the breaker at the threshold
+func (b *CircuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures == b.threshold {
+		b.openedAt = b.now()
+	}
+}
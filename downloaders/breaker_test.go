@@ -0,0 +1,63 @@
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	requests := 0
+	down := true
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data")), Request: req}, nil
+	})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, CircuitBreaker: breaker})
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	unavailable := 0
+	for result := range d.URLResponses(context.Background(), start, start.AddDate(0, 0, 9), false) {
+		var omieErr *types.OMIEError
+		if errors.As(result.Error, &omieErr) && omieErr.Code == types.ErrCodeUnavailable {
+			unavailable++
+		}
+	}
+
+	// Two attempts of the first date and one of the second trip the breaker
+	if requests != 3 {
+		t.Errorf("expected 3 requests before the breaker tripped, got %d", requests)
+	}
+	if unavailable != 9 || !breaker.Open() {
+		t.Errorf("expected the other 9 dates to fail fast as unavailable, got %d", unavailable)
+	}
+
+	// After the cooldown a probe goes through and closes the breaker
+	down = false
+	now = now.Add(time.Minute)
+	for result := range d.URLResponses(context.Background(), start, start.AddDate(0, 0, 1), false) {
+		if result.Error != nil {
+			t.Errorf("unexpected error: %v", result.Error)
+			continue
+		}
+		result.Response.Body.Close()
+	}
+	if breaker.Open() || requests != 5 {
+		t.Errorf("expected the breaker to close after a successful probe, open %v with %d requests", breaker.Open(), requests)
+	}
+}
@@ -0,0 +1,76 @@
+package downloaders
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/devuo/omiedata/internal/atomicfile"
+)
+
+// DefaultCacheMinAge is how old a date must be before its files are cached. OMIE may
+// still publish or correct the files of the last few days, but older files never change.
+const DefaultCacheMinAge = 7 * 24 * time.Hour
+
+// FileCache keeps downloaded files on disk, keyed by dataset and date through the names
+// DownloadData gives them, so downloading the same file again is served from disk
+// without contacting OMIE. Only successful downloads of dates older than the minimum
+// age are cached; for the monthly and yearly files, the age counts from the last day of
+// the month or year.
+type FileCache struct {
+	dir    string
+	minAge time.Duration
+	now    func() time.Time
+}
+
+// NewFileCache creates a cache keeping files under dir, created when the first file is
+// stored
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{dir: dir, minAge: DefaultCacheMinAge, now: time.Now}
+}
+
+// SetMinAge sets how old a date must be before its files are cached
+func (c *FileCache) SetMinAge(minAge time.Duration) {
+	c.minAge = minAge
+}
+
+// Dir returns the folder the files are kept in
+func (c *FileCache) Dir() string {
+	return c.dir
+}
+
+// cacheable reports whether a file whose period ends on last is old enough to be cached
+func (c *FileCache) cacheable(last time.Time) bool {
+	return c.now().Sub(last) >= c.minAge
+}
+
+// open returns the cached file name, whose period ends on last, as a successful response,
+// if there is one. The age is checked again, so files stored under a shorter minimum age
+// are downloaded again until their period is old enough.
+func (c *FileCache) open(name string, last time.Time) (*http.Response, bool) {
+	if !c.cacheable(last) {
+		return nil, false
+	}
+	file, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, false
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: file}, true
+}
+
+// store saves a copy of the body of resp as name, leaving an identical body in place for
+// the caller. The file is written through a temporary sibling renamed into place, so
+// concurrent imports never read a partial file.
+func (c *FileCache) store(resp *http.Response, name string) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(filepath.Join(c.dir, name), body)
+}
@@ -0,0 +1,16 @@
+package downloaders
+
+// ContinuousIntradayDownloader downloads the intraday continuous market (MIC) price files
+type ContinuousIntradayDownloader struct {
+	*GeneralDownloader
+}
+
+// NewContinuousIntradayDownloader creates a new intraday continuous market downloader
+func NewContinuousIntradayDownloader() *ContinuousIntradayDownloader {
+	urlMask := fileDownloadURL + "precios_pibcic&filename=precios_pibcic_YYYYMMDD.1"
+	outputMask := "precios_pibcic_YYYYMMDD.1"
+
+	return &ContinuousIntradayDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
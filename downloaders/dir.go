@@ -0,0 +1,109 @@
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/devuo/omiedata/compression"
+	"github.com/devuo/omiedata/types"
+)
+
+// DirResponses returns a channel with one response per date in the range, read from the
+// files a previous DownloadData saved in dir under the output mask. Files saved with
+// any compression are decompressed. Dates without a file yield a not found error with
+// a 404 status, like dates OMIE hasn't published.
+func (d *GeneralDownloader) DirResponses(ctx context.Context, dir string, dateIni, dateEnd time.Time) <-chan ResponseResult {
+	resultChan := make(chan ResponseResult)
+
+	go func() {
+		defer close(resultChan)
+
+		dates, err := types.NewDateRange(dateIni, dateEnd)
+		if err != nil {
+			sendResult(ctx, resultChan, ResponseResult{Date: dateIni, Error: err})
+			return
+		}
+
+		for date := range dates.All() {
+			if !sendResult(ctx, resultChan, d.readFile(dir, date)) {
+				return
+			}
+		}
+	}()
+
+	return resultChan
+}
+
+// readFile opens the file saved for date in dir as a response
+func (d *GeneralDownloader) readFile(dir string, date time.Time) ResponseResult {
+	name := d.applyMask(d.outputMask, date)
+	result := ResponseResult{Date: date}
+
+	for _, c := range []compression.Compression{compression.None, compression.Gzip, compression.Zstd} {
+		path := filepath.Join(dir, name+c.Extension())
+		result.URL = path
+
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			result.Error = types.NewOMIEError(types.ErrCodeStorage, "failed to open "+path, err)
+			return result
+		}
+
+		body, err := c.NewReader(file)
+		if err != nil {
+			file.Close()
+			result.Error = types.NewOMIEError(types.ErrCodeStorage, "failed to decompress "+path, err)
+			return result
+		}
+
+		result.Attempts = 1
+		result.StatusCode = http.StatusOK
+		result.Response = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       fileBody{ReadCloser: body, file: file},
+		}
+		return result
+	}
+
+	result.URL = filepath.Join(dir, name)
+	result.StatusCode = http.StatusNotFound
+	result.Error = types.NewOMIEError(types.ErrCodeNotFound, fmt.Sprintf("no file for date %s in %s", date.Format("2006-01-02"), dir), nil)
+	return result
+}
+
+// fileBody closes both a decompressing reader and the file underneath it
+type fileBody struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (b fileBody) Close() error {
+	err := b.ReadCloser.Close()
+	if fileErr := b.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+// sendResult sends result unless ctx is cancelled first, in which case its response is
+// released and false is returned
+func sendResult(ctx context.Context, resultChan chan<- ResponseResult, result ResponseResult) bool {
+	select {
+	case <-ctx.Done():
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+		return false
+	case resultChan <- result:
+		return true
+	}
+}
@@ -2,8 +2,14 @@ package downloaders
 
 import (
 	"context"
+	"crypto/tls"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/devuo/omiedata/compression"
 )
 
 // Downloader defines the interface for downloading OMIE data
@@ -24,12 +30,122 @@ type ResponseResult struct {
 	Date     time.Time
 	URL      string
 	Error    error
+
+	Attempts   int           // Requests made, including retries
+	StatusCode int           // HTTP status of the last request, 0 if none completed
+	Duration   time.Duration // Time spent requesting, including retry delays
 }
 
 // DownloadConfig holds configuration for downloading
 type DownloadConfig struct {
+	// MaxRetries bounds the retries of transient failures, see types.Classify, and the
+	// Range requests resuming a file whose connection dropped midway
 	MaxRetries     int
-	RetryDelay     time.Duration
+	RetryDelay     time.Duration // Wait before the first retry, doubled for every later one
 	RequestTimeout time.Duration
 	MaxConcurrent  int
+
+	// MaxRetryDelay caps the wait between retries, or leaves it uncapped when zero
+	MaxRetryDelay time.Duration
+
+	// RetryJitter shortens every wait by a random fraction of up to RetryJitter (0-1),
+	// so workers that failed together don't retry in lockstep. Waits asked for by the
+	// Retry-After header of a 429 or 503 response are kept as they are.
+	RetryJitter float64
+
+	// DisableCompression stops asking omie.es for gzip-compressed responses. OMIE's text
+	// files compress about tenfold, so they are requested compressed and decompressed as
+	// they are read by default.
+	DisableCompression bool
+
+	// Compression compresses files saved by DownloadData, appending its extension
+	// (e.g. ".gz") to the output file names
+	Compression compression.Compression
+
+	// HTTPClient, when set, makes the requests instead of the downloader's own client,
+	// e.g. to go through a corporate proxy, instrument requests or serve test doubles.
+	// RequestTimeout doesn't apply to it; set the client's Timeout instead.
+	HTTPClient *http.Client
+
+	// Transport, when set, is the RoundTripper of the downloader's own client. It is
+	// ignored when HTTPClient is set.
+	Transport http.RoundTripper
+
+	// Proxy, when set, is the proxy every request goes through, overriding the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables used otherwise
+	Proxy *url.URL
+
+	// TLSConfig, when set, configures the TLS connections to omie.es, e.g. with the
+	// RootCAs of a corporate gateway, see LoadRootCAs. Proxy and TLSConfig apply to the
+	// downloader's own client; they are ignored when HTTPClient or Transport is set.
+	TLSConfig *tls.Config
+
+	// Logger, when set, receives structured events for every request, response, retry
+	// and saved file, with url, date, attempt, status and duration attributes. Without
+	// it, events are only printed to stdout in verbose mode.
+	Logger *slog.Logger
+
+	// Hooks are called at points of the download loop to observe or change its behavior
+	Hooks Hooks
+
+	// Metrics, when set, receives the counts and durations of requests, retries, bytes
+	// received and failures, see Metrics
+	Metrics Metrics
+
+	// Archive, when set, receives a copy of every file downloaded successfully, named by
+	// the output mask and compressed like DownloadData would, before the response is
+	// handed on. A folder archived this way can be re-parsed with DirResponses.
+	Archive Writer
+
+	// RateLimiter, when set, bounds the requests made per second. Downloaders, and
+	// importers, configured with the same limiter share the limit.
+	RateLimiter *RateLimiter
+
+	// Pool, when set, bounds the files downloaded at once together with every other
+	// downloader sharing it, on top of MaxConcurrent, see WorkerPool
+	Pool *WorkerPool
+
+	// CircuitBreaker, when set, fails requests fast while omie.es looks down, see
+	// CircuitBreaker
+	CircuitBreaker *CircuitBreaker
+
+	// Cache, when set, serves files downloaded before from disk instead of requesting
+	// them again, and keeps the files it doesn't have yet once downloaded
+	Cache *FileCache
+
+	// Fixtures, when their Dir is set, record every response to a folder or replay
+	// them from it, see Fixtures. They wrap HTTPClient too when it is set.
+	Fixtures Fixtures
+}
+
+// retryWait returns how long to wait before retry number retry (from 1): RetryDelay
+// doubled for every retry after the first, capped at MaxRetryDelay and shortened by
+// the jitter, drawn with random from [0, 1)
+func (c DownloadConfig) retryWait(retry int, random func() float64) time.Duration {
+	wait := c.RetryDelay
+	for i := 1; i < retry && (c.MaxRetryDelay <= 0 || wait < c.MaxRetryDelay); i++ {
+		wait *= 2
+	}
+	if c.MaxRetryDelay > 0 && wait > c.MaxRetryDelay {
+		wait = c.MaxRetryDelay
+	}
+
+	jitter := math.Min(math.Max(c.RetryJitter, 0), 1)
+	return time.Duration(float64(wait) * (1 - jitter*random()))
+}
+
+// Hooks are called at points of the download loop, e.g. to add headers, record metrics or
+// skip some dates. Every hook is optional and may be called concurrently by the download
+// workers. attempt starts at 1 and counts the retries of a date.
+type Hooks struct {
+	// OnRequest is called before every request and may modify it. Returning an error
+	// stops the download of the date, which fails with that error without retrying.
+	OnRequest func(req *http.Request, date time.Time, attempt int) error
+
+	// OnRetry is called before waiting to retry a date, with the error of the last attempt
+	OnRetry func(date time.Time, attempt int, err error)
+
+	// OnResponse is called with every response received, whatever its status. The body
+	// must be left for the parser.
+	OnResponse func(resp *http.Response, date time.Time, attempt int, duration time.Duration)
 }
@@ -3,8 +3,6 @@ package downloaders
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"strings"
 	"time"
 
 	"github.com/devuo/omiedata/types"
@@ -16,108 +14,73 @@ type EnergyByTechnologyDownloader struct {
 	systemType types.SystemType
 }
 
+// SystemResponseResult is a ResponseResult tagged with the system it was downloaded for
+type SystemResponseResult struct {
+	ResponseResult
+	System types.SystemType
+}
+
 // NewEnergyByTechnologyDownloader creates a new energy by technology downloader
 func NewEnergyByTechnologyDownloader(systemType types.SystemType) *EnergyByTechnologyDownloader {
 	urlMask := "AGNO_YYYY/MES_MM/TXT/INT_PBC_TECNOLOGIAS_H_SYS_DD_MM_YYYY_DD_MM_YYYY.TXT"
 	outputMask := "EnergyByTechnology_SYS_YYYYMMDD.TXT"
 
-	return &EnergyByTechnologyDownloader{
+	d := &EnergyByTechnologyDownloader{
 		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
 		systemType:        systemType,
 	}
+	d.setPlaceholder("SYS", fmt.Sprintf("%d", int(systemType)))
+
+	return d
 }
 
-// URLResponses returns a channel of HTTP responses for the date range
-func (d *EnergyByTechnologyDownloader) URLResponses(ctx context.Context, dateIni, dateEnd time.Time, verbose bool) <-chan ResponseResult {
-	// Override to use custom URL generation
-	resultChan := make(chan ResponseResult)
+// SystemsURLResponses returns a channel of HTTP responses for every system and date in
+// the range. All files go through a single worker pool sharing this downloader's HTTP
+// client and configuration, so MaxConcurrent bounds the total number of requests.
+func (d *EnergyByTechnologyDownloader) SystemsURLResponses(ctx context.Context, systems []types.SystemType, dateIni, dateEnd time.Time, verbose bool) <-chan SystemResponseResult {
+	resultChan := make(chan SystemResponseResult)
+
+	// One downloader per system, sharing the HTTP client and configuration
+	downloaders := make(map[*GeneralDownloader]types.SystemType, len(systems))
+	systemDownloaders := make([]*GeneralDownloader, len(systems))
+	for i, system := range systems {
+		sd := NewEnergyByTechnologyDownloader(system).GeneralDownloader
+		sd.client = d.client
+		sd.config = d.config
+		downloaders[sd] = system
+		systemDownloaders[i] = sd
+	}
 
 	go func() {
 		defer close(resultChan)
 
-		for date := dateIni; !date.After(dateEnd); date = date.AddDate(0, 0, 1) {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				result := d.downloadSingleDate(ctx, date, verbose)
-				resultChan <- result
-			}
-		}
-	}()
-
-	return resultChan
-}
-
-// downloadSingleDate downloads data for a single date with custom URL generation
-func (d *EnergyByTechnologyDownloader) downloadSingleDate(ctx context.Context, date time.Time, verbose bool) ResponseResult {
-	url := d.generateURL(date)
-
-	var lastErr error
-	for attempt := 0; attempt <= d.GeneralDownloader.config.MaxRetries; attempt++ {
-		if attempt > 0 {
+		dates, err := types.NewDateRange(dateIni, dateEnd)
+		if err != nil {
 			select {
 			case <-ctx.Done():
-				return ResponseResult{Date: date, URL: url, Error: ctx.Err()}
-			case <-time.After(d.GeneralDownloader.config.RetryDelay * time.Duration(attempt)):
+			case resultChan <- SystemResponseResult{ResponseResult: ResponseResult{Date: dateIni, Error: err}}:
 			}
+			return
 		}
 
-		if verbose {
-			if attempt > 0 {
-				fmt.Printf("Retrying (%d/%d) %s...\n", attempt, d.GeneralDownloader.config.MaxRetries, url)
-			} else {
-				fmt.Printf("Requesting %s...\n", url)
+		jobs := make(chan downloadJob)
+		go func() {
+			defer close(jobs)
+			for date := range dates.All() {
+				for _, sd := range systemDownloaders {
+					select {
+					case <-ctx.Done():
+						return
+					case jobs <- downloadJob{downloader: sd, date: date}:
+					}
+				}
 			}
-		}
+		}()
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		resp, err := d.GeneralDownloader.client.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		if resp.StatusCode == http.StatusOK {
-			return ResponseResult{Response: resp, Date: date, URL: url}
-		}
-
-		resp.Body.Close()
-		if resp.StatusCode == http.StatusNotFound {
-			lastErr = types.NewOMIEError(types.ErrCodeNotFound, fmt.Sprintf("data not available for date %s", date.Format("2006-01-02")), nil)
-		} else {
-			lastErr = types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("HTTP %d", resp.StatusCode), nil)
-		}
-	}
-
-	return ResponseResult{
-		Date:  date,
-		URL:   url,
-		Error: types.NewOMIEError(types.ErrCodeDownload, fmt.Sprintf("failed after %d attempts", d.GeneralDownloader.config.MaxRetries), lastErr),
-	}
-}
-
-// generateURL generates the URL for a specific date, replacing SYS with system type
-func (d *EnergyByTechnologyDownloader) generateURL(date time.Time) string {
-	url := d.GetCompleteURL()
-	url = strings.ReplaceAll(url, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	url = strings.ReplaceAll(url, "MM", fmt.Sprintf("%02d", date.Month()))
-	url = strings.ReplaceAll(url, "DD", fmt.Sprintf("%02d", date.Day()))
-	url = strings.ReplaceAll(url, "SYS", fmt.Sprintf("%d", int(d.systemType)))
-	return url
-}
+		runJobs(ctx, d.config.MaxConcurrent, jobs, verbose, func(job downloadJob, result ResponseResult) {
+			resultChan <- SystemResponseResult{ResponseResult: result, System: downloaders[job.downloader]}
+		})
+	}()
 
-// generateFilename generates the output filename, replacing SYS with system type
-func (d *EnergyByTechnologyDownloader) generateFilename(date time.Time) string {
-	filename := d.outputMask
-	filename = strings.ReplaceAll(filename, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	filename = strings.ReplaceAll(filename, "MM", fmt.Sprintf("%02d", date.Month()))
-	filename = strings.ReplaceAll(filename, "DD", fmt.Sprintf("%02d", date.Day()))
-	filename = strings.ReplaceAll(filename, "SYS", fmt.Sprintf("%d", int(d.systemType)))
-	return filename
+	return resultChan
 }
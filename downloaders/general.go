@@ -1,29 +1,39 @@
 package downloaders
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/devuo/omiedata/compression"
+	"github.com/devuo/omiedata/internal/atomicfile"
 	"github.com/devuo/omiedata/types"
 )
 
 const (
 	baseURL = "https://www.omie.es/sites/default/files/dados/"
+
+	// fileDownloadURL serves the files listed in OMIE's file access area by folder and name
+	fileDownloadURL = "https://www.omie.es/es/file-download?parents%5B0%5D="
 )
 
 // GeneralDownloader implements the base functionality for OMIE downloaders
 type GeneralDownloader struct {
-	urlMask    string
-	outputMask string
-	client     *http.Client
-	config     DownloadConfig
+	urlMask      string
+	outputMask   string
+	placeholders []string // Extra token/value pairs replaced in both masks, e.g. "SYS", "9"
+	client       *http.Client
+	config       DownloadConfig
 }
 
 // NewGeneralDownloader creates a new GeneralDownloader
@@ -39,6 +49,8 @@ func NewGeneralDownloader(urlMask, outputMask string) *GeneralDownloader {
 			RetryDelay:     time.Second,
 			RequestTimeout: 30 * time.Second,
 			MaxConcurrent:  5,
+			MaxRetryDelay:  30 * time.Second,
+			RetryJitter:    0.5,
 		},
 	}
 }
@@ -46,14 +58,39 @@ func NewGeneralDownloader(urlMask, outputMask string) *GeneralDownloader {
 // SetConfig updates the download configuration
 func (d *GeneralDownloader) SetConfig(config DownloadConfig) {
 	d.config = config
-	d.client.Timeout = config.RequestTimeout
+	if config.HTTPClient != nil {
+		d.client = config.HTTPClient
+	} else {
+		d.client = &http.Client{
+			Timeout:   config.RequestTimeout,
+			Transport: newTransport(config),
+		}
+	}
+	if config.Fixtures.Dir != "" {
+		d.client = config.Fixtures.client(d.client)
+	}
 }
 
-// GetCompleteURL returns the complete URL pattern
+// setPlaceholder registers a token replaced by value in the URL and output masks,
+// after the date tokens have been substituted
+func (d *GeneralDownloader) setPlaceholder(token, value string) {
+	d.placeholders = append(d.placeholders, token, value)
+}
+
+// GetCompleteURL returns the complete URL pattern. Masks are relative to the OMIE
+// files folder unless they are absolute URLs.
 func (d *GeneralDownloader) GetCompleteURL() string {
+	if strings.HasPrefix(d.urlMask, "https://") || strings.HasPrefix(d.urlMask, "http://") {
+		return d.urlMask
+	}
 	return baseURL + d.urlMask
 }
 
+// URLForDate returns the URL the file of date is downloaded from
+func (d *GeneralDownloader) URLForDate(date time.Time) string {
+	return d.generateURL(date)
+}
+
 // DownloadData downloads data for a date range and saves to folder
 func (d *GeneralDownloader) DownloadData(ctx context.Context, dateIni, dateEnd time.Time, outputFolder string, verbose bool) error {
 	// Ensure output folder exists
@@ -61,9 +98,17 @@ func (d *GeneralDownloader) DownloadData(ctx context.Context, dateIni, dateEnd t
 		return types.NewOMIEError(types.ErrCodeDownload, "failed to create output folder", err)
 	}
 
-	// Use the response channel to download and save files
-	responseChan := d.URLResponses(ctx, dateIni, dateEnd, verbose)
+	return d.DownloadTo(ctx, dateIni, dateEnd, NewLocalWriter(outputFolder), verbose)
+}
+
+// DownloadTo downloads data for a date range and delivers every file through writer,
+// e.g. to an HTTP endpoint or an SFTP server instead of the local disk
+func (d *GeneralDownloader) DownloadTo(ctx context.Context, dateIni, dateEnd time.Time, writer Writer, verbose bool) error {
+	return d.saveAll(ctx, d.URLResponses(ctx, dateIni, dateEnd, verbose), writer, verbose)
+}
 
+// saveAll delivers every downloaded response through writer, named by the output mask
+func (d *GeneralDownloader) saveAll(ctx context.Context, responseChan <-chan ResponseResult, writer Writer, verbose bool) error {
 	var errors []error
 	for result := range responseChan {
 		if result.Error != nil {
@@ -73,13 +118,11 @@ func (d *GeneralDownloader) DownloadData(ctx context.Context, dateIni, dateEnd t
 
 		// Generate output filename
 		filename := d.generateFilename(result.Date)
-		filepath := filepath.Join(outputFolder, filename)
 
-		if verbose {
-			fmt.Printf("Saving to %s...\n", filepath)
-		}
+		d.logger(verbose).LogAttrs(ctx, slog.LevelInfo, "saving file",
+			slog.String("file", filename), slog.String("date", result.Date.Format("2006-01-02")))
 
-		if err := d.saveResponse(result.Response, filepath); err != nil {
+		if err := d.saveResponse(ctx, result.Response, writer, filename); err != nil {
 			errors = append(errors, types.NewOMIEError(types.ErrCodeDownload, "failed to save file", err))
 		}
 
@@ -87,7 +130,73 @@ func (d *GeneralDownloader) DownloadData(ctx context.Context, dateIni, dateEnd t
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("download completed with %d errors: %v", len(errors), errors[0])
+		return types.JoinErrors("download completed", errors)
+	}
+
+	return nil
+}
+
+// DownloadArchive downloads data for a date range into a single tar archive. The archive
+// is compressed according to its extension: ".tar", ".tar.gz"/".tgz" or ".tar.zst".
+func (d *GeneralDownloader) DownloadArchive(ctx context.Context, dateIni, dateEnd time.Time, archivePath string, verbose bool) error {
+	return d.archiveAll(d.URLResponses(ctx, dateIni, dateEnd, verbose), archivePath, verbose)
+}
+
+// archiveAll writes every downloaded response into a tar archive, named by the output mask
+func (d *GeneralDownloader) archiveAll(responseChan <-chan ResponseResult, archivePath string, verbose bool) error {
+	// The archive is written atomically, so a failed download never leaves a truncated
+	// archive behind
+	var errors []error
+	err := atomicfile.Write(archivePath, func(file io.Writer) error {
+		compressed, err := compression.FromFilename(archivePath).NewWriter(file)
+		if err != nil {
+			return err
+		}
+		archive := tar.NewWriter(compressed)
+
+		for result := range responseChan {
+			if result.Error != nil {
+				errors = append(errors, result.Error)
+				continue
+			}
+
+			// Tar headers need the size up front, and OMIE files are small enough to buffer
+			body, err := io.ReadAll(result.Response.Body)
+			result.Response.Body.Close()
+			if err != nil {
+				errors = append(errors, types.NewOMIEError(types.ErrCodeDownload, "failed to read response", err))
+				continue
+			}
+
+			name := d.applyMask(d.outputMask, result.Date)
+			d.logger(verbose).LogAttrs(context.Background(), slog.LevelInfo, "adding file to archive",
+				slog.String("file", name), slog.String("archive", archivePath))
+
+			header := &tar.Header{
+				Name:    name,
+				Mode:    0644,
+				Size:    int64(len(body)),
+				ModTime: result.Date,
+			}
+			if err := archive.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := archive.Write(body); err != nil {
+				return err
+			}
+		}
+
+		if err := archive.Close(); err != nil {
+			return err
+		}
+		return compressed.Close()
+	})
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeDownload, "failed to write archive", err)
+	}
+
+	if len(errors) > 0 {
+		return types.JoinErrors("download completed", errors)
 	}
 
 	return nil
@@ -100,70 +209,133 @@ func (d *GeneralDownloader) URLResponses(ctx context.Context, dateIni, dateEnd t
 	go func() {
 		defer close(resultChan)
 
-		// Create a channel for dates
-		dateChan := make(chan time.Time)
-
-		// Create worker pool
-		var wg sync.WaitGroup
-		for i := 0; i < d.config.MaxConcurrent; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for date := range dateChan {
-					select {
-					case <-ctx.Done():
-						return
-					default:
-						result := d.downloadSingleDate(ctx, date, verbose)
-						resultChan <- result
-					}
-				}
-			}()
+		dates, err := types.NewDateRange(dateIni, dateEnd)
+		if err != nil {
+			sendResult(ctx, resultChan, ResponseResult{Date: dateIni, Error: err})
+			return
 		}
 
-		// Send dates to workers
+		jobs := make(chan downloadJob)
 		go func() {
-			defer close(dateChan)
-			for d := dateIni; !d.After(dateEnd); d = d.AddDate(0, 0, 1) {
+			defer close(jobs)
+			for date := range dates.All() {
 				select {
 				case <-ctx.Done():
 					return
-				case dateChan <- d:
+				case jobs <- downloadJob{downloader: d, date: date}:
 				}
 			}
 		}()
 
-		wg.Wait()
+		runJobs(ctx, d.config.MaxConcurrent, jobs, verbose, func(_ downloadJob, result ResponseResult) {
+			resultChan <- result
+		})
 	}()
 
 	return resultChan
 }
 
+// downloadJob is a single file requested from a worker pool
+type downloadJob struct {
+	downloader *GeneralDownloader
+	date       time.Time
+}
+
+// runJobs downloads jobs through a pool of workers, handing each result to emit,
+// and returns once every job has been processed
+func runJobs(ctx context.Context, workers int, jobs <-chan downloadJob, verbose bool, emit func(downloadJob, ResponseResult)) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+					emit(job, job.downloader.downloadSingleDate(ctx, job.date, verbose))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 // downloadSingleDate downloads data for a single date with retries
-func (d *GeneralDownloader) downloadSingleDate(ctx context.Context, date time.Time, verbose bool) ResponseResult {
+func (d *GeneralDownloader) downloadSingleDate(ctx context.Context, date time.Time, verbose bool) (result ResponseResult) {
 	url := d.generateURL(date)
+	started := time.Now()
+	result = ResponseResult{Date: date, URL: url}
+	logger := d.logger(verbose)
+	if metrics := d.config.Metrics; metrics != nil {
+		defer func() {
+			if result.Error != nil && ctx.Err() == nil {
+				metrics.IncFailures(types.ErrorCode(result.Error))
+			}
+		}()
+	}
+
+	if d.config.Cache != nil {
+		if resp, ok := d.config.Cache.open(d.applyMask(d.outputMask, date), d.periodEnd(date)); ok {
+			logger.LogAttrs(ctx, slog.LevelDebug, "cache hit", slog.String("url", url),
+				slog.String("date", date.Format("2006-01-02")))
+			return d.finishResponse(ctx, logger, result, resp, started)
+		}
+	}
+
+	// The slot is held while retrying too, so a date failing over and over doesn't let
+	// the pool's other downloaders pile up more requests to a struggling server. A
+	// successful response keeps it until its body is closed, as the file is still being
+	// downloaded while it's read.
+	pool := d.config.Pool
+	if pool != nil {
+		if err := pool.acquire(ctx); err != nil {
+			result.Error = err
+			result.Duration = time.Since(started)
+			return result
+		}
+		defer func() {
+			if result.Response == nil {
+				pool.release()
+				return
+			}
+			result.Response.Body = &poolBody{ReadCloser: result.Response.Body, pool: pool}
+		}()
+	}
 
 	var lastErr error
+	var retryAfter time.Duration // Wait asked for by the last response, if any
 	for attempt := 0; attempt <= d.config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			// Wait before retry
+			if d.config.Hooks.OnRetry != nil {
+				d.config.Hooks.OnRetry(date, attempt+1, lastErr)
+			}
+			if d.config.Metrics != nil {
+				d.config.Metrics.IncRetries()
+			}
+
+			// Wait before retry, as long as the server asked for if it did
+			wait := d.config.retryWait(attempt, rand.Float64)
+			if retryAfter > 0 {
+				wait = retryAfter
+			}
 			select {
 			case <-ctx.Done():
-				return ResponseResult{
-					Date:  date,
-					URL:   url,
-					Error: ctx.Err(),
-				}
-			case <-time.After(d.config.RetryDelay * time.Duration(attempt)):
+				result.Error = ctx.Err()
+				result.Duration = time.Since(started)
+				return result
+			case <-time.After(wait):
 			}
 		}
 
-		if verbose {
-			if attempt > 0 {
-				fmt.Printf("Retrying (%d/%d) %s...\n", attempt, d.config.MaxRetries, url)
-			} else {
-				fmt.Printf("Requesting %s...\n", url)
-			}
+		if attempt > 0 {
+			logger.LogAttrs(ctx, slog.LevelInfo, "retrying request", slog.String("url", url),
+				slog.String("date", date.Format("2006-01-02")), slog.Int("attempt", attempt+1), slog.Any("error", lastErr))
+		} else {
+			logger.LogAttrs(ctx, slog.LevelDebug, "requesting", slog.String("url", url),
+				slog.String("date", date.Format("2006-01-02")), slog.Int("attempt", attempt+1))
 		}
 
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -171,64 +343,260 @@ func (d *GeneralDownloader) downloadSingleDate(ctx context.Context, date time.Ti
 			lastErr = err
 			continue
 		}
+		if !d.config.DisableCompression {
+			acceptGzip(req)
+		}
 
+		if d.config.Hooks.OnRequest != nil {
+			if err := d.config.Hooks.OnRequest(req, date, attempt+1); err != nil {
+				result.Error = types.NewOMIEError(types.ErrCodeDownload, "request stopped by hook", err)
+				result.Duration = time.Since(started)
+				return result
+			}
+		}
+
+		if breaker := d.config.CircuitBreaker; breaker != nil {
+			if err := breaker.allow(); err != nil {
+				logger.LogAttrs(ctx, slog.LevelWarn, "circuit breaker open", slog.String("url", url),
+					slog.String("date", date.Format("2006-01-02")))
+				result.Error = err
+				result.Duration = time.Since(started)
+				return result
+			}
+		}
+
+		if d.config.RateLimiter != nil {
+			if err := d.config.RateLimiter.Wait(ctx); err != nil {
+				result.Error = err
+				result.Duration = time.Since(started)
+				return result
+			}
+		}
+
+		result.Attempts++
+		retryAfter = 0
+		requested := time.Now()
 		resp, err := d.client.Do(req)
+		d.recordOutcome(ctx, resp, err)
+		if d.config.Metrics != nil {
+			status := 0
+			if err == nil {
+				status = resp.StatusCode
+			}
+			d.config.Metrics.ObserveRequest(status, time.Since(requested))
+		}
 		if err != nil {
 			lastErr = err
+			logger.LogAttrs(ctx, slog.LevelWarn, "request failed", slog.String("url", url),
+				slog.String("date", date.Format("2006-01-02")), slog.Int("attempt", attempt+1),
+				slog.Duration("duration", time.Since(requested)), slog.Any("error", err))
 			continue
 		}
+		result.StatusCode = resp.StatusCode
+		if d.config.Hooks.OnResponse != nil {
+			d.config.Hooks.OnResponse(resp, date, attempt+1, time.Since(requested))
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "response", slog.String("url", url),
+			slog.String("date", date.Format("2006-01-02")), slog.Int("attempt", attempt+1),
+			slog.Int("status", resp.StatusCode), slog.Duration("duration", time.Since(requested)))
 
 		// Check for success
 		if resp.StatusCode == http.StatusOK {
-			return ResponseResult{
-				Response: resp,
-				Date:     date,
-				URL:      url,
+			resp.Body = newResumableBody(req, resp, d.client, d.config.MaxRetries, func(req *http.Request, offset int64, cause error) error {
+				logger.LogAttrs(req.Context(), slog.LevelInfo, "resuming download", slog.String("url", url),
+					slog.String("date", date.Format("2006-01-02")), slog.Int64("offset", offset), slog.Any("error", cause))
+				if d.config.RateLimiter != nil {
+					return d.config.RateLimiter.Wait(req.Context())
+				}
+				return nil
+			})
+			if d.config.Metrics != nil {
+				resp.Body = &meteredBody{ReadCloser: resp.Body, metrics: d.config.Metrics}
+			}
+			decompress(resp)
+			if d.config.Cache != nil && d.config.Cache.cacheable(d.periodEnd(date)) {
+				if err := d.config.Cache.store(resp, d.applyMask(d.outputMask, date)); err != nil {
+					resp.Body.Close()
+					result.Error = types.NewOMIEError(types.ErrCodeStorage, "failed to cache response", err)
+					result.Duration = time.Since(started)
+					return result
+				}
 			}
+			return d.finishResponse(ctx, logger, result, resp, started)
 		}
 
 		// Handle different error codes
 		resp.Body.Close()
+		httpErr := &types.HTTPError{StatusCode: resp.StatusCode}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			httpErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
 		if resp.StatusCode == http.StatusNotFound {
-			lastErr = types.NewOMIEError(types.ErrCodeNotFound, fmt.Sprintf("data not available for date %s", date.Format("2006-01-02")), nil)
+			lastErr = types.NewOMIEError(types.ErrCodeNotFound, fmt.Sprintf("data not available for date %s", date.Format("2006-01-02")), httpErr)
 		} else {
-			lastErr = types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("HTTP %d", resp.StatusCode), nil)
+			lastErr = types.NewOMIEError(types.ErrCodeNetwork, "unexpected response", httpErr)
+		}
+
+		// A file that isn't published yet won't be within the retries, and other client
+		// errors won't go away by asking again
+		if httpErr.Class() != types.ClassTransient {
+			break
+		}
+		retryAfter = httpErr.RetryAfter
+	}
+
+	result.Error = types.NewOMIEError(types.ErrCodeDownload, fmt.Sprintf("failed after %d attempts", result.Attempts), lastErr)
+	result.Duration = time.Since(started)
+	logger.LogAttrs(ctx, slog.LevelWarn, "download failed", slog.String("url", url),
+		slog.String("date", date.Format("2006-01-02")), slog.Int("attempts", result.Attempts),
+		slog.Int("status", result.StatusCode), slog.Duration("duration", result.Duration), slog.Any("error", lastErr))
+	return result
+}
+
+// parseRetryAfter returns the wait asked for by a Retry-After header, given either in
+// seconds or as an HTTP date, or zero when there is none or it can't be parsed
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// recordOutcome reports the outcome of a request to the circuit breaker, if any. Network
+// errors and 5xx responses count as failures; errors caused by ctx ending don't count.
+func (d *GeneralDownloader) recordOutcome(ctx context.Context, resp *http.Response, err error) {
+	breaker := d.config.CircuitBreaker
+	switch {
+	case breaker == nil, ctx.Err() != nil:
+	case err != nil, resp.StatusCode >= 500:
+		breaker.failure()
+	default:
+		breaker.success()
+	}
+}
+
+// finishResponse completes result with a successful response, archiving a copy of it
+// first when the downloader is configured to
+func (d *GeneralDownloader) finishResponse(ctx context.Context, logger *slog.Logger, result ResponseResult, resp *http.Response, started time.Time) ResponseResult {
+	if d.config.Archive != nil {
+		logger.LogAttrs(ctx, slog.LevelDebug, "archiving file", slog.String("file", d.generateFilename(result.Date)),
+			slog.String("date", result.Date.Format("2006-01-02")))
+		if err := d.archiveResponse(ctx, resp, result.Date); err != nil {
+			resp.Body.Close()
+			result.Error = types.NewOMIEError(types.ErrCodeStorage, "failed to archive response", err)
+			result.Duration = time.Since(started)
+			return result
 		}
 	}
 
-	return ResponseResult{
-		Date:  date,
-		URL:   url,
-		Error: types.NewOMIEError(types.ErrCodeDownload, fmt.Sprintf("failed after %d attempts", d.config.MaxRetries), lastErr),
+	result.StatusCode = resp.StatusCode
+	result.Response = resp
+	result.Duration = time.Since(started)
+	return result
+}
+
+// verboseLogger prints every event to stdout, for verbose mode without a configured logger
+var verboseLogger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// discardLogger drops every event
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// logger returns the configured logger, falling back to printing to stdout in verbose
+// mode and to discarding events otherwise
+func (d *GeneralDownloader) logger(verbose bool) *slog.Logger {
+	switch {
+	case d.config.Logger != nil:
+		return d.config.Logger
+	case verbose:
+		return verboseLogger
+	default:
+		return discardLogger
 	}
 }
 
 // generateURL generates the URL for a specific date
 func (d *GeneralDownloader) generateURL(date time.Time) string {
-	url := d.GetCompleteURL()
-	url = strings.ReplaceAll(url, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	url = strings.ReplaceAll(url, "MM", fmt.Sprintf("%02d", date.Month()))
-	url = strings.ReplaceAll(url, "DD", fmt.Sprintf("%02d", date.Day()))
-	return url
+	return d.applyMask(d.GetCompleteURL(), date)
 }
 
 // generateFilename generates the output filename for a specific date
 func (d *GeneralDownloader) generateFilename(date time.Time) string {
-	filename := d.outputMask
-	filename = strings.ReplaceAll(filename, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	filename = strings.ReplaceAll(filename, "MM", fmt.Sprintf("%02d", date.Month()))
-	filename = strings.ReplaceAll(filename, "DD", fmt.Sprintf("%02d", date.Day()))
-	return filename
+	return d.applyMask(d.outputMask, date) + d.config.Compression.Extension()
 }
 
-// saveResponse saves an HTTP response to a file
-func (d *GeneralDownloader) saveResponse(resp *http.Response, filepath string) error {
-	file, err := os.Create(filepath)
+// applyMask replaces the date tokens and registered placeholders in a mask
+func (d *GeneralDownloader) applyMask(mask string, date time.Time) string {
+	mask = strings.ReplaceAll(mask, "YYYY", fmt.Sprintf("%04d", date.Year()))
+	mask = strings.ReplaceAll(mask, "MM", fmt.Sprintf("%02d", date.Month()))
+	mask = strings.ReplaceAll(mask, "DD", fmt.Sprintf("%02d", date.Day()))
+	if len(d.placeholders) > 0 {
+		mask = strings.NewReplacer(d.placeholders...).Replace(mask)
+	}
+	return mask
+}
+
+// periodEnd returns the last day covered by the file of date: date itself for daily
+// files, or the last day of the month or year for the files whose output mask has no
+// day, e.g. the monthly averages and the ZIP archives
+func (d *GeneralDownloader) periodEnd(date time.Time) time.Time {
+	switch {
+	case strings.Contains(d.outputMask, "DD"):
+		return date
+	case strings.Contains(d.outputMask, "MM"):
+		return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location())
+	case strings.Contains(d.outputMask, "YYYY"):
+		return time.Date(date.Year(), time.December, 31, 0, 0, 0, 0, date.Location())
+	}
+	return date
+}
+
+// archiveResponse saves a copy of the body of resp through the archive writer, leaving
+// an identical body in place for the caller
+func (d *GeneralDownloader) archiveResponse(ctx context.Context, resp *http.Response, date time.Time) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	archived := &http.Response{Body: io.NopCloser(bytes.NewReader(body))}
+	return d.saveResponse(ctx, archived, d.config.Archive, d.generateFilename(date))
+}
+
+// saveResponse delivers an HTTP response through writer, compressing it if configured
+func (d *GeneralDownloader) saveResponse(ctx context.Context, resp *http.Response, writer Writer, filename string) error {
+	if d.config.Compression == compression.None {
+		return writer.WriteFile(ctx, filename, resp.Body)
+	}
+
+	// Compress on the fly. The pipe is closed once the writer returns, which stops the
+	// compressing goroutine if the writer gave up early, and we wait for it to finish
+	// so the response body is no longer in use when the caller closes it.
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		compressed, err := d.config.Compression.NewWriter(pw)
+		if err == nil {
+			_, err = io.Copy(compressed, resp.Body)
+			if closeErr := compressed.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err := writer.WriteFile(ctx, filename, pr)
+	pr.Close()
+	<-done
+
 	return err
 }
@@ -0,0 +1,399 @@
+package downloaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGeneralDownloaderHTTPClient(t *testing.T) {
+	var requested []string
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("data")),
+			Request:    req,
+		}, nil
+	})}
+
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, HTTPClient: client})
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for result := range d.URLResponses(context.Background(), date, date, false) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		result.Response.Body.Close()
+	}
+
+	if len(requested) != 1 || !strings.Contains(requested[0], "15_01_2024") {
+		t.Errorf("expected the request to go through the custom client, got %v", requested)
+	}
+}
+
+func TestGeneralDownloaderLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	attempts := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		status := http.StatusOK
+		if attempts == 1 {
+			status = http.StatusServiceUnavailable
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, Logger: logger})
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for result := range d.URLResponses(context.Background(), date, date, false) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		result.Response.Body.Close()
+	}
+
+	type event struct {
+		Msg     string `json:"msg"`
+		Date    string `json:"date"`
+		Attempt int    `json:"attempt"`
+		Status  int    `json:"status"`
+	}
+	var events []event
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var e event
+		if err := decoder.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	expected := []event{
+		{Msg: "requesting", Date: "2024-01-15", Attempt: 1},
+		{Msg: "response", Date: "2024-01-15", Attempt: 1, Status: http.StatusServiceUnavailable},
+		{Msg: "retrying request", Date: "2024-01-15", Attempt: 2},
+		{Msg: "response", Date: "2024-01-15", Attempt: 2, Status: http.StatusOK},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected[i], events[i])
+		}
+	}
+}
+
+func TestGeneralDownloaderHooks(t *testing.T) {
+	skipped := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
+	errSkipped := errors.New("skipped")
+
+	var mu sync.Mutex
+	var headers []string
+	var retries, responses int
+	hooks := Hooks{
+		OnRequest: func(req *http.Request, date time.Time, attempt int) error {
+			if date.Equal(skipped) {
+				return errSkipped
+			}
+			req.Header.Set("X-Attempt", strconv.Itoa(attempt))
+			return nil
+		},
+		OnRetry: func(date time.Time, attempt int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries++
+		},
+		OnResponse: func(resp *http.Response, date time.Time, attempt int, duration time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			responses++
+		},
+	}
+
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		headers = append(headers, req.Header.Get("X-Attempt"))
+		status := http.StatusOK
+		if len(headers) == 1 {
+			status = http.StatusServiceUnavailable
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, Hooks: hooks})
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for result := range d.URLResponses(context.Background(), start, skipped, false) {
+		switch {
+		case result.Date.Equal(skipped):
+			if !errors.Is(result.Error, errSkipped) || result.Attempts != 0 {
+				t.Errorf("expected the skipped date to fail without requests, got %v after %d attempts", result.Error, result.Attempts)
+			}
+		case result.Error != nil:
+			t.Errorf("unexpected error: %v", result.Error)
+		default:
+			result.Response.Body.Close()
+		}
+	}
+
+	if len(headers) != 2 || headers[0] != "1" || headers[1] != "2" {
+		t.Errorf("expected the hook's header on both attempts, got %v", headers)
+	}
+	if retries != 1 || responses != 2 {
+		t.Errorf("expected 1 retry and 2 responses, got %d and %d", retries, responses)
+	}
+}
+
+func TestGeneralDownloaderArchive(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("data")),
+			Request:    req,
+		}, nil
+	})}
+
+	dir := t.TempDir()
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, HTTPClient: client, Archive: NewLocalWriter(dir)})
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for result := range d.URLResponses(context.Background(), date, date, false) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		body, _ := io.ReadAll(result.Response.Body)
+		result.Response.Body.Close()
+		if string(body) != "data" {
+			t.Errorf("expected the response body to be left intact, got %q", body)
+		}
+	}
+
+	archived, err := os.ReadFile(filepath.Join(dir, "PMD_20240115.txt"))
+	if err != nil || string(archived) != "data" {
+		t.Errorf("expected the archived file, got %q: %v", archived, err)
+	}
+}
+
+func TestGeneralDownloaderCache(t *testing.T) {
+	requests := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data " + req.URL.Path)), Request: req}, nil
+	})
+
+	dir := t.TempDir()
+	cache := NewFileCache(dir)
+	cache.now = func() time.Time { return time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC) }
+
+	d := NewEnergyByTechnologyDownloader(9)
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, Cache: cache})
+
+	// The 10th and 12th are old enough to be cached, the 15th may still be revised
+	download := func(days ...int) map[int]string {
+		bodies := make(map[int]string)
+		for _, day := range days {
+			date := time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+			for result := range d.URLResponses(context.Background(), date, date, false) {
+				if result.Error != nil {
+					t.Fatalf("unexpected error: %v", result.Error)
+				}
+				body, _ := io.ReadAll(result.Response.Body)
+				result.Response.Body.Close()
+				bodies[day] = string(body)
+			}
+		}
+		return bodies
+	}
+
+	first := download(10, 12, 15)
+	if requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "EnergyByTechnology_9_20240110.TXT")); err != nil {
+		t.Errorf("expected the file of the 10th to be cached: %v", err)
+	}
+
+	second := download(10, 12, 15)
+	if requests != 4 {
+		t.Errorf("expected only the recent date to be requested again, got %d requests", requests-3)
+	}
+	for day, body := range first {
+		if second[day] != body || !strings.Contains(body, "INT_PBC_TECNOLOGIAS_H_9") {
+			t.Errorf("day %d: cached body %q differs from downloaded body %q", day, second[day], body)
+		}
+	}
+}
+
+func TestGeneralDownloaderCacheMonthly(t *testing.T) {
+	requests := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("downloaded")), Request: req}, nil
+	})
+
+	dir := t.TempDir()
+	cache := NewFileCache(dir)
+	d := NewMonthlyPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, Cache: cache})
+
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	download := func() string {
+		var body []byte
+		for result := range d.URLResponses(context.Background(), january, january, false) {
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			body, _ = io.ReadAll(result.Response.Body)
+			result.Response.Body.Close()
+		}
+		return string(body)
+	}
+
+	// Three days after January ended the month may still be revised, even though its
+	// first day is a month old; a file already in the cache isn't trusted either
+	cache.now = func() time.Time { return time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC) }
+	if err := os.WriteFile(filepath.Join(dir, "MonthlyPrice_202401.txt"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if body := download(); body != "downloaded" || requests != 1 {
+		t.Fatalf("expected the recent month to be downloaded, got %q after %d requests", body, requests)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "MonthlyPrice_202401.txt")); string(data) != "stale" {
+		t.Errorf("expected the recent month not to be cached, got %q", data)
+	}
+
+	// A week after it ended, the month is cached and served from disk
+	cache.now = func() time.Time { return time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC) }
+	os.Remove(filepath.Join(dir, "MonthlyPrice_202401.txt"))
+	download()
+	if body := download(); body != "downloaded" || requests != 2 {
+		t.Errorf("expected the month served from the cache, got %q after %d requests", body, requests)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	config := DownloadConfig{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second}
+	noJitter := func() float64 { return 0 }
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := config.retryWait(i+1, noJitter); got != w {
+			t.Errorf("retry %d: waited %s, want %s", i+1, got, w)
+		}
+	}
+
+	config.MaxRetryDelay = 0
+	if got := config.retryWait(10, noJitter); got != 512*time.Second {
+		t.Errorf("expected an uncapped wait of 512s, got %s", got)
+	}
+
+	config.RetryJitter = 0.5
+	if got := config.retryWait(2, func() float64 { return 0.5 }); got != 1500*time.Millisecond {
+		t.Errorf("expected the jitter to shorten 2s by a quarter, got %s", got)
+	}
+	if got := config.retryWait(2, func() float64 { return 0.999 }); got <= time.Second {
+		t.Errorf("expected the jitter to take at most half of the wait, got %s", got)
+	}
+}
+
+func TestGeneralDownloaderRetryClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		header   string
+		attempts int
+		class    types.ErrorClass
+	}{
+		{"not published", []int{http.StatusNotFound}, "", 1, types.ClassNotPublished},
+		{"forbidden", []int{http.StatusForbidden}, "", 1, types.ClassPermanent},
+		{"retry after", []int{http.StatusTooManyRequests, http.StatusOK}, "1", 2, ""},
+		{"server errors", []int{http.StatusBadGateway, http.StatusBadGateway}, "", 2, types.ClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				status := tt.statuses[min(requests, len(tt.statuses)-1)]
+				requests++
+				header := http.Header{}
+				if tt.header != "" {
+					header.Set("Retry-After", tt.header)
+				}
+				return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+			})
+
+			// A retry delay far longer than the test only lets Retry-After through
+			retryDelay := time.Hour
+			if tt.header == "" {
+				retryDelay = time.Millisecond
+			}
+
+			d := NewMarginalPriceDownloader()
+			d.SetConfig(DownloadConfig{MaxRetries: 1, RetryDelay: retryDelay, MaxConcurrent: 1, Transport: transport})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+			for result := range d.URLResponses(ctx, date, date, false) {
+				if result.Response != nil {
+					result.Response.Body.Close()
+				}
+				if result.Attempts != tt.attempts {
+					t.Errorf("expected %d attempts, got %d", tt.attempts, result.Attempts)
+				}
+				if got := types.Classify(result.Error); got != tt.class {
+					t.Errorf("expected class %q, got %q (%v)", tt.class, got, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"Mon, 15 Jan 2024 12:00:30 GMT": 30 * time.Second,
+		"Mon, 15 Jan 2024 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
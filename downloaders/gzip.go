@@ -0,0 +1,51 @@
+package downloaders
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// acceptGzip asks for a gzip-compressed response. Setting the header ourselves turns off
+// the transparent decompression of http.Transport, so responses are decompressed by
+// decompress whatever the RoundTripper, and Range requests resuming them count bytes of
+// the compressed file.
+func acceptGzip(req *http.Request) {
+	req.Header.Set("Accept-Encoding", "gzip")
+}
+
+// decompress replaces the body of a gzip-encoded response with its decompressed content
+func decompress(resp *http.Response) {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipBody decompresses a gzip-encoded body as it is read
+type gzipBody struct {
+	body io.ReadCloser
+	gz   *gzip.Reader // Created on the first read, which reads the gzip header
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.gz == nil {
+		gz, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, types.NewOMIEError(types.ErrCodeNetwork, "invalid gzip response", err)
+		}
+		b.gz = gz
+	}
+	return b.gz.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,53 @@
+package downloaders
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneralDownloaderGzip(t *testing.T) {
+	const file = "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n"
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(file))
+	gz.Close()
+
+	for _, disabled := range []bool{false, true} {
+		var accepted string
+		transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			accepted = req.Header.Get("Accept-Encoding")
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(file)), Request: req}
+			if strings.Contains(accepted, "gzip") {
+				resp.Header.Set("Content-Encoding", "gzip")
+				resp.ContentLength = int64(compressed.Len())
+				resp.Body = io.NopCloser(bytes.NewReader(compressed.Bytes()))
+			}
+			return resp, nil
+		})
+
+		d := NewMarginalPriceDownloader()
+		d.SetConfig(DownloadConfig{MaxRetries: 1, MaxConcurrent: 1, Transport: transport, DisableCompression: disabled})
+
+		date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+		for result := range d.URLResponses(context.Background(), date, date, false) {
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			body, err := io.ReadAll(result.Response.Body)
+			result.Response.Body.Close()
+			if err != nil || string(body) != file {
+				t.Errorf("disabled=%v: expected the decompressed file, got %q (%v)", disabled, body, err)
+			}
+		}
+
+		if (accepted == "gzip") == disabled {
+			t.Errorf("disabled=%v: unexpected Accept-Encoding %q", disabled, accepted)
+		}
+	}
+}
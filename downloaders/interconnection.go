@@ -0,0 +1,18 @@
+package downloaders
+
+// InterconnectionDownloader downloads the Spain-Portugal interconnection capacity and
+// congestion rent files (capacidad_inter_pbc). The folder and file names haven't been
+// checked against a published file yet.
+type InterconnectionDownloader struct {
+	*GeneralDownloader
+}
+
+// NewInterconnectionDownloader creates a new interconnection downloader
+func NewInterconnectionDownloader() *InterconnectionDownloader {
+	urlMask := fileDownloadURL + "capacidad_inter_pbc&filename=capacidad_inter_pbc_YYYYMMDD.1"
+	outputMask := "capacidad_inter_pbc_YYYYMMDD.1"
+
+	return &InterconnectionDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
@@ -0,0 +1,16 @@
+package downloaders
+
+// MarginalPDBCDownloader downloads the day-ahead prices in the plain marginalpdbc format
+type MarginalPDBCDownloader struct {
+	*GeneralDownloader
+}
+
+// NewMarginalPDBCDownloader creates a new marginalpdbc downloader
+func NewMarginalPDBCDownloader() *MarginalPDBCDownloader {
+	urlMask := fileDownloadURL + "marginalpdbc&filename=marginalpdbc_YYYYMMDD.1"
+	outputMask := "marginalpdbc_YYYYMMDD.1"
+
+	return &MarginalPDBCDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
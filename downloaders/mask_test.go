@@ -0,0 +1,56 @@
+package downloaders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestSubDownloaderMasks(t *testing.T) {
+	date := time.Date(2020, 11, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		downloader *GeneralDownloader
+		url        string
+		filename   string
+	}{
+		{
+			"energy by technology",
+			NewEnergyByTechnologyDownloader(types.Iberian).GeneralDownloader,
+			"AGNO_2020/MES_11/TXT/INT_PBC_TECNOLOGIAS_H_9_13_11_2020_13_11_2020.TXT",
+			"EnergyByTechnology_9_20201113.TXT",
+		},
+		{
+			"intraday price",
+			NewIntradayPriceDownloader(types.Session2).GeneralDownloader,
+			"AGNO_2020/MES_11/TXT/INT_PIB_EV_H_1_2_13_11_2020_13_11_2020.TXT",
+			"PrecioIntra_2_20201113.txt",
+		},
+		{
+			"supply and demand curve",
+			NewSupplyDemandCurveDownloader(7).GeneralDownloader,
+			"AGNO_2020/MES_11/TXT/INT_CURVA_ACUM_UO_MIB_1_7_13_11_2020_13_11_2020.TXT",
+			"OfferAndDemandCurve_7_20201113.TXT",
+		},
+		{
+			"monthly price",
+			NewMonthlyPriceDownloader().GeneralDownloader,
+			"AGNO_2020/MES_11/TXT/INT_PBC_EV_M_1_11_2020_11_2020.TXT",
+			"MonthlyPrice_202011.txt",
+		},
+	}
+
+	// DownloadData and URLResponses only go through GeneralDownloader, so the tokens of
+	// the sub-downloaders must be replaced there
+	for _, tc := range tests {
+		if url := tc.downloader.generateURL(date); !strings.HasSuffix(url, tc.url) {
+			t.Errorf("%s: URL %s, want it to end in %s", tc.name, url, tc.url)
+		}
+		if filename := tc.downloader.generateFilename(date); filename != tc.filename {
+			t.Errorf("%s: file name %s, want %s", tc.name, filename, tc.filename)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package downloaders
+
+import (
+	"io"
+	"time"
+)
+
+// Metrics receives the counters and timings of downloads and parsing, e.g. to export
+// them to Prometheus or OpenTelemetry while a long sync job runs. Set it in
+// DownloadConfig, or ImportOptions for importers to report parsing too. Its methods are
+// called concurrently by the download and parse workers, so implementations must be safe
+// for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called after every request with its status, 0 when it failed
+	// without a response, and how long it took until the response headers came in
+	ObserveRequest(status int, duration time.Duration)
+
+	// IncRetries is called before every retry of a file
+	IncRetries()
+
+	// AddBytes is called with the bytes of file content received from omie.es as they
+	// are read, before they are decompressed
+	AddBytes(n int)
+
+	// ObserveParse is called with how long parsing every downloaded file took
+	ObserveParse(duration time.Duration)
+
+	// IncFailures is called for every file that failed to download or parse, with the
+	// code of its error, see types.ErrorCode. Downloads stopped by their context
+	// ending don't count.
+	IncFailures(code string)
+}
+
+// meteredBody reports the bytes read through it to a Metrics
+type meteredBody struct {
+	io.ReadCloser
+	metrics Metrics
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.metrics.AddBytes(n)
+	}
+	return n, err
+}
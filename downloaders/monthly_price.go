@@ -0,0 +1,106 @@
+package downloaders
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// MonthlyPriceDownloader downloads the monthly average price files (medias mensuales),
+// one per month. The URL follows the naming of OMIE's daily files and hasn't been checked
+// against a published monthly file yet.
+type MonthlyPriceDownloader struct {
+	*GeneralDownloader
+}
+
+// NewMonthlyPriceDownloader creates a new monthly average price downloader
+func NewMonthlyPriceDownloader() *MonthlyPriceDownloader {
+	urlMask := "AGNO_YYYY/MES_MM/TXT/INT_PBC_EV_M_1_MM_YYYY_MM_YYYY.TXT"
+	outputMask := "MonthlyPrice_YYYYMM.txt"
+
+	return &MonthlyPriceDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
+
+// DownloadData downloads the files of every month in a date range and saves them to folder
+func (d *MonthlyPriceDownloader) DownloadData(ctx context.Context, dateIni, dateEnd time.Time, outputFolder string, verbose bool) error {
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		return types.NewOMIEError(types.ErrCodeDownload, "failed to create output folder", err)
+	}
+
+	return d.DownloadTo(ctx, dateIni, dateEnd, NewLocalWriter(outputFolder), verbose)
+}
+
+// DownloadTo downloads the files of every month in a date range and delivers them through writer
+func (d *MonthlyPriceDownloader) DownloadTo(ctx context.Context, dateIni, dateEnd time.Time, writer Writer, verbose bool) error {
+	return d.saveAll(ctx, d.URLResponses(ctx, dateIni, dateEnd, verbose), writer, verbose)
+}
+
+// DownloadArchive downloads the files of every month in a date range into a single tar archive
+func (d *MonthlyPriceDownloader) DownloadArchive(ctx context.Context, dateIni, dateEnd time.Time, archivePath string, verbose bool) error {
+	return d.archiveAll(d.URLResponses(ctx, dateIni, dateEnd, verbose), archivePath, verbose)
+}
+
+// URLResponses returns a channel of HTTP responses, one per month in the date range,
+// dated on the first day of the month
+func (d *MonthlyPriceDownloader) URLResponses(ctx context.Context, dateIni, dateEnd time.Time, verbose bool) <-chan ResponseResult {
+	resultChan := make(chan ResponseResult)
+
+	go func() {
+		defer close(resultChan)
+
+		if _, err := types.NewDateRange(dateIni, dateEnd); err != nil {
+			sendResult(ctx, resultChan, ResponseResult{Date: dateIni, Error: err})
+			return
+		}
+
+		jobs := make(chan downloadJob)
+		go func() {
+			defer close(jobs)
+			for month := firstOfMonth(dateIni); !month.After(dateEnd); month = month.AddDate(0, 1, 0) {
+				select {
+				case <-ctx.Done():
+					return
+				case jobs <- downloadJob{downloader: d.GeneralDownloader, date: month}:
+				}
+			}
+		}()
+
+		runJobs(ctx, d.config.MaxConcurrent, jobs, verbose, func(_ downloadJob, result ResponseResult) {
+			resultChan <- result
+		})
+	}()
+
+	return resultChan
+}
+
+// DirResponses returns a channel with one response per month in the date range, read
+// from the files a previous DownloadData saved in dir
+func (d *MonthlyPriceDownloader) DirResponses(ctx context.Context, dir string, dateIni, dateEnd time.Time) <-chan ResponseResult {
+	resultChan := make(chan ResponseResult)
+
+	go func() {
+		defer close(resultChan)
+
+		if _, err := types.NewDateRange(dateIni, dateEnd); err != nil {
+			sendResult(ctx, resultChan, ResponseResult{Date: dateIni, Error: err})
+			return
+		}
+
+		for month := firstOfMonth(dateIni); !month.After(dateEnd); month = month.AddDate(0, 1, 0) {
+			if !sendResult(ctx, resultChan, d.readFile(dir, month)) {
+				return
+			}
+		}
+	}()
+
+	return resultChan
+}
+
+// firstOfMonth returns midnight of the first day of the month of date
+func firstOfMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+}
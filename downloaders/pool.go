@@ -0,0 +1,69 @@
+package downloaders
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WorkerPool bounds how many files are being downloaded at once, with a single limit for
+// every downloader configured with it. Each downloader still runs up to MaxConcurrent
+// workers, but they take a slot of the pool for every file they request, so importing
+// prices, technologies and intraday prices together doesn't multiply the connections
+// to omie.es. It is safe for concurrent use: share a single pool between downloaders, or
+// between importers through ImportOptions.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool creates a pool letting size files be downloaded at once
+func NewWorkerPool(size int) *WorkerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &WorkerPool{slots: make(chan struct{}, size)}
+}
+
+// Size returns how many files the pool lets be downloaded at once
+func (p *WorkerPool) Size() int {
+	return cap(p.slots)
+}
+
+// Do runs fn once a slot of the pool is free, holding the slot until fn returns. It
+// fails with ctx's error, without running fn, if ctx is done first.
+func (p *WorkerPool) Do(ctx context.Context, fn func() error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+	return fn()
+}
+
+// acquire waits for a free slot, or until ctx is done
+func (p *WorkerPool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (p *WorkerPool) release() {
+	<-p.slots
+}
+
+// poolBody is a response body holding a slot of a pool until it's closed
+type poolBody struct {
+	io.ReadCloser
+	pool *WorkerPool
+	once sync.Once
+}
+
+// Close closes the body and frees its slot
+func (b *poolBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.pool.release)
+	return err
+}
@@ -0,0 +1,103 @@
+package downloaders
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolShared(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data")), Request: req}, nil
+	})
+
+	// Two downloaders of 5 workers each sharing a pool of 2
+	pool := NewWorkerPool(2)
+	config := DownloadConfig{MaxRetries: 1, MaxConcurrent: 5, Transport: transport, Pool: pool}
+	prices, technology := NewMarginalPriceDownloader(), NewEnergyByTechnologyDownloader(9)
+	prices.SetConfig(config)
+	technology.SetConfig(config)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 9)
+
+	var downloaded atomic.Int32
+	var wg sync.WaitGroup
+	for _, d := range []*GeneralDownloader{prices.GeneralDownloader, technology.GeneralDownloader} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range d.URLResponses(context.Background(), start, end, false) {
+				if result.Error != nil {
+					t.Errorf("unexpected error: %v", result.Error)
+					continue
+				}
+				result.Response.Body.Close()
+				downloaded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if downloaded.Load() != 20 {
+		t.Errorf("expected 20 files, got %d", downloaded.Load())
+	}
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 requests at once, got %d", peak.Load())
+	}
+
+	// A pool whose slots are all taken fails to run fn once ctx is done
+	full := NewWorkerPool(1)
+	full.slots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := full.Do(ctx, func() error { t.Error("fn ran without a free slot"); return nil }); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to stop Do, got %v", err)
+	}
+}
+
+func TestWorkerPoolHeldUntilBodyClosed(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data")), Request: req}, nil
+	})
+	pool := NewWorkerPool(1)
+	d := NewMarginalPriceDownloader()
+	d.SetConfig(DownloadConfig{MaxRetries: 0, MaxConcurrent: 1, Transport: transport, Pool: pool})
+
+	result := d.downloadSingleDate(context.Background(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	// The body is still being downloaded, so its slot isn't free yet
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Do(ctx, func() error { return nil }); err != context.DeadlineExceeded {
+		t.Errorf("expected the slot held until the body is closed, got %v", err)
+	}
+
+	io.ReadAll(result.Response.Body)
+	result.Response.Body.Close()
+	result.Response.Body.Close() // Closing twice frees the slot once
+	if err := pool.Do(context.Background(), func() error { return nil }); err != nil {
+		t.Errorf("expected the slot freed once the body is closed, got %v", err)
+	}
+	if len(pool.slots) != 0 {
+		t.Errorf("expected no slot taken, got %d", len(pool.slots))
+	}
+}
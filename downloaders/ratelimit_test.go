@@ -0,0 +1,82 @@
+package downloaders
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterShared(t *testing.T) {
+	var requests atomic.Int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("data")), Request: req}, nil
+	})
+
+	// Two downloaders sharing a limiter of 20 requests per second, with a burst of 2
+	limiter := NewRateLimiter(20, 2)
+	config := DownloadConfig{MaxRetries: 1, MaxConcurrent: 5, Transport: transport, RateLimiter: limiter}
+	prices, technology := NewMarginalPriceDownloader(), NewEnergyByTechnologyDownloader(9)
+	prices.SetConfig(config)
+	technology.SetConfig(config)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 3)
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for _, d := range []*GeneralDownloader{prices.GeneralDownloader, technology.GeneralDownloader} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range d.URLResponses(context.Background(), start, end, false) {
+				if result.Error != nil {
+					t.Errorf("unexpected error: %v", result.Error)
+					continue
+				}
+				result.Response.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 8 requests: 2 from the burst, then 6 more at 50ms intervals
+	if elapsed := time.Since(started); elapsed < 250*time.Millisecond {
+		t.Errorf("expected the shared limit to spread 8 requests over about 300ms, took %s", elapsed)
+	}
+	if requests.Load() != 8 {
+		t.Errorf("expected 8 requests, got %d", requests.Load())
+	}
+}
+
+func TestRateLimiterTransport(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	client := &http.Client{Transport: limiter.Transport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))}
+
+	get := func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.omie.es/", nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The next token comes in a second, past the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := get(ctx); err == nil {
+		t.Error("expected the request to give up waiting when the context ends")
+	}
+}
@@ -0,0 +1,160 @@
+package downloaders
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// FixtureMode tells what Fixtures do with the responses of the downloads
+type FixtureMode int
+
+const (
+	// Replay serves the responses recorded in the folder without any network access,
+	// failing the requests that weren't recorded
+	Replay FixtureMode = iota
+
+	// Record downloads as usual and saves every response to the folder, replacing the
+	// recording of the same URL
+	Record
+)
+
+// Fixtures records the responses of downloads to a folder and replays them later, so
+// end-to-end tests of the importers run offline with the same files every time, e.g.
+// recorded once against omie.es and replayed on every run after. Every response, 404s
+// included, is kept as a JSON file named after the file requested.
+type Fixtures struct {
+	Dir  string // Folder of the recordings; Fixtures are off when empty
+	Mode FixtureMode
+}
+
+// Transport returns a RoundTripper recording or replaying as set by the mode. Recording
+// makes the requests through next, or http.DefaultTransport when nil.
+func (f Fixtures) Transport(next http.RoundTripper) http.RoundTripper {
+	if f.Mode == Record {
+		return NewRecordingTransport(f.Dir, next)
+	}
+	return NewReplayTransport(f.Dir)
+}
+
+// client returns a copy of client whose transport goes through the fixtures
+func (f Fixtures) client(client *http.Client) *http.Client {
+	wrapped := *client
+	wrapped.Transport = f.Transport(client.Transport)
+	return &wrapped
+}
+
+// recording is a response saved by a RecordingTransport
+type recording struct {
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// RecordingTransport makes requests through another RoundTripper and saves every
+// response to a folder, see Fixtures
+type RecordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// NewRecordingTransport creates a transport making requests through next and saving
+// their responses under dir
+func NewRecordingTransport(dir string, next http.RoundTripper) *RecordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{dir: dir, next: next}
+}
+
+// RoundTrip makes the request and saves its response. Failed requests aren't recorded.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	data, err := json.MarshalIndent(recording{URL: req.URL.String(), Status: resp.StatusCode, Header: resp.Header, Body: body}, "", "  ")
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to encode recording", err)
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to create recordings folder", err)
+	}
+	if err := os.WriteFile(recordingPath(t.dir, req.URL), data, 0644); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to write recording", err)
+	}
+	return resp, nil
+}
+
+// ReplayTransport serves the responses saved by a RecordingTransport without making
+// any request, see Fixtures
+type ReplayTransport struct {
+	dir string
+}
+
+// NewReplayTransport creates a transport serving the responses recorded under dir
+func NewReplayTransport(dir string) *ReplayTransport {
+	return &ReplayTransport{dir: dir}
+}
+
+// RoundTrip returns the recorded response of the request's URL. Requests without a
+// recording fail like requests omie.es couldn't be reached for.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(recordingPath(t.dir, req.URL))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "no recorded response for "+req.URL.String(), err)
+	}
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read recording", err)
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "invalid recording", err)
+	}
+	return &http.Response{
+		Status:        http.StatusText(rec.Status),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// unsafeName matches the characters left out of recording file names
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// recordingPath returns the file holding the recording of u: the name of the file
+// requested, readable in a folder listing, followed by a hash of the whole URL
+func recordingPath(dir string, u *url.URL) string {
+	name := u.Query().Get("filename")
+	if name == "" {
+		name = path.Base(u.Path)
+	}
+	sum := sha256.Sum256([]byte(u.String()))
+	return filepath.Join(dir, unsafeName.ReplaceAllString(name, "_")+"-"+hex.EncodeToString(sum[:4])+".json")
+}
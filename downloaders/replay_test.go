@@ -0,0 +1,72 @@
+package downloaders
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestFixturesRecordReplay(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	start := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// The 2nd isn't published
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		if strings.Contains(req.URL.Path, "_02_06_2009_") {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(fixture))), Request: req}, nil
+	})}
+
+	download := func(config DownloadConfig, end time.Time) map[string]string {
+		d := NewMarginalPriceDownloader()
+		d.SetConfig(config)
+		outcomes := make(map[string]string)
+		for result := range d.URLResponses(context.Background(), start, end, false) {
+			date := result.Date.Format("2006-01-02")
+			if result.Error != nil {
+				outcomes[date] = string(types.Classify(result.Error))
+				continue
+			}
+			body, err := io.ReadAll(result.Response.Body)
+			result.Response.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			outcomes[date] = string(body)
+		}
+		return outcomes
+	}
+
+	recorded := download(DownloadConfig{MaxConcurrent: 2, HTTPClient: client, Fixtures: Fixtures{Dir: dir, Mode: Record}}, start.AddDate(0, 0, 1))
+	if recorded["2009-06-01"] != string(fixture) || recorded["2009-06-02"] != string(types.ClassNotPublished) {
+		t.Fatalf("unexpected recorded downloads: %v", recorded)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("expected the file and the 404 to be recorded, got %d recordings", len(files))
+	}
+
+	// Replaying makes no requests, and fails the dates that weren't recorded
+	requests.Store(0)
+	replayed := download(DownloadConfig{MaxConcurrent: 2, HTTPClient: client, Fixtures: Fixtures{Dir: dir}}, start.AddDate(0, 0, 2))
+	if requests.Load() != 0 {
+		t.Errorf("expected no requests when replaying, got %d", requests.Load())
+	}
+	if replayed["2009-06-01"] != string(fixture) || replayed["2009-06-02"] != string(types.ClassNotPublished) || replayed["2009-06-03"] != string(types.ClassTransient) {
+		t.Errorf("unexpected replayed downloads: %v", replayed)
+	}
+}
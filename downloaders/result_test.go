@@ -0,0 +1,29 @@
+package downloaders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseResultAttempts(t *testing.T) {
+	d := newFilesDownloader(filesTransport{"_15_01_2024_15_01_2024.TXT": "prices of the 15th"})
+	d.config.MaxRetries = 2
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	results := make(map[time.Time]ResponseResult)
+	for result := range d.URLResponses(context.Background(), date, date.AddDate(0, 0, 1), false) {
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+		results[result.Date] = result
+	}
+
+	if found := results[date]; found.Error != nil || found.Attempts != 1 || found.StatusCode != http.StatusOK || found.Duration <= 0 {
+		t.Errorf("unexpected result of a published file %+v", found)
+	}
+	if missing := results[date.AddDate(0, 0, 1)]; missing.Error == nil || missing.Attempts < 1 || missing.StatusCode != http.StatusNotFound || missing.Duration <= 0 {
+		t.Errorf("unexpected result of a missing file %+v", missing)
+	}
+}
@@ -0,0 +1,141 @@
+package downloaders
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// resumableBody reads the body of a successful response. When the connection drops
+// midway and the server accepts ranges, it requests the rest of the file with a Range
+// request instead of failing, so the big ZIP archives and curve files by unit don't
+// restart from zero. Whether it resumes or not, a body ending short of its
+// Content-Length fails rather than handing a truncated file to the parser.
+type resumableBody struct {
+	body      io.ReadCloser
+	req       *http.Request // Original request, cloned for every resume
+	client    *http.Client
+	validator string // ETag or Last-Modified, sent as If-Range so a changed file isn't spliced
+	ranges    bool   // Whether the server accepts byte ranges of the file
+	size      int64  // Content-Length, -1 when unknown
+	read      int64
+	resumes   int   // Resume requests left
+	err       error // Error that interrupted the last read
+
+	// before is called before every resume request, see newResumableBody
+	before func(req *http.Request, offset int64, cause error) error
+}
+
+// newResumableBody wraps the body of resp, the response to req, resuming it up to resumes
+// times. before, when set, is called before every resume request and may stop it by
+// returning an error.
+func newResumableBody(req *http.Request, resp *http.Response, client *http.Client, resumes int, before func(*http.Request, int64, error) error) *resumableBody {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") { // If-Range only takes strong validators
+		validator = resp.Header.Get("Last-Modified")
+	}
+	return &resumableBody{
+		body:      resp.Body,
+		req:       req,
+		client:    client,
+		validator: validator,
+		// Offsets in a body decompressed by the transport don't match the file's
+		ranges:  resp.Header.Get("Accept-Ranges") == "bytes" && !resp.Uncompressed,
+		size:    resp.ContentLength,
+		resumes: resumes,
+		before:  before,
+	}
+}
+
+func (b *resumableBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		if err := b.resume(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	switch {
+	case err == io.EOF && (b.size < 0 || b.read >= b.size):
+		return n, io.EOF
+	case err == io.EOF:
+		err = io.ErrUnexpectedEOF
+	case err == nil:
+		return n, nil
+	}
+
+	// Hand over what was read and resume on the next call
+	b.err = err
+	if n > 0 {
+		return n, nil
+	}
+	return b.Read(p)
+}
+
+func (b *resumableBody) Close() error {
+	return b.body.Close()
+}
+
+// resume requests the rest of the file after a dropped connection, failing when the
+// server doesn't accept ranges, the resumes are used up or the file changed
+func (b *resumableBody) resume() error {
+	cause := b.err
+	for b.ranges && b.resumes > 0 && b.req.Context().Err() == nil {
+		b.resumes--
+		req := b.req.Clone(b.req.Context())
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.read))
+		if b.validator != "" {
+			req.Header.Set("If-Range", b.validator)
+		}
+		if b.before != nil {
+			if err := b.before(req, b.read, cause); err != nil {
+				return types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("download interrupted after %d bytes", b.read), err)
+			}
+		}
+
+		resp, err := b.client.Do(req)
+		if err != nil {
+			cause = err
+			continue
+		}
+		if resp.StatusCode != http.StatusPartialContent || rangeStart(resp.Header.Get("Content-Range")) != b.read {
+			// A full response means the file changed since, and its start was already read
+			resp.Body.Close()
+			return types.NewOMIEError(types.ErrCodeNetwork,
+				fmt.Sprintf("download interrupted after %d bytes and the server didn't resume it (HTTP %d)", b.read, resp.StatusCode), cause)
+		}
+
+		b.body.Close()
+		b.body = resp.Body
+		b.err = nil
+		return nil
+	}
+
+	if b.size >= 0 {
+		return types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("download interrupted after %d of %d bytes", b.read, b.size), cause)
+	}
+	return types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("download interrupted after %d bytes", b.read), cause)
+}
+
+// rangeStart returns the first byte of a "bytes first-last/size" Content-Range header,
+// or -1 when it isn't one
+func rangeStart(contentRange string) int64 {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
@@ -0,0 +1,91 @@
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestResumableBody(t *testing.T) {
+	const file = "OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024;;05/03/2024;Curvas agregadas;;;;\n"
+	errDropped := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name    string
+		ranges  bool
+		etag    string // ETag of the resumed file, when it changed
+		want    string
+		wantErr bool
+	}{
+		{"resumed", true, "", file, false},
+		{"no ranges", false, "", "", true},
+		{"file changed", true, `"v2"`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				header := http.Header{"Etag": {`"v1"`}}
+				if tt.ranges {
+					header.Set("Accept-Ranges", "bytes")
+				}
+
+				// The first response drops the connection halfway through the file
+				if req.Header.Get("Range") == "" {
+					body := io.MultiReader(strings.NewReader(file[:40]), iotest.ErrReader(errDropped))
+					return &http.Response{StatusCode: http.StatusOK, Header: header, ContentLength: int64(len(file)),
+						Body: io.NopCloser(body), Request: req}, nil
+				}
+
+				ranges = append(ranges, req.Header.Get("Range")+" "+req.Header.Get("If-Range"))
+				if tt.etag != "" && req.Header.Get("If-Range") != tt.etag {
+					return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(file)), Request: req}, nil
+				}
+				header.Set("Content-Range", "bytes 40-"+strconv.Itoa(len(file)-1)+"/"+strconv.Itoa(len(file)))
+				return &http.Response{StatusCode: http.StatusPartialContent, Header: header, Body: io.NopCloser(strings.NewReader(file[40:])), Request: req}, nil
+			})
+
+			d := NewMarginalPriceDownloader()
+			d.SetConfig(DownloadConfig{MaxRetries: 2, MaxConcurrent: 1, Transport: transport})
+
+			date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+			for result := range d.URLResponses(context.Background(), date, date, false) {
+				if result.Error != nil {
+					t.Fatalf("unexpected error: %v", result.Error)
+				}
+				body, err := io.ReadAll(result.Response.Body)
+				result.Response.Body.Close()
+
+				if tt.wantErr {
+					if err == nil || !errors.Is(err, errDropped) {
+						t.Errorf("expected the interruption to fail the read, got %v", err)
+					}
+					return
+				}
+				if err != nil || string(body) != tt.want {
+					t.Errorf("expected the whole file, got %q (%v)", body, err)
+				}
+			}
+
+			if tt.ranges && (len(ranges) != 1 || ranges[0] != `bytes=40- "v1"`) {
+				t.Errorf("expected a single request for the rest of the file, got %q", ranges)
+			}
+		})
+	}
+}
+
+func TestResumableBodyShort(t *testing.T) {
+	// A body ending before its Content-Length fails instead of passing as a whole file
+	resp := &http.Response{StatusCode: http.StatusOK, ContentLength: 100, Body: io.NopCloser(strings.NewReader("truncated"))}
+	body := newResumableBody(nil, resp, http.DefaultClient, 3, nil)
+	if _, err := io.ReadAll(body); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected an unexpected EOF, got %v", err)
+	}
+}
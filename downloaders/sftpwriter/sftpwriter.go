@@ -0,0 +1,46 @@
+// Package sftpwriter delivers downloaded OMIE files to a remote folder over SFTP
+package sftpwriter
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"github.com/pkg/sftp"
+)
+
+// Writer is a downloaders.Writer uploading files to a folder on an SFTP server
+type Writer struct {
+	client *sftp.Client
+	dir    string
+}
+
+// New creates a writer uploading files under dir through client. The caller owns
+// the SSH connection and client, and is responsible for closing them.
+func New(client *sftp.Client, dir string) *Writer {
+	return &Writer{client: client, dir: dir}
+}
+
+// WriteFile uploads the contents read from r to dir/name
+func (w *Writer) WriteFile(ctx context.Context, name string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	remotePath := path.Join(w.dir, name)
+	if err := w.client.MkdirAll(path.Dir(remotePath)); err != nil {
+		return err
+	}
+
+	file, err := w.client.Create(remotePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,57 @@
+package sftpwriter
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newClient connects a client to an in-memory SFTP server
+func newClient(t *testing.T) *sftp.Client {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client
+}
+
+func TestWriter(t *testing.T) {
+	client := newClient(t)
+	writer := New(client, "/omie/prices")
+
+	if err := writer.WriteFile(context.Background(), "2024/PMD_20240115.txt", strings.NewReader("prices")); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	file, err := client.Open("/omie/prices/2024/PMD_20240115.txt")
+	if err != nil {
+		t.Fatalf("expected the uploaded file with its folders created: %v", err)
+	}
+	defer file.Close()
+	if data, err := io.ReadAll(file); err != nil || string(data) != "prices" {
+		t.Errorf("unexpected contents %q, %v", data, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := writer.WriteFile(ctx, "PMD_20240116.txt", strings.NewReader("prices")); err != context.Canceled {
+		t.Errorf("expected the cancellation error, got %v", err)
+	}
+	if _, err := client.Stat("/omie/prices/PMD_20240116.txt"); err == nil {
+		t.Error("expected no upload once the context is cancelled")
+	}
+}
@@ -1,44 +1,129 @@
 package downloaders
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"time"
+
+	"github.com/devuo/omiedata/types"
 )
 
 // SupplyDemandCurveDownloader downloads supply/demand curve data files
 type SupplyDemandCurveDownloader struct {
 	*GeneralDownloader
-	hour int // Hour of the day (1-24)
+	hour types.HourIndex // Hour of the day (1-25)
+}
+
+// HourResponseResult is a ResponseResult tagged with the hour it was downloaded for
+type HourResponseResult struct {
+	ResponseResult
+	Hour types.HourIndex
 }
 
 // NewSupplyDemandCurveDownloader creates a new supply/demand curve downloader
-func NewSupplyDemandCurveDownloader(hour int) *SupplyDemandCurveDownloader {
+func NewSupplyDemandCurveDownloader(hour types.HourIndex) *SupplyDemandCurveDownloader {
 	urlMask := "AGNO_YYYY/MES_MM/TXT/INT_CURVA_ACUM_UO_MIB_1_HH_DD_MM_YYYY_DD_MM_YYYY.TXT"
 	outputMask := "OfferAndDemandCurve_HH_YYYYMMDD.TXT"
 
-	return &SupplyDemandCurveDownloader{
+	d := &SupplyDemandCurveDownloader{
 		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
 		hour:              hour,
 	}
+	d.setPlaceholder("HH", fmt.Sprintf("%d", int(hour)))
+
+	return d
+}
+
+// DayURLResponses returns a channel of HTTP responses for every hour of every day in
+// the range, 23 to 25 depending on DST. All files go through a single worker pool
+// sharing this downloader's HTTP client and configuration.
+func (d *SupplyDemandCurveDownloader) DayURLResponses(ctx context.Context, dateIni, dateEnd time.Time, verbose bool) <-chan HourResponseResult {
+	resultChan := make(chan HourResponseResult)
+
+	// One downloader per hour, sharing the HTTP client and configuration
+	hourDownloaders := make([]*GeneralDownloader, types.MaxHourIndex)
+	hours := make(map[*GeneralDownloader]types.HourIndex, types.MaxHourIndex)
+	for i := range hourDownloaders {
+		hour := types.HourIndex(i + 1)
+		hd := NewSupplyDemandCurveDownloader(hour).GeneralDownloader
+		hd.client = d.client
+		hd.config = d.config
+		hourDownloaders[i] = hd
+		hours[hd] = hour
+	}
+
+	go func() {
+		defer close(resultChan)
+
+		dates, err := types.NewDateRange(dateIni, dateEnd)
+		if err != nil {
+			sendHourResult(ctx, resultChan, HourResponseResult{ResponseResult: ResponseResult{Date: dateIni, Error: err}})
+			return
+		}
+
+		jobs := make(chan downloadJob)
+		go func() {
+			defer close(jobs)
+			for date := range dates.All() {
+				for _, hd := range hourDownloaders[:types.HoursInDay(date)] {
+					select {
+					case <-ctx.Done():
+						return
+					case jobs <- downloadJob{downloader: hd, date: date}:
+					}
+				}
+			}
+		}()
+
+		runJobs(ctx, d.config.MaxConcurrent, jobs, verbose, func(job downloadJob, result ResponseResult) {
+			resultChan <- HourResponseResult{ResponseResult: result, Hour: hours[job.downloader]}
+		})
+	}()
+
+	return resultChan
 }
 
-// generateURL generates the URL for a specific date, replacing HH with hour
-func (d *SupplyDemandCurveDownloader) generateURL(date time.Time) string {
-	url := d.GetCompleteURL()
-	url = strings.ReplaceAll(url, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	url = strings.ReplaceAll(url, "MM", fmt.Sprintf("%02d", date.Month()))
-	url = strings.ReplaceAll(url, "DD", fmt.Sprintf("%02d", date.Day()))
-	url = strings.ReplaceAll(url, "HH", fmt.Sprintf("%d", d.hour))
-	return url
+// DayDirResponses returns a channel with a response for every hour of every day in the
+// range, read from the files a previous DownloadData saved in dir for each hour
+func (d *SupplyDemandCurveDownloader) DayDirResponses(ctx context.Context, dir string, dateIni, dateEnd time.Time) <-chan HourResponseResult {
+	resultChan := make(chan HourResponseResult)
+
+	hourDownloaders := make([]*GeneralDownloader, types.MaxHourIndex)
+	for i := range hourDownloaders {
+		hourDownloaders[i] = NewSupplyDemandCurveDownloader(types.HourIndex(i + 1)).GeneralDownloader
+	}
+
+	go func() {
+		defer close(resultChan)
+
+		dates, err := types.NewDateRange(dateIni, dateEnd)
+		if err != nil {
+			sendHourResult(ctx, resultChan, HourResponseResult{ResponseResult: ResponseResult{Date: dateIni, Error: err}})
+			return
+		}
+
+		for date := range dates.All() {
+			for i, hd := range hourDownloaders[:types.HoursInDay(date)] {
+				result := HourResponseResult{ResponseResult: hd.readFile(dir, date), Hour: types.HourIndex(i + 1)}
+				if !sendHourResult(ctx, resultChan, result) {
+					return
+				}
+			}
+		}
+	}()
+
+	return resultChan
 }
 
-// generateFilename generates the output filename, replacing HH with hour
-func (d *SupplyDemandCurveDownloader) generateFilename(date time.Time) string {
-	filename := d.outputMask
-	filename = strings.ReplaceAll(filename, "YYYY", fmt.Sprintf("%04d", date.Year()))
-	filename = strings.ReplaceAll(filename, "MM", fmt.Sprintf("%02d", date.Month()))
-	filename = strings.ReplaceAll(filename, "DD", fmt.Sprintf("%02d", date.Day()))
-	filename = strings.ReplaceAll(filename, "HH", fmt.Sprintf("%d", d.hour))
-	return filename
+// sendHourResult sends result unless ctx is cancelled first, see sendResult
+func sendHourResult(ctx context.Context, resultChan chan<- HourResponseResult, result HourResponseResult) bool {
+	select {
+	case <-ctx.Done():
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+		return false
+	case resultChan <- result:
+		return true
+	}
 }
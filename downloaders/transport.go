@@ -0,0 +1,50 @@
+package downloaders
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// newTransport returns the RoundTripper of a downloader's own client: config.Transport
+// when set, otherwise a copy of http.DefaultTransport going through config.Proxy and
+// using config.TLSConfig when either is set, or nil for http.DefaultTransport
+func newTransport(config DownloadConfig) http.RoundTripper {
+	if config.Transport != nil || (config.Proxy == nil && config.TLSConfig == nil) {
+		return config.Transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Proxy != nil {
+		transport.Proxy = http.ProxyURL(config.Proxy)
+	}
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+	return transport
+}
+
+// LoadRootCAs returns the system's root certificates together with the PEM-encoded
+// certificates of files, e.g. the CA of a corporate gateway inspecting TLS traffic, to
+// be set as the RootCAs of DownloadConfig.TLSConfig
+func LoadRootCAs(files ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read certificates", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("no PEM certificates found in %s", file), nil)
+		}
+	}
+
+	return pool, nil
+}
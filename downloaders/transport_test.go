@@ -0,0 +1,77 @@
+package downloaders
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadConfigTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data")
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // The untrusted handshake is logged
+	server.StartTLS()
+	defer server.Close()
+
+	// Trust the test server's self-signed certificate like a corporate gateway's CA
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0644); err != nil {
+		t.Fatal(err)
+	}
+	roots, err := LoadRootCAs(caFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for _, trusted := range []bool{false, true} {
+		d := NewGeneralDownloader(server.URL+"/YYYYMMDD.txt", "YYYYMMDD.txt")
+		config := DownloadConfig{MaxConcurrent: 1, RequestTimeout: 5 * time.Second}
+		if trusted {
+			config.TLSConfig = &tls.Config{RootCAs: roots}
+		}
+		d.SetConfig(config)
+
+		for result := range d.URLResponses(context.Background(), date, date, false) {
+			if (result.Error == nil) != trusted {
+				t.Errorf("trusted=%v: unexpected result %v", trusted, result.Error)
+			}
+			if result.Response != nil {
+				result.Response.Body.Close()
+			}
+		}
+	}
+
+	if _, err := LoadRootCAs(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected an error for a missing certificate file")
+	}
+}
+
+func TestDownloadConfigProxy(t *testing.T) {
+	proxy, _ := url.Parse("http://proxy.example.com:3128")
+	t.Setenv("HTTPS_PROXY", "http://env.example.com:8080")
+
+	transport, ok := newTransport(DownloadConfig{Proxy: proxy}).(*http.Transport)
+	if !ok {
+		t.Fatal("expected an *http.Transport")
+	}
+	req, _ := http.NewRequest("GET", "https://www.omie.es/", nil)
+	if got, err := transport.Proxy(req); err != nil || got.String() != proxy.String() {
+		t.Errorf("expected the configured proxy to override HTTPS_PROXY, got %v (%v)", got, err)
+	}
+
+	if newTransport(DownloadConfig{}) != nil {
+		t.Error("expected the default transport without proxy or TLS settings")
+	}
+}
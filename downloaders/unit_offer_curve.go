@@ -0,0 +1,16 @@
+package downloaders
+
+// UnitOfferCurveDownloader downloads the day-ahead offers by bidding unit (curva_pbc_uof)
+type UnitOfferCurveDownloader struct {
+	*GeneralDownloader
+}
+
+// NewUnitOfferCurveDownloader creates a new unit offer curve downloader
+func NewUnitOfferCurveDownloader() *UnitOfferCurveDownloader {
+	urlMask := fileDownloadURL + "curva_pbc_uof&filename=curva_pbc_uof_YYYYMMDD.1"
+	outputMask := "curva_pbc_uof_YYYYMMDD.1"
+
+	return &UnitOfferCurveDownloader{
+		GeneralDownloader: NewGeneralDownloader(urlMask, outputMask),
+	}
+}
@@ -0,0 +1,102 @@
+package downloaders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// Writer delivers downloaded files to wherever they are stored
+type Writer interface {
+	// WriteFile stores the contents read from r under name
+	WriteFile(ctx context.Context, name string, r io.Reader) error
+}
+
+// LocalWriter writes files to a folder on the local disk
+type LocalWriter struct {
+	dir string
+}
+
+// NewLocalWriter creates a writer saving files under dir
+func NewLocalWriter(dir string) *LocalWriter {
+	return &LocalWriter{dir: dir}
+}
+
+// WriteFile stores the contents read from r in dir/name. The file is written through a
+// temporary sibling renamed into place once complete, so an interrupted download never
+// leaves a partial file behind.
+func (w *LocalWriter) WriteFile(ctx context.Context, name string, r io.Reader) error {
+	return atomicfile.Write(filepath.Join(w.dir, name), func(file io.Writer) error {
+		_, err := io.Copy(file, r)
+		return err
+	})
+}
+
+// HTTPPutWriter uploads files with HTTP PUT requests to a base URL, e.g. an object
+// storage bucket or a WebDAV folder
+type HTTPPutWriter struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+}
+
+// NewHTTPPutWriter creates a writer uploading every file to baseURL/name
+func NewHTTPPutWriter(baseURL string) *HTTPPutWriter {
+	return &HTTPPutWriter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+		header:  make(http.Header),
+	}
+}
+
+// SetClient sets the HTTP client used for uploads
+func (w *HTTPPutWriter) SetClient(client *http.Client) {
+	w.client = client
+}
+
+// SetHeader sets a header sent with every upload, e.g. "Authorization"
+func (w *HTTPPutWriter) SetHeader(key, value string) {
+	w.header.Set(key, value)
+}
+
+// WriteFile uploads the contents read from r to baseURL/name, escaping name as a path
+func (w *HTTPPutWriter) WriteFile(ctx context.Context, name string, r io.Reader) error {
+	target, err := url.JoinPath(w.baseURL, name)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "invalid upload URL for "+name, err)
+	}
+
+	// OMIE files are small, buffer them so the upload has a Content-Length
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range w.header {
+		req.Header[key] = values
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeNetwork, "failed to upload "+name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("upload of %s failed with HTTP %d", name, resp.StatusCode), nil)
+	}
+
+	return nil
+}
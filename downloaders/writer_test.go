@@ -0,0 +1,111 @@
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// failingReader returns some data and then fails, like a download cut short
+type failingReader struct {
+	data string
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+	r.read = true
+	return copy(p, r.data), nil
+}
+
+func TestLocalWriter(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewLocalWriter(dir)
+
+	if err := writer.WriteFile(context.Background(), "2024/PMD_20240115.txt", strings.NewReader("prices")); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	path := filepath.Join(dir, "2024", "PMD_20240115.txt")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "prices" {
+		t.Fatalf("expected the file in a subfolder, got %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("expected a 0644 file, got %v, %v", info.Mode(), err)
+	}
+
+	// A failed download leaves the previous file in place and no temporary file behind
+	if err := writer.WriteFile(context.Background(), "2024/PMD_20240115.txt", &failingReader{data: "partial"}); err == nil {
+		t.Fatal("expected the read error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "prices" {
+		t.Errorf("expected the previous file to survive a failed write, got %q", data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the written file, got %v", entries)
+	}
+}
+
+func TestHTTPPutWriter(t *testing.T) {
+	var mu sync.Mutex
+	uploads := map[string]string{}
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/denied.txt") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploads[r.URL.EscapedPath()] = string(body)
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	writer := NewHTTPPutWriter(srv.URL + "/bucket/")
+	writer.SetClient(srv.Client())
+	writer.SetHeader("Authorization", "Bearer token")
+
+	// Names are escaped as paths, so characters such as spaces, ? and # reach the server
+	// as part of the file name
+	for name, want := range map[string]string{
+		"PMD_20240115.txt":   "/bucket/PMD_20240115.txt",
+		"2024/01/prices.txt": "/bucket/2024/01/prices.txt",
+		"odd name?#1.txt":    "/bucket/odd%20name%3F%231.txt",
+	} {
+		if err := writer.WriteFile(context.Background(), name, strings.NewReader(name)); err != nil {
+			t.Fatalf("WriteFile(%q) error: %v", name, err)
+		}
+		if got := uploads[want]; got != name {
+			t.Errorf("expected %q uploaded to %s, got uploads %v", name, want, uploads)
+		}
+	}
+	if authorization != "Bearer token" {
+		t.Errorf("expected the header sent with the upload, got %q", authorization)
+	}
+
+	err := writer.WriteFile(context.Background(), "denied.txt", strings.NewReader("x"))
+	if types.ErrorCode(err) != types.ErrCodeNetwork || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("expected a network error for the rejected upload, got %v", err)
+	}
+}
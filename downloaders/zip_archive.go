@@ -0,0 +1,311 @@
+package downloaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// ZipArchiveDownloader downloads the monthly and yearly ZIP archives OMIE publishes for a
+// file family and extracts the daily files from them in memory. A backfill then needs one
+// request per month or year instead of one per day.
+//
+// Archives are fetched one after another and buffered whole, since a yearly archive can
+// hold hundreds of megabytes; raise DownloadConfig.RequestTimeout accordingly.
+type ZipArchiveDownloader struct {
+	monthly    *GeneralDownloader
+	yearly     *GeneralDownloader
+	memberMask string // Daily file name inside the archives, e.g. "marginalpdbc_YYYYMMDD.1"
+}
+
+// NewZipArchiveDownloader creates a downloader for the archives of an OMIE file access folder,
+// e.g. "marginalpdbc". memberMask is the name of the daily files inside the archives, with
+// YYYY, MM and DD date tokens.
+func NewZipArchiveDownloader(folder, memberMask string) *ZipArchiveDownloader {
+	monthly := NewGeneralDownloader(
+		fileDownloadURL+folder+"&filename="+folder+"_YYYYMM.zip",
+		folder+"_YYYYMM.zip",
+	)
+	yearly := NewGeneralDownloader(
+		fileDownloadURL+folder+"&filename="+folder+"_YYYY.zip",
+		folder+"_YYYY.zip",
+	)
+	yearly.client = monthly.client
+
+	return &ZipArchiveDownloader{
+		monthly:    monthly,
+		yearly:     yearly,
+		memberMask: memberMask,
+	}
+}
+
+// NewMarginalPDBCArchiveDownloader creates a ZIP archive downloader for the day-ahead
+// prices in the marginalpdbc format
+func NewMarginalPDBCArchiveDownloader() *ZipArchiveDownloader {
+	return NewZipArchiveDownloader("marginalpdbc", "marginalpdbc_YYYYMMDD.1")
+}
+
+// NewAggregatedCurveArchiveDownloader creates a ZIP archive downloader for the day-ahead
+// aggregated supply and demand curves (curva_pbc)
+func NewAggregatedCurveArchiveDownloader() *ZipArchiveDownloader {
+	return NewZipArchiveDownloader("curva_pbc", "curva_pbc_YYYYMMDD.1")
+}
+
+// NewUnitOfferCurveArchiveDownloader creates a ZIP archive downloader for the day-ahead
+// offers by bidding unit (curva_pbc_uof)
+func NewUnitOfferCurveArchiveDownloader() *ZipArchiveDownloader {
+	return NewZipArchiveDownloader("curva_pbc_uof", "curva_pbc_uof_YYYYMMDD.1")
+}
+
+// SetConfig updates the download configuration
+func (d *ZipArchiveDownloader) SetConfig(config DownloadConfig) {
+	d.monthly.SetConfig(config)
+	d.yearly.config = config
+	d.yearly.client = d.monthly.client
+}
+
+// GetCompleteURL returns the URL pattern of the monthly archives
+func (d *ZipArchiveDownloader) GetCompleteURL() string {
+	return d.monthly.GetCompleteURL()
+}
+
+// DownloadData downloads the archives covering a date range and saves the daily files
+// within the range to folder
+func (d *ZipArchiveDownloader) DownloadData(ctx context.Context, dateIni, dateEnd time.Time, outputFolder string, verbose bool) error {
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		return types.NewOMIEError(types.ErrCodeDownload, "failed to create output folder", err)
+	}
+
+	return d.DownloadTo(ctx, dateIni, dateEnd, NewLocalWriter(outputFolder), verbose)
+}
+
+// DownloadTo downloads the archives covering a date range and delivers the daily files
+// within the range through writer
+func (d *ZipArchiveDownloader) DownloadTo(ctx context.Context, dateIni, dateEnd time.Time, writer Writer, verbose bool) error {
+	var errors []error
+	for result := range d.URLResponses(ctx, dateIni, dateEnd, verbose) {
+		if result.Error != nil {
+			errors = append(errors, result.Error)
+			continue
+		}
+
+		filename := d.monthly.applyMask(d.memberMask, result.Date) + d.monthly.config.Compression.Extension()
+		d.monthly.logger(verbose).LogAttrs(ctx, slog.LevelInfo, "saving file",
+			slog.String("file", filename), slog.String("date", result.Date.Format("2006-01-02")))
+
+		if err := d.monthly.saveResponse(ctx, result.Response, writer, filename); err != nil {
+			errors = append(errors, types.NewOMIEError(types.ErrCodeDownload, "failed to save file", err))
+		}
+
+		result.Response.Body.Close()
+	}
+
+	if len(errors) > 0 {
+		return types.JoinErrors("download completed", errors)
+	}
+
+	return nil
+}
+
+// URLResponses returns a channel with one response per day of the date range, read from
+// the daily files of the archives that cover it. Years covered entirely use the yearly
+// archive, falling back to the monthly ones if it isn't published; the rest use the
+// monthly archives.
+//
+// Responses are delivered in date order. Days without a file in their archive yield an
+// ErrCodeNotFound result. The request for an archive is accounted to the first day
+// delivered from it, and a failed archive yields a single error result dated at the
+// start of its period.
+func (d *ZipArchiveDownloader) URLResponses(ctx context.Context, dateIni, dateEnd time.Time, verbose bool) <-chan ResponseResult {
+	resultChan := make(chan ResponseResult)
+
+	start := time.Date(dateIni.Year(), dateIni.Month(), dateIni.Day(), 0, 0, 0, 0, dateIni.Location())
+	end := time.Date(dateEnd.Year(), dateEnd.Month(), dateEnd.Day(), 0, 0, 0, 0, dateIni.Location())
+
+	go func() {
+		defer close(resultChan)
+
+		if _, err := types.NewDateRange(dateIni, dateEnd); err != nil {
+			sendResult(ctx, resultChan, ResponseResult{Date: dateIni, Error: err})
+			return
+		}
+
+		send := func(result ResponseResult) bool {
+			select {
+			case <-ctx.Done():
+				if result.Response != nil {
+					result.Response.Body.Close()
+				}
+				return false
+			case resultChan <- result:
+				return true
+			}
+		}
+
+		month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+		for !month.After(end) {
+			if month.Month() == time.January && !start.After(month) && !end.Before(month.AddDate(1, 0, -1)) {
+				ok, found := d.extract(ctx, d.yearly, month, start, end, verbose, send)
+				if !ok {
+					return
+				}
+				if found {
+					month = month.AddDate(1, 0, 0)
+					continue
+				}
+			}
+
+			if ok, _ := d.extract(ctx, d.monthly, month, start, end, verbose, send); !ok {
+				return
+			}
+			month = month.AddDate(0, 1, 0)
+		}
+	}()
+
+	return resultChan
+}
+
+// extract downloads the archive for period and sends its daily files between start and
+// end. found is false if a yearly archive isn't published, in which case nothing is
+// sent; ok is false once the context is cancelled.
+func (d *ZipArchiveDownloader) extract(ctx context.Context, archive *GeneralDownloader, period, start, end time.Time, verbose bool, send func(ResponseResult) bool) (ok, found bool) {
+	result := archive.downloadSingleDate(ctx, period, verbose)
+	if result.Error != nil {
+		if archive == d.yearly && result.StatusCode == http.StatusNotFound {
+			return ctx.Err() == nil, false
+		}
+		return send(result), true
+	}
+
+	body, err := io.ReadAll(result.Response.Body)
+	result.Response.Body.Close()
+	if err == nil {
+		var reader *zip.Reader
+		if reader, err = zip.NewReader(bytes.NewReader(body), int64(len(body))); err == nil {
+			last := period.AddDate(0, 1, -1)
+			if archive == d.yearly {
+				last = period.AddDate(1, 0, -1)
+			}
+			return d.sendMembers(reader, result, period, last, start, end, send), true
+		}
+	}
+
+	result.Response = nil
+	result.Error = types.NewOMIEError(types.ErrCodeDownload, "failed to read archive", err)
+	return send(result), true
+}
+
+// sendMembers sends the daily files of an archive covering period..last that fall
+// between start and end, in date order. Dates without a file in the archive are sent as
+// ErrCodeNotFound results, like the days OMIE doesn't publish with the daily downloads.
+func (d *ZipArchiveDownloader) sendMembers(reader *zip.Reader, archive ResponseResult, period, last, start, end time.Time, send func(ResponseResult) bool) bool {
+	members := make(map[string]*zip.File) // By date, as YYYYMMDD
+	for _, file := range reader.File {
+		date, ok := parseMaskDate(d.memberMask, path.Base(file.Name), start.Location())
+		if !ok || date.Before(start) || date.After(end) {
+			continue
+		}
+		members[date.Format("20060102")] = file
+	}
+
+	if period.Before(start) {
+		period = start
+	}
+	if last.After(end) {
+		last = end
+	}
+
+	first := true
+	for date := period; !date.After(last); date = date.AddDate(0, 0, 1) {
+		result := ResponseResult{Date: date, Attempts: 1}
+		if first {
+			result.Attempts = archive.Attempts
+			result.Duration = archive.Duration
+			first = false
+		}
+
+		file, ok := members[date.Format("20060102")]
+		if !ok {
+			name := d.monthly.applyMask(d.memberMask, date)
+			result.URL = archive.URL + "#" + name
+			result.StatusCode = http.StatusNotFound
+			result.Error = types.NewOMIEError(types.ErrCodeNotFound, fmt.Sprintf("%s not found in archive", name), nil)
+			if !send(result) {
+				return false
+			}
+			continue
+		}
+
+		result.URL = archive.URL + "#" + file.Name
+		result.StatusCode = http.StatusOK
+		body, err := file.Open()
+		if err != nil {
+			result.Error = types.NewOMIEError(types.ErrCodeDownload, fmt.Sprintf("failed to extract %s", file.Name), err)
+		} else {
+			result.Response = &http.Response{
+				Status:        "200 OK",
+				StatusCode:    http.StatusOK,
+				Body:          body,
+				ContentLength: int64(file.UncompressedSize64),
+				Request:       archive.Response.Request,
+			}
+		}
+
+		if !send(result) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseMaskDate extracts the date from a file name generated from mask, reporting whether
+// the name matches the mask
+func parseMaskDate(mask, name string, loc *time.Location) (time.Time, bool) {
+	var year, month, day int
+	for mask != "" {
+		var field *int
+		var width int
+		switch {
+		case strings.HasPrefix(mask, "YYYY"):
+			field, width = &year, 4
+		case strings.HasPrefix(mask, "MM"):
+			field, width = &month, 2
+		case strings.HasPrefix(mask, "DD"):
+			field, width = &day, 2
+		default:
+			if name == "" || name[0] != mask[0] {
+				return time.Time{}, false
+			}
+			mask, name = mask[1:], name[1:]
+			continue
+		}
+
+		if len(name) < width {
+			return time.Time{}, false
+		}
+		value, err := strconv.Atoi(name[:width])
+		if err != nil {
+			return time.Time{}, false
+		}
+		*field = value
+		mask, name = mask[width:], name[width:]
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	if name != "" || date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,170 @@
+package downloaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// archiveTransport serves in-memory ZIP archives by file name and records the requests
+type archiveTransport struct {
+	mu       sync.Mutex
+	archives map[string][]byte // archive file name -> contents
+	requests []string
+}
+
+func (t *archiveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := req.URL.Query().Get("filename")
+
+	t.mu.Lock()
+	t.requests = append(t.requests, name)
+	data, ok := t.archives[name]
+	t.mu.Unlock()
+
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(data)), Request: req}, nil
+}
+
+func buildZip(t *testing.T, members ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range members {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("contents of " + name))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestArchiveDownloader(transport *archiveTransport) *ZipArchiveDownloader {
+	d := NewZipArchiveDownloader("marginalpdbc", "marginalpdbc_YYYYMMDD.1")
+	d.SetConfig(DownloadConfig{MaxConcurrent: 1, RequestTimeout: time.Second, Transport: transport})
+	return d
+}
+
+// collectArchive returns the dates delivered from the archives and the dates reported
+// missing from them
+func collectArchive(t *testing.T, d *ZipArchiveDownloader, start, end time.Time) (got, missing []string) {
+	t.Helper()
+
+	for result := range d.URLResponses(context.Background(), start, end, false) {
+		if types.ErrorCode(result.Error) == types.ErrCodeNotFound && result.StatusCode == http.StatusNotFound {
+			missing = append(missing, result.Date.Format("2006-01-02"))
+			continue
+		}
+		if result.Error != nil {
+			t.Fatalf("unexpected error for %s: %v", result.Date.Format("2006-01-02"), result.Error)
+		}
+		body, err := io.ReadAll(result.Response.Body)
+		result.Response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "contents of marginalpdbc_" + result.Date.Format("20060102") + ".1"; string(body) != want {
+			t.Errorf("body for %s = %q, want %q", result.Date.Format("2006-01-02"), body, want)
+		}
+		got = append(got, result.Date.Format("2006-01-02"))
+	}
+	return got, missing
+}
+
+func TestZipArchiveDownloaderMonthly(t *testing.T) {
+	transport := &archiveTransport{archives: map[string][]byte{
+		// Members are deliberately out of order and include a file of another family
+		"marginalpdbc_202312.zip": buildZip(t, "marginalpdbc_20231231.1", "marginalpdbc_20231201.1", "marginalpdbc_20231230.1"),
+		"marginalpdbc_202401.zip": buildZip(t, "marginalpdbc_20240102.1", "marginalpdbc_20240101.1", "marginalpdbc_20240103.1", "README.txt"),
+	}}
+	d := newTestArchiveDownloader(transport)
+
+	got, missing := collectArchive(t, d,
+		time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	want := []string{"2023-12-30", "2023-12-31", "2024-01-01", "2024-01-02"}
+	if strings.Join(got, ",") != strings.Join(want, ",") || len(missing) != 0 {
+		t.Errorf("dates = %v, missing %v, want %v", got, missing, want)
+	}
+	if len(transport.requests) != 2 {
+		t.Errorf("expected 2 archive requests, got %v", transport.requests)
+	}
+}
+
+func TestZipArchiveDownloaderYearly(t *testing.T) {
+	transport := &archiveTransport{archives: map[string][]byte{
+		"marginalpdbc_2022.zip":   buildZip(t, "marginalpdbc_20220101.1", "marginalpdbc_20221231.1"),
+		"marginalpdbc_202301.zip": buildZip(t, "marginalpdbc_20230101.1"),
+	}}
+	d := newTestArchiveDownloader(transport)
+
+	got, missing := collectArchive(t, d,
+		time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	want := []string{"2022-01-01", "2022-12-31", "2023-01-01"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("dates = %v, want %v", got, want)
+	}
+	// Every other day of the yearly archive is reported missing, in date order
+	if len(missing) != 363 || missing[0] != "2022-01-02" || missing[362] != "2022-12-30" {
+		t.Errorf("expected the 363 days missing from the archive, got %d", len(missing))
+	}
+	if want := "marginalpdbc_2022.zip,marginalpdbc_202301.zip"; strings.Join(transport.requests, ",") != want {
+		t.Errorf("requests = %v, want %s", transport.requests, want)
+	}
+}
+
+func TestZipArchiveDownloaderYearlyFallback(t *testing.T) {
+	transport := &archiveTransport{archives: map[string][]byte{}}
+	for month := 1; month <= 12; month++ {
+		name := fmt.Sprintf("marginalpdbc_2021%02d", month)
+		transport.archives[name+".zip"] = buildZip(t, name+"15.1")
+	}
+	d := newTestArchiveDownloader(transport)
+
+	got, missing := collectArchive(t, d,
+		time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC))
+
+	if len(got) != 12 || len(missing) != 365-12 {
+		t.Errorf("expected one file per month and the other days missing, got %v and %d missing", got, len(missing))
+	}
+	if len(transport.requests) != 13 || transport.requests[0] != "marginalpdbc_2021.zip" {
+		t.Errorf("expected the yearly archive then 12 monthly ones, got %v", transport.requests)
+	}
+}
+
+func TestZipArchiveDownloaderCorruptArchive(t *testing.T) {
+	transport := &archiveTransport{archives: map[string][]byte{
+		"marginalpdbc_202401.zip": []byte("not a zip"),
+	}}
+	d := newTestArchiveDownloader(transport)
+
+	var results []ResponseResult
+	for result := range d.URLResponses(context.Background(),
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), false) {
+		results = append(results, result)
+	}
+
+	if len(results) != 1 || results[0].Error == nil {
+		t.Fatalf("expected a single error result, got %+v", results)
+	}
+}
@@ -31,17 +31,20 @@ func main() {
 		log.Fatalf("Invalid end date: %v", err)
 	}
 
-	// Add one day to end date to include it fully (up to midnight of next day)
-	end = end.AddDate(0, 0, 1)
+	// Both the start and end days are included
+	dates, err := types.NewDateRange(start, end)
+	if err != nil {
+		log.Fatalf("Invalid date range: %v", err)
+	}
 
-	fmt.Printf("Fetching OMIE data from %s to %s (exclusive)\n",
-		start.Format("02-01-2006"), end.Format("02-01-2006"))
+	fmt.Printf("Fetching OMIE data from %s to %s (%d days)\n",
+		dates.Start.Format("02-01-2006"), dates.End.Format("02-01-2006"), dates.Days())
 
 	ctx := context.Background()
 	importer := importers.NewDefaultMarginalPriceImporter()
 
 	// Fetch data for the date range
-	results, err := importer.Import(ctx, start, end)
+	dataList, err := importer.ImportDays(ctx, dates.Start, dates.End)
 	if err != nil {
 		log.Fatalf("Failed to import data: %v", err)
 	}
@@ -50,11 +53,6 @@ func main() {
 	var totalPrice float64
 	var totalHours int
 
-	dataList, ok := results.([]*types.MarginalPriceData)
-	if !ok {
-		log.Fatal("Unexpected result type from importer")
-	}
-
 	for _, data := range dataList {
 		// Get Portugal prices for each hour
 		for _, price := range data.PortugalPrices {
@@ -73,8 +71,8 @@ func main() {
 	fmt.Printf("\nAverage PT price: %.2f EUR/MWh\n", avgPrice)
 	fmt.Printf("Based on %d hours of data\n", totalHours)
 	fmt.Printf("Date range: %s to %s\n",
-		start.Format("02-01-2006"),
-		end.AddDate(0, 0, -1).Format("02-01-2006"))
+		dates.Start.Format("02-01-2006"),
+		dates.End.Format("02-01-2006"))
 }
 
 func parseDate(dateStr string) (time.Time, error) {
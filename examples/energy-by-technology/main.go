@@ -28,16 +28,11 @@ func main() {
 		date.Format("2006-01-02"))
 
 	ctx := context.Background()
-	result, err := importer.ImportSingleDate(ctx, date)
+	dayData, err := importer.ImportDay(ctx, date)
 	if err != nil {
 		log.Fatalf("Failed to import data: %v", err)
 	}
 
-	dayData, ok := result.(*types.TechnologyEnergyDay)
-	if !ok {
-		log.Fatal("Unexpected result type")
-	}
-
 	fmt.Printf("\nEnergy data for %s (%s system):\n",
 		dayData.Date.Format("2006-01-02"), dayData.System)
 	fmt.Printf("Number of hours: %d\n", len(dayData.Records))
@@ -59,14 +54,9 @@ func main() {
 		printIfNotNaN("  Coal", record.Coal)
 		printIfNotNaN("  Imports", record.ImportInt)
 
-		// Calculate total renewable energy
-		renewable := sumNonNaN(record.Wind, record.SolarPV, record.SolarThermal, record.Hydro)
-		total := sumNonNaN(renewable, record.Nuclear, record.CombinedCycle, record.Coal,
-			record.FuelGas, record.Cogeneration, record.SelfProducer)
-
-		if total > 0 && !math.IsNaN(renewable) {
-			renewablePct := (renewable / total) * 100
-			fmt.Printf("  Renewable %%:  %8.1f%%\n", renewablePct)
+		// Share of the generation from hydro, wind and solar, imports left out
+		if share := record.RenewableShare(); !math.IsNaN(share) {
+			fmt.Printf("  Renewable %%:  %8.1f%%\n", share*100)
 		}
 	}
 
@@ -92,6 +82,9 @@ func main() {
 			fmt.Printf("  %-15s: %10.1f MWh\n", tech, total)
 		}
 	}
+	if share := dayData.RenewableShare(); !math.IsNaN(share) {
+		fmt.Printf("  %-15s: %10.1f%%\n", "Renewable", share*100)
+	}
 }
 
 // printIfNotNaN prints a value only if it's not NaN
@@ -101,22 +94,6 @@ func printIfNotNaN(label string, value float64) {
 	}
 }
 
-// sumNonNaN sums values, ignoring NaN values
-func sumNonNaN(values ...float64) float64 {
-	sum := 0.0
-	hasValue := false
-	for _, v := range values {
-		if !math.IsNaN(v) {
-			sum += v
-			hasValue = true
-		}
-	}
-	if !hasValue {
-		return math.NaN()
-	}
-	return sum
-}
-
 // addToTotal adds a value to the total if it's not NaN
 func addToTotal(totals map[string]float64, key string, value float64) {
 	if !math.IsNaN(value) {
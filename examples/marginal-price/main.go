@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/devuo/omiedata/importers"
-	"github.com/devuo/omiedata/types"
 )
 
 func main() {
@@ -28,16 +27,11 @@ func main() {
 		start.Format("2006-01-02"), end.Format("2006-01-02"))
 
 	ctx := context.Background()
-	results, err := importer.Import(ctx, start, end)
+	dataList, err := importer.ImportDays(ctx, start, end)
 	if err != nil {
 		log.Fatalf("Failed to import data: %v", err)
 	}
 
-	dataList, ok := results.([]*types.MarginalPriceData)
-	if !ok {
-		log.Fatal("Unexpected result type")
-	}
-
 	fmt.Printf("\nSuccessfully imported data for %d days:\n", len(dataList))
 
 	for _, data := range dataList {
@@ -0,0 +1,121 @@
+// Package arrowexport converts parsed OMIE data into Apache Arrow record batches, to hand
+// it to Arrow-based analytics (arrow-go compute, ADBC drivers, Flight) without going
+// through an intermediate file. Missing values (NaN) become Arrow nulls.
+package arrowexport
+
+import (
+	"math"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Prices is a dataset of marginal price days, e.g. the result of ImportDays
+type Prices []*types.MarginalPriceData
+
+// Technology is a dataset of energy by technology days
+type Technology []*types.TechnologyEnergyDay
+
+// PriceSchema is the schema of the records of Prices: date, hour (or period), resolution
+// and a nullable column per series, named as in the JSON wire format (e.g. "spain_prices")
+var PriceSchema = newPriceSchema()
+
+// TechnologySchema is the schema of the records of Technology: date, hour, system and a
+// nullable column per technology, named after it in lower case (e.g. "combined_cycle")
+var TechnologySchema = newTechnologySchema()
+
+// newPriceSchema builds PriceSchema from the series of MarginalPriceData
+func newPriceSchema() *arrow.Schema {
+	fields := []arrow.Field{
+		{Name: "date", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "hour", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "resolution", Type: arrow.BinaryTypes.String},
+	}
+	for _, series := range (&types.MarginalPriceData{}).Series() {
+		fields = append(fields, arrow.Field{Name: series.Name, Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// newTechnologySchema builds TechnologySchema from the technologies of TechnologyEnergy
+func newTechnologySchema() *arrow.Schema {
+	fields := []arrow.Field{
+		{Name: "date", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "hour", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "system", Type: arrow.BinaryTypes.String},
+	}
+	for _, tech := range types.Technologies() {
+		fields = append(fields, arrow.Field{Name: strings.ToLower(string(tech)), Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// ToArrowRecord returns the dataset as a record batch with a row per day and hour (or
+// period), see PriceSchema. A nil mem uses the Go allocator. The caller must Release the
+// record.
+func (p Prices) ToArrowRecord(mem memory.Allocator) arrow.RecordBatch {
+	builder := array.NewRecordBuilder(allocator(mem), PriceSchema)
+	defer builder.Release()
+
+	for _, day := range p {
+		resolution := types.Hourly
+		if day.Resolution == types.QuarterHourly {
+			resolution = types.QuarterHourly
+		}
+		for _, hour := range day.HoursSorted() {
+			builder.Field(0).(*array.Date32Builder).Append(arrow.Date32FromTime(day.Date))
+			builder.Field(1).(*array.Int32Builder).Append(int32(hour))
+			builder.Field(2).(*array.StringBuilder).Append(resolution.String())
+			for i, series := range day.Series() {
+				value, ok := series.Values[hour]
+				appendFloat(builder.Field(3+i).(*array.Float64Builder), value, ok)
+			}
+		}
+	}
+
+	return builder.NewRecordBatch()
+}
+
+// ToArrowRecord returns the dataset as a record batch with a row per day and hour in hour
+// order, see TechnologySchema. A nil mem uses the Go allocator. The caller must Release
+// the record.
+func (t Technology) ToArrowRecord(mem memory.Allocator) arrow.RecordBatch {
+	builder := array.NewRecordBuilder(allocator(mem), TechnologySchema)
+	defer builder.Release()
+
+	technologies := types.Technologies()
+	for _, day := range t {
+		day.ForEachHour(func(record types.TechnologyEnergy) {
+			builder.Field(0).(*array.Date32Builder).Append(arrow.Date32FromTime(record.Date))
+			builder.Field(1).(*array.Int32Builder).Append(int32(record.Hour))
+			builder.Field(2).(*array.StringBuilder).Append(record.System.String())
+			for i, tech := range technologies {
+				value, ok := record.Value(tech)
+				appendFloat(builder.Field(3+i).(*array.Float64Builder), value, ok)
+			}
+		})
+	}
+
+	return builder.NewRecordBatch()
+}
+
+// appendFloat appends a value, or a null when it isn't present or is NaN
+func appendFloat(builder *array.Float64Builder, value float64, ok bool) {
+	if !ok || math.IsNaN(value) {
+		builder.AppendNull()
+		return
+	}
+	builder.Append(value)
+}
+
+// allocator returns mem, or the Go allocator when it's nil
+func allocator(mem memory.Allocator) memory.Allocator {
+	if mem == nil {
+		return memory.DefaultAllocator
+	}
+	return mem
+}
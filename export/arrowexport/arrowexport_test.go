@@ -0,0 +1,71 @@
+package arrowexport
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestPricesToArrowRecord(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	data := types.NewMarginalPriceData(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	data.SpainPrices[1] = 45.5
+	data.SpainPrices[2] = 40
+	data.PortugalPrices[1] = math.NaN()
+
+	record := Prices{data}.ToArrowRecord(mem)
+	defer record.Release()
+
+	if record.NumRows() != 2 || !record.Schema().Equal(PriceSchema) {
+		t.Fatalf("unexpected record %v", record)
+	}
+
+	dates := record.Column(0).(*array.Date32)
+	if dates.Value(0) != arrow.Date32FromTime(data.Date) {
+		t.Errorf("unexpected date %v", dates.Value(0).ToTime())
+	}
+
+	spain := record.Column(3).(*array.Float64)
+	portugal := record.Column(4).(*array.Float64)
+	if spain.Value(0) != 45.5 || spain.Value(1) != 40 {
+		t.Errorf("unexpected Spain prices %v", spain)
+	}
+	if !portugal.IsNull(0) || !portugal.IsNull(1) {
+		t.Errorf("expected NaN and absent Portugal prices to be null, got %v", portugal)
+	}
+}
+
+func TestTechnologyToArrowRecord(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := &types.TechnologyEnergyDay{Date: date, System: types.Iberian, Records: []types.TechnologyEnergy{
+		{Date: date, Hour: 2, System: types.Iberian, Coal: math.NaN(), Wind: 20},
+		{Date: date, Hour: 1, System: types.Iberian, Coal: 5, Wind: 10},
+	}}
+
+	record := Technology{day}.ToArrowRecord(mem)
+	defer record.Release()
+
+	if record.NumRows() != 2 || record.NumCols() != 3+12 {
+		t.Fatalf("unexpected shape %dx%d", record.NumRows(), record.NumCols())
+	}
+
+	hours := record.Column(1).(*array.Int32)
+	coal := record.Column(3).(*array.Float64)
+	if hours.Value(0) != 1 || coal.Value(0) != 5 || !coal.IsNull(1) {
+		t.Errorf("unexpected values: hours %v, coal %v", hours, coal)
+	}
+	if system := record.Column(2).(*array.String).Value(0); system != "IBERIAN" {
+		t.Errorf("unexpected system %q", system)
+	}
+}
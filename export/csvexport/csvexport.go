@@ -0,0 +1,236 @@
+// Package csvexport writes parsed OMIE data as CSV, either in a long (tidy) layout with
+// one value per row or in a wide layout with one row per hour, ready for spreadsheets
+// and dataframe libraries such as pandas.
+package csvexport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Layout selects how values are laid out in rows
+type Layout int
+
+const (
+	// Long writes one row per value: date, hour, name and value
+	Long Layout = iota
+
+	// Wide writes one row per hour with a column per series or technology
+	Wide
+)
+
+// dateLayout is the layout of the date column
+const dateLayout = "2006-01-02"
+
+// Options configures the CSV output. The zero value writes comma-separated values with
+// a dot as decimal mark in the long layout.
+type Options struct {
+	Layout    Layout
+	Separator rune // Field separator, ',' when zero; use ';' together with a ',' decimal mark
+	Decimal   rune // Decimal mark, '.' when zero
+	Precision int  // Digits after the decimal mark, 0 for the shortest exact representation
+	Missing   string
+}
+
+// Format implements export.Format, writing the results of the marginal price, energy by
+// technology and supply/demand curve importers as CSV
+type Format struct {
+	Options Options
+}
+
+// Extension returns ".csv"
+func (Format) Extension() string {
+	return ".csv"
+}
+
+// Write encodes importer results as CSV
+func (f Format) Write(w io.Writer, results interface{}) error {
+	switch results := results.(type) {
+	case []*types.MarginalPriceData:
+		return WritePrices(w, results, f.Options)
+	case []*types.TechnologyEnergyDay:
+		return WriteTechnology(w, results, f.Options)
+	case []*types.MarketCurveDay:
+		return WriteCurves(w, results, f.Options)
+	case []*types.MarketCurve:
+		day := &types.MarketCurveDay{}
+		for _, curve := range results {
+			day.Curves = append(day.Curves, *curve)
+		}
+		return WriteCurves(w, []*types.MarketCurveDay{day}, f.Options)
+	default:
+		return types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("cannot write %T as CSV", results), nil)
+	}
+}
+
+// WritePrices writes marginal price data. The long layout has the columns date, hour,
+// series and value, skipping missing values; the wide layout has a column per series
+// holding values in any of the days, named as in the JSON wire format (e.g.
+// "spain_prices"). For quarter-hourly data the hour column holds the period.
+func WritePrices(w io.Writer, data []*types.MarginalPriceData, options Options) error {
+	cw := newCSVWriter(w, options)
+
+	if options.Layout == Wide {
+		var names []string
+		used := make(map[string]bool)
+		for _, day := range data {
+			for _, series := range day.Series() {
+				if len(series.Values) > 0 {
+					used[series.Name] = true
+				}
+			}
+		}
+		for _, series := range (&types.MarginalPriceData{}).Series() {
+			if used[series.Name] {
+				names = append(names, series.Name)
+			}
+		}
+
+		cw.Write(append([]string{"date", "hour"}, names...))
+		for _, day := range data {
+			series := make(map[string]types.HourlyValues)
+			for _, s := range day.Series() {
+				series[s.Name] = s.Values
+			}
+			for _, hour := range day.HoursSorted() {
+				row := []string{day.Date.Format(dateLayout), strconv.Itoa(hour)}
+				for _, name := range names {
+					value, ok := series[name][hour]
+					row = append(row, options.formatValue(value, ok))
+				}
+				cw.Write(row)
+			}
+		}
+		return flush(cw)
+	}
+
+	cw.Write([]string{"date", "hour", "series", "value"})
+	for _, day := range data {
+		for _, hour := range day.HoursSorted() {
+			for _, series := range day.Series() {
+				value, ok := series.Values[hour]
+				if !ok || math.IsNaN(value) {
+					continue
+				}
+				cw.Write([]string{day.Date.Format(dateLayout), strconv.Itoa(hour), series.Name, options.formatValue(value, true)})
+			}
+		}
+	}
+	return flush(cw)
+}
+
+// WriteTechnology writes energy by technology data. The long layout has the columns
+// date, hour, system, technology and value, skipping missing values; the wide layout
+// has a column per technology, named after it in lower case (e.g. "combined_cycle").
+func WriteTechnology(w io.Writer, days []*types.TechnologyEnergyDay, options Options) error {
+	cw := newCSVWriter(w, options)
+	technologies := types.Technologies()
+
+	if options.Layout == Wide {
+		header := []string{"date", "hour", "system"}
+		for _, tech := range technologies {
+			header = append(header, strings.ToLower(string(tech)))
+		}
+		cw.Write(header)
+
+		for _, day := range days {
+			day.ForEachHour(func(record types.TechnologyEnergy) {
+				row := []string{record.Date.Format(dateLayout), strconv.Itoa(record.Hour), systemName(record.System)}
+				for _, tech := range technologies {
+					row = append(row, options.formatValue(record.Value(tech)))
+				}
+				cw.Write(row)
+			})
+		}
+		return flush(cw)
+	}
+
+	cw.Write([]string{"date", "hour", "system", "technology", "value"})
+	for _, day := range days {
+		day.ForEachHour(func(record types.TechnologyEnergy) {
+			for _, tech := range technologies {
+				if value, ok := record.Value(tech); ok {
+					cw.Write([]string{record.Date.Format(dateLayout), strconv.Itoa(record.Hour), systemName(record.System),
+						strings.ToLower(string(tech)), options.formatValue(value, true)})
+				}
+			}
+		})
+	}
+	return flush(cw)
+}
+
+// WriteCurves writes supply/demand curves with one row per point and the columns date,
+// hour, side ("supply" or "demand"), matched ("O" or "C"), unit, energy and price.
+// Curve points are already tidy, so the layout is ignored.
+func WriteCurves(w io.Writer, days []*types.MarketCurveDay, options Options) error {
+	cw := newCSVWriter(w, options)
+
+	cw.Write([]string{"date", "hour", "side", "matched", "unit", "energy", "price"})
+	for _, day := range days {
+		for _, curve := range day.Curves {
+			for _, side := range []struct {
+				name   string
+				points []types.MarketPoint
+			}{{"supply", curve.Supply}, {"demand", curve.Demand}} {
+				for _, point := range side.points {
+					cw.Write([]string{
+						curve.Date.Format(dateLayout), strconv.Itoa(curve.Hour), side.name, string(point.Matched), point.UnitCode,
+						options.formatValue(point.Energy, true), options.formatValue(point.Price, true),
+					})
+				}
+			}
+		}
+	}
+	return flush(cw)
+}
+
+// newCSVWriter creates a CSV writer using the separator of options
+func newCSVWriter(w io.Writer, options Options) *csv.Writer {
+	cw := csv.NewWriter(w)
+	if options.Separator != 0 {
+		cw.Comma = options.Separator
+	}
+	return cw
+}
+
+// flush flushes cw, returning the first error of any write
+func flush(cw *csv.Writer) error {
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write CSV", err)
+	}
+	return nil
+}
+
+// formatValue formats a value with the decimal mark and precision of options, writing
+// options.Missing when it isn't present or is NaN
+func (o Options) formatValue(value float64, ok bool) string {
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return o.Missing
+	}
+
+	precision := o.Precision
+	if precision <= 0 {
+		precision = -1
+	}
+	s := strconv.FormatFloat(value, 'f', precision, 64)
+	if o.Decimal != 0 && o.Decimal != '.' {
+		s = strings.Replace(s, ".", string(o.Decimal), 1)
+	}
+	return s
+}
+
+// systemName returns the text form of a system, e.g. "SPAIN"
+func systemName(system types.SystemType) string {
+	text, err := system.MarshalText()
+	if err != nil {
+		return strconv.Itoa(int(system))
+	}
+	return string(text)
+}
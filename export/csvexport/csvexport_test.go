@@ -0,0 +1,117 @@
+package csvexport
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestWritePrices(t *testing.T) {
+	day := types.NewMarginalPriceData(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	day.SpainPrices[1] = 45.5
+	day.SpainPrices[2] = 40
+	day.PortugalPrices[1] = 45.5
+	day.PortugalPrices[2] = math.NaN()
+
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{
+			"long",
+			Options{},
+			"date,hour,series,value\n" +
+				"2024-01-15,1,spain_prices,45.5\n" +
+				"2024-01-15,1,portugal_prices,45.5\n" +
+				"2024-01-15,2,spain_prices,40\n",
+		},
+		{
+			"wide european",
+			Options{Layout: Wide, Separator: ';', Decimal: ',', Precision: 2, Missing: "NA"},
+			"date;hour;spain_prices;portugal_prices\n" +
+				"2024-01-15;1;45,50;45,50\n" +
+				"2024-01-15;2;40,00;NA\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePrices(&buf, []*types.MarginalPriceData{day}, tt.options); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTechnology(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := &types.TechnologyEnergyDay{
+		Date:   date,
+		System: types.Spain,
+		Records: []types.TechnologyEnergy{
+			{Date: date, Hour: 2, System: types.Spain, Wind: 1200.5, Coal: math.NaN()},
+			{Date: date, Hour: 1, System: types.Spain, Wind: 1000, Coal: math.NaN()},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTechnology(&buf, []*types.TechnologyEnergyDay{day}, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Zero values are present, NaN ones aren't
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1+2*11 {
+		t.Fatalf("expected a header and 11 values per hour, got %d lines:\n%s", len(lines), buf.String())
+	}
+	if string(lines[0]) != "date,hour,system,technology,value" {
+		t.Errorf("unexpected header %s", lines[0])
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("2024-01-15,1,SPAIN,wind,1000\n")) || bytes.Contains(buf.Bytes(), []byte(",coal,")) {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteTechnology(&buf, []*types.TechnologyEnergyDay{day}, Options{Layout: Wide}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "date,hour,system,coal,fuel_gas,self_producer,nuclear,hydro,combined_cycle,wind,thermal_solar,photovoltaic_solar,residuals,import,import_without_mibel\n" +
+		"2024-01-15,1,SPAIN,,0,0,0,0,0,1000,0,0,0,0,0\n" +
+		"2024-01-15,2,SPAIN,,0,0,0,0,0,1200.5,0,0,0,0,0\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatWriteCurves(t *testing.T) {
+	curve := &types.MarketCurve{
+		Date:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Hour:   3,
+		Supply: []types.MarketPoint{{Energy: 100, Price: -0.5, Matched: types.Matched, UnitCode: "UNIT1"}},
+		Demand: []types.MarketPoint{{Energy: 50, Price: 180.3, Matched: types.Offered}},
+	}
+
+	var buf bytes.Buffer
+	if err := (Format{}).Write(&buf, []*types.MarketCurve{curve}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "date,hour,side,matched,unit,energy,price\n" +
+		"2024-01-15,3,supply,C,UNIT1,100,-0.5\n" +
+		"2024-01-15,3,demand,O,,50,180.3\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if err := (Format{}).Write(&buf, []string{"unsupported"}); err == nil {
+		t.Error("expected an error for unsupported results")
+	}
+}
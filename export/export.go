@@ -0,0 +1,184 @@
+// Package export writes imported OMIE data to files, optionally partitioned by date
+// into directory trees suitable for analytical data lakes.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/compression"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// Exporter imports a date range partition by partition and writes each partition to
+// its own file. Partitions whose output file already exists are skipped, so an
+// interrupted export resumes where it stopped when run again.
+type Exporter struct {
+	importer     importers.Importer
+	format       Format
+	partitioning Partitioning
+	concurrency  int
+	compression  compression.Compression
+	name         string
+}
+
+// Summary reports the outcome of an export run
+type Summary struct {
+	Written []string // Files written in this run
+	Skipped []string // Files that already existed
+	Empty   int      // Partitions for which no data was available
+}
+
+// NewExporter creates an exporter that writes the results of importer using format.
+// By default the output is not partitioned and partitions are processed one at a time.
+func NewExporter(importer importers.Importer, format Format) *Exporter {
+	return &Exporter{
+		importer:    importer,
+		format:      format,
+		concurrency: 1,
+		name:        "data",
+	}
+}
+
+// SetPartitioning sets how the exported range is split into output directories
+func (e *Exporter) SetPartitioning(partitioning Partitioning) {
+	e.partitioning = partitioning
+}
+
+// SetConcurrency sets how many partitions are imported and written in parallel
+func (e *Exporter) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	e.concurrency = concurrency
+}
+
+// SetCompression compresses every output file, appending the compression extension
+// (e.g. "data.json.gz")
+func (e *Exporter) SetCompression(c compression.Compression) {
+	e.compression = c
+}
+
+// SetName sets the base name of the output files (default "data")
+func (e *Exporter) SetName(name string) {
+	e.name = name
+}
+
+// Export imports the inclusive range [start, end] and writes it under outputDir
+func (e *Exporter) Export(ctx context.Context, outputDir string, start, end time.Time) (*Summary, error) {
+	if end.Before(start) {
+		return nil, types.NewOMIEError(types.ErrCodeInvalidDate, "end date is before start date", nil)
+	}
+
+	partitions := e.partitioning.Split(start, end)
+	partitionChan := make(chan Partition)
+	summary := &Summary{}
+
+	var mu sync.Mutex
+	var errors []error
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partition := range partitionChan {
+				path, written, err := e.exportPartition(ctx, outputDir, partition)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					errors = append(errors, err)
+				case path == "":
+					summary.Empty++
+				case written:
+					summary.Written = append(summary.Written, path)
+				default:
+					summary.Skipped = append(summary.Skipped, path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	go func() {
+		defer close(partitionChan)
+		for _, partition := range partitions {
+			select {
+			case <-ctx.Done():
+				return
+			case partitionChan <- partition:
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+
+	if len(errors) > 0 {
+		return summary, types.JoinErrors("export completed", errors)
+	}
+
+	return summary, nil
+}
+
+// exportPartition imports and writes a single partition. It returns the output path
+// and whether it was written in this call; the path is empty when there was no data.
+func (e *Exporter) exportPartition(ctx context.Context, outputDir string, partition Partition) (string, bool, error) {
+	path := filepath.Join(outputDir, partition.Dir, e.name+e.format.Extension()+e.compression.Extension())
+	if _, err := os.Stat(path); err == nil {
+		return path, false, nil
+	}
+
+	results, err := e.importer.Import(ctx, partition.Start, partition.End)
+	if err != nil {
+		return "", false, fmt.Errorf("partition %s: %w", partitionLabel(partition), err)
+	}
+
+	if isEmpty(results) {
+		return "", false, nil
+	}
+
+	// Partitions are written atomically, so partially written files are never mistaken
+	// for finished partitions
+	if err := atomicfile.Write(path, func(file io.Writer) error {
+		writer, err := e.compression.NewWriter(file)
+		if err != nil {
+			return err
+		}
+		if err := e.format.Write(writer, results); err != nil {
+			writer.Close()
+			return err
+		}
+		return writer.Close()
+	}); err != nil {
+		return "", false, types.NewOMIEError(types.ErrCodeStorage, "failed to write "+path, err)
+	}
+
+	return path, true, nil
+}
+
+// isEmpty reports whether importer results hold no data
+func isEmpty(results interface{}) bool {
+	if results == nil {
+		return true
+	}
+	v := reflect.ValueOf(results)
+	return v.Kind() == reflect.Slice && v.Len() == 0
+}
+
+// partitionLabel formats a partition's date range for error messages
+func partitionLabel(p Partition) string {
+	return fmt.Sprintf("%s..%s", p.Start.Format("2006-01-02"), p.End.Format("2006-01-02"))
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Format encodes importer results into an output file
+type Format interface {
+	// Extension returns the file extension including the leading dot, e.g. ".json"
+	Extension() string
+
+	// Write encodes the results returned by an importer (e.g. []*types.MarginalPriceData) to w
+	Write(w io.Writer, results interface{}) error
+}
+
+// JSONFormat writes results as a JSON array using the stable wire format of the types package
+type JSONFormat struct{}
+
+// Extension returns ".json"
+func (JSONFormat) Extension() string {
+	return ".json"
+}
+
+// Write encodes results as a JSON array
+func (JSONFormat) Write(w io.Writer, results interface{}) error {
+	return json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,157 @@
+// Package ndjson writes parsed OMIE data as newline-delimited JSON, one object per hourly
+// record, so imports can be piped into jq, Elasticsearch or log shippers as they run.
+// Values use the JSON wire format of the types package.
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Writer writes records to an underlying writer, one line per record. Every line is
+// written with a single Write call as soon as it's encoded, so readers never see
+// partial lines and nothing is buffered between days.
+type Writer struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewWriter creates a writer of records to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WritePrices writes a line per hour (or period) of a day with the date, the hour, the
+// resolution and the value of every series that has one, e.g.
+//
+//	{"date":"2024-01-15","hour":1,"resolution":"HOURLY","spain_prices":45.5,"portugal_prices":45.5}
+//
+// Missing values (NaN) are written as null.
+func (w *Writer) WritePrices(data *types.MarginalPriceData) error {
+	resolution := types.Hourly
+	if data.Resolution == types.QuarterHourly {
+		resolution = types.QuarterHourly
+	}
+	date, err := json.Marshal(data.Date.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	for _, hour := range data.HoursSorted() {
+		w.buf.Reset()
+		fmt.Fprintf(&w.buf, `{"date":%s,"hour":%d,"resolution":%q`, date, hour, resolution.String())
+		for _, series := range data.Series() {
+			value, ok := series.Values[hour]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&w.buf, ",%q:%s", series.Name, formatFloat(value))
+		}
+		w.buf.WriteString("}\n")
+
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTechnology writes a line per record of a day, as encoded by TechnologyEnergy
+func (w *Writer) WriteTechnology(day *types.TechnologyEnergyDay) error {
+	var err error
+	day.ForEachHour(func(record types.TechnologyEnergy) {
+		if err == nil {
+			err = w.encode(record)
+		}
+	})
+	return err
+}
+
+// WriteCurves writes a line per hourly curve of a day, as encoded by MarketCurve
+func (w *Writer) WriteCurves(day *types.MarketCurveDay) error {
+	for _, curve := range day.Curves {
+		if err := w.encode(curve); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes importer results, either a slice of days or a single day of marginal
+// prices, energy by technology or curves
+func (w *Writer) Write(results interface{}) error {
+	switch results := results.(type) {
+	case *types.MarginalPriceData:
+		return w.WritePrices(results)
+	case *types.TechnologyEnergyDay:
+		return w.WriteTechnology(results)
+	case *types.MarketCurveDay:
+		return w.WriteCurves(results)
+	case *types.MarketCurve:
+		return w.encode(results)
+	case []*types.MarginalPriceData:
+		return writeAll(results, w.WritePrices)
+	case []*types.TechnologyEnergyDay:
+		return writeAll(results, w.WriteTechnology)
+	case []*types.MarketCurveDay:
+		return writeAll(results, w.WriteCurves)
+	case []*types.MarketCurve:
+		return writeAll(results, func(curve *types.MarketCurve) error { return w.encode(curve) })
+	default:
+		return types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("cannot write %T as NDJSON", results), nil)
+	}
+}
+
+// encode writes v as a line
+func (w *Writer) encode(v interface{}) error {
+	w.buf.Reset()
+	if err := json.NewEncoder(&w.buf).Encode(v); err != nil {
+		return types.NewOMIEError(types.ErrCodeInvalidData, "failed to encode record", err)
+	}
+	return w.flush()
+}
+
+// flush writes the buffered line to the underlying writer
+func (w *Writer) flush() error {
+	if _, err := w.w.Write(w.buf.Bytes()); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write record", err)
+	}
+	return nil
+}
+
+// writeAll writes every day with write, stopping at the first error
+func writeAll[T any](days []T, write func(T) error) error {
+	for _, day := range days {
+		if err := write(day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatFloat formats a value as a JSON number, or null when it's missing
+func formatFloat(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "null"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Format implements export.Format, writing importer results as NDJSON
+type Format struct{}
+
+// Extension returns ".ndjson"
+func (Format) Extension() string {
+	return ".ndjson"
+}
+
+// Write encodes importer results as NDJSON
+func (Format) Write(w io.Writer, results interface{}) error {
+	return NewWriter(w).Write(results)
+}
@@ -0,0 +1,75 @@
+package ndjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestWritePrices(t *testing.T) {
+	data := types.NewMarginalPriceData(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	data.SpainPrices[2] = 40
+	data.SpainPrices[1] = 45.5
+	data.PortugalPrices[1] = math.NaN()
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WritePrices(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"date":"2024-01-15","hour":1,"resolution":"HOURLY","spain_prices":45.5,"portugal_prices":null}` + "\n" +
+		`{"date":"2024-01-15","hour":2,"resolution":"HOURLY","spain_prices":40}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// lineWriter records every Write call
+type lineWriter struct {
+	writes []string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriteTechnology(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := &types.TechnologyEnergyDay{
+		Date:   date,
+		System: types.Spain,
+		Records: []types.TechnologyEnergy{
+			{Date: date, Hour: 2, System: types.Spain, Wind: 1200},
+			{Date: date, Hour: 1, System: types.Spain, Wind: 1000, Coal: math.NaN()},
+		},
+	}
+
+	var out lineWriter
+	if err := (Format{}).Write(&out, []*types.TechnologyEnergyDay{day}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// One complete line per Write, in hour order
+	if len(out.writes) != 2 {
+		t.Fatalf("expected 2 writes, got %d", len(out.writes))
+	}
+	scanner := bufio.NewScanner(bytes.NewBufferString(out.writes[0] + out.writes[1]))
+	for hour := 1; scanner.Scan(); hour++ {
+		var record types.TechnologyEnergy
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %s: %v", scanner.Text(), err)
+		}
+		if record.Hour != hour || record.Wind == 0 {
+			t.Errorf("unexpected record %+v", record)
+		}
+	}
+	if !bytes.Contains([]byte(out.writes[0]), []byte(`"coal":null`)) {
+		t.Errorf("expected missing coal as null, got %s", out.writes[0])
+	}
+}
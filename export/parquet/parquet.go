@@ -0,0 +1,178 @@
+// Package parquet writes parsed OMIE data as Parquet files with a stable, long (tidy)
+// schema, suited to multi-year datasets queried from DuckDB, Spark or pandas. Days are
+// streamed into row groups as they are written, so whole ranges never need to be held
+// in memory.
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	goparquet "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// PriceRow is a row of a marginal price file: a single value of a series
+type PriceRow struct {
+	Date       int32   `parquet:"date,date"`       // Days since 1970-01-01, a DATE for Parquet readers
+	Hour       int32   `parquet:"hour"`            // Hour, or period when Resolution is QUARTER_HOURLY
+	Resolution string  `parquet:"resolution,dict"` // HOURLY or QUARTER_HOURLY
+	Series     string  `parquet:"series,dict"`     // Name of the series in the JSON wire format, e.g. "spain_prices"
+	Value      float64 `parquet:"value"`           // EUR/MWh for prices, MWh for energies
+}
+
+// TechnologyRow is a row of an energy by technology file: the energy of a technology
+type TechnologyRow struct {
+	Date       int32   `parquet:"date,date"` // Days since 1970-01-01
+	Hour       int32   `parquet:"hour"`
+	System     string  `parquet:"system,dict"`     // SPAIN, PORTUGAL or IBERIAN
+	Technology string  `parquet:"technology,dict"` // Technology in lower case, e.g. "combined_cycle"
+	Value      float64 `parquet:"value"`           // MWh
+}
+
+// rowWriter streams rows of type T into a Parquet file
+type rowWriter[T any] struct {
+	writer *goparquet.GenericWriter[T]
+	rows   []T
+}
+
+// PriceWriter writes marginal price data, see PriceRow
+type PriceWriter struct {
+	rowWriter[PriceRow]
+}
+
+// TechnologyWriter writes energy by technology data, see TechnologyRow
+type TechnologyWriter struct {
+	rowWriter[TechnologyRow]
+}
+
+// NewPriceWriter creates a writer of marginal price data to w. Close must be called to
+// write the file footer.
+func NewPriceWriter(w io.Writer) *PriceWriter {
+	return &PriceWriter{newWriter[PriceRow](w)}
+}
+
+// NewTechnologyWriter creates a writer of energy by technology data to w. Close must be
+// called to write the file footer.
+func NewTechnologyWriter(w io.Writer) *TechnologyWriter {
+	return &TechnologyWriter{newWriter[TechnologyRow](w)}
+}
+
+// newWriter creates a writer compressing columns with Snappy, the codec every Parquet
+// reader supports
+func newWriter[T any](w io.Writer) rowWriter[T] {
+	return rowWriter[T]{
+		writer: goparquet.NewGenericWriter[T](w,
+			goparquet.Compression(&snappy.Codec{}),
+			goparquet.CreatedBy("omiedata", "", ""),
+		),
+	}
+}
+
+// Write writes the values of a day, skipping missing ones
+func (w *PriceWriter) Write(data *types.MarginalPriceData) error {
+	resolution := types.Hourly
+	if data.Resolution == types.QuarterHourly {
+		resolution = types.QuarterHourly
+	}
+
+	w.rows = w.rows[:0]
+	for _, hour := range data.HoursSorted() {
+		for _, series := range data.Series() {
+			value, ok := series.Values[hour]
+			if !ok || math.IsNaN(value) {
+				continue
+			}
+			w.rows = append(w.rows, PriceRow{
+				Date:       epochDays(data.Date),
+				Hour:       int32(hour),
+				Resolution: resolution.String(),
+				Series:     series.Name,
+				Value:      value,
+			})
+		}
+	}
+	return w.flush()
+}
+
+// Write writes the records of a day, skipping missing values
+func (w *TechnologyWriter) Write(day *types.TechnologyEnergyDay) error {
+	w.rows = w.rows[:0]
+	day.ForEachHour(func(record types.TechnologyEnergy) {
+		for _, tech := range types.Technologies() {
+			if value, ok := record.Value(tech); ok {
+				w.rows = append(w.rows, TechnologyRow{
+					Date:       epochDays(record.Date),
+					Hour:       int32(record.Hour),
+					System:     record.System.String(),
+					Technology: strings.ToLower(string(tech)),
+					Value:      value,
+				})
+			}
+		}
+	})
+	return w.flush()
+}
+
+// epochDays returns the days from 1970-01-01 to the calendar date of t, the physical
+// value of the Parquet DATE type
+func epochDays(t time.Time) int32 {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int32(date.Unix() / 86400)
+}
+
+// flush writes the buffered rows
+func (w *rowWriter[T]) flush() error {
+	if _, err := w.writer.Write(w.rows); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write Parquet rows", err)
+	}
+	return nil
+}
+
+// Close flushes the remaining rows and writes the file footer. It doesn't close the
+// underlying writer.
+func (w *rowWriter[T]) Close() error {
+	if err := w.writer.Close(); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to close Parquet file", err)
+	}
+	return nil
+}
+
+// Format implements export.Format, writing the results of the marginal price and energy
+// by technology importers as Parquet. Parquet compresses its own columns, so there is no
+// need to compress the exported files.
+type Format struct{}
+
+// Extension returns ".parquet"
+func (Format) Extension() string {
+	return ".parquet"
+}
+
+// Write encodes importer results as a Parquet file
+func (Format) Write(w io.Writer, results interface{}) error {
+	switch results := results.(type) {
+	case []*types.MarginalPriceData:
+		writer := NewPriceWriter(w)
+		for _, data := range results {
+			if err := writer.Write(data); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	case []*types.TechnologyEnergyDay:
+		writer := NewTechnologyWriter(w)
+		for _, day := range results {
+			if err := writer.Write(day); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	default:
+		return types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("cannot write %T as Parquet", results), nil)
+	}
+}
@@ -0,0 +1,88 @@
+package parquet
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	goparquet "github.com/parquet-go/parquet-go"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestPriceWriter(t *testing.T) {
+	first := types.NewMarginalPriceData(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	first.SpainPrices[1] = 45.5
+	first.PortugalPrices[1] = math.NaN()
+	second := types.NewMarginalPriceData(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+	second.Resolution = types.QuarterHourly
+	second.SpainPrices[96] = 80
+
+	var buf bytes.Buffer
+	if err := (Format{}).Write(&buf, []*types.MarginalPriceData{first, second}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := readRows[PriceRow](t, buf.Bytes())
+	want := []PriceRow{
+		{Date: 19737, Hour: 1, Resolution: "HOURLY", Series: "spain_prices", Value: 45.5},
+		{Date: 20362, Hour: 96, Resolution: "QUARTER_HOURLY", Series: "spain_prices", Value: 80},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), rows)
+	}
+	for i := range want {
+		if rows[i].Date != want[i].Date || rows[i].Hour != want[i].Hour || rows[i].Resolution != want[i].Resolution ||
+			rows[i].Series != want[i].Series || rows[i].Value != want[i].Value {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestTechnologyWriter(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	records := make([]types.TechnologyEnergy, 24)
+	for i := range records {
+		records[i] = types.TechnologyEnergy{Date: date, Hour: i + 1, System: types.Spain, Wind: float64(i), Coal: math.NaN()}
+	}
+
+	var buf bytes.Buffer
+	writer := NewTechnologyWriter(&buf)
+	if err := writer.Write(&types.TechnologyEnergyDay{Date: date, System: types.Spain, Records: records}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := readRows[TechnologyRow](t, buf.Bytes())
+	if len(rows) != 24*11 {
+		t.Fatalf("expected 11 values per hour, got %d rows", len(rows))
+	}
+	for _, row := range rows {
+		if row.Technology == "coal" {
+			t.Fatal("expected missing coal values to be skipped")
+		}
+		if row.Technology == "wind" && row.Value != float64(row.Hour-1) {
+			t.Errorf("unexpected wind row %+v", row)
+		}
+	}
+	if rows[0].System != "SPAIN" {
+		t.Errorf("unexpected system %q", rows[0].System)
+	}
+}
+
+// readRows reads every row of a Parquet file
+func readRows[T any](t *testing.T, data []byte) []T {
+	t.Helper()
+
+	reader := goparquet.NewGenericReader[T](bytes.NewReader(data))
+	defer reader.Close()
+
+	rows := make([]T, reader.NumRows())
+	if n, err := reader.Read(rows); n != len(rows) {
+		t.Fatalf("read %d of %d rows: %v", n, len(rows), err)
+	}
+	return rows
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Partitioning describes how an export is split into output directories
+type Partitioning string
+
+const (
+	PartitionNone         Partitioning = ""
+	PartitionYear         Partitioning = "year"
+	PartitionYearMonth    Partitioning = "year/month"
+	PartitionYearMonthDay Partitioning = "year/month/day"
+)
+
+// ParsePartitioning parses a partitioning name such as "year/month"
+func ParsePartitioning(s string) (Partitioning, error) {
+	switch p := Partitioning(s); p {
+	case PartitionNone, PartitionYear, PartitionYearMonth, PartitionYearMonthDay:
+		return p, nil
+	case "none":
+		return PartitionNone, nil
+	default:
+		return "", types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("unknown partitioning %q", s), nil)
+	}
+}
+
+// Partition is a contiguous, inclusive date range written to a single output file
+type Partition struct {
+	Start time.Time
+	End   time.Time
+	Dir   string // Relative directory, e.g. "year=2024/month=01", or "" when not partitioned
+}
+
+// Split divides the inclusive range [start, end] into partitions. Directories use the
+// Hive "key=value" convention so DuckDB, Spark and similar tools detect partition columns.
+func (p Partitioning) Split(start, end time.Time) []Partition {
+	if p == PartitionNone {
+		return []Partition{{Start: start, End: end}}
+	}
+
+	var partitions []Partition
+	for current := start; !current.After(end); {
+		var next time.Time
+		var dir string
+
+		switch p {
+		case PartitionYear:
+			next = time.Date(current.Year()+1, 1, 1, 0, 0, 0, 0, current.Location())
+			dir = fmt.Sprintf("year=%04d", current.Year())
+		case PartitionYearMonth:
+			next = time.Date(current.Year(), current.Month()+1, 1, 0, 0, 0, 0, current.Location())
+			dir = filepath.Join(fmt.Sprintf("year=%04d", current.Year()), fmt.Sprintf("month=%02d", current.Month()))
+		default:
+			next = current.AddDate(0, 0, 1)
+			dir = filepath.Join(fmt.Sprintf("year=%04d", current.Year()), fmt.Sprintf("month=%02d", current.Month()), fmt.Sprintf("day=%02d", current.Day()))
+		}
+
+		last := next.AddDate(0, 0, -1)
+		if last.After(end) {
+			last = end
+		}
+
+		partitions = append(partitions, Partition{Start: current, End: last, Dir: dir})
+		current = next
+	}
+
+	return partitions
+}
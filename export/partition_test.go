@@ -0,0 +1,61 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPartitioning_Split(t *testing.T) {
+	start := time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		partitioning Partitioning
+		expectedDirs []string
+		lastStart    time.Time
+	}{
+		{PartitionNone, []string{""}, start},
+		{PartitionYear, []string{"year=2023", "year=2024"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{
+			PartitionYearMonth,
+			[]string{
+				filepath.Join("year=2023", "month=11"),
+				filepath.Join("year=2023", "month=12"),
+				filepath.Join("year=2024", "month=01"),
+				filepath.Join("year=2024", "month=02"),
+			},
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.partitioning), func(t *testing.T) {
+			partitions := tt.partitioning.Split(start, end)
+
+			if len(partitions) != len(tt.expectedDirs) {
+				t.Fatalf("expected %d partitions, got %d", len(tt.expectedDirs), len(partitions))
+			}
+
+			for i, partition := range partitions {
+				if partition.Dir != tt.expectedDirs[i] {
+					t.Errorf("partition %d: expected dir %q, got %q", i, tt.expectedDirs[i], partition.Dir)
+				}
+			}
+
+			if !partitions[0].Start.Equal(start) {
+				t.Errorf("first partition should start at %v, got %v", start, partitions[0].Start)
+			}
+
+			last := partitions[len(partitions)-1]
+			if !last.Start.Equal(tt.lastStart) || !last.End.Equal(end) {
+				t.Errorf("last partition: expected %v..%v, got %v..%v", tt.lastStart, end, last.Start, last.End)
+			}
+		})
+	}
+
+	days := PartitionYearMonthDay.Split(start, start.AddDate(0, 0, 2))
+	if len(days) != 3 || !days[2].Start.Equal(days[2].End) {
+		t.Errorf("expected 3 single-day partitions, got %v", days)
+	}
+}
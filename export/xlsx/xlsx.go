@@ -0,0 +1,313 @@
+// Package xlsx writes parsed OMIE data as Excel workbooks, with a sheet of prices and a
+// sheet of energy by technology (or one of each per day), formatted dates and units in
+// the column headers.
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Options configures the layout of a workbook
+type Options struct {
+	// PerDay writes a sheet per day, e.g. "Prices 2024-01-15", instead of a single sheet
+	// of each kind
+	PerDay bool
+}
+
+// seriesLabels are the column headers of the series of MarginalPriceData
+var seriesLabels = map[string]string{
+	"spain_prices":               "Spain price (EUR/MWh)",
+	"portugal_prices":            "Portugal price (EUR/MWh)",
+	"spain_buy_energy":           "Spain buy energy (MWh)",
+	"spain_sell_energy":          "Spain sell energy (MWh)",
+	"iberian_energy":             "Iberian energy (MWh)",
+	"bilateral_energy":           "Bilateral energy (MWh)",
+	"spain_adjustment_prices":    "Spain adjustment price (EUR/MWh)",
+	"portugal_adjustment_prices": "Portugal adjustment price (EUR/MWh)",
+	"export_spain_to_portugal":   "Export Spain to Portugal (MWh)",
+	"export_portugal_to_spain":   "Export Portugal to Spain (MWh)",
+}
+
+// technologyLabels are the column headers of the technologies of TechnologyEnergy
+var technologyLabels = map[types.TechnologyType]string{
+	types.Coal:               "Coal (MWh)",
+	types.FuelGas:            "Fuel-gas (MWh)",
+	types.SelfProducer:       "Self-producer (MWh)",
+	types.Nuclear:            "Nuclear (MWh)",
+	types.Hydro:              "Hydro (MWh)",
+	types.CombinedCycle:      "Combined cycle (MWh)",
+	types.Wind:               "Wind (MWh)",
+	types.ThermalSolar:       "Solar thermal (MWh)",
+	types.PhotovoltaicSolar:  "Solar PV (MWh)",
+	types.Residuals:          "Cogeneration and residuals (MWh)",
+	types.Import:             "Imports (MWh)",
+	types.ImportWithoutMIBEL: "Imports without MIBEL (MWh)",
+}
+
+// Workbook is an Excel workbook being filled with OMIE data
+type Workbook struct {
+	file    *excelize.File
+	options Options
+	sheets  int
+
+	// Cell styles
+	header int
+	date   int
+	number int
+}
+
+// NewWorkbook creates an empty workbook
+func NewWorkbook(options Options) (*Workbook, error) {
+	file := excelize.NewFile()
+	b := &Workbook{file: file, options: options}
+
+	var err error
+	if b.header, err = file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return nil, wrapError(err)
+	}
+	dateFormat := "yyyy-mm-dd"
+	if b.date, err = file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return nil, wrapError(err)
+	}
+	numberFormat := "#,##0.00"
+	if b.number, err = file.NewStyle(&excelize.Style{CustomNumFmt: &numberFormat}); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return b, nil
+}
+
+// AddPrices adds the marginal prices of days, with a column per series that has values
+func (b *Workbook) AddPrices(days []*types.MarginalPriceData) error {
+	if !b.options.PerDay {
+		return b.addPriceSheet("Prices", days)
+	}
+	for _, day := range days {
+		if err := b.addPriceSheet("Prices "+day.Date.Format("2006-01-02"), []*types.MarginalPriceData{day}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTechnology adds the energy by technology of days, with a column per technology
+func (b *Workbook) AddTechnology(days []*types.TechnologyEnergyDay) error {
+	if !b.options.PerDay {
+		return b.addTechnologySheet("Technology", days)
+	}
+
+	// Days of several systems share the sheet of their date
+	byDate := make(map[time.Time][]*types.TechnologyEnergyDay)
+	var dates []time.Time
+	for _, day := range days {
+		if _, ok := byDate[day.Date]; !ok {
+			dates = append(dates, day.Date)
+		}
+		byDate[day.Date] = append(byDate[day.Date], day)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	for _, date := range dates {
+		if err := b.addTechnologySheet("Technology "+date.Format("2006-01-02"), byDate[date]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes the workbook to w
+func (b *Workbook) Write(w io.Writer) error {
+	b.file.SetActiveSheet(0)
+	if err := b.file.Write(w); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write workbook", err)
+	}
+	return nil
+}
+
+// Close releases the resources of the workbook
+func (b *Workbook) Close() error {
+	return b.file.Close()
+}
+
+// addPriceSheet writes days of prices to a new sheet
+func (b *Workbook) addPriceSheet(name string, days []*types.MarginalPriceData) error {
+	var names []string
+	used := make(map[string]bool)
+	quarterHourly := len(days) > 0
+	for _, day := range days {
+		quarterHourly = quarterHourly && day.Resolution == types.QuarterHourly
+		for _, series := range day.Series() {
+			if len(series.Values) > 0 {
+				used[series.Name] = true
+			}
+		}
+	}
+
+	hourLabel := "Hour"
+	if quarterHourly {
+		hourLabel = "Period"
+	}
+	header := []string{"Date", hourLabel}
+	for _, series := range (&types.MarginalPriceData{}).Series() {
+		if used[series.Name] {
+			names = append(names, series.Name)
+			header = append(header, seriesLabels[series.Name])
+		}
+	}
+
+	return b.addSheet(name, header, func(writeRow func(values ...interface{}) error) error {
+		for _, day := range days {
+			series := make(map[string]types.HourlyValues)
+			for _, s := range day.Series() {
+				series[s.Name] = s.Values
+			}
+			for _, hour := range day.HoursSorted() {
+				row := []interface{}{day.Date, hour}
+				for _, name := range names {
+					value, ok := series[name][hour]
+					row = append(row, b.numberCell(value, ok))
+				}
+				if err := writeRow(row...); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// addTechnologySheet writes days of energy by technology to a new sheet
+func (b *Workbook) addTechnologySheet(name string, days []*types.TechnologyEnergyDay) error {
+	technologies := types.Technologies()
+	header := []string{"Date", "Hour", "System"}
+	for _, tech := range technologies {
+		header = append(header, technologyLabels[tech])
+	}
+
+	return b.addSheet(name, header, func(writeRow func(values ...interface{}) error) error {
+		var err error
+		for _, day := range days {
+			day.ForEachHour(func(record types.TechnologyEnergy) {
+				if err != nil {
+					return
+				}
+				row := []interface{}{record.Date, record.Hour, record.System.String()}
+				for _, tech := range technologies {
+					row = append(row, b.numberCell(record.Value(tech)))
+				}
+				err = writeRow(row...)
+			})
+		}
+		return err
+	})
+}
+
+// addSheet creates a sheet with a bold, frozen header row and streams the rows passed
+// by fill into it. The first column holds dates.
+func (b *Workbook) addSheet(name string, header []string, fill func(writeRow func(values ...interface{}) error) error) error {
+	if b.sheets == 0 {
+		if err := b.file.SetSheetName(b.file.GetSheetName(0), name); err != nil {
+			return wrapError(err)
+		}
+	} else if _, err := b.file.NewSheet(name); err != nil {
+		return wrapError(err)
+	}
+	b.sheets++
+
+	stream, err := b.file.NewStreamWriter(name)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := stream.SetColWidth(1, 1, 12); err != nil {
+		return wrapError(err)
+	}
+	if err := stream.SetColWidth(2, len(header), 16); err != nil {
+		return wrapError(err)
+	}
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return wrapError(err)
+	}
+
+	headerCells := make([]interface{}, len(header))
+	for i, label := range header {
+		headerCells[i] = excelize.Cell{StyleID: b.header, Value: label}
+	}
+	if err := stream.SetRow("A1", headerCells); err != nil {
+		return wrapError(err)
+	}
+
+	row := 1
+	err = fill(func(values ...interface{}) error {
+		row++
+		values[0] = excelize.Cell{StyleID: b.date, Value: values[0]}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		return stream.SetRow(cell, values)
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := stream.Flush(); err != nil {
+		return wrapError(err)
+	}
+	return nil
+}
+
+// numberCell returns a formatted number cell, or an empty one when the value is missing
+func (b *Workbook) numberCell(value float64, ok bool) interface{} {
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return excelize.Cell{StyleID: b.number, Value: value}
+}
+
+// wrapError wraps an error of the workbook library
+func wrapError(err error) error {
+	return types.NewOMIEError(types.ErrCodeStorage, "failed to build workbook", err)
+}
+
+// Format implements export.Format, writing the results of the marginal price and energy
+// by technology importers as a workbook
+type Format struct {
+	Options Options
+}
+
+// Extension returns ".xlsx"
+func (Format) Extension() string {
+	return ".xlsx"
+}
+
+// Write encodes importer results as a workbook
+func (f Format) Write(w io.Writer, results interface{}) error {
+	workbook, err := NewWorkbook(f.Options)
+	if err != nil {
+		return err
+	}
+	defer workbook.Close()
+
+	switch results := results.(type) {
+	case []*types.MarginalPriceData:
+		err = workbook.AddPrices(results)
+	case []*types.TechnologyEnergyDay:
+		err = workbook.AddTechnology(results)
+	default:
+		return types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("cannot write %T as a workbook", results), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return workbook.Write(w)
+}
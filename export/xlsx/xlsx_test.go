@@ -0,0 +1,105 @@
+package xlsx
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestWorkbook(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	prices := types.NewMarginalPriceData(date)
+	prices.SpainPrices[1] = 45.5
+	prices.PortugalPrices[1] = math.NaN()
+	prices.PortugalPrices[2] = 40
+	technology := &types.TechnologyEnergyDay{Date: date, System: types.Spain, Records: []types.TechnologyEnergy{
+		{Date: date, Hour: 1, System: types.Spain, Coal: math.NaN(), Wind: 1234.5},
+	}}
+
+	workbook, err := NewWorkbook(Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer workbook.Close()
+	if err := workbook.AddPrices([]*types.MarginalPriceData{prices}); err != nil {
+		t.Fatalf("AddPrices() error: %v", err)
+	}
+	if err := workbook.AddTechnology([]*types.TechnologyEnergyDay{technology}); err != nil {
+		t.Fatalf("AddTechnology() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := workbook.Write(&buf); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer file.Close()
+
+	if sheets := file.GetSheetList(); len(sheets) != 2 || sheets[0] != "Prices" || sheets[1] != "Technology" {
+		t.Fatalf("unexpected sheets %v", sheets)
+	}
+
+	rows, err := file.GetRows("Prices")
+	if err != nil {
+		t.Fatalf("GetRows() error: %v", err)
+	}
+	want := [][]string{
+		{"Date", "Hour", "Spain price (EUR/MWh)", "Portugal price (EUR/MWh)"},
+		{"2024-01-15", "1", "45.50"},
+		{"2024-01-15", "2", "", "40.00"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %v", len(want), rows)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if j >= len(rows[i]) || rows[i][j] != want[i][j] {
+				t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+				break
+			}
+		}
+	}
+
+	wind, err := file.GetCellValue("Technology", "J2")
+	if err != nil || wind != "1,234.50" {
+		t.Errorf("expected formatted wind energy, got %q, %v", wind, err)
+	}
+	if header, _ := file.GetCellValue("Technology", "J1"); header != "Wind (MWh)" {
+		t.Errorf("unexpected header %q", header)
+	}
+}
+
+func TestWorkbookPerDay(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	var days []*types.MarginalPriceData
+	for i := 0; i < 3; i++ {
+		day := types.NewMarginalPriceData(date.AddDate(0, 0, i))
+		day.SpainPrices[1] = float64(i)
+		days = append(days, day)
+	}
+
+	var buf bytes.Buffer
+	if err := (Format{Options: Options{PerDay: true}}).Write(&buf, days); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) != 3 || sheets[2] != "Prices 2024-01-17" {
+		t.Errorf("unexpected sheets %v", sheets)
+	}
+}
@@ -2,8 +2,20 @@ module github.com/devuo/omiedata
 
 go 1.24.5
 
-require golang.org/x/text v0.27.0
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/pkg/sftp v1.13.9
+	golang.org/x/text v0.27.0
+)
 
-require github.com/fzipp/gocyclo v0.6.0 // indirect
+require (
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/fzipp/gocyclo v0.6.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	github.com/stretchr/testify v1.11.1 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
 
 tool github.com/fzipp/gocyclo/cmd/gocyclo
@@ -3,6 +3,8 @@ package importers
 import (
 	"context"
 	"fmt"
+	"iter"
+	"sort"
 	"time"
 
 	"github.com/devuo/omiedata/downloaders"
@@ -13,7 +15,7 @@ import (
 // EnergyByTechnologyImporter imports energy by technology data
 type EnergyByTechnologyImporter struct {
 	downloader *downloaders.EnergyByTechnologyDownloader
-	parser     *parsers.EnergyByTechnologyParser
+	parser     parsers.Parser
 	options    ImportOptions
 	systemType types.SystemType
 }
@@ -22,18 +24,14 @@ type EnergyByTechnologyImporter struct {
 func NewEnergyByTechnologyImporter(systemType types.SystemType, options ImportOptions) *EnergyByTechnologyImporter {
 	downloader := downloaders.NewEnergyByTechnologyDownloader(systemType)
 
-	// Configure downloader
-	config := downloaders.DownloadConfig{
-		MaxRetries:     options.MaxRetries,
-		RetryDelay:     options.RetryDelay,
-		RequestTimeout: 30 * time.Second,
-		MaxConcurrent:  options.MaxConcurrent,
-	}
-	downloader.SetConfig(config)
+	downloader.SetConfig(options.downloadConfig())
+
+	parser := parsers.NewEnergyByTechnologyParser()
+	parser.Strict = options.Strict
 
 	return &EnergyByTechnologyImporter{
 		downloader: downloader,
-		parser:     parsers.NewEnergyByTechnologyParser(),
+		parser:     options.wrapCachedParser(parser, "energy_by_technology"),
 		options:    options,
 		systemType: systemType,
 	}
@@ -49,48 +47,153 @@ func NewDefaultEnergyByTechnologyImporter(systemType types.SystemType) *EnergyBy
 	})
 }
 
-// Import downloads and parses energy by technology data for a date range
+// Import downloads and parses energy by technology data for a date range, returning a
+// []*types.TechnologyEnergyDay. See ImportDays for the typed equivalent.
 func (i *EnergyByTechnologyImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
-	responseChan := i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
+
+// ImportDays downloads and parses energy by technology data for a date range
+func (i *EnergyByTechnologyImporter) ImportDays(ctx context.Context, start, end time.Time) ([]*types.TechnologyEnergyDay, error) {
+	results, _, err := importAll[*types.TechnologyEnergyDay](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+	return results, err
+}
+
+// ImportPartial downloads and parses energy by technology data for a date range. Unlike
+// ImportDays it never fails as a whole: the dates that couldn't be imported are listed
+// in the result's Errors, so they can be retried on their own.
+func (i *EnergyByTechnologyImporter) ImportPartial(ctx context.Context, start, end time.Time) *ImportResult[*types.TechnologyEnergyDay] {
+	return collect[*types.TechnologyEnergyDay](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+}
+
+// ImportWithStats downloads and parses energy by technology data for a date range, also returning
+// a summary of the run
+func (i *EnergyByTechnologyImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	results, stats, err := importAll[*types.TechnologyEnergyDay](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+	return results, stats, nil
+}
 
-	var results []*types.TechnologyEnergyDay
+// ImportAllSystems downloads and parses energy by technology data for Spain, Portugal
+// and the Iberian market concurrently, returning the days of each system keyed by
+// SystemType. All systems share a single worker pool bounded by MaxConcurrent.
+func (i *EnergyByTechnologyImporter) ImportAllSystems(ctx context.Context, start, end time.Time) (map[types.SystemType][]*types.TechnologyEnergyDay, error) {
+	systems := []types.SystemType{types.Spain, types.Portugal, types.Iberian}
+	responseChan := i.downloader.SystemsURLResponses(ctx, systems, start, end, i.options.Verbose)
+
+	results := make(map[types.SystemType][]*types.TechnologyEnergyDay, len(systems))
+	stats := i.options.newStats(daysIn(start, end) * len(systems))
 	var errors []error
+	imported := 0
 
 	for result := range responseChan {
+		stats.recordDownload(result.ResponseResult)
 		if result.Error != nil {
-			errors = append(errors, result.Error)
+			errors = append(errors, fmt.Errorf("%s: %w", result.System, result.Error))
 			continue
 		}
 
 		// Parse the response
+		parseStarted := time.Now()
 		parsed, err := i.parser.ParseResponse(result.Response)
 		result.Response.Body.Close()
+		stats.recordParse(result.Date, time.Since(parseStarted), err)
 
 		if err != nil {
-			errors = append(errors, fmt.Errorf("parse error for %s: %w", result.Date.Format("2006-01-02"), err))
+			errors = append(errors, fmt.Errorf("parse error for %s %s: %w", result.System, result.Date.Format("2006-01-02"), err))
 			continue
 		}
 
 		if data, ok := parsed.(*types.TechnologyEnergyDay); ok {
-			results = append(results, data)
+			results[result.System] = append(results[result.System], data)
+			imported++
 		}
 	}
 
-	if len(results) == 0 && len(errors) > 0 {
-		return nil, fmt.Errorf("no data imported, %d errors occurred: %v", len(errors), errors[0])
+	if imported == 0 && len(errors) > 0 {
+		return nil, types.JoinErrors("no data imported", errors)
+	}
+
+	// Workers finish in any order, keep each system's days chronological
+	for _, days := range results {
+		sort.Slice(days, func(a, b int) bool { return days[a].Date.Before(days[b].Date) })
 	}
 
 	return results, nil
 }
 
-// ImportSingleDate downloads and parses energy by technology data for a single date
+// ImportEach downloads and parses energy by technology data for a date range, calling fn with
+// every day in date order instead of collecting them, together with a token that
+// continues the import after that day when passed to ResumeEach. After a failed date the
+// token stays before it, so resuming retries the date.
+func (i *EnergyByTechnologyImporter) ImportEach(ctx context.Context, start, end time.Time, fn func(*types.TechnologyEnergyDay, ResumeToken) error) error {
+	return importEach(ctx, i.urlResponses, i.parser, i.options, i.dataset(), start, end, fn)
+}
+
+// ImportSeq downloads and parses energy by technology data for a date range, yielding the days in
+// date order without collecting them. Dates that failed are yielded with a DateError;
+// stopping the loop early cancels the downloads still in flight.
+func (i *EnergyByTechnologyImporter) ImportSeq(ctx context.Context, start, end time.Time) iter.Seq2[*types.TechnologyEnergyDay, error] {
+	return importSeq[*types.TechnologyEnergyDay](ctx, i.urlResponses, i.parser, i.options, start, end)
+}
+
+// ResumeEach continues an ImportEach interrupted after the day of token
+func (i *EnergyByTechnologyImporter) ResumeEach(ctx context.Context, token string, fn func(*types.TechnologyEnergyDay, ResumeToken) error) error {
+	start, end, err := resumeRange(token, i.dataset())
+	if err != nil {
+		return err
+	}
+	if start.After(end) {
+		return nil // Nothing left to import
+	}
+	return i.ImportEach(ctx, start, end, fn)
+}
+
+// dataset returns the name identifying this importer's data in resume tokens
+func (i *EnergyByTechnologyImporter) dataset() string {
+	return fmt.Sprintf("energy_by_technology_%d", int(i.systemType))
+}
+
+// urlResponses starts downloading the date range, skipping the days in the result cache
+func (i *EnergyByTechnologyImporter) urlResponses(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+	download := func(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+		return i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+	}
+	return cachedResponses(i.options.ResultCache, "energy_by_technology", i.systemType, i.options.resultConfig(), download)(ctx, start, end)
+}
+
+// ImportFromDir parses energy by technology data for a date range from the files a
+// previous DownloadData saved in dir, returning the same results as Import
+func (i *EnergyByTechnologyImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	responses := i.downloader.DirResponses(ctx, dir, start, end)
+	results, _, err := importAll[*types.TechnologyEnergyDay](responses, i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ImportSingleDate downloads and parses energy by technology data for a single date, returning a
+// *types.TechnologyEnergyDay. See ImportDay for the typed equivalent.
 func (i *EnergyByTechnologyImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
-	results, err := i.Import(ctx, date, date)
+	data, err := i.ImportDay(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImportDay downloads and parses energy by technology data for a single date
+func (i *EnergyByTechnologyImporter) ImportDay(ctx context.Context, date time.Time) (*types.TechnologyEnergyDay, error) {
+	dataList, err := i.ImportDays(ctx, date, date)
 	if err != nil {
 		return nil, err
 	}
 
-	if dataList, ok := results.([]*types.TechnologyEnergyDay); ok && len(dataList) > 0 {
+	if len(dataList) > 0 {
 		return dataList[0], nil
 	}
 
@@ -99,16 +202,11 @@ func (i *EnergyByTechnologyImporter) ImportSingleDate(ctx context.Context, date
 
 // ImportToRecords imports data and returns it as a flat list of records
 func (i *EnergyByTechnologyImporter) ImportToRecords(ctx context.Context, start, end time.Time) ([]types.TechnologyEnergy, error) {
-	results, err := i.Import(ctx, start, end)
+	dataList, err := i.ImportDays(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	dataList, ok := results.([]*types.TechnologyEnergyDay)
-	if !ok {
-		return nil, types.NewOMIEError(types.ErrCodeParse, "unexpected result type", nil)
-	}
-
 	var records []types.TechnologyEnergy
 
 	for _, dayData := range dataList {
@@ -0,0 +1,156 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// GapPolicy re-fetches the dates an import of a range missed, with a backoff of its
+// own on top of the retries of every download, e.g. to ride out an outage of omie.es
+// in the middle of a long backfill
+type GapPolicy struct {
+	Rounds   int           // Re-fetch rounds after the import, 3 when zero
+	Delay    time.Duration // Wait before the first round, doubled for every later one
+	MaxDelay time.Duration // Optional cap on the wait between rounds
+
+	// RetryNotPublished also re-fetches the dates OMIE hadn't published, e.g. to wait
+	// for the file of the current day. Failures that retrying can't fix, like files
+	// that don't parse, are never re-fetched.
+	RetryNotPublished bool
+}
+
+// GapReport is the outcome of ImportFillingGaps
+type GapReport[T any] struct {
+	Days      []T         // Every day imported, in the order they were imported
+	Recovered []time.Time // Dates missed by the import and imported by a later round, in date order
+	Gaps      []DateError // Dates still missing after every round, with their last error, in date order
+	Rounds    int         // Re-fetch rounds run
+}
+
+// Err returns a *types.MultiError holding the gaps, or nil when the range is complete
+func (r *GapReport[T]) Err() error {
+	errs := make([]error, len(r.Gaps))
+	for i, gap := range r.Gaps {
+		errs[i] = gap
+	}
+	return types.JoinErrors("import left gaps", errs)
+}
+
+// ImportFillingGaps imports the days from start to end, then re-fetches the dates that
+// failed in rounds, waiting as set by policy before each one, until the range is
+// complete, the rounds run out or only failures retrying can't fix are left. Runs of
+// consecutive missing dates are re-fetched together. The dates that are still missing
+// are reported as gaps; cancelling ctx stops the rounds and reports the dates missing
+// so far.
+func ImportFillingGaps[T any](ctx context.Context, importer TypedImporter[T], start, end time.Time, policy GapPolicy) *GapReport[T] {
+	rounds := policy.Rounds
+	if rounds < 1 {
+		rounds = 3
+	}
+
+	first := importer.ImportPartial(ctx, start, end)
+	report := &GapReport[T]{Days: first.Days}
+	gaps := first.Errors
+	missed := make(map[time.Time]bool, len(gaps))
+	for _, gap := range gaps {
+		missed[gap.Date] = true
+	}
+
+	delay := policy.Delay
+	for report.Rounds < rounds && policy.refetchable(gaps) && ctx.Err() == nil {
+		if !sleep(ctx, delay) {
+			break
+		}
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		report.Rounds++
+
+		var remaining []DateError
+		for _, run := range policy.runs(gaps, &remaining) {
+			result := importer.ImportPartial(ctx, run.Start, run.End)
+			report.Days = append(report.Days, result.Days...)
+			remaining = append(remaining, result.Errors...)
+		}
+		gaps = remaining
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].Date.Before(gaps[j].Date) })
+	report.Gaps = gaps
+	still := make(map[time.Time]bool, len(gaps))
+	for _, gap := range gaps {
+		still[gap.Date] = true
+	}
+	for date := range missed {
+		if !still[date] {
+			report.Recovered = append(report.Recovered, date)
+		}
+	}
+	sort.Slice(report.Recovered, func(i, j int) bool { return report.Recovered[i].Before(report.Recovered[j]) })
+	return report
+}
+
+// refetchable reports whether any of the gaps may be filled by fetching it again
+func (p GapPolicy) refetchable(gaps []DateError) bool {
+	for _, gap := range gaps {
+		if p.retries(gap) {
+			return true
+		}
+	}
+	return false
+}
+
+// retries reports whether the policy re-fetches a gap
+func (p GapPolicy) retries(gap DateError) bool {
+	switch types.Classify(gap.Err) {
+	case types.ClassPermanent:
+		return false
+	case types.ClassNotPublished:
+		return p.RetryNotPublished
+	}
+	return !errors.Is(gap.Err, context.Canceled) && !errors.Is(gap.Err, context.DeadlineExceeded)
+}
+
+// runs groups the gaps the policy re-fetches into ranges of consecutive dates, in date
+// order, appending the gaps it doesn't re-fetch to kept
+func (p GapPolicy) runs(gaps []DateError, kept *[]DateError) []types.DateRange {
+	var dates []time.Time
+	for _, gap := range gaps {
+		if p.retries(gap) {
+			dates = append(dates, gap.Date)
+		} else {
+			*kept = append(*kept, gap)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	var runs []types.DateRange
+	for _, date := range dates {
+		if n := len(runs); n > 0 && !date.After(runs[n-1].End.AddDate(0, 0, 1)) {
+			runs[n-1].End = date
+			continue
+		}
+		runs = append(runs, types.DateRange{Start: date, End: date})
+	}
+	return runs
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
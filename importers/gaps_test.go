@@ -0,0 +1,57 @@
+package importers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestImportFillingGaps(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 2nd fails once, the 4th isn't published and the 5th is garbage
+	var outage atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, string(fixture)
+		switch {
+		case strings.Contains(req.URL.Path, "_02_06_2009_") && outage.Add(1) == 1:
+			status = http.StatusServiceUnavailable
+		case strings.Contains(req.URL.Path, "_04_06_2009_"):
+			status = http.StatusNotFound
+		case strings.Contains(req.URL.Path, "_05_06_2009_"):
+			body = "not an OMIE file"
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})}
+	importer := NewMarginalPriceImporter(ImportOptions{MaxConcurrent: 2, HTTPClient: client})
+	start := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.AddDate(0, 0, n-1) }
+
+	report := ImportFillingGaps(context.Background(), importer, start, day(5), GapPolicy{Rounds: 3, Delay: time.Millisecond})
+	if len(report.Days) != 3 || report.Rounds != 1 {
+		t.Errorf("Expected 3 days after a round, got %d days after %d rounds", len(report.Days), report.Rounds)
+	}
+	if len(report.Recovered) != 1 || !report.Recovered[0].Equal(day(2)) {
+		t.Errorf("Expected the 2nd to be recovered, got %v", report.Recovered)
+	}
+	if len(report.Gaps) != 2 || !report.Gaps[0].Date.Equal(day(4)) || !report.Gaps[1].Date.Equal(day(5)) {
+		t.Errorf("Expected the 4th and 5th to be gaps, got %v", report.Gaps)
+	}
+	if report.Err() == nil {
+		t.Error("Expected an error listing the gaps")
+	}
+
+	// Waiting for unpublished files uses up the rounds
+	report = ImportFillingGaps(context.Background(), importer, day(4), day(5), GapPolicy{Rounds: 2, Delay: time.Millisecond, RetryNotPublished: true})
+	if report.Rounds != 2 || len(report.Gaps) != 2 {
+		t.Errorf("Expected 2 rounds leaving 2 gaps, got %d rounds and %v", report.Rounds, report.Gaps)
+	}
+}
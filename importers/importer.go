@@ -2,7 +2,17 @@ package importers
 
 import (
 	"context"
+	"crypto/tls"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+	"github.com/devuo/omiedata/validate"
 )
 
 // Importer defines the interface for high-level data importers
@@ -14,10 +24,261 @@ type Importer interface {
 	ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error)
 }
 
+// TypedImporter is an Importer whose results are statically typed, T being the data of
+// a single day, e.g. *types.MarginalPriceData. Prefer its methods over Import and
+// ImportSingleDate so result type mistakes are caught by the compiler.
+type TypedImporter[T any] interface {
+	Importer
+
+	// ImportDays downloads and parses the days of a date range
+	ImportDays(ctx context.Context, start, end time.Time) ([]T, error)
+
+	// ImportDay downloads and parses a single day
+	ImportDay(ctx context.Context, date time.Time) (T, error)
+
+	// ImportPartial downloads and parses the days of a date range, reporting the dates
+	// that failed alongside the days that were imported
+	ImportPartial(ctx context.Context, start, end time.Time) *ImportResult[T]
+
+	// ImportSeq downloads and parses the days of a date range, yielding them in date
+	// order without collecting them
+	ImportSeq(ctx context.Context, start, end time.Time) iter.Seq2[T, error]
+}
+
+var (
+	_ TypedImporter[*types.MarginalPriceData]   = (*MarginalPriceImporter)(nil)
+	_ TypedImporter[*types.TechnologyEnergyDay] = (*EnergyByTechnologyImporter)(nil)
+)
+
 // ImportOptions holds configuration options for importing data
 type ImportOptions struct {
 	Verbose       bool
 	MaxRetries    int
-	RetryDelay    time.Duration
+	RetryDelay    time.Duration // Wait before the first retry, doubled for every later one
 	MaxConcurrent int
+
+	// ParseWorkers is the number of files parsed at once, independently of the
+	// MaxConcurrent files downloaded at once. Parsing runs in a stage of its own, so it
+	// overlaps with downloading even with the default of one worker; more use more cores
+	// on large ranges, e.g. runtime.NumCPU().
+	ParseWorkers int
+
+	// MaxRetryDelay and RetryJitter cap and randomize the waits between retries, see
+	// downloaders.DownloadConfig
+	MaxRetryDelay time.Duration
+	RetryJitter   float64
+
+	// ParseCache, when set, skips re-parsing files whose contents were parsed before.
+	// Days served from it are shared and must not be modified.
+	ParseCache *parsers.ParseCache
+
+	// ResultCache, when set, keeps the days imported by the marginal price and energy by
+	// technology importers in memory and serves them again without downloading or parsing.
+	// Days newer than downloaders.DefaultCacheMinAge aren't cached, and Validation and
+	// OnParsed still apply to the days served from it.
+	ResultCache *ResultCache
+
+	// HTTPClient, when set, makes the download requests instead of the downloaders' own
+	// client, e.g. to instrument requests
+	HTTPClient *http.Client
+
+	// Proxy and TLSConfig configure the downloaders' own client for corporate gateways
+	// without replacing it, see downloaders.DownloadConfig
+	Proxy     *url.URL
+	TLSConfig *tls.Config
+
+	// Logger, when set, receives structured download events instead of the verbose
+	// output on stdout
+	Logger *slog.Logger
+
+	// Progress, when set, is called after every file an import processes, from the
+	// goroutine running the import, e.g. to display the progress of long backfills
+	Progress func(Progress)
+
+	// Hooks are passed to the downloaders, see downloaders.Hooks
+	Hooks downloaders.Hooks
+
+	// Metrics, when set, receives the request, retry, byte and failure counts of the
+	// downloaders and the duration and failures of parsing every file, see
+	// downloaders.Metrics
+	Metrics downloaders.Metrics
+
+	// OnParsed, when set, is called with the result of parsing every downloaded file,
+	// e.g. a *types.MarginalPriceData, or the parse error. It is called from the parse
+	// workers, concurrently when there are several.
+	OnParsed func(data interface{}, err error)
+
+	// ArchiveDir, when set, keeps a copy of every downloaded file in this folder, named
+	// like DownloadData names them, so imports can be audited or re-parsed later with
+	// ImportFromDir
+	ArchiveDir string
+
+	// RateLimiter, when set, bounds the requests made per second. Importers created with
+	// the same limiter, e.g. from the same options, share the limit, see
+	// downloaders.NewRateLimiter.
+	RateLimiter *downloaders.RateLimiter
+
+	// Pool, when set, bounds the files downloaded at once by every importer sharing it,
+	// whatever their MaxConcurrent, e.g. when importing prices and technologies at the
+	// same time, see downloaders.NewWorkerPool
+	Pool *downloaders.WorkerPool
+
+	// CircuitBreaker, when set, stops requesting files while omie.es looks down, failing
+	// the remaining dates fast with ErrCodeUnavailable. Share one between importers, see
+	// downloaders.NewCircuitBreaker.
+	CircuitBreaker *downloaders.CircuitBreaker
+
+	// CacheDir, when set, keeps downloaded files in this folder and serves later imports
+	// of the same files from it without contacting OMIE. The files of the last days,
+	// which OMIE may still revise, are always downloaded, see downloaders.FileCache.
+	CacheDir string
+
+	// BulkArchives reads the daily curve files of SupplyDemandCurveDayImporter from OMIE's
+	// monthly and yearly ZIP archives, one request per month or year instead of one per
+	// day, e.g. for backfills, see downloaders.ZipArchiveDownloader
+	BulkArchives bool
+
+	// Fixtures, when their Dir is set, record every response to a folder or replay
+	// them from it, so end-to-end tests run offline, see downloaders.Fixtures
+	Fixtures downloaders.Fixtures
+
+	// Strict makes the marginal price and energy by technology parsers fail on rows and
+	// values they can't parse instead of skipping them, see parsers.MarginalPriceParser
+	Strict bool
+
+	// Concepts are registered with the marginal price parser, mapping row labels it
+	// doesn't know to data types, see parsers.MarginalPriceParser.RegisterConcept. An
+	// invalid concept fails every day the importer parses.
+	Concepts []parsers.Concept
+
+	// Validation, when set, is checked on every parsed file. Files with violations fail
+	// with ErrCodeInvalidData wrapping a *validate.Error that lists them, e.g.
+	// validate.DefaultRules().
+	Validation validate.RuleSet
+}
+
+// newStats creates the stats of an import of total files, reporting progress as configured
+func (o ImportOptions) newStats(total int) *ImportStats {
+	stats := newStats(o.Progress, total)
+	stats.parseWorkers = o.ParseWorkers
+	stats.metrics = o.Metrics
+	return stats
+}
+
+// downloadConfig returns the download configuration of the options
+func (o ImportOptions) downloadConfig() downloaders.DownloadConfig {
+	var archive downloaders.Writer
+	if o.ArchiveDir != "" {
+		archive = downloaders.NewLocalWriter(o.ArchiveDir)
+	}
+
+	var cache *downloaders.FileCache
+	if o.CacheDir != "" {
+		cache = downloaders.NewFileCache(o.CacheDir)
+	}
+
+	return downloaders.DownloadConfig{
+		MaxRetries:     o.MaxRetries,
+		RetryDelay:     o.RetryDelay,
+		MaxRetryDelay:  o.MaxRetryDelay,
+		RetryJitter:    o.RetryJitter,
+		RequestTimeout: 30 * time.Second,
+		MaxConcurrent:  o.MaxConcurrent,
+		HTTPClient:     o.HTTPClient,
+		Proxy:          o.Proxy,
+		TLSConfig:      o.TLSConfig,
+		Logger:         o.Logger,
+		Hooks:          o.Hooks,
+		Metrics:        o.Metrics,
+		Archive:        archive,
+		RateLimiter:    o.RateLimiter,
+		Pool:           o.Pool,
+		CircuitBreaker: o.CircuitBreaker,
+		Cache:          cache,
+		Fixtures:       o.Fixtures,
+	}
+}
+
+// archiveRequestTimeout bounds the download of a ZIP archive, which for a whole year can
+// hold hundreds of megabytes
+const archiveRequestTimeout = 10 * time.Minute
+
+// archiveConfig returns the download configuration of the options for ZIP archives,
+// whose yearly files take much longer to download than a daily file
+func (o ImportOptions) archiveConfig() downloaders.DownloadConfig {
+	config := o.downloadConfig()
+	config.RequestTimeout = archiveRequestTimeout
+	return config
+}
+
+// wrapParser wraps parser in a CachedParser when the options configure a parse cache,
+// validates the parsed data when rules are set and reports every parsed response to
+// OnParsed when set
+func (o ImportOptions) wrapParser(parser parsers.Parser) parsers.Parser {
+	return o.checkParser(o.parseCacheParser(parser))
+}
+
+// parseCacheParser wraps parser in a CachedParser when the options configure a parse cache
+func (o ImportOptions) parseCacheParser(parser parsers.Parser) parsers.Parser {
+	if o.ParseCache != nil {
+		return parsers.NewCachedParser(parser, o.ParseCache)
+	}
+	return parser
+}
+
+// checkParser wraps parser to validate the parsed data when rules are set and to report
+// every parsed response to OnParsed when set
+func (o ImportOptions) checkParser(parser parsers.Parser) parsers.Parser {
+	if len(o.Validation) > 0 {
+		parser = &validatingParser{Parser: parser, rules: o.Validation}
+	}
+	if o.OnParsed != nil {
+		parser = &hookedParser{Parser: parser, onParsed: o.OnParsed}
+	}
+	return parser
+}
+
+// hookedParser calls onParsed with the outcome of every response it parses
+type hookedParser struct {
+	parsers.Parser
+	onParsed func(interface{}, error)
+}
+
+// ParseResponse parses data from an HTTP response and reports it to the hook
+func (p *hookedParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	data, err := p.Parser.ParseResponse(resp)
+	p.onParsed(data, err)
+	return data, err
+}
+
+// validatingParser checks every response it parses against rules
+type validatingParser struct {
+	parsers.Parser
+	rules validate.RuleSet
+}
+
+// ParseResponse parses data from an HTTP response and validates it, failing when the
+// data violates any rule
+func (p *validatingParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	data, err := p.Parser.ParseResponse(resp)
+	if err != nil {
+		return data, err
+	}
+	if err := p.rules.Validate(data); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeInvalidData, "parsed data failed validation", err)
+	}
+	return data, nil
+}
+
+// importAll parses every downloaded response into a T, collecting the results and a
+// summary of the run. Days that fail are skipped; an error is only returned when no
+// day could be imported.
+func importAll[T any](responseChan <-chan downloaders.ResponseResult, parser parsers.Parser, stats *ImportStats) ([]T, *ImportStats, error) {
+	result := collect[T](responseChan, parser, stats)
+
+	if len(result.Days) == 0 && len(result.Errors) > 0 {
+		return nil, result.ImportStats, result.err("no data imported")
+	}
+
+	return result.Days, result.ImportStats, nil
 }
@@ -0,0 +1,200 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/compression"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+	"github.com/devuo/omiedata/validate"
+)
+
+func TestOnParsedHook(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	download := fixtureResponses(t, time.Time{})
+
+	var parsed []interface{}
+	options := ImportOptions{OnParsed: func(data interface{}, err error) {
+		if err != nil {
+			t.Errorf("Unexpected parse error: %v", err)
+		}
+		parsed = append(parsed, data)
+	}}
+
+	parser := options.wrapParser(parsers.NewMarginalPriceParser())
+	days, _, err := importAll[*types.MarginalPriceData](download(context.Background(), start, end), parser, options.newStats(daysIn(start, end)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 2 || len(days) != 2 {
+		t.Fatalf("Expected the hook to see both days, got %d hook calls for %d days", len(parsed), len(days))
+	}
+	if _, ok := parsed[0].(*types.MarginalPriceData); !ok {
+		t.Errorf("Expected the parsed data, got %T", parsed[0])
+	}
+}
+
+func TestValidation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	download := fixtureResponses(t, time.Time{})
+
+	for _, tt := range []struct {
+		rules validate.RuleSet
+		fail  bool
+	}{
+		{validate.DefaultRules(), false},
+		{validate.RuleSet{validate.PriceBounds(0, 10)}, true},
+	} {
+		options := ImportOptions{Validation: tt.rules}
+		parser := options.wrapParser(parsers.NewMarginalPriceParser())
+		result := collect[*types.MarginalPriceData](download(context.Background(), start, start), parser, options.newStats(1))
+
+		if !tt.fail {
+			if len(result.Days) != 1 {
+				t.Errorf("Expected the day to pass the default rules, got %v", result.Errors)
+			}
+			continue
+		}
+		var validationErr *validate.Error
+		if len(result.Errors) != 1 || !errors.As(result.Errors[0], &validationErr) || len(validationErr.Violations) == 0 {
+			t.Fatalf("Expected the day to fail validation, got %v", result.Errors)
+		}
+		if types.Classify(result.Errors[0]) != types.ClassPermanent {
+			t.Errorf("Expected a permanent failure, got %s", types.Classify(result.Errors[0]))
+		}
+	}
+}
+
+func TestImportFromDir(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One plain and one gzipped day, the third day is missing
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "PMD_20240101.txt"), fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(filepath.Join(dir, "PMD_20240102.txt"+compression.Gzip.Extension()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, _ := compression.Gzip.NewWriter(file)
+	writer.Write(fixture)
+	writer.Close()
+	file.Close()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	importer := NewDefaultMarginalPriceImporter()
+
+	results, err := importer.ImportFromDir(context.Background(), dir, start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := results.([]*types.MarginalPriceData)
+	if len(days) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(days))
+	}
+	if len(days[1].SpainPrices) == 0 {
+		t.Error("Expected prices from the gzipped file")
+	}
+
+	_, err = importer.ImportFromDir(context.Background(), dir, start.AddDate(0, 0, 2), start.AddDate(0, 0, 2))
+	var omieErr *types.OMIEError
+	if !errors.As(err, &omieErr) || omieErr.Code != types.ErrCodeNotFound {
+		t.Errorf("Expected a not found error for a missing file, got %v", err)
+	}
+}
+
+func TestBulkArchives(t *testing.T) {
+	// The monthly archive of March 2024 holds the 5th and 6th but misses the 7th
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, day := range []string{"05", "06"} {
+		member, err := archive.Create("curva_pbc_202403" + day + ".1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(member, "OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;"+day+"/03/2024;Curvas agregadas de oferta y demanda del mercado diario;;;;\n"+
+			"Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C);\n"+
+			"1;"+day+"/03/2024;MI;;V;12.010,0;0,00;O;\n")
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var requests []string
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		name := req.URL.Query().Get("filename")
+		mu.Lock()
+		requests = append(requests, name)
+		mu.Unlock()
+		if name != "curva_pbc_202403.zip" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil)), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(buf.Bytes())), Request: req}, nil
+	})}
+
+	importer := NewSupplyDemandCurveDayImporter(ImportOptions{MaxConcurrent: 1, HTTPClient: client, BulkArchives: true})
+	importer.SetSource(AggregatedCurveFile)
+	start := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	results, stats, err := importer.ImportWithStats(context.Background(), start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	days := results.([]*types.MarketCurveDay)
+	if len(days) != 2 || !days[0].Date.Equal(start) || !days[1].Date.Equal(start.AddDate(0, 0, 1)) {
+		t.Fatalf("expected the 5th and 6th, got %d days", len(days))
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected a single request for the monthly archive, got %v", requests)
+	}
+	if stats.NotFound != 1 || stats.Attempted != 3 {
+		t.Errorf("expected the 7th reported as not found, got %+v", stats)
+	}
+}
+
+func TestConcepts(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		file, err := os.Open("../testdata/PMD_20090601.txt")
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: file, Request: req}, nil
+	})}
+	date := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	options := ImportOptions{MaxConcurrent: 1, HTTPClient: client, Concepts: []parsers.Concept{
+		{Label: "Precio marginal en el sistema español (Cent/kWh)", DataType: "SPAIN_EUR_MWH", Multiplier: 10},
+	}}
+	day, err := NewMarginalPriceImporter(options).ImportDay(context.Background(), date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := day.CustomConcepts["SPAIN_EUR_MWH"][1]; math.Abs(got-39.97) > 1e-9 {
+		t.Errorf("expected the registered concept in EUR/MWh, got %v", got)
+	}
+
+	options.Concepts = []parsers.Concept{{DataType: "NO_LABEL"}}
+	_, err = NewMarginalPriceImporter(options).ImportDay(context.Background(), date)
+	var omieErr *types.OMIEError
+	if !errors.As(err, &omieErr) || omieErr.Code != types.ErrCodeInvalidData {
+		t.Errorf("expected the invalid concept reported on import, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package importers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// InterconnectionImporter imports the Spain-Portugal interconnection capacity and congestion rent
+type InterconnectionImporter struct {
+	downloader *downloaders.InterconnectionDownloader
+	parser     parsers.Parser
+	options    ImportOptions
+}
+
+// NewInterconnectionImporter creates a new interconnection importer
+func NewInterconnectionImporter(options ImportOptions) *InterconnectionImporter {
+	downloader := downloaders.NewInterconnectionDownloader()
+
+	downloader.SetConfig(options.downloadConfig())
+
+	return &InterconnectionImporter{
+		downloader: downloader,
+		parser:     options.wrapParser(parsers.NewInterconnectionParser()),
+		options:    options,
+	}
+}
+
+// NewDefaultInterconnectionImporter creates an interconnection importer with default options
+func NewDefaultInterconnectionImporter() *InterconnectionImporter {
+	return NewInterconnectionImporter(ImportOptions{
+		Verbose:       false,
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+		MaxConcurrent: 5,
+	})
+}
+
+// Import downloads and parses interconnection data for a date range, returning a
+// []*types.InterconnectionData in date order
+func (i *InterconnectionImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
+
+// ImportWithStats downloads and parses interconnection data for a date range, also
+// returning a summary of the run
+func (i *InterconnectionImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	responses := i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+	results, stats, err := importAll[*types.InterconnectionData](responses, i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Date.Before(results[b].Date) })
+	return results, stats, nil
+}
+
+// ImportFromDir parses interconnection data for a date range from the files a previous
+// DownloadData saved in dir, returning the same results as Import
+func (i *InterconnectionImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	responses := i.downloader.DirResponses(ctx, dir, start, end)
+	results, _, err := importAll[*types.InterconnectionData](responses, i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Date.Before(results[b].Date) })
+	return results, nil
+}
+
+// ImportSingleDate downloads and parses interconnection data for a single date
+func (i *InterconnectionImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
+	results, err := i.Import(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataList, ok := results.([]*types.InterconnectionData); ok && len(dataList) > 0 {
+		return dataList[0], nil
+	}
+
+	return nil, types.NewOMIEError(types.ErrCodeNotFound, "no data found for date", nil)
+}
@@ -0,0 +1,141 @@
+package importers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// Journal records the dates of every dataset imported successfully, so repeated imports
+// of a range, e.g. by a cron job, only fetch the dates that are new or failed before.
+// Datasets are named by the caller, e.g. "prices" or "technology-spain".
+type Journal interface {
+	// Missing returns the ranges of start..end not recorded for dataset, in date order
+	Missing(ctx context.Context, dataset string, start, end time.Time) ([]types.DateRange, error)
+
+	// Record marks dates as imported for dataset
+	Record(ctx context.Context, dataset string, dates ...time.Time) error
+}
+
+// FileJournal is a Journal kept in a JSON file, holding the dates of every dataset as
+// ranges so years of daily imports stay a small file. It is safe for concurrent use.
+type FileJournal struct {
+	mu       sync.Mutex
+	path     string
+	datasets map[string][]types.DateRange
+}
+
+// OpenFileJournal reads the journal at path, or starts an empty one when the file
+// doesn't exist yet. The file is written on every Record.
+func OpenFileJournal(path string) (*FileJournal, error) {
+	journal := &FileJournal{path: path, datasets: make(map[string][]types.DateRange)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read journal "+path, err)
+	}
+
+	var file struct {
+		Datasets map[string][]types.DateRange `json:"datasets"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "invalid journal "+path, err)
+	}
+	for dataset, ranges := range file.Datasets {
+		journal.datasets[dataset] = types.MergeDateRanges(ranges)
+	}
+	return journal, nil
+}
+
+// Missing returns the ranges of start..end not recorded for dataset, in date order
+func (j *FileJournal) Missing(_ context.Context, dataset string, start, end time.Time) ([]types.DateRange, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return types.DateRange{Start: start, End: end}.Subtract(j.datasets[dataset]), nil
+}
+
+// Record marks dates as imported for dataset and writes the journal
+func (j *FileJournal) Record(_ context.Context, dataset string, dates ...time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ranges := j.datasets[dataset]
+	for _, date := range dates {
+		ranges = append(ranges, types.SingleDay(date))
+	}
+	j.datasets[dataset] = types.MergeDateRanges(ranges)
+	return j.save()
+}
+
+// save writes the journal, replacing the file atomically so an interruption never
+// leaves it half written
+func (j *FileJournal) save() error {
+	data, err := json.MarshalIndent(struct {
+		Datasets map[string][]types.DateRange `json:"datasets"`
+	}{j.datasets}, "", "  ")
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to encode journal", err)
+	}
+
+	if err := atomicfile.WriteFile(j.path, data); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write journal", err)
+	}
+	return nil
+}
+
+// ImportIncremental imports the dates from start to end that journal hasn't recorded
+// for dataset, then records the ones imported. Failed dates stay unrecorded, so the
+// next run fetches them again along with any new dates; running it on a schedule over
+// a range ending today keeps a dataset in sync. The result holds the days imported and
+// the dates that failed in this run, with the counts summed over every missing range.
+// An error is only returned when the journal can't be read or written. Nothing is
+// recorded from a range whose import ctx cancelled, as the dates it skipped aren't
+// reported.
+func ImportIncremental[T any](ctx context.Context, importer TypedImporter[T], journal Journal, dataset string, start, end time.Time) (*ImportResult[T], error) {
+	missing, err := journal.Missing(ctx, dataset, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult[T]{ImportStats: &ImportStats{}}
+	for _, gap := range missing {
+		if ctx.Err() != nil {
+			break
+		}
+
+		partial := importer.ImportPartial(ctx, gap.Start, gap.End)
+		result.Days = append(result.Days, partial.Days...)
+		result.Errors = append(result.Errors, partial.Errors...)
+		result.ImportStats.add(partial.ImportStats)
+		if ctx.Err() != nil {
+			break
+		}
+
+		failed := make(map[time.Time]bool, len(partial.Errors))
+		for _, dateErr := range partial.Errors {
+			failed[dateErr.Date] = true
+		}
+		var imported []time.Time
+		for date := range gap.All() {
+			if !failed[date] {
+				imported = append(imported, date)
+			}
+		}
+		if err := journal.Record(ctx, dataset, imported...); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package importers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImportIncremental(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 2nd fails on the first run only
+	var mu sync.Mutex
+	var requested []string
+	failing := true
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		requested = append(requested, req.URL.Path)
+		status := http.StatusOK
+		if failing && strings.Contains(req.URL.Path, "_02_06_2009_") {
+			status = http.StatusNotFound
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(string(fixture))), Request: req}, nil
+	})}
+	importer := NewMarginalPriceImporter(ImportOptions{MaxConcurrent: 2, HTTPClient: client})
+	start := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.AddDate(0, 0, n-1) }
+
+	path := filepath.Join(t.TempDir(), "sync", "journal.json")
+	journal, err := OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := ImportIncremental(context.Background(), importer, journal, "prices", start, day(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Days) != 2 || len(result.Errors) != 1 || result.Attempted != 3 {
+		t.Errorf("Expected 2 days and 1 failure out of 3, got %d days, %v, %d attempted", len(result.Days), result.Errors, result.Attempted)
+	}
+
+	// A later run, from the file, only fetches the failed date and the new ones
+	failing = false
+	requested = nil
+	journal, err = OpenFileJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err = ImportIncremental(context.Background(), importer, journal, "prices", start, day(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Days) != 2 || len(requested) != 2 || result.Attempted != 2 {
+		t.Errorf("Expected the 2nd and 4th to be fetched, got %d days from %v", len(result.Days), requested)
+	}
+
+	missing, err := journal.Missing(context.Background(), "prices", start, day(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missing) != 1 || !missing[0].Start.Equal(day(5)) {
+		t.Errorf("Expected only the 5th missing, got %v", missing)
+	}
+	if missing, _ := journal.Missing(context.Background(), "technology", start, day(4)); len(missing) != 1 || missing[0].Days() != 4 {
+		t.Errorf("Expected datasets to be journaled apart, got %v", missing)
+	}
+
+	// Nothing left to fetch
+	requested = nil
+	if result, err := ImportIncremental(context.Background(), importer, journal, "prices", start, day(4)); err != nil || len(result.Days) != 0 || len(requested) != 0 {
+		t.Errorf("Expected nothing to be fetched, got %v and %d requests", err, len(requested))
+	}
+}
+
+func TestOpenFileJournalInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileJournal(path); err == nil {
+		t.Error("Expected an error opening an invalid journal")
+	}
+}
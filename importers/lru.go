@@ -0,0 +1,271 @@
+package importers
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// ResultKey identifies a parsed day in a ResultCache
+type ResultKey struct {
+	Dataset string           // "marginal_price" or "energy_by_technology"
+	Date    time.Time        // Delivery date
+	System  types.SystemType // System of energy by technology days, zero for prices
+	Config  string           // Parser settings changing the parsed day, e.g. "strict"
+}
+
+// ResultCache is a bounded in-memory LRU of parsed days, shared by importers through
+// ImportOptions.ResultCache. Imports serve the days it holds without downloading or
+// parsing them again, e.g. for interactive applications querying overlapping ranges.
+// Cached days are shared and must not be modified.
+//
+// Like downloaders.FileCache, only days older than the minimum age are cached, so the
+// recent days OMIE may still correct are always downloaded again, e.g. by
+// RevisionPolicy.Sync.
+type ResultCache struct {
+	mu       sync.Mutex
+	capacity int
+	minAge   time.Duration
+	now      func() time.Time
+	entries  map[ResultKey]*list.Element
+	order    *list.List // Most recently used first
+}
+
+// resultEntry is an element of the LRU list
+type resultEntry struct {
+	key  ResultKey
+	data interface{}
+}
+
+// NewResultCache creates a cache holding up to capacity days
+func NewResultCache(capacity int) *ResultCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ResultCache{
+		capacity: capacity,
+		minAge:   downloaders.DefaultCacheMinAge,
+		now:      time.Now,
+		entries:  make(map[ResultKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// SetMinAge sets how old a day must be before it's cached
+func (c *ResultCache) SetMinAge(minAge time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minAge = minAge
+}
+
+// cacheable reports whether date is old enough for its day to be cached
+func (c *ResultCache) cacheable(date time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now().Sub(date) >= c.minAge
+}
+
+// Get returns the day cached under key, marking it as recently used
+func (c *ResultCache) Get(key ResultKey) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*resultEntry).data, true
+}
+
+// Add caches a day under key, evicting the least recently used day when full
+func (c *ResultCache) Add(key ResultKey, data interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*resultEntry).data = data
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&resultEntry{key: key, data: data})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultEntry).key)
+	}
+}
+
+// midnight returns the calendar date of t as midnight UTC, the form of the dates of
+// parsed files, so keys match whatever time of day the requested dates carry
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Len returns the number of days cached
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// cachedDayKey is the context key under which stand-in responses carry their cached day
+type cachedDayKey struct{}
+
+// standIn returns a response standing in for a cached day. The day travels in the
+// context of its request, which survives the body being wrapped, e.g. by ImportStats.
+func standIn(data interface{}) *http.Response {
+	req := (&http.Request{}).WithContext(context.WithValue(context.Background(), cachedDayKey{}, data))
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}
+}
+
+// cachedResponses wraps download so the dates of start..end found in cache are answered
+// with a stand-in response, and only the other dates are downloaded. Stand-ins are
+// recognised by the parser returned by cachedParser with the same dataset and config.
+func cachedResponses(cache *ResultCache, dataset string, system types.SystemType, config string,
+	download func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult) func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult {
+	if cache == nil {
+		return download
+	}
+
+	return func(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+		resultChan := make(chan downloaders.ResponseResult)
+
+		go func() {
+			defer close(resultChan)
+
+			// Download every run of missing dates in turn, answering cached dates in between
+			var missing *types.DateRange
+			flush := func() bool {
+				if missing == nil {
+					return true
+				}
+				for result := range download(ctx, missing.Start, missing.End) {
+					if !sendResult(ctx, resultChan, result) {
+						return false
+					}
+				}
+				missing = nil
+				return true
+			}
+
+			for date := range (types.DateRange{Start: start, End: end}).All() {
+				var data interface{}
+				ok := false
+				if cache.cacheable(midnight(date)) {
+					data, ok = cache.Get(ResultKey{Dataset: dataset, Date: midnight(date), System: system, Config: config})
+				}
+				if !ok {
+					if missing == nil {
+						missing = &types.DateRange{Start: date}
+					}
+					missing.End = date
+					continue
+				}
+
+				if !flush() {
+					return
+				}
+				result := downloaders.ResponseResult{
+					Date:       date,
+					StatusCode: http.StatusOK,
+					Response:   standIn(data),
+				}
+				if !sendResult(ctx, resultChan, result) {
+					return
+				}
+			}
+			flush()
+		}()
+
+		return resultChan
+	}
+}
+
+// sendResult sends result unless ctx is cancelled first, in which case its response is
+// released and false is returned
+func sendResult(ctx context.Context, resultChan chan<- downloaders.ResponseResult, result downloaders.ResponseResult) bool {
+	select {
+	case <-ctx.Done():
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+		return false
+	case resultChan <- result:
+		return true
+	}
+}
+
+// resultCacheParser returns the days of stand-in responses from cachedResponses and adds
+// every other day old enough to the cache
+type resultCacheParser struct {
+	parsers.Parser
+	cache   *ResultCache
+	dataset string
+	config  string
+}
+
+// cachedParser wraps parser to work with cachedResponses, or returns it as is without a cache
+func cachedParser(parser parsers.Parser, cache *ResultCache, dataset, config string) parsers.Parser {
+	if cache == nil {
+		return parser
+	}
+	return &resultCacheParser{Parser: parser, cache: cache, dataset: dataset, config: config}
+}
+
+// wrapCachedParser wraps parser like wrapParser, with the result cache between the parse
+// cache and the validation and hooks, so the days served from the result cache are
+// validated and reported to OnParsed too
+func (o ImportOptions) wrapCachedParser(parser parsers.Parser, dataset string) parsers.Parser {
+	return o.checkParser(cachedParser(o.parseCacheParser(parser), o.ResultCache, dataset, o.resultConfig()))
+}
+
+// resultConfig returns the ResultKey.Config of the days parsed with the options: their
+// strict mode and registered concepts
+func (o ImportOptions) resultConfig() string {
+	var config []string
+	if o.Strict {
+		config = append(config, "strict")
+	}
+	for _, concept := range o.Concepts {
+		config = append(config, fmt.Sprintf("%s=%s*%g", strings.ToLower(strings.TrimSpace(concept.Label)), concept.DataType, concept.Multiplier))
+	}
+	return strings.Join(config, ";")
+}
+
+// ParseResponse parses data from an HTTP response, or returns the cached day it stands for
+func (p *resultCacheParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	if resp.Request != nil {
+		if data := resp.Request.Context().Value(cachedDayKey{}); data != nil {
+			return data, nil
+		}
+	}
+
+	data, err := p.Parser.ParseResponse(resp)
+	if err != nil {
+		return data, err
+	}
+
+	switch day := data.(type) {
+	case *types.MarginalPriceData:
+		if p.cache.cacheable(midnight(day.Date)) {
+			p.cache.Add(ResultKey{Dataset: p.dataset, Date: midnight(day.Date), Config: p.config}, day)
+		}
+	case *types.TechnologyEnergyDay:
+		// Keyed by the system read from the file, as ImportAllSystems parses every system's
+		// files with the same parser
+		if p.cache.cacheable(midnight(day.Date)) {
+			p.cache.Add(ResultKey{Dataset: p.dataset, Date: midnight(day.Date), System: day.System, Config: p.config}, day)
+		}
+	}
+	return data, nil
+}
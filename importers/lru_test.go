@@ -0,0 +1,130 @@
+package importers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	cache := NewResultCache(2)
+	key := func(day int) ResultKey {
+		return ResultKey{Dataset: "marginal_price", Date: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)}
+	}
+
+	cache.Add(key(1), "first")
+	cache.Add(key(2), "second")
+	cache.Get(key(1)) // The 2nd is now the least recently used
+	cache.Add(key(3), "third")
+
+	if _, ok := cache.Get(key(2)); ok {
+		t.Error("expected the least recently used day to be evicted")
+	}
+	if data, ok := cache.Get(key(1)); !ok || data != "first" {
+		t.Errorf("expected the 1st to stay cached, got %v", data)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+
+	techKey := key(3)
+	techKey.Dataset, techKey.System = "energy_by_technology", types.Spain
+	if _, ok := cache.Get(techKey); ok {
+		t.Error("expected keys of other datasets and systems to miss")
+	}
+}
+
+func TestImporterResultCache(t *testing.T) {
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		file, err := os.Open("../testdata/PMD_20090601.txt")
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: file, Request: req}, nil
+	})}
+
+	cache := NewResultCache(10)
+	importer := NewMarginalPriceImporter(ImportOptions{MaxConcurrent: 1, HTTPClient: client, ResultCache: cache})
+	date := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	first, err := importer.ImportDay(context.Background(), date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := importer.ImportDay(context.Background(), date.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("expected a single request, got %d", requests.Load())
+	}
+	if first != second {
+		t.Error("expected the second import to return the cached day")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected one cached day, got %d", cache.Len())
+	}
+}
+
+func TestImporterResultCacheChecks(t *testing.T) {
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		file, err := os.Open("../testdata/PMD_20090601.txt")
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: file, Request: req}, nil
+	})}
+	date := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Days OMIE may still correct aren't cached
+	recent := NewResultCache(10)
+	recent.now = func() time.Time { return date.AddDate(0, 0, 3) }
+	importer := NewMarginalPriceImporter(ImportOptions{MaxConcurrent: 1, HTTPClient: client, ResultCache: recent})
+	for range 2 {
+		if _, err := importer.ImportDay(context.Background(), date); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if requests.Load() != 2 || recent.Len() != 0 {
+		t.Errorf("expected the recent day downloaded every time, got %d requests and %d cached days", requests.Load(), recent.Len())
+	}
+
+	// Days served from the cache are still reported, and strict imports don't share the
+	// days parsed leniently
+	cache := NewResultCache(10)
+	parsed := 0
+	options := ImportOptions{MaxConcurrent: 1, HTTPClient: client, ResultCache: cache, OnParsed: func(interface{}, error) { parsed++ }}
+	for range 2 {
+		if _, err := NewMarginalPriceImporter(options).ImportDay(context.Background(), date); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if requests.Load() != 3 || parsed != 2 {
+		t.Errorf("expected a single request and both days reported, got %d requests and %d reports", requests.Load()-2, parsed)
+	}
+
+	options.Strict = true
+	if _, err := NewMarginalPriceImporter(options).ImportDay(context.Background(), date); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests.Load() != 4 || cache.Len() != 2 {
+		t.Errorf("expected the strict import to parse its own copy, got %d requests and %d cached days", requests.Load()-3, cache.Len())
+	}
+}
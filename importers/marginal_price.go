@@ -2,7 +2,9 @@ package importers
 
 import (
 	"context"
-	"fmt"
+	"io"
+	"iter"
+	"net/http"
 	"time"
 
 	"github.com/devuo/omiedata/downloaders"
@@ -13,7 +15,7 @@ import (
 // MarginalPriceImporter imports marginal price data
 type MarginalPriceImporter struct {
 	downloader *downloaders.MarginalPriceDownloader
-	parser     *parsers.MarginalPriceParser
+	parser     parsers.Parser
 	options    ImportOptions
 }
 
@@ -21,22 +23,44 @@ type MarginalPriceImporter struct {
 func NewMarginalPriceImporter(options ImportOptions) *MarginalPriceImporter {
 	downloader := downloaders.NewMarginalPriceDownloader()
 
-	// Configure downloader
-	config := downloaders.DownloadConfig{
-		MaxRetries:     options.MaxRetries,
-		RetryDelay:     options.RetryDelay,
-		RequestTimeout: 30 * time.Second,
-		MaxConcurrent:  options.MaxConcurrent,
+	downloader.SetConfig(options.downloadConfig())
+
+	parser := parsers.NewMarginalPriceParser()
+	parser.Strict = options.Strict
+	for _, concept := range options.Concepts {
+		if err := parser.RegisterConcept(concept.Label, concept.DataType, concept.Multiplier); err != nil {
+			return &MarginalPriceImporter{downloader: downloader, parser: options.checkParser(failingParser{err: err}), options: options}
+		}
 	}
-	downloader.SetConfig(config)
 
 	return &MarginalPriceImporter{
 		downloader: downloader,
-		parser:     parsers.NewMarginalPriceParser(),
+		parser:     options.wrapCachedParser(parser, "marginal_price"),
 		options:    options,
 	}
 }
 
+// failingParser fails every response with err, e.g. the error of an invalid option, so
+// constructors that can't return an error report it on import
+type failingParser struct {
+	err error
+}
+
+// ParseResponse returns the error
+func (p failingParser) ParseResponse(*http.Response) (interface{}, error) {
+	return nil, p.err
+}
+
+// ParseFile returns the error
+func (p failingParser) ParseFile(string) (interface{}, error) {
+	return nil, p.err
+}
+
+// ParseReader returns the error
+func (p failingParser) ParseReader(io.Reader) (interface{}, error) {
+	return nil, p.err
+}
+
 // NewDefaultMarginalPriceImporter creates a marginal price importer with default options
 func NewDefaultMarginalPriceImporter() *MarginalPriceImporter {
 	return NewMarginalPriceImporter(ImportOptions{
@@ -47,48 +71,100 @@ func NewDefaultMarginalPriceImporter() *MarginalPriceImporter {
 	})
 }
 
-// Import downloads and parses marginal price data for a date range
+// Import downloads and parses marginal price data for a date range, returning a
+// []*types.MarginalPriceData. See ImportDays for the typed equivalent.
 func (i *MarginalPriceImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
-	responseChan := i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
 
-	var results []*types.MarginalPriceData
-	var errors []error
+// ImportDays downloads and parses marginal price data for a date range
+func (i *MarginalPriceImporter) ImportDays(ctx context.Context, start, end time.Time) ([]*types.MarginalPriceData, error) {
+	results, _, err := importAll[*types.MarginalPriceData](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+	return results, err
+}
 
-	for result := range responseChan {
-		if result.Error != nil {
-			errors = append(errors, result.Error)
-			continue
-		}
+// ImportPartial downloads and parses marginal price data for a date range. Unlike
+// ImportDays it never fails as a whole: the dates that couldn't be imported are listed
+// in the result's Errors, so they can be retried on their own.
+func (i *MarginalPriceImporter) ImportPartial(ctx context.Context, start, end time.Time) *ImportResult[*types.MarginalPriceData] {
+	return collect[*types.MarginalPriceData](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+}
 
-		// Parse the response
-		parsed, err := i.parser.ParseResponse(result.Response)
-		result.Response.Body.Close()
+// ImportWithStats downloads and parses marginal price data for a date range, also returning
+// a summary of the run
+func (i *MarginalPriceImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	results, stats, err := importAll[*types.MarginalPriceData](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+	return results, stats, nil
+}
 
-		if err != nil {
-			errors = append(errors, fmt.Errorf("parse error for %s: %w", result.Date.Format("2006-01-02"), err))
-			continue
-		}
+// ImportEach downloads and parses marginal price data for a date range, calling fn with
+// every day in date order instead of collecting them, together with a token that
+// continues the import after that day when passed to ResumeEach. After a failed date the
+// token stays before it, so resuming retries the date.
+func (i *MarginalPriceImporter) ImportEach(ctx context.Context, start, end time.Time, fn func(*types.MarginalPriceData, ResumeToken) error) error {
+	return importEach(ctx, i.urlResponses, i.parser, i.options, "marginal_price", start, end, fn)
+}
 
-		if data, ok := parsed.(*types.MarginalPriceData); ok {
-			results = append(results, data)
-		}
+// ImportSeq downloads and parses marginal price data for a date range, yielding the days in
+// date order without collecting them. Dates that failed are yielded with a DateError;
+// stopping the loop early cancels the downloads still in flight.
+func (i *MarginalPriceImporter) ImportSeq(ctx context.Context, start, end time.Time) iter.Seq2[*types.MarginalPriceData, error] {
+	return importSeq[*types.MarginalPriceData](ctx, i.urlResponses, i.parser, i.options, start, end)
+}
+
+// ResumeEach continues an ImportEach interrupted after the day of token
+func (i *MarginalPriceImporter) ResumeEach(ctx context.Context, token string, fn func(*types.MarginalPriceData, ResumeToken) error) error {
+	start, end, err := resumeRange(token, "marginal_price")
+	if err != nil {
+		return err
 	}
+	if start.After(end) {
+		return nil // Nothing left to import
+	}
+	return i.ImportEach(ctx, start, end, fn)
+}
 
-	if len(results) == 0 && len(errors) > 0 {
-		return nil, fmt.Errorf("no data imported, %d errors occurred: %v", len(errors), errors[0])
+// urlResponses starts downloading the date range, skipping the days in the result cache
+func (i *MarginalPriceImporter) urlResponses(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+	download := func(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+		return i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
 	}
+	return cachedResponses(i.options.ResultCache, "marginal_price", 0, i.options.resultConfig(), download)(ctx, start, end)
+}
 
+// ImportFromDir parses marginal price data for a date range from the files a previous
+// DownloadData saved in dir, returning the same results as Import
+func (i *MarginalPriceImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	responses := i.downloader.DirResponses(ctx, dir, start, end)
+	results, _, err := importAll[*types.MarginalPriceData](responses, i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
-// ImportSingleDate downloads and parses marginal price data for a single date
+// ImportSingleDate downloads and parses marginal price data for a single date, returning a
+// *types.MarginalPriceData. See ImportDay for the typed equivalent.
 func (i *MarginalPriceImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
-	results, err := i.Import(ctx, date, date)
+	data, err := i.ImportDay(ctx, date)
 	if err != nil {
 		return nil, err
 	}
+	return data, nil
+}
 
-	if dataList, ok := results.([]*types.MarginalPriceData); ok && len(dataList) > 0 {
+// ImportDay downloads and parses marginal price data for a single date
+func (i *MarginalPriceImporter) ImportDay(ctx context.Context, date time.Time) (*types.MarginalPriceData, error) {
+	dataList, err := i.ImportDays(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataList) > 0 {
 		return dataList[0], nil
 	}
 
@@ -98,16 +174,11 @@ func (i *MarginalPriceImporter) ImportSingleDate(ctx context.Context, date time.
 // ImportToDataFrame imports data and returns it in a flattened format
 // This method provides a pandas-like interface for easier data analysis
 func (i *MarginalPriceImporter) ImportToDataFrame(ctx context.Context, start, end time.Time) ([]types.MarginalPriceRecord, error) {
-	results, err := i.Import(ctx, start, end)
+	dataList, err := i.ImportDays(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	dataList, ok := results.([]*types.MarginalPriceData)
-	if !ok {
-		return nil, types.NewOMIEError(types.ErrCodeParse, "unexpected result type", nil)
-	}
-
 	var records []types.MarginalPriceRecord
 
 	for _, data := range dataList {
@@ -159,6 +230,38 @@ func (i *MarginalPriceImporter) ImportToDataFrame(ctx context.Context, start, en
 				Values:  data.SpainSellEnergy,
 			})
 		}
+
+		if len(data.SpainAdjustmentPrices) > 0 {
+			records = append(records, types.MarginalPriceRecord{
+				Date:    data.Date,
+				Concept: types.AdjustmentPriceSpain,
+				Values:  data.SpainAdjustmentPrices,
+			})
+		}
+
+		if len(data.PortugalAdjustmentPrices) > 0 {
+			records = append(records, types.MarginalPriceRecord{
+				Date:    data.Date,
+				Concept: types.AdjustmentPricePortugal,
+				Values:  data.PortugalAdjustmentPrices,
+			})
+		}
+
+		if len(data.ExportSpainToPortugal) > 0 {
+			records = append(records, types.MarginalPriceRecord{
+				Date:    data.Date,
+				Concept: types.ExportSpainToPortugal,
+				Values:  data.ExportSpainToPortugal,
+			})
+		}
+
+		if len(data.ExportPortugalToSpain) > 0 {
+			records = append(records, types.MarginalPriceRecord{
+				Date:    data.Date,
+				Concept: types.ExportPortugalToSpain,
+				Values:  data.ExportPortugalToSpain,
+			})
+		}
 	}
 
 	return records, nil
@@ -0,0 +1,93 @@
+package importers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// MonthlyPriceImporter imports the monthly average prices published by OMIE
+type MonthlyPriceImporter struct {
+	downloader *downloaders.MonthlyPriceDownloader
+	parser     parsers.Parser
+	options    ImportOptions
+}
+
+// NewMonthlyPriceImporter creates a new monthly average price importer
+func NewMonthlyPriceImporter(options ImportOptions) *MonthlyPriceImporter {
+	downloader := downloaders.NewMonthlyPriceDownloader()
+
+	downloader.SetConfig(options.downloadConfig())
+
+	return &MonthlyPriceImporter{
+		downloader: downloader,
+		parser:     options.wrapParser(parsers.NewMonthlyPriceParser()),
+		options:    options,
+	}
+}
+
+// NewDefaultMonthlyPriceImporter creates a monthly average price importer with default options
+func NewDefaultMonthlyPriceImporter() *MonthlyPriceImporter {
+	return NewMonthlyPriceImporter(ImportOptions{
+		Verbose:       false,
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+		MaxConcurrent: 5,
+	})
+}
+
+// Import downloads and parses the summaries of every month overlapping a date range,
+// returning a []*types.MonthlyPriceSummary in month order
+func (i *MonthlyPriceImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
+
+// ImportWithStats downloads and parses the summaries of every month overlapping a date
+// range, also returning a summary of the run
+func (i *MonthlyPriceImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	responses := i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+	results, stats, err := importAll[*types.MonthlyPriceSummary](responses, i.parser, i.options.newStats(monthsIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Month.Before(results[b].Month) })
+	return results, stats, nil
+}
+
+// ImportFromDir parses the summaries of every month overlapping a date range from the
+// files a previous DownloadData saved in dir, returning the same results as Import
+func (i *MonthlyPriceImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	responses := i.downloader.DirResponses(ctx, dir, start, end)
+	results, _, err := importAll[*types.MonthlyPriceSummary](responses, i.parser, i.options.newStats(monthsIn(start, end)))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Month.Before(results[b].Month) })
+	return results, nil
+}
+
+// ImportSingleDate downloads and parses the summary of the month containing date
+func (i *MonthlyPriceImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
+	results, err := i.Import(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if summaries, ok := results.([]*types.MonthlyPriceSummary); ok && len(summaries) > 0 {
+		return summaries[0], nil
+	}
+
+	return nil, types.NewOMIEError(types.ErrCodeNotFound, "no data found for month", nil)
+}
+
+// monthsIn returns the number of months overlapping start..end
+func monthsIn(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+}
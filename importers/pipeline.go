@@ -0,0 +1,64 @@
+package importers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+)
+
+// parsedResponse is a downloaded response together with the outcome of parsing it
+type parsedResponse struct {
+	downloaders.ResponseResult
+	data     interface{}
+	err      error         // Parse error
+	bytes    int64         // Bytes of file content read by the parser
+	duration time.Duration // Time spent parsing
+}
+
+// parseResponses parses the responses of responseChan in a stage of its own, with
+// workers goroutines (at least one), so parsing overlaps with downloading and with the
+// handling of the days parsed before. Results come out as they are parsed, which with
+// several workers isn't always the order they were downloaded in. Failed downloads are
+// passed on as they are. The returned channel must be drained.
+func parseResponses(responseChan <-chan downloaders.ResponseResult, parser parsers.Parser, workers int) <-chan parsedResponse {
+	workers = max(workers, 1)
+	parsedChan := make(chan parsedResponse, workers)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range responseChan {
+				parsedChan <- parseResponse(result, parser)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(parsedChan)
+	}()
+
+	return parsedChan
+}
+
+// parseResponse parses a downloaded response and closes its body
+func parseResponse(result downloaders.ResponseResult, parser parsers.Parser) parsedResponse {
+	parsed := parsedResponse{ResponseResult: result}
+	if result.Error != nil {
+		return parsed
+	}
+
+	body := &countingReader{ReadCloser: result.Response.Body, count: &parsed.bytes}
+	result.Response.Body = body
+
+	started := time.Now()
+	parsed.data, parsed.err = parser.ParseResponse(result.Response)
+	parsed.duration = time.Since(started)
+	body.Close()
+
+	return parsed
+}
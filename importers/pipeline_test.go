@@ -0,0 +1,68 @@
+package importers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// slowParser parses like parser, taking a while and recording how many files it parses
+// at once
+type slowParser struct {
+	parsers.Parser
+	parsing, peak atomic.Int32
+}
+
+func (p *slowParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	n := p.parsing.Add(1)
+	defer p.parsing.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return p.Parser.ParseResponse(resp)
+}
+
+func TestParseWorkers(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	parser := &slowParser{Parser: parsers.NewMarginalPriceParser()}
+	options := ImportOptions{ParseWorkers: 4}
+
+	var dates []time.Time
+	err := importEach(context.Background(), fixtureResponses(t, time.Time{}), parser, options, "marginal_price", start, end,
+		func(data *types.MarginalPriceData, token ResumeToken) error {
+			dates = append(dates, token.Last)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(dates) != 8 {
+		t.Fatalf("Expected 8 days, got %d", len(dates))
+	}
+	for i, date := range dates {
+		if !date.Equal(start.AddDate(0, 0, i)) {
+			t.Errorf("Day %d is %s, expected the days in date order", i, date.Format("2006-01-02"))
+		}
+	}
+	if peak := parser.peak.Load(); peak < 2 || peak > 4 {
+		t.Errorf("Expected 2 to 4 files parsed at once, got %d", peak)
+	}
+
+	// Bytes are counted by every parse worker
+	stats := options.newStats(8)
+	result := collect[*types.MarginalPriceData](fixtureResponses(t, time.Time{})(context.Background(), start, end), parser, stats)
+	if len(result.Days) != 8 || result.Succeeded != 8 || result.Bytes == 0 || result.Parse == 0 {
+		t.Errorf("Unexpected result of %d days: %+v", len(result.Days), *result.ImportStats)
+	}
+}
@@ -0,0 +1,133 @@
+package importers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestImportProgress(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	failed := start.AddDate(0, 0, 1)
+	download := fixtureResponses(t, failed)
+
+	var reports []Progress
+	stats := newStats(func(p Progress) { reports = append(reports, p) }, daysIn(start, end))
+	if _, _, err := importAll[*types.MarginalPriceData](download(context.Background(), start, end), parsers.NewMarginalPriceParser(), stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("Expected a report per date, got %v", reports)
+	}
+	for i, report := range reports {
+		if report.Total != 3 || report.Completed != i+1 {
+			t.Errorf("Report %d: unexpected counts %+v", i, report)
+		}
+	}
+
+	// The fixture delivers dates newest first, so the failed date is the second one
+	if last := reports[2]; last.Failed != 1 {
+		t.Errorf("Expected one failed date, got %+v", last)
+	}
+	if reports[1].Failed != 1 || !reports[1].Date.Equal(failed) {
+		t.Errorf("Expected the second report to be the failed date, got %+v", reports[1])
+	}
+}
+
+// recordingMetrics records the metrics reported to it
+type recordingMetrics struct {
+	mu       sync.Mutex
+	statuses []int
+	retries  int
+	bytes    int
+	parses   int
+	failures map[string]int
+}
+
+func (m *recordingMetrics) ObserveRequest(status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, status)
+}
+
+func (m *recordingMetrics) IncRetries() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *recordingMetrics) AddBytes(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += n
+}
+
+func (m *recordingMetrics) ObserveParse(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parses++
+}
+
+func (m *recordingMetrics) IncFailures(code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[code]++
+}
+
+func TestImportMetrics(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 1st is served after a 503, the 2nd isn't published and the 3rd is garbage
+	var unavailable atomic.Bool
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := "not an OMIE file"
+		switch {
+		case strings.Contains(req.URL.Path, "_01_06_2009_"):
+			if unavailable.CompareAndSwap(false, true) {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+			}
+			body = string(fixture)
+		case strings.Contains(req.URL.Path, "_02_06_2009_"):
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})}
+
+	metrics := &recordingMetrics{failures: map[string]int{}}
+	importer := NewMarginalPriceImporter(ImportOptions{
+		MaxConcurrent: 1,
+		MaxRetries:    1,
+		RetryDelay:    time.Millisecond,
+		HTTPClient:    client,
+		Metrics:       metrics,
+	})
+	start := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+	importer.ImportPartial(context.Background(), start, start.AddDate(0, 0, 2))
+
+	if len(metrics.statuses) != 4 || metrics.retries != 1 {
+		t.Errorf("Expected 4 requests and a retry, got %v and %d", metrics.statuses, metrics.retries)
+	}
+	if metrics.bytes != len(fixture)+len("not an OMIE file") {
+		t.Errorf("Expected %d bytes, got %d", len(fixture)+len("not an OMIE file"), metrics.bytes)
+	}
+	if metrics.parses != 2 {
+		t.Errorf("Expected 2 files parsed, got %d", metrics.parses)
+	}
+	if metrics.failures[types.ErrCodeNotFound] != 1 || metrics.failures[types.ErrCodeParse] != 1 || len(metrics.failures) != 2 {
+		t.Errorf("Expected a NOT_FOUND and a PARSE_ERROR failure, got %v", metrics.failures)
+	}
+}
@@ -0,0 +1,82 @@
+package importers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// DateError records why a single date couldn't be imported
+type DateError struct {
+	Date time.Time
+	Err  error
+}
+
+// Error implements the error interface
+func (e DateError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Date.Format("2006-01-02"), e.Err)
+}
+
+// Unwrap returns the underlying error, so errors.Is and errors.As see through a DateError
+func (e DateError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult is the outcome of an import that may have partly failed: the days that
+// were imported, the dates that weren't and why, and the counts of the run
+type ImportResult[T any] struct {
+	Days   []T
+	Errors []DateError // In the order the failures happened
+	*ImportStats
+}
+
+// FailedDates returns the dates that couldn't be imported in date order, e.g. to retry
+// just those
+func (r *ImportResult[T]) FailedDates() []time.Time {
+	dates := make([]time.Time, 0, len(r.Errors))
+	for _, dateErr := range r.Errors {
+		dates = append(dates, dateErr.Date)
+	}
+	sort.Slice(dates, func(a, b int) bool { return dates[a].Before(dates[b]) })
+	return dates
+}
+
+// err returns a *types.MultiError holding every date error, or nil when there are none
+func (r *ImportResult[T]) err(message string) error {
+	errs := make([]error, len(r.Errors))
+	for i, dateErr := range r.Errors {
+		errs[i] = dateErr
+	}
+	return types.JoinErrors(message, errs)
+}
+
+// collect parses every downloaded response into a T, recording the dates that failed
+// to download or parse in the result and the counts of the run in stats
+func collect[T any](responseChan <-chan downloaders.ResponseResult, parser parsers.Parser, stats *ImportStats) *ImportResult[T] {
+	started := time.Now()
+	result := &ImportResult[T]{ImportStats: stats}
+	defer func() { result.Total = time.Since(started) }()
+
+	for response := range parseResponses(responseChan, parser, stats.parseWorkers) {
+		result.recordParsed(response)
+		if response.Error != nil {
+			result.Errors = append(result.Errors, DateError{Date: response.Date, Err: response.Error})
+			continue
+		}
+
+		if response.err != nil {
+			result.Errors = append(result.Errors, DateError{Date: response.Date, Err: fmt.Errorf("parse error: %w", response.err)})
+			continue
+		}
+
+		if data, ok := response.data.(T); ok {
+			result.Days = append(result.Days, data)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,37 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestCollectPartialFailure(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	failed := start.AddDate(0, 0, 1)
+	download := fixtureResponses(t, failed)
+
+	result := collect[*types.MarginalPriceData](download(context.Background(), start, end), parsers.NewMarginalPriceParser(), newStats(nil, 3))
+
+	if len(result.Days) != 2 {
+		t.Fatalf("Expected 2 imported days, got %d", len(result.Days))
+	}
+	if result.Attempted != 3 || result.Succeeded != 2 || result.NotFound != 0 || result.Failed != 1 {
+		t.Errorf("Unexpected counts: %+v", *result.ImportStats)
+	}
+
+	dates := result.FailedDates()
+	if len(dates) != 1 || !dates[0].Equal(failed) {
+		t.Fatalf("Expected %s to fail, got %v", failed.Format("2006-01-02"), dates)
+	}
+
+	var omieErr *types.OMIEError
+	if !errors.As(result.Errors[0], &omieErr) || omieErr.Code != types.ErrCodeNotFound {
+		t.Errorf("Expected the download error to be kept, got %v", result.Errors[0])
+	}
+}
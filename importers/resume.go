@@ -0,0 +1,172 @@
+package importers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// ResumeToken records how far a streaming import got, so an interrupted import can be
+// continued exactly where it stopped. Persist it with String and restore it with
+// ParseResumeToken.
+type ResumeToken struct {
+	Dataset string    // Dataset being imported, e.g. "marginal_price"
+	Last    time.Time // Last date up to which every date was imported
+	End     time.Time // Last date of the requested range
+}
+
+// resumeTokenJSON is the wire format of a resume token
+type resumeTokenJSON struct {
+	Dataset string `json:"dataset"`
+	Last    string `json:"last"`
+	End     string `json:"end"`
+}
+
+// String encodes the token as an opaque string
+func (t ResumeToken) String() string {
+	data, _ := json.Marshal(resumeTokenJSON{
+		Dataset: t.Dataset,
+		Last:    t.Last.Format("2006-01-02"),
+		End:     t.End.Format("2006-01-02"),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseResumeToken decodes a token produced by ResumeToken.String
+func ParseResumeToken(s string) (ResumeToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ResumeToken{}, types.NewOMIEError(types.ErrCodeInvalidData, "invalid resume token", err)
+	}
+
+	var w resumeTokenJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return ResumeToken{}, types.NewOMIEError(types.ErrCodeInvalidData, "invalid resume token", err)
+	}
+
+	last, err := time.Parse("2006-01-02", w.Last)
+	if err != nil {
+		return ResumeToken{}, types.NewOMIEError(types.ErrCodeInvalidData, "invalid resume token", err)
+	}
+	end, err := time.Parse("2006-01-02", w.End)
+	if err != nil {
+		return ResumeToken{}, types.NewOMIEError(types.ErrCodeInvalidData, "invalid resume token", err)
+	}
+
+	return ResumeToken{Dataset: w.Dataset, Last: last, End: end}, nil
+}
+
+// Done reports whether the import the token belongs to already processed its whole range
+func (t ResumeToken) Done() bool {
+	return !t.Last.Before(t.End)
+}
+
+// resumeRange returns the dates left to import for a token string of dataset
+func resumeRange(token, dataset string) (time.Time, time.Time, error) {
+	parsed, err := ParseResumeToken(token)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if parsed.Dataset != dataset {
+		return time.Time{}, time.Time{}, types.NewOMIEError(types.ErrCodeInvalidData,
+			fmt.Sprintf("resume token is for %s, not %s", parsed.Dataset, dataset), nil)
+	}
+	return parsed.Last.AddDate(0, 0, 1), parsed.End, nil
+}
+
+// dayResult is a parsed day, or the reason it couldn't be imported
+type dayResult[T any] struct {
+	data T
+	err  error
+}
+
+// importEach downloads and parses start..end through download, calling fn with every
+// parsed day in date order together with the token to resume after it. Days are
+// downloaded concurrently and buffered until the days before them are done. Dates
+// that fail are skipped and reported in the returned error; returning an error from
+// fn stops the import. The token stops advancing at the first failed date, so a
+// resumed import retries it, along with the days after it.
+func importEach[T any](ctx context.Context, download func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult,
+	parser parsers.Parser, options ImportOptions, dataset string, start, end time.Time, fn func(T, ResumeToken) error) error {
+	var errors []error
+	last := start.AddDate(0, 0, -1)
+
+	err := streamDays(ctx, download, parser, options.newStats(daysIn(start, end)), start, end, func(date time.Time, day dayResult[T]) error {
+		if day.err != nil {
+			errors = append(errors, DateError{Date: date, Err: day.err})
+			return nil
+		}
+		if len(errors) == 0 {
+			last = date
+		}
+		return fn(day.data, ResumeToken{Dataset: dataset, Last: last, End: end})
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(errors) > 0 {
+		return types.JoinErrors("import completed", errors)
+	}
+
+	return nil
+}
+
+// streamDays downloads and parses start..end through download, calling fn with every
+// day in date order, including the days that failed, and recording the run in stats. Days are downloaded concurrently
+// and buffered until the days before them are done. Returning an error from fn stops
+// the import and is returned as is.
+func streamDays[T any](ctx context.Context, download func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult,
+	parser parsers.Parser, stats *ImportStats, start, end time.Time, fn func(time.Time, dayResult[T]) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The parse stage closes every response, so draining it after stopping early
+	// releases the responses still in flight
+	parsedChan := parseResponses(download(ctx, start, end), parser, stats.parseWorkers)
+	defer func() {
+		for range parsedChan {
+		}
+	}()
+
+	pending := make(map[time.Time]dayResult[T])
+	next := start
+
+	for result := range parsedChan {
+		stats.recordParsed(result)
+		day := dayResult[T]{err: result.Error}
+		if result.Error == nil {
+			switch data, ok := result.data.(T); {
+			case result.err != nil:
+				day.err = fmt.Errorf("parse error: %w", result.err)
+			case !ok:
+				day.err = types.NewOMIEError(types.ErrCodeParse, "unexpected result type", nil)
+			default:
+				day.data = data
+			}
+		}
+		pending[result.Date] = day
+
+		// Hand over every day that is now complete in date order
+		for {
+			day, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+
+			if err := fn(next, day); err != nil {
+				return err
+			}
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,146 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// fixtureResponses returns a download function answering dates out of order, serving
+// the marginal price fixture for every date except failed ones
+func fixtureResponses(t *testing.T, failed time.Time) func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult {
+	return func(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+		ch := make(chan downloaders.ResponseResult)
+		go func() {
+			defer close(ch)
+			var dates []time.Time
+			for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+				dates = append([]time.Time{d}, dates...) // Newest first
+			}
+			for _, date := range dates {
+				result := downloaders.ResponseResult{Date: date}
+				if date.Equal(failed) {
+					result.Error = types.NewOMIEError(types.ErrCodeNotFound, "data not available", nil)
+				} else {
+					file, err := os.Open("../testdata/PMD_20090601.txt")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					result.Response = &http.Response{StatusCode: http.StatusOK, Body: file}
+				}
+				select {
+				case ch <- result:
+				case <-ctx.Done():
+					if result.Response != nil {
+						result.Response.Body.Close()
+					}
+					return
+				}
+			}
+		}()
+		return ch
+	}
+}
+
+func TestImportEachResume(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 4)
+	failed := start.AddDate(0, 0, 1)
+	download := fixtureResponses(t, failed)
+	parser := parsers.NewMarginalPriceParser()
+
+	// Stop after the third date, as if the process was interrupted
+	stop := errors.New("interrupted")
+	var seen []time.Time
+	var saved string
+	err := importEach(context.Background(), download, parser, ImportOptions{}, "marginal_price", start, end,
+		func(_ *types.MarginalPriceData, token ResumeToken) error {
+			seen = append(seen, token.Last)
+			saved = token.String()
+			if len(seen) == 2 {
+				return stop
+			}
+			return nil
+		})
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+
+	// The token stops advancing at the failed date, so it still points at the first day
+	if len(seen) != 2 || !seen[0].Equal(start) || !seen[1].Equal(start) {
+		t.Fatalf("Unexpected tokens: %v", seen)
+	}
+
+	resumeStart, resumeEnd, err := resumeRange(saved, "marginal_price")
+	if err != nil {
+		t.Fatalf("resumeRange failed: %v", err)
+	}
+	if !resumeStart.Equal(failed) || !resumeEnd.Equal(end) {
+		t.Errorf("Expected to resume from %s to %s, got %s to %s", failed, end, resumeStart, resumeEnd)
+	}
+
+	if _, _, err := resumeRange(saved, "energy_by_technology_9"); err == nil {
+		t.Error("Expected an error resuming a token of another dataset")
+	}
+}
+
+func TestImportEachResumeRetriesFailedDate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	failed := start.AddDate(0, 0, 1)
+	parser := parsers.NewMarginalPriceParser()
+
+	// The second of three days fails, the third still arrives
+	var saved string
+	err := importEach(context.Background(), fixtureResponses(t, failed), parser, ImportOptions{}, "marginal_price", start, end,
+		func(_ *types.MarginalPriceData, token ResumeToken) error {
+			saved = token.String()
+			return nil
+		})
+	var dateErr DateError
+	if !errors.As(err, &dateErr) || !dateErr.Date.Equal(failed) {
+		t.Fatalf("Expected the failed date reported, got %v", err)
+	}
+
+	token, err := ParseResumeToken(saved)
+	if err != nil {
+		t.Fatalf("ParseResumeToken failed: %v", err)
+	}
+	if token.Done() {
+		t.Error("Expected the token not done while a date failed")
+	}
+
+	// Resuming once the date is available fetches it
+	resumeStart, resumeEnd, err := resumeRange(saved, "marginal_price")
+	if err != nil {
+		t.Fatalf("resumeRange failed: %v", err)
+	}
+	var fetched []time.Time
+	download := fixtureResponses(t, time.Time{})
+	err = importEach(context.Background(), func(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			fetched = append(fetched, d)
+		}
+		return download(ctx, start, end)
+	}, parser, ImportOptions{}, "marginal_price", resumeStart, resumeEnd, func(_ *types.MarginalPriceData, token ResumeToken) error {
+		saved = token.String()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Resumed import failed: %v", err)
+	}
+	if len(fetched) == 0 || !fetched[0].Equal(failed) {
+		t.Errorf("Expected the resumed import to fetch %s first, fetched %v", failed, fetched)
+	}
+	if token, _ := ParseResumeToken(saved); !token.Done() {
+		t.Errorf("Expected the resumed import to finish the range, token at %s", token.Last)
+	}
+}
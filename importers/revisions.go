@@ -0,0 +1,142 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// RevisionStore keeps the last imported data of every day, so later syncs can tell
+// whether OMIE republished a file with corrections
+type RevisionStore interface {
+	// Load returns the stored data for date, or nil when the date was never stored
+	Load(date time.Time) (interface{}, error)
+
+	// Save stores data for date, replacing any previous copy
+	Save(date time.Time, data interface{}) error
+}
+
+// DirRevisionStore is a RevisionStore keeping one versioned JSON envelope per day in a folder
+type DirRevisionStore struct {
+	dir string
+}
+
+// NewDirRevisionStore creates a revision store under dir. Use one folder per dataset.
+func NewDirRevisionStore(dir string) (*DirRevisionStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to create revision folder", err)
+	}
+	return &DirRevisionStore{dir: dir}, nil
+}
+
+// Load returns the stored data for date, or nil when the date was never stored
+func (s *DirRevisionStore) Load(date time.Time) (interface{}, error) {
+	data, err := os.ReadFile(s.path(date))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read stored revision", err)
+	}
+
+	return types.Decode(data)
+}
+
+// Save stores data for date, replacing any previous copy
+func (s *DirRevisionStore) Save(date time.Time, data interface{}) error {
+	encoded, err := types.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so an interrupted save keeps the previous copy
+	if err := atomicfile.WriteFile(s.path(date), encoded); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to write revision", err)
+	}
+
+	return nil
+}
+
+// path returns the file holding the data of date
+func (s *DirRevisionStore) path(date time.Time) string {
+	return filepath.Join(s.dir, date.Format("2006-01-02")+".json")
+}
+
+// Revision lists the values that changed when OMIE republished the file of a day
+type Revision struct {
+	Date    time.Time
+	Changes []types.Change
+}
+
+// RevisionPolicy re-imports the most recent days on every sync to pick up files that
+// OMIE republished with corrections
+type RevisionPolicy struct {
+	Days      int     // Number of days, ending at the sync date, re-downloaded on every sync
+	Tolerance float64 // Absolute tolerance below which float differences are ignored
+}
+
+// Sync re-imports the last Days days up to end, compares each one with the copy in
+// store and saves the fresh data. Days that changed are returned as revisions; days
+// that weren't stored before are saved without being reported. Days that fail to
+// import (e.g. not published yet) are skipped, and an error is only returned when
+// every day failed.
+func (p RevisionPolicy) Sync(ctx context.Context, importer Importer, store RevisionStore, end time.Time) ([]Revision, error) {
+	var revisions []Revision
+	var errs []error
+
+	days := p.Days
+	if days < 1 {
+		days = 1
+	}
+
+	for date := end.AddDate(0, 0, 1-days); !date.After(end); date = date.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return revisions, err
+		}
+
+		fresh, err := importer.ImportSingleDate(ctx, date)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("import error for %s: %w", date.Format("2006-01-02"), err))
+			continue
+		}
+
+		stored, err := store.Load(date)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if stored != nil {
+			changes := diffRevision(stored, fresh, p.Tolerance)
+			if len(changes) == 0 {
+				continue // Unchanged, nothing to save
+			}
+			revisions = append(revisions, Revision{Date: date, Changes: changes})
+		}
+
+		if err := store.Save(date, fresh); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == days {
+		return nil, types.JoinErrors("no data synced", errs)
+	}
+
+	return revisions, nil
+}
+
+// diffRevision compares the stored and fresh data of a day
+func diffRevision(stored, fresh interface{}, tolerance float64) []types.Change {
+	if reflect.TypeOf(stored) != reflect.TypeOf(fresh) {
+		return []types.Change{{Old: stored, New: fresh}}
+	}
+	return types.Diff(stored, fresh, tolerance)
+}
@@ -0,0 +1,70 @@
+package importers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// fakeImporter serves marginal price data with a fixed Spain price per day
+type fakeImporter struct {
+	prices map[time.Time]float64
+}
+
+func (f *fakeImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
+	return nil, nil
+}
+
+func (f *fakeImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
+	price, ok := f.prices[date]
+	if !ok {
+		return nil, types.NewOMIEError(types.ErrCodeNotFound, "no data found for date", nil)
+	}
+	data := types.NewMarginalPriceData(date)
+	data.SpainPrices[1] = price
+	return data, nil
+}
+
+func TestRevisionPolicySync(t *testing.T) {
+	store, err := NewDirRevisionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day1 := time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	importer := &fakeImporter{prices: map[time.Time]float64{day1: 50, day2: 60}}
+	policy := RevisionPolicy{Days: 3, Tolerance: 0.001}
+
+	revisions, err := policy.Sync(context.Background(), importer, store, day2)
+	if err != nil {
+		t.Fatalf("First sync failed: %v", err)
+	}
+	if len(revisions) != 0 {
+		t.Fatalf("Expected no revisions on first sync, got %v", revisions)
+	}
+
+	// OMIE republishes day1 with a corrected price
+	importer.prices[day1] = 55
+
+	revisions, err = policy.Sync(context.Background(), importer, store, day2)
+	if err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	if len(revisions) != 1 || !revisions[0].Date.Equal(day1) {
+		t.Fatalf("Expected a revision for %s, got %v", day1.Format("2006-01-02"), revisions)
+	}
+	if got := revisions[0].Changes; len(got) != 1 || got[0].Path != "SpainPrices[1]" {
+		t.Errorf("Unexpected changes: %v", got)
+	}
+
+	stored, err := store.Load(day1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price := stored.(*types.MarginalPriceData).SpainPrices[1]; price != 55 {
+		t.Errorf("Expected corrected price to be stored, got %v", price)
+	}
+}
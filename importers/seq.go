@@ -0,0 +1,39 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+)
+
+// errStopSeq stops streamDays when the consumer of a sequence breaks out of its loop
+var errStopSeq = errors.New("sequence stopped")
+
+// importSeq returns the days of start..end downloaded through download as a sequence in
+// date order, so they can be consumed and discarded one at a time. Dates that failed
+// are yielded with a DateError and the zero T, and the sequence goes on with the next
+// date. If ctx is cancelled, its error is yielded last.
+func importSeq[T any](ctx context.Context, download func(context.Context, time.Time, time.Time) <-chan downloaders.ResponseResult,
+	parser parsers.Parser, options ImportOptions, start, end time.Time) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := streamDays(ctx, download, parser, options.newStats(daysIn(start, end)), start, end, func(date time.Time, day dayResult[T]) error {
+			var err error
+			if day.err != nil {
+				err = DateError{Date: date, Err: day.err}
+			}
+			if !yield(day.data, err) {
+				return errStopSeq
+			}
+			return nil
+		})
+
+		if err != nil && err != errStopSeq {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
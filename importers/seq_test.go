@@ -0,0 +1,63 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestImportSeq(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 3)
+	failed := start.AddDate(0, 0, 1)
+	seq := importSeq[*types.MarginalPriceData](context.Background(), fixtureResponses(t, failed), parsers.NewMarginalPriceParser(), ImportOptions{}, start, end)
+
+	var days int
+	var dateErrs []DateError
+	for data, err := range seq {
+		var dateErr DateError
+		switch {
+		case errors.As(err, &dateErr):
+			dateErrs = append(dateErrs, dateErr)
+		case err != nil:
+			t.Fatalf("Unexpected error: %v", err)
+		case data == nil:
+			t.Fatal("Expected data for a successful day")
+		default:
+			days++
+		}
+	}
+
+	if days != 3 {
+		t.Errorf("Expected 3 days, got %d", days)
+	}
+	if len(dateErrs) != 1 || !dateErrs[0].Date.Equal(failed) {
+		t.Errorf("Expected a single error for %s, got %v", failed.Format("2006-01-02"), dateErrs)
+	}
+}
+
+func TestImportSeqBreak(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 4)
+	seq := importSeq[*types.MarginalPriceData](context.Background(), fixtureResponses(t, time.Time{}), parsers.NewMarginalPriceParser(), ImportOptions{}, start, end)
+
+	// Stopping early must not deadlock on the downloads still in flight
+	seen := 0
+	for _, err := range seq {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		seen++
+		if seen == 2 {
+			break
+		}
+	}
+
+	if seen != 2 {
+		t.Errorf("Expected to stop after 2 days, got %d", seen)
+	}
+}
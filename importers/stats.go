@@ -0,0 +1,155 @@
+package importers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/types"
+)
+
+// ImportStats summarizes a single import run
+type ImportStats struct {
+	Attempted int   // Dates requested
+	Succeeded int   // Dates downloaded and parsed
+	NotFound  int   // Dates OMIE answered with 404 (not published)
+	Failed    int   // Dates that failed for any other reason
+	Retried   int   // Requests repeated after a failed attempt
+	Bytes     int64 // Bytes of file content received
+
+	// Unavailable counts the failed dates that weren't requested because the circuit
+	// breaker was open, i.e. omie.es looked down
+	Unavailable int
+
+	Download time.Duration // Time spent downloading, summed over all dates
+	Parse    time.Duration // Time spent parsing, summed over all dates
+	Total    time.Duration // Wall-clock duration of the import
+
+	progress     func(Progress)      // Called after every file, see ImportOptions.Progress
+	total        int                 // Files expected, reported as Progress.Total
+	parseWorkers int                 // Files parsed at once, see ImportOptions.ParseWorkers
+	metrics      downloaders.Metrics // Receives parse durations and failures, see ImportOptions.Metrics
+}
+
+// Progress reports how far an import got, see ImportOptions.Progress
+type Progress struct {
+	Total     int       // Files to import, one per date for most importers
+	Completed int       // Files processed so far, including the failed ones
+	Failed    int       // Files that failed to download or parse, or weren't published
+	Date      time.Time // Date of the file just processed
+}
+
+// newStats creates the stats of an import of total files, reporting progress to fn
+// when it isn't nil
+func newStats(fn func(Progress), total int) *ImportStats {
+	return &ImportStats{progress: fn, total: total}
+}
+
+// recordDownload accounts for a download result and, on success, wraps its body so
+// the bytes read by the parser are counted
+func (s *ImportStats) recordDownload(result downloaders.ResponseResult) {
+	s.countDownload(result)
+	if result.Error == nil {
+		result.Response.Body = &countingReader{ReadCloser: result.Response.Body, count: &s.Bytes}
+	}
+}
+
+// recordParsed accounts for the download and parsing of a response that went through
+// parseResponses
+func (s *ImportStats) recordParsed(parsed parsedResponse) {
+	s.countDownload(parsed.ResponseResult)
+	if parsed.Error == nil {
+		s.Bytes += parsed.bytes
+		s.recordParse(parsed.Date, parsed.duration, parsed.err)
+	}
+}
+
+// countDownload accounts for a download result, reporting progress for failed ones
+func (s *ImportStats) countDownload(result downloaders.ResponseResult) {
+	s.Attempted++
+	s.Download += result.Duration
+	if result.Attempts > 1 {
+		s.Retried += result.Attempts - 1
+	}
+
+	switch {
+	case result.Error == nil:
+	case result.StatusCode == http.StatusNotFound:
+		s.NotFound++
+		s.reportProgress(result.Date)
+	default:
+		s.Failed++
+		var omieErr *types.OMIEError
+		if errors.As(result.Error, &omieErr) && omieErr.Code == types.ErrCodeUnavailable {
+			s.Unavailable++
+		}
+		s.reportProgress(result.Date)
+	}
+}
+
+// recordParse accounts for the parsing of a downloaded date, which took duration
+func (s *ImportStats) recordParse(date time.Time, duration time.Duration, err error) {
+	s.Parse += duration
+	if s.metrics != nil {
+		s.metrics.ObserveParse(duration)
+		if err != nil {
+			code := types.ErrorCode(err)
+			if code == "" {
+				code = types.ErrCodeParse
+			}
+			s.metrics.IncFailures(code)
+		}
+	}
+	if err != nil {
+		s.Failed++
+	} else {
+		s.Succeeded++
+	}
+	s.reportProgress(date)
+}
+
+// reportProgress hands the progress after the file of date to the progress function
+func (s *ImportStats) reportProgress(date time.Time) {
+	if s.progress == nil {
+		return
+	}
+	s.progress(Progress{
+		Total:     s.total,
+		Completed: s.Succeeded + s.NotFound + s.Failed,
+		Failed:    s.NotFound + s.Failed,
+		Date:      date,
+	})
+}
+
+// add sums the counts and durations of other, another run, into the stats
+func (s *ImportStats) add(other *ImportStats) {
+	s.Attempted += other.Attempted
+	s.Succeeded += other.Succeeded
+	s.NotFound += other.NotFound
+	s.Failed += other.Failed
+	s.Retried += other.Retried
+	s.Bytes += other.Bytes
+	s.Unavailable += other.Unavailable
+	s.Download += other.Download
+	s.Parse += other.Parse
+	s.Total += other.Total
+}
+
+// daysIn returns the number of days from start to end, both included
+func daysIn(start, end time.Time) int {
+	return types.DateRange{Start: start, End: end}.Days()
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.count += int64(n)
+	return n, err
+}
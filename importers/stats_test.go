@@ -0,0 +1,49 @@
+package importers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+)
+
+func TestImportStats(t *testing.T) {
+	stats := &ImportStats{}
+	body := "OMIE - Mercado de electricidad;15/01/2024;\n"
+
+	ok := downloaders.ResponseResult{
+		Response: &http.Response{Body: io.NopCloser(strings.NewReader(body))},
+		Attempts: 3,
+		Duration: 40 * time.Millisecond,
+	}
+	stats.recordDownload(ok)
+	stats.recordDownload(downloaders.ResponseResult{Error: errors.New("not found"), StatusCode: http.StatusNotFound, Attempts: 1, Duration: 10 * time.Millisecond})
+	stats.recordDownload(downloaders.ResponseResult{Error: errors.New("timeout"), Attempts: 2, Duration: 50 * time.Millisecond})
+
+	// Bytes are counted as the parser reads the body
+	if stats.Bytes != 0 {
+		t.Errorf("expected no bytes before the body is read, got %d", stats.Bytes)
+	}
+	if _, err := io.ReadAll(ok.Response.Body); err != nil {
+		t.Fatal(err)
+	}
+	stats.recordParse(time.Time{}, 5*time.Millisecond, nil)
+
+	want := ImportStats{Attempted: 3, Succeeded: 1, NotFound: 1, Failed: 1, Retried: 3, Bytes: int64(len(body)), Download: 100 * time.Millisecond}
+	if stats.Attempted != want.Attempted || stats.Succeeded != want.Succeeded || stats.NotFound != want.NotFound ||
+		stats.Failed != want.Failed || stats.Retried != want.Retried || stats.Bytes != want.Bytes || stats.Download != want.Download {
+		t.Errorf("got %+v, want %+v", *stats, want)
+	}
+	if stats.Parse != 5*time.Millisecond {
+		t.Errorf("expected the parse time counted, got %v", stats.Parse)
+	}
+
+	stats.recordParse(time.Time{}, 0, errors.New("invalid file"))
+	if stats.Failed != 2 || stats.Succeeded != 1 {
+		t.Errorf("expected a failed parse counted as failed, got %+v", *stats)
+	}
+}
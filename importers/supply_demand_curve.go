@@ -0,0 +1,112 @@
+package importers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// SupplyDemandCurveImporter imports the supply/demand curves of a single hour
+type SupplyDemandCurveImporter struct {
+	downloader *downloaders.SupplyDemandCurveDownloader
+	parser     parsers.Parser
+	options    ImportOptions
+	hour       types.HourIndex
+}
+
+// NewSupplyDemandCurveImporter creates a new supply/demand curve importer for hour
+func NewSupplyDemandCurveImporter(hour types.HourIndex, options ImportOptions) *SupplyDemandCurveImporter {
+	downloader := downloaders.NewSupplyDemandCurveDownloader(hour)
+
+	downloader.SetConfig(options.downloadConfig())
+
+	return &SupplyDemandCurveImporter{
+		downloader: downloader,
+		parser:     options.wrapParser(parsers.NewSupplyDemandCurveParser()),
+		options:    options,
+		hour:       hour,
+	}
+}
+
+// NewDefaultSupplyDemandCurveImporter creates a supply/demand curve importer with default options
+func NewDefaultSupplyDemandCurveImporter(hour types.HourIndex) *SupplyDemandCurveImporter {
+	return NewSupplyDemandCurveImporter(hour, ImportOptions{
+		Verbose:       false,
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+		MaxConcurrent: 5,
+	})
+}
+
+// Import downloads and parses the curves of the importer's hour for a date range
+func (i *SupplyDemandCurveImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
+
+// ImportWithStats downloads and parses the curves of the importer's hour for a date
+// range, also returning a summary of the run
+func (i *SupplyDemandCurveImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	results, stats, err := importAll[*types.MarketCurve](i.urlResponses(ctx, start, end), i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+	return results, stats, nil
+}
+
+// ImportEach downloads and parses the curves of the importer's hour for a date range,
+// calling fn with every day in date order together with a token for ResumeEach
+func (i *SupplyDemandCurveImporter) ImportEach(ctx context.Context, start, end time.Time, fn func(*types.MarketCurve, ResumeToken) error) error {
+	return importEach(ctx, i.urlResponses, i.parser, i.options, i.dataset(), start, end, fn)
+}
+
+// ResumeEach continues an ImportEach interrupted after the day of token
+func (i *SupplyDemandCurveImporter) ResumeEach(ctx context.Context, token string, fn func(*types.MarketCurve, ResumeToken) error) error {
+	start, end, err := resumeRange(token, i.dataset())
+	if err != nil {
+		return err
+	}
+	if start.After(end) {
+		return nil // Nothing left to import
+	}
+	return i.ImportEach(ctx, start, end, fn)
+}
+
+// dataset returns the name identifying this importer's data in resume tokens
+func (i *SupplyDemandCurveImporter) dataset() string {
+	return fmt.Sprintf("supply_demand_curve_%d", i.hour.Int())
+}
+
+// urlResponses starts downloading the date range
+func (i *SupplyDemandCurveImporter) urlResponses(ctx context.Context, start, end time.Time) <-chan downloaders.ResponseResult {
+	return i.downloader.URLResponses(ctx, start, end, i.options.Verbose)
+}
+
+// ImportFromDir parses the curves of the importer's hour for a date range from the files
+// a previous DownloadData saved in dir, returning the same results as Import
+func (i *SupplyDemandCurveImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	responses := i.downloader.DirResponses(ctx, dir, start, end)
+	results, _, err := importAll[*types.MarketCurve](responses, i.parser, i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ImportSingleDate downloads and parses the curves of the importer's hour for a single date
+func (i *SupplyDemandCurveImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
+	results, err := i.Import(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if curves, ok := results.([]*types.MarketCurve); ok && len(curves) > 0 {
+		return curves[0], nil
+	}
+
+	return nil, types.NewOMIEError(types.ErrCodeNotFound, "no data found for date", nil)
+}
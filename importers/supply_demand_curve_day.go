@@ -0,0 +1,190 @@
+package importers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+// CurveSource selects the files a SupplyDemandCurveDayImporter reads the curves from
+type CurveSource int
+
+const (
+	HourlyCurveFiles    CurveSource = iota // One aggregated file per hour (INT_CURVA_ACUM_UO_MIB), the default
+	AggregatedCurveFile                    // The daily aggregated curve file (curva_pbc), one request per day
+	UnitCurveFile                          // The daily file of offers by bidding unit (curva_pbc_uof)
+)
+
+// SupplyDemandCurveDayImporter imports the supply/demand curves of every hour of a
+// day, assembling them into a MarketCurveDay
+type SupplyDemandCurveDayImporter struct {
+	downloader *downloaders.SupplyDemandCurveDownloader
+	parser     parsers.Parser
+	options    ImportOptions
+	source     CurveSource
+}
+
+// NewSupplyDemandCurveDayImporter creates a new whole-day supply/demand curve importer
+func NewSupplyDemandCurveDayImporter(options ImportOptions) *SupplyDemandCurveDayImporter {
+	downloader := downloaders.NewSupplyDemandCurveDownloader(1)
+
+	downloader.SetConfig(options.downloadConfig())
+
+	return &SupplyDemandCurveDayImporter{
+		downloader: downloader,
+		parser:     options.wrapParser(parsers.NewSupplyDemandCurveParser()),
+		options:    options,
+	}
+}
+
+// NewDefaultSupplyDemandCurveDayImporter creates a whole-day supply/demand curve importer with default options
+func NewDefaultSupplyDemandCurveDayImporter() *SupplyDemandCurveDayImporter {
+	return NewSupplyDemandCurveDayImporter(ImportOptions{
+		Verbose:       false,
+		MaxRetries:    3,
+		RetryDelay:    time.Second,
+		MaxConcurrent: 5,
+	})
+}
+
+// SetSource selects the files the curves are read from. The daily files need a single
+// request per day, or one per month or year with ImportOptions.BulkArchives; the unit
+// file also identifies the bidding unit of every point.
+func (i *SupplyDemandCurveDayImporter) SetSource(source CurveSource) {
+	i.source = source
+}
+
+// Import downloads and parses the curves of every hour for a date range
+func (i *SupplyDemandCurveDayImporter) Import(ctx context.Context, start, end time.Time) (interface{}, error) {
+	results, _, err := i.ImportWithStats(ctx, start, end)
+	return results, err
+}
+
+// ImportWithStats downloads and parses the curves of every hour for a date range, also
+// returning a summary of the run in which every hour file counts as an attempt. Days
+// missing some hours are returned with the hours that could be imported.
+func (i *SupplyDemandCurveDayImporter) ImportWithStats(ctx context.Context, start, end time.Time) (interface{}, *ImportStats, error) {
+	if i.source == AggregatedCurveFile || i.source == UnitCurveFile {
+		downloader, parser := i.dailySource()
+		if i.options.BulkArchives {
+			archives := downloaders.NewAggregatedCurveArchiveDownloader()
+			if i.source == UnitCurveFile {
+				archives = downloaders.NewUnitOfferCurveArchiveDownloader()
+			}
+			archives.SetConfig(i.options.archiveConfig())
+			return i.importDaily(archives.URLResponses(ctx, start, end, i.options.Verbose), parser, start, end)
+		}
+
+		downloader.SetConfig(i.options.downloadConfig())
+		return i.importDaily(downloader.URLResponses(ctx, start, end, i.options.Verbose), parser, start, end)
+	}
+
+	return i.importHours(i.downloader.DayURLResponses(ctx, start, end, i.options.Verbose), start, end)
+}
+
+// ImportFromDir parses the curves of every hour for a date range from the files a
+// previous DownloadData saved in dir, returning the same results as Import
+func (i *SupplyDemandCurveDayImporter) ImportFromDir(ctx context.Context, dir string, start, end time.Time) (interface{}, error) {
+	if i.source == AggregatedCurveFile || i.source == UnitCurveFile {
+		downloader, parser := i.dailySource()
+		results, _, err := i.importDaily(downloader.DirResponses(ctx, dir, start, end), parser, start, end)
+		return results, err
+	}
+
+	results, _, err := i.importHours(i.downloader.DayDirResponses(ctx, dir, start, end), start, end)
+	return results, err
+}
+
+// importHours imports the curves from the hourly files, grouping them by day
+func (i *SupplyDemandCurveDayImporter) importHours(responses <-chan downloaders.HourResponseResult, start, end time.Time) (interface{}, *ImportStats, error) {
+	started := time.Now()
+	hours := 0
+	for date := range (types.DateRange{Start: start, End: end}).All() {
+		hours += types.HoursInDay(date)
+	}
+	stats := i.options.newStats(hours)
+	defer func() { stats.Total = time.Since(started) }()
+
+	days := make(map[time.Time]*types.MarketCurveDay)
+	var errors []error
+
+	for result := range responses {
+		stats.recordDownload(result.ResponseResult)
+		if result.Error != nil {
+			errors = append(errors, fmt.Errorf("hour %d: %w", result.Hour, result.Error))
+			continue
+		}
+
+		// Parse the response
+		parseStarted := time.Now()
+		parsed, err := i.parser.ParseResponse(result.Response)
+		result.Response.Body.Close()
+		stats.recordParse(result.Date, time.Since(parseStarted), err)
+
+		if err != nil {
+			errors = append(errors, fmt.Errorf("parse error for %s hour %d: %w", result.Date.Format("2006-01-02"), result.Hour, err))
+			continue
+		}
+
+		if curve, ok := parsed.(*types.MarketCurve); ok {
+			day, exists := days[result.Date]
+			if !exists {
+				day = &types.MarketCurveDay{Date: curve.Date}
+				days[result.Date] = day
+			}
+			day.Curves = append(day.Curves, *curve)
+		}
+	}
+
+	if len(days) == 0 && len(errors) > 0 {
+		return nil, stats, types.JoinErrors("no data imported", errors)
+	}
+
+	// Hours arrive in any order, return days and curves in chronological order
+	results := make([]*types.MarketCurveDay, 0, len(days))
+	for _, day := range days {
+		sort.Slice(day.Curves, func(a, b int) bool { return day.Curves[a].Hour < day.Curves[b].Hour })
+		results = append(results, day)
+	}
+	sort.Slice(results, func(a, b int) bool { return results[a].Date.Before(results[b].Date) })
+
+	return results, stats, nil
+}
+
+// dailySource returns the downloader and parser of a source with a single file per day
+func (i *SupplyDemandCurveDayImporter) dailySource() (*downloaders.GeneralDownloader, parsers.Parser) {
+	if i.source == UnitCurveFile {
+		return downloaders.NewUnitOfferCurveDownloader().GeneralDownloader, parsers.NewUnitOfferCurveParser()
+	}
+	return downloaders.NewAggregatedCurveDownloader().GeneralDownloader, parsers.NewAggregatedCurveParser()
+}
+
+// importDaily imports the curves from a source with a single file per day
+func (i *SupplyDemandCurveDayImporter) importDaily(responses <-chan downloaders.ResponseResult, parser parsers.Parser, start, end time.Time) (interface{}, *ImportStats, error) {
+	results, stats, err := importAll[*types.MarketCurveDay](responses, i.options.wrapParser(parser), i.options.newStats(daysIn(start, end)))
+	if err != nil {
+		return nil, stats, err
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Date.Before(results[b].Date) })
+	return results, stats, nil
+}
+
+// ImportSingleDate downloads and parses the curves of every hour for a single date
+func (i *SupplyDemandCurveDayImporter) ImportSingleDate(ctx context.Context, date time.Time) (interface{}, error) {
+	results, err := i.Import(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if days, ok := results.([]*types.MarketCurveDay); ok && len(days) > 0 {
+		return days[0], nil
+	}
+
+	return nil, types.NewOMIEError(types.ErrCodeNotFound, "no data found for date", nil)
+}
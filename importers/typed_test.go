@@ -0,0 +1,39 @@
+package importers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestImportAllTyped(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	download := fixtureResponses(t, start.AddDate(0, 0, 1))
+
+	days, _, err := importAll[*types.MarginalPriceData](download(context.Background(), start, end), parsers.NewMarginalPriceParser(), &ImportStats{})
+	if err != nil {
+		t.Fatalf("importAll() error: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("expected the 2 days downloaded, got %d", len(days))
+	}
+	for _, day := range days {
+		if day.Date.IsZero() || len(day.SpainPrices) != 24 {
+			t.Errorf("unexpected day %+v", day)
+		}
+	}
+
+	// An error is only returned when no day was imported
+	_, _, err = importAll[*types.MarginalPriceData](download(context.Background(), end, end), parsers.NewMarginalPriceParser(), &ImportStats{})
+	if err != nil {
+		t.Errorf("unexpected error importing a published day: %v", err)
+	}
+	failed := start.AddDate(0, 0, 1)
+	if _, _, err := importAll[*types.MarginalPriceData](download(context.Background(), failed, failed), parsers.NewMarginalPriceParser(), &ImportStats{}); err == nil {
+		t.Error("expected an error when no day was imported")
+	}
+}
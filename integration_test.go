@@ -36,12 +36,12 @@ func TestMarginalPriceIntegration(t *testing.T) {
 				t.Errorf("Expected year %d, got %d", test.year, data.Date.Year())
 			}
 
-			if len(data.SpainPrices) == 0 {
+			if len(data.SpainPrices) == 0 && len(data.SpainAdjustmentPrices) == 0 {
 				t.Error("No Spain prices found")
 			}
 
 			// Check that we have 24 or 25 hours (DST adjustment)
-			hourCount := len(data.SpainPrices)
+			hourCount := len(data.HoursSorted())
 			if hourCount < 23 || hourCount > 25 {
 				t.Errorf("Unexpected hour count: %d (should be 23-25)", hourCount)
 			}
@@ -0,0 +1,39 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "file.txt")
+	if err := WriteFile(path, []byte("first")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A failed write keeps the previous file and leaves no temporary file behind
+	failed := errors.New("failed")
+	err := Write(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the write error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "first" {
+		t.Errorf("expected the previous file kept, got %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0644 {
+		t.Errorf("expected a 0644 file, got %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected only the file in its folder, got %d entries", len(entries))
+	}
+}
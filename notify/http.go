@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// WebhookNotifier posts events as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting events to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// webhookPayload is the JSON body sent by WebhookNotifier
+type webhookPayload struct {
+	Kind    EventKind              `json:"kind"`
+	Job     string                 `json:"job"`
+	Time    time.Time              `json:"time"`
+	Subject string                 `json:"subject"`
+	Message string                 `json:"message,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Stats   *importers.ImportStats `json:"stats,omitempty"`
+
+	Dataset string             `json:"dataset,omitempty"`
+	System  string             `json:"system,omitempty"`
+	Date    string             `json:"date,omitempty"`
+	Summary map[string]float64 `json:"summary,omitempty"`
+}
+
+// Notify posts the event to the webhook
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	payload := webhookPayload{
+		Kind:    event.Kind,
+		Job:     event.Job,
+		Time:    event.Time,
+		Subject: event.Subject(),
+		Message: event.Message,
+		Stats:   event.Stats,
+		Dataset: event.Dataset,
+		System:  event.System,
+		Summary: event.Summary,
+	}
+	if event.Err != nil {
+		payload.Error = event.Err.Error()
+	}
+	if !event.Date.IsZero() {
+		payload.Date = event.Date.Format("2006-01-02")
+	}
+
+	return postJSON(ctx, n.client, n.url, payload)
+}
+
+// SlackNotifier posts events to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to a Slack incoming webhook URL
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the event text to Slack
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, n.client, n.webhookURL, map[string]string{"text": event.Text()})
+}
+
+// postJSON posts payload as JSON to url, failing on non-2xx responses
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeNetwork, "failed to send notification", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return types.NewOMIEError(types.ErrCodeNetwork, fmt.Sprintf("notification failed with HTTP %d", resp.StatusCode), nil)
+	}
+
+	return nil
+}
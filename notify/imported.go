@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// PricesImported returns the DataImported event of a day of marginal prices, summarized
+// by the number of periods and the minimum, maximum and average price of each country
+func PricesImported(data *types.MarginalPriceData) Event {
+	summary := map[string]float64{"periods": float64(len(data.SpainPrices))}
+	addStats(summary, "spain_price", data.SpainPrices)
+	addStats(summary, "portugal_price", data.PortugalPrices)
+
+	return Event{
+		Kind:    DataImported,
+		Job:     "day-ahead prices",
+		Time:    time.Now(),
+		Dataset: "marginal_price",
+		Date:    data.Date,
+		Summary: summary,
+	}
+}
+
+// TechnologyImported returns the DataImported event of a day of energy by technology,
+// summarized by the number of hours and the total energy in MWh
+func TechnologyImported(day *types.TechnologyEnergyDay) Event {
+	total := 0.0
+	for _, record := range day.Records {
+		if value := record.Total(); !math.IsNaN(value) {
+			total += value
+		}
+	}
+
+	return Event{
+		Kind:    DataImported,
+		Job:     "energy by technology",
+		Time:    time.Now(),
+		Message: fmt.Sprintf("%s system", day.System),
+		Dataset: "energy_by_technology",
+		System:  day.System.String(),
+		Date:    day.Date,
+		Summary: map[string]float64{"hours": float64(len(day.Records)), "total_energy": total},
+	}
+}
+
+// OnPrices returns a handler notifying every imported day of prices, to be used with the
+// scheduler's jobs
+func OnPrices(notifier Notifier) func(context.Context, *types.MarginalPriceData) error {
+	return func(ctx context.Context, data *types.MarginalPriceData) error {
+		return notifier.Notify(ctx, PricesImported(data))
+	}
+}
+
+// OnTechnology returns a handler notifying every imported day of energy by technology,
+// to be used with the scheduler's jobs
+func OnTechnology(notifier Notifier) func(context.Context, *types.TechnologyEnergyDay) error {
+	return func(ctx context.Context, day *types.TechnologyEnergyDay) error {
+		return notifier.Notify(ctx, TechnologyImported(day))
+	}
+}
+
+// addStats adds the minimum, maximum and average of the values present in values to
+// summary as <prefix>_min, <prefix>_max and <prefix>_avg
+func addStats(summary map[string]float64, prefix string, values types.HourlyValues) {
+	min, max, sum, count := math.Inf(1), math.Inf(-1), 0.0, 0
+	for _, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		min, max = math.Min(min, value), math.Max(max, value)
+		sum += value
+		count++
+	}
+	if count == 0 {
+		return
+	}
+
+	summary[prefix+"_min"] = min
+	summary[prefix+"_max"] = max
+	summary[prefix+"_avg"] = sum / float64(count)
+}
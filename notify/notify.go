@@ -0,0 +1,142 @@
+// Package notify sends notifications about long-running jobs, such as backfills and
+// watchers, to email, Slack or any HTTP endpoint
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+)
+
+// EventKind identifies why a notification was sent
+type EventKind string
+
+const (
+	JobFinished      EventKind = "job_finished"      // A job completed, successfully or not
+	FailureThreshold EventKind = "failure_threshold" // Consecutive failures exceeded the threshold
+	DataImported     EventKind = "data_imported"     // A new day of a dataset was imported
+)
+
+// Event describes something worth notifying about
+type Event struct {
+	Kind    EventKind
+	Job     string // Name of the job, e.g. "backfill marginal prices"
+	Time    time.Time
+	Message string
+	Err     error                  // Last error, if the job failed
+	Stats   *importers.ImportStats // Summary of the run, if available
+
+	// Day imported, for DataImported events
+	Dataset string             // e.g. "marginal_price"
+	System  string             // System of the dataset, if it has one, e.g. "IBERIAN"
+	Date    time.Time          // Delivery date
+	Summary map[string]float64 // Statistics of the day, e.g. "spain_price_avg"
+}
+
+// Subject returns a one-line summary of the event
+func (e Event) Subject() string {
+	switch e.Kind {
+	case FailureThreshold:
+		return fmt.Sprintf("[omiedata] %s is failing", e.Job)
+	case DataImported:
+		return fmt.Sprintf("[omiedata] %s for %s imported", e.Job, e.Date.Format("2006-01-02"))
+	case JobFinished:
+		if e.Err != nil {
+			return fmt.Sprintf("[omiedata] %s finished with errors", e.Job)
+		}
+		return fmt.Sprintf("[omiedata] %s finished", e.Job)
+	default:
+		return fmt.Sprintf("[omiedata] %s: %s", e.Job, e.Kind)
+	}
+}
+
+// Text returns a plain-text description of the event
+func (e Event) Text() string {
+	text := e.Subject()
+	if e.Message != "" {
+		text += "\n" + e.Message
+	}
+	if e.Err != nil {
+		text += "\nError: " + e.Err.Error()
+	}
+	if e.Stats != nil {
+		text += fmt.Sprintf("\nDates: %d attempted, %d succeeded, %d not found, %d failed (%s)",
+			e.Stats.Attempted, e.Stats.Succeeded, e.Stats.NotFound, e.Stats.Failed, e.Stats.Total.Round(time.Second))
+	}
+	return text
+}
+
+// Notifier delivers events to an external system
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// multiNotifier forwards events to several notifiers
+type multiNotifier []Notifier
+
+// Multi returns a notifier delivering every event to all notifiers, returning the
+// errors of those that failed
+func Multi(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
+
+// Notify delivers the event to every notifier
+func (m multiNotifier) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FailureTracker counts consecutive failures of a job and notifies once they reach
+// the threshold. A success resets the count, so a new streak notifies again.
+type FailureTracker struct {
+	notifier  Notifier
+	job       string
+	threshold int
+
+	mu          sync.Mutex
+	consecutive int
+}
+
+// NewFailureTracker creates a tracker notifying after threshold consecutive failures of job
+func NewFailureTracker(notifier Notifier, job string, threshold int) *FailureTracker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &FailureTracker{notifier: notifier, job: job, threshold: threshold}
+}
+
+// Success records a successful run, resetting the failure count
+func (t *FailureTracker) Success() {
+	t.mu.Lock()
+	t.consecutive = 0
+	t.mu.Unlock()
+}
+
+// Failure records a failed run, notifying when the failure count reaches the threshold
+func (t *FailureTracker) Failure(ctx context.Context, err error) error {
+	t.mu.Lock()
+	t.consecutive++
+	count := t.consecutive
+	t.mu.Unlock()
+
+	if count != t.threshold {
+		return nil
+	}
+
+	return t.notifier.Notify(ctx, Event{
+		Kind:    FailureThreshold,
+		Job:     t.job,
+		Time:    time.Now(),
+		Message: fmt.Sprintf("%d consecutive failures", count),
+		Err:     err,
+	})
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestFailureTrackerNotifiesWebhook(t *testing.T) {
+	var received []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	tracker := NewFailureTracker(NewWebhookNotifier(server.URL), "daily prices", 3)
+	ctx := context.Background()
+	failure := errors.New("HTTP 503")
+
+	for i := 0; i < 2; i++ {
+		if err := tracker.Failure(ctx, failure); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tracker.Success()
+	for i := 0; i < 4; i++ {
+		if err := tracker.Failure(ctx, failure); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected a single notification, got %d", len(received))
+	}
+	if received[0].Kind != FailureThreshold || received[0].Job != "daily prices" || received[0].Error != "HTTP 503" {
+		t.Errorf("Unexpected payload: %+v", received[0])
+	}
+}
+
+func TestOnPricesNotifiesWebhook(t *testing.T) {
+	var received []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	data := types.NewMarginalPriceData(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	data.SpainPrices[1], data.SpainPrices[2], data.SpainPrices[3] = 50, 70, math.NaN()
+	data.PortugalPrices[1] = 60
+
+	if err := OnPrices(NewWebhookNotifier(server.URL))(context.Background(), data); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected a single notification, got %d", len(received))
+	}
+	payload := received[0]
+	if payload.Kind != DataImported || payload.Dataset != "marginal_price" || payload.Date != "2024-01-16" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	want := map[string]float64{
+		"periods": 3, "spain_price_min": 50, "spain_price_max": 70, "spain_price_avg": 60,
+		"portugal_price_min": 60, "portugal_price_max": 60, "portugal_price_avg": 60,
+	}
+	if len(payload.Summary) != len(want) {
+		t.Errorf("Expected summary %v, got %v", want, payload.Summary)
+	}
+	for key, value := range want {
+		if payload.Summary[key] != value {
+			t.Errorf("Summary[%s] = %v, want %v", key, payload.Summary[key], value)
+		}
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var received []map[string]string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		received = append(received, payload)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	event := Event{Kind: FailureThreshold, Job: "day-ahead prices", Message: "3 consecutive failures", Err: errors.New("HTTP 503")}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0]["text"] != "[omiedata] day-ahead prices is failing\n3 consecutive failures\nError: HTTP 503" {
+		t.Errorf("Unexpected payloads: %v", received)
+	}
+
+	status = http.StatusNotFound
+	if err := notifier.Notify(context.Background(), event); types.ErrorCode(err) != types.ErrCodeNetwork {
+		t.Errorf("Expected a network error for a rejected notification, got %v", err)
+	}
+}
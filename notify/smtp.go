@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// SMTPNotifier emails events through an SMTP server
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier creates a notifier sending mail from from to the to addresses
+// through the server at addr ("host:port"). auth may be nil for servers that don't
+// require authentication.
+func NewSMTPNotifier(addr string, auth smtp.Auth, from string, to ...string) *SMTPNotifier {
+	return &SMTPNotifier{addr: addr, auth: auth, from: from, to: to}
+}
+
+// Notify emails the event
+func (n *SMTPNotifier) Notify(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", headerValue(n.from))
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue(strings.Join(n.to, ", ")))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerValue(event.Subject()))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(event.Text(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(msg.String())); err != nil {
+		return types.NewOMIEError(types.ErrCodeNetwork, "failed to send notification email", err)
+	}
+
+	return nil
+}
+
+// headerValue replaces the line breaks of value with spaces, so a value such as a job
+// name can't end its header early and add headers of its own
+func headerValue(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// smtpMessage is a message received by fakeSMTP
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP accepts a single connection speaking just enough SMTP for net/smtp to send
+// a message, rejecting recipients in reject, and returns the address it listens on and
+// the channel the message is delivered to
+func fakeSMTP(t *testing.T, reject string) (string, <-chan smtpMessage) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var msg smtpMessage
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				text.PrintfLine("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				text.PrintfLine("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				to := strings.Trim(line[len("RCPT TO:"):], "<>")
+				if to == reject {
+					text.PrintfLine("550 No such user")
+					continue
+				}
+				msg.to = append(msg.to, to)
+				text.PrintfLine("250 OK")
+			case command == "DATA":
+				text.PrintfLine("354 Go ahead")
+				lines, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				msg.data = strings.Join(lines, "\n")
+				text.PrintfLine("250 Queued")
+				messages <- msg
+			case command == "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := fakeSMTP(t, "")
+
+	notifier := NewSMTPNotifier(addr, nil, "omie@example.com", "ops@example.com", "data@example.com")
+	event := Event{
+		Kind:    JobFinished,
+		Job:     "backfill\r\nBcc: attacker@example.com",
+		Time:    time.Now(),
+		Message: "prices: 31 days downloaded",
+		Err:     errors.New("2 days could not be downloaded"),
+	}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	if msg.from != "omie@example.com" || strings.Join(msg.to, ",") != "ops@example.com,data@example.com" {
+		t.Errorf("unexpected envelope from %s to %v", msg.from, msg.to)
+	}
+
+	headers, body, _ := strings.Cut(msg.data, "\n\n")
+	for _, want := range []string{
+		"From: omie@example.com",
+		"To: ops@example.com, data@example.com",
+		"Subject: [omiedata] backfill Bcc: attacker@example.com finished with errors",
+	} {
+		if !strings.Contains(headers, want+"\n") {
+			t.Errorf("expected header %q in\n%s", want, headers)
+		}
+	}
+	for _, header := range strings.Split(headers, "\n") {
+		if strings.HasPrefix(header, "Bcc:") {
+			t.Errorf("the job name injected a header: %q", header)
+		}
+	}
+	if !strings.Contains(body, "prices: 31 days downloaded\nError: 2 days could not be downloaded") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestSMTPNotifierRejected(t *testing.T) {
+	addr, _ := fakeSMTP(t, "ops@example.com")
+
+	err := NewSMTPNotifier(addr, nil, "omie@example.com", "ops@example.com").Notify(context.Background(), Event{Kind: JobFinished, Job: "backfill"})
+	if types.ErrorCode(err) != types.ErrCodeNetwork {
+		t.Errorf("expected a network error for the rejected recipient, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewSMTPNotifier(addr, nil, "omie@example.com", "ops@example.com").Notify(ctx, Event{}); err != context.Canceled {
+		t.Errorf("expected the cancellation error, got %v", err)
+	}
+}
@@ -14,6 +14,9 @@
 package omiedata
 
 import (
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
 	"github.com/devuo/omiedata/importers"
 	"github.com/devuo/omiedata/types"
 )
@@ -26,17 +29,72 @@ type (
 	// Technology types
 	TechnologyType = types.TechnologyType
 
+	// Hour and quarter-hour period indexes and resolutions
+	HourIndex   = types.HourIndex
+	PeriodIndex = types.PeriodIndex
+	Resolution  = types.Resolution
+
+	// Layouts of the marginal price files
+	FormatVersion = types.FormatVersion
+
+	// Character encodings of the parsed files
+	Encoding = types.Encoding
+
 	// Data types
+	HourlyValues        = types.HourlyValues
 	MarginalPriceData   = types.MarginalPriceData
 	TechnologyEnergy    = types.TechnologyEnergy
 	TechnologyEnergyDay = types.TechnologyEnergyDay
+	MarketPoint         = types.MarketPoint
+	MarketCurve         = types.MarketCurve
+	MarketCurveDay      = types.MarketCurveDay
+
+	ContinuousIntradayPrice = types.ContinuousIntradayPrice
+	ContinuousIntradayDay   = types.ContinuousIntradayDay
+	MonthlyPriceSummary     = types.MonthlyPriceSummary
+	InterconnectionData     = types.InterconnectionData
+	FuturesSettlement       = types.FuturesSettlement
+	FuturesSettlementDay    = types.FuturesSettlementDay
+	DateRange               = types.DateRange
+	Nullable                = types.Nullable
 
 	// Import options
 	ImportOptions = importers.ImportOptions
+	ImportStats   = importers.ImportStats
+	DateError     = importers.DateError
+	GapPolicy     = importers.GapPolicy
+	Journal       = importers.Journal
+	FileJournal   = importers.FileJournal
+	ResultCache   = importers.ResultCache
+	Progress      = importers.Progress
+	DownloadHooks = downloaders.Hooks
+	WorkerPool    = downloaders.WorkerPool
+	Metrics       = downloaders.Metrics
 
 	// Importers
-	MarginalPriceImporter      = importers.MarginalPriceImporter
-	EnergyByTechnologyImporter = importers.EnergyByTechnologyImporter
+	MarginalPriceImporter        = importers.MarginalPriceImporter
+	EnergyByTechnologyImporter   = importers.EnergyByTechnologyImporter
+	SupplyDemandCurveImporter    = importers.SupplyDemandCurveImporter
+	SupplyDemandCurveDayImporter = importers.SupplyDemandCurveDayImporter
+	MonthlyPriceImporter         = importers.MonthlyPriceImporter
+	InterconnectionImporter      = importers.InterconnectionImporter
+	CurveSource                  = importers.CurveSource
+)
+
+// TypedImporter is an importer whose results are statically typed
+type TypedImporter[T any] = importers.TypedImporter[T]
+
+// ImportResult holds the days of a partly failed import and the dates that failed
+type ImportResult[T any] = importers.ImportResult[T]
+
+// GapReport holds the days of an import that re-fetched its missing dates and the gaps left
+type GapReport[T any] = importers.GapReport[T]
+
+// Curve source constants
+const (
+	HourlyCurveFiles    = importers.HourlyCurveFiles
+	AggregatedCurveFile = importers.AggregatedCurveFile
+	UnitCurveFile       = importers.UnitCurveFile
 )
 
 // System type constants
@@ -46,6 +104,12 @@ const (
 	Iberian  = types.Iberian
 )
 
+// Resolution constants
+const (
+	Hourly        = types.Hourly
+	QuarterHourly = types.QuarterHourly
+)
+
 // Technology type constants
 const (
 	Coal               = types.Coal
@@ -83,3 +147,65 @@ func NewEnergyByTechnologyImporter(systemType SystemType) *EnergyByTechnologyImp
 func NewEnergyByTechnologyImporterWithOptions(systemType SystemType, options ImportOptions) *EnergyByTechnologyImporter {
 	return importers.NewEnergyByTechnologyImporter(systemType, options)
 }
+
+// NewSupplyDemandCurveImporter creates a new supply/demand curve importer for an hour with default settings
+func NewSupplyDemandCurveImporter(hour HourIndex) *SupplyDemandCurveImporter {
+	return importers.NewDefaultSupplyDemandCurveImporter(hour)
+}
+
+// NewSupplyDemandCurveImporterWithOptions creates a new supply/demand curve importer for an hour with custom options
+func NewSupplyDemandCurveImporterWithOptions(hour HourIndex, options ImportOptions) *SupplyDemandCurveImporter {
+	return importers.NewSupplyDemandCurveImporter(hour, options)
+}
+
+// NewSupplyDemandCurveDayImporter creates a new importer of the curves of every hour of a day with default settings
+func NewSupplyDemandCurveDayImporter() *SupplyDemandCurveDayImporter {
+	return importers.NewDefaultSupplyDemandCurveDayImporter()
+}
+
+// NewSupplyDemandCurveDayImporterWithOptions creates a new importer of the curves of every hour of a day with custom options
+func NewSupplyDemandCurveDayImporterWithOptions(options ImportOptions) *SupplyDemandCurveDayImporter {
+	return importers.NewSupplyDemandCurveDayImporter(options)
+}
+
+// NewMonthlyPriceImporter creates a new monthly average price importer with default settings
+func NewMonthlyPriceImporter() *MonthlyPriceImporter {
+	return importers.NewDefaultMonthlyPriceImporter()
+}
+
+// NewMonthlyPriceImporterWithOptions creates a new monthly average price importer with custom options
+func NewMonthlyPriceImporterWithOptions(options ImportOptions) *MonthlyPriceImporter {
+	return importers.NewMonthlyPriceImporter(options)
+}
+
+// NewInterconnectionImporter creates a new interconnection importer with default settings
+func NewInterconnectionImporter() *InterconnectionImporter {
+	return importers.NewDefaultInterconnectionImporter()
+}
+
+// NewInterconnectionImporterWithOptions creates a new interconnection importer with custom options
+func NewInterconnectionImporterWithOptions(options ImportOptions) *InterconnectionImporter {
+	return importers.NewInterconnectionImporter(options)
+}
+
+// NewResultCache creates an in-memory LRU of parsed days holding up to capacity days,
+// to be set in ImportOptions.ResultCache
+func NewResultCache(capacity int) *ResultCache {
+	return importers.NewResultCache(capacity)
+}
+
+// NewWorkerPool creates a pool letting size files be downloaded at once, shared by every
+// importer it is set in through ImportOptions.Pool
+func NewWorkerPool(size int) *WorkerPool {
+	return downloaders.NewWorkerPool(size)
+}
+
+// OpenFileJournal reads the journal of incremental imports at path, or starts an empty one
+func OpenFileJournal(path string) (*FileJournal, error) {
+	return importers.OpenFileJournal(path)
+}
+
+// NewDateRange returns the range of days from start to end, both included
+func NewDateRange(start, end time.Time) (DateRange, error) {
+	return types.NewDateRange(start, end)
+}
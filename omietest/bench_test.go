@@ -0,0 +1,64 @@
+package omietest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// benchmarkPipeline imports a month served over HTTP by the fake server at several
+// concurrencies, measuring the whole download, parse and collect path of the importers
+func benchmarkPipeline[T any](b *testing.B, serve func(srv *Server, date time.Time) error, newImporter func(importers.ImportOptions) importers.TypedImporter[T], start time.Time) {
+	srv := NewServer()
+	defer srv.Close()
+
+	end := start.AddDate(0, 0, 29)
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if err := serve(srv, date); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, concurrent := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("concurrent=%d", concurrent), func(b *testing.B) {
+			options := srv.Options()
+			options.MaxConcurrent = concurrent
+			importer := newImporter(options)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				result := importer.ImportPartial(context.Background(), start, end)
+				if len(result.Errors) > 0 || len(result.Days) != 30 {
+					b.Fatalf("expected 30 days, got %d and errors %v", len(result.Days), result.Errors)
+				}
+			}
+			b.ReportMetric(float64(30*b.N)/b.Elapsed().Seconds(), "days/s")
+		})
+	}
+}
+
+func BenchmarkMarginalPricePipeline(b *testing.B) {
+	benchmarkPipeline(b,
+		func(srv *Server, date time.Time) error {
+			return srv.ServeFile(MarginalPriceURL(date), "../testdata/PMD_20090601.txt")
+		},
+		func(options importers.ImportOptions) importers.TypedImporter[*types.MarginalPriceData] {
+			return importers.NewMarginalPriceImporter(options)
+		},
+		time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func BenchmarkEnergyByTechnologyPipeline(b *testing.B) {
+	benchmarkPipeline(b,
+		func(srv *Server, date time.Time) error {
+			return srv.ServeFile(EnergyByTechnologyURL(types.Iberian, date), "../testdata/EnergyByTechnology_9_20201113.TXT")
+		},
+		func(options importers.ImportOptions) importers.TypedImporter[*types.TechnologyEnergyDay] {
+			return importers.NewEnergyByTechnologyImporter(types.Iberian, options)
+		},
+		time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,188 @@
+// Package omietest provides a fake omie.es for testing code built on the importers
+// without reaching the real site. The server answers the files it was given for their
+// URLs, 404 Not Found for any other file, like OMIE does for days it hasn't published,
+// and can simulate server errors and slow responses:
+//
+//	srv := omietest.NewServer()
+//	defer srv.Close()
+//	srv.Serve(omietest.MarginalPriceURL(date), fixture)
+//	srv.Inject(omietest.MarginalPriceURL(date.AddDate(0, 0, 1)), omietest.Fault{Status: http.StatusServiceUnavailable})
+//
+//	importer := importers.NewMarginalPriceImporter(srv.Options())
+package omietest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// RequestTimeout is the timeout of the clients of the server, so faults delaying
+// responses longer than it time out
+const RequestTimeout = 2 * time.Second
+
+// Fault is a failure simulated for a file instead of answering it
+type Fault struct {
+	Status int           // Status answered, e.g. http.StatusServiceUnavailable; none when zero
+	Delay  time.Duration // Wait before answering, e.g. longer than RequestTimeout to time out
+	Times  int           // Requests failed before the file is answered again, every one when zero
+}
+
+// Server is a fake omie.es serving files from memory. It is safe for concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	files    map[string][]byte // Contents by request URI, see key
+	faults   map[string]*Fault
+	requests []string
+}
+
+// NewServer starts a server with no files. Close it when done.
+func NewServer() *Server {
+	s := &Server{files: make(map[string][]byte), faults: make(map[string]*Fault)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the base URL the server listens on
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close shuts the server down, waiting for the requests in flight
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns an HTTP client sending the requests for any host, e.g. omie.es, to the
+// server, with RequestTimeout as its timeout
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.srv.URL)
+	transport := s.srv.Client().Transport
+	return &http.Client{
+		Timeout: RequestTimeout,
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.URL.Scheme, req.URL.Host, req.Host = target.Scheme, target.Host, target.Host
+			return transport.RoundTrip(req)
+		}),
+	}
+}
+
+// Options returns import options downloading from the server, retrying failed requests
+// once without waiting so tests of retries stay fast
+func (s *Server) Options() importers.ImportOptions {
+	return importers.ImportOptions{
+		MaxRetries:    1,
+		RetryDelay:    time.Millisecond,
+		MaxConcurrent: 2,
+		HTTPClient:    s.Client(),
+	}
+}
+
+// Serve answers the file at fileURL, as given by the URLForDate of a downloader, with
+// body
+func (s *Server) Serve(fileURL string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key(fileURL)] = body
+}
+
+// ServeFile answers the file at fileURL with the contents of the file at path, e.g. a
+// fixture downloaded with ImportOptions.ArchiveDir
+func (s *Server) ServeFile(fileURL, path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.Serve(fileURL, body)
+	return nil
+}
+
+// Inject makes the server fail the requests for fileURL as described by fault, whether
+// it serves the file or not. A later fault for the same file replaces it, and the zero
+// Fault clears it.
+func (s *Server) Inject(fileURL string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[key(fileURL)] = &fault
+}
+
+// Requests returns the request URIs received so far, in the order they arrived
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// handle answers a request with its fault, its file or 404 Not Found
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	uri := r.URL.RequestURI()
+	s.mu.Lock()
+	s.requests = append(s.requests, uri)
+	body, found := s.files[uri]
+	var fault Fault
+	if f := s.faults[uri]; f != nil {
+		fault = *f
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				delete(s.faults, uri)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if fault.Delay > 0 {
+		timer := time.NewTimer(fault.Delay)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	switch {
+	case fault.Status != 0:
+		http.Error(w, http.StatusText(fault.Status), fault.Status)
+	case !found:
+		http.NotFound(w, r)
+	default:
+		w.Write(body)
+	}
+}
+
+// MarginalPriceURL returns the URL of the marginal price file of date
+func MarginalPriceURL(date time.Time) string {
+	return downloaders.NewMarginalPriceDownloader().URLForDate(date)
+}
+
+// EnergyByTechnologyURL returns the URL of the energy by technology file of system on
+// date
+func EnergyByTechnologyURL(system types.SystemType, date time.Time) string {
+	return downloaders.NewEnergyByTechnologyDownloader(system).URLForDate(date)
+}
+
+// key returns the request URI the server receives for fileURL, whatever its host
+func key(fileURL string) string {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return fileURL
+	}
+	return u.RequestURI()
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,130 @@
+package omietest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/downloaders"
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	start := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.AddDate(0, 0, n-1) }
+	for n := 1; n <= 3; n++ {
+		if err := srv.ServeFile(MarginalPriceURL(day(n)), "../testdata/PMD_20090601.txt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The 2nd fails once and is served on the retry, the 3rd is down; the 4th isn't published
+	srv.Inject(MarginalPriceURL(day(2)), Fault{Status: http.StatusServiceUnavailable, Times: 1})
+	srv.Inject(MarginalPriceURL(day(3)), Fault{Status: http.StatusInternalServerError})
+
+	importer := importers.NewMarginalPriceImporter(srv.Options())
+	result := importer.ImportPartial(context.Background(), start, day(4))
+	if len(result.Days) != 2 {
+		t.Errorf("Expected the 1st and 2nd to be imported, got %d days", len(result.Days))
+	}
+	if len(result.Errors) != 2 || result.NotFound != 1 || result.Retried < 2 {
+		t.Errorf("Expected the 3rd to fail and the 4th to be missing after retries, got %v (%d not found, %d retried)",
+			result.Errors, result.NotFound, result.Retried)
+	}
+	// Files that aren't published aren't retried
+	if got := len(srv.Requests()); got != 6 {
+		t.Errorf("Expected 6 requests with the retries, got %d: %v", got, srv.Requests())
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	date := time.Date(2020, 11, 13, 0, 0, 0, 0, time.UTC)
+	if err := srv.ServeFile(EnergyByTechnologyURL(types.Iberian, date), "../testdata/EnergyByTechnology_9_20201113.TXT"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Inject(EnergyByTechnologyURL(types.Iberian, date), Fault{Delay: time.Minute})
+
+	options := srv.Options()
+	options.MaxRetries = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := importers.NewEnergyByTechnologyImporter(types.Iberian, options).ImportDay(ctx, date)
+	if err == nil {
+		t.Fatal("Expected the slow response to time out")
+	}
+
+	srv.Inject(EnergyByTechnologyURL(types.Iberian, date), Fault{})
+	data, err := importers.NewEnergyByTechnologyImporter(types.Iberian, options).ImportDay(context.Background(), date)
+	if err != nil || len(data.Records) == 0 {
+		t.Errorf("Expected the day once the server recovers, got %v", err)
+	}
+}
+
+func TestServerAllSystems(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	start := time.Date(2020, 11, 13, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	systems := []types.SystemType{types.Spain, types.Portugal, types.Iberian}
+	for _, system := range systems {
+		for date := range (types.DateRange{Start: start, End: end}).All() {
+			if err := srv.ServeFile(EnergyByTechnologyURL(system, date), "../testdata/EnergyByTechnology_9_20201113.TXT"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Portugal is down on both days, Spain fails once on the 2nd and is served on the retry
+	for date := range (types.DateRange{Start: start, End: end}).All() {
+		srv.Inject(EnergyByTechnologyURL(types.Portugal, date), Fault{Status: http.StatusInternalServerError})
+	}
+	srv.Inject(EnergyByTechnologyURL(types.Spain, end), Fault{Status: http.StatusServiceUnavailable, Times: 1})
+
+	// Every system and date is answered through the shared worker pool
+	downloader := downloaders.NewEnergyByTechnologyDownloader(types.Iberian)
+	downloader.SetConfig(downloaders.DownloadConfig{MaxRetries: 1, RetryDelay: time.Millisecond, MaxConcurrent: 2, HTTPClient: srv.Client()})
+	failed := map[types.SystemType]int{}
+	answered := map[types.SystemType]int{}
+	for result := range downloader.SystemsURLResponses(context.Background(), systems, start, end, false) {
+		if result.Error != nil {
+			failed[result.System]++
+			continue
+		}
+		result.Response.Body.Close()
+		answered[result.System]++
+	}
+	if answered[types.Spain] != 2 || answered[types.Iberian] != 2 || answered[types.Portugal] != 0 || failed[types.Portugal] != 2 {
+		t.Errorf("Expected Spain and Iberian answered and Portugal failed, got %v answered and %v failed", answered, failed)
+	}
+
+	// The failing system is left out of the import without failing the others
+	results, err := importers.NewEnergyByTechnologyImporter(types.Iberian, srv.Options()).ImportAllSystems(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("Expected the systems that answered to be imported, got %v", err)
+	}
+	for _, system := range []types.SystemType{types.Spain, types.Iberian} {
+		if days := results[system]; len(days) != 2 {
+			t.Errorf("Expected both days of %s, got %d", system, len(days))
+		}
+	}
+	if days, ok := results[types.Portugal]; ok {
+		t.Errorf("Expected no days for Portugal, got %d", len(days))
+	}
+
+	// An error is only returned when no system could be imported
+	for _, system := range systems {
+		for date := range (types.DateRange{Start: start, End: end}).All() {
+			srv.Inject(EnergyByTechnologyURL(system, date), Fault{Status: http.StatusInternalServerError})
+		}
+	}
+	if _, err := importers.NewEnergyByTechnologyImporter(types.Iberian, srv.Options()).ImportAllSystems(context.Background(), start, end); err == nil {
+		t.Error("Expected an error when every system fails")
+	}
+}
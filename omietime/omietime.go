@@ -0,0 +1,111 @@
+// Package omietime maps the hour and quarter-hour indexes used in OMIE files to instants
+// and wall-clock times. OMIE counts hours from midnight in Spanish time, so a market day
+// has 23 hours when DST starts, skipping 02:00-03:00, and 25 when it ends, going through
+// 02:00-03:00 twice.
+package omietime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Slot is an hour or quarter-hour period of a market day
+type Slot struct {
+	Index int       // 1-based index as used in OMIE files
+	Start time.Time // Instant the slot starts, in UTC
+	End   time.Time // Instant the slot ends, in UTC
+
+	// Label is the wall-clock interval in Spanish time with its time zone, e.g.
+	// "02:00-03:00 CEST". Its zone tells the two slots of the repeated hour apart.
+	Label string
+
+	// Repeated is set on the slots whose wall-clock time occurs twice on the day DST ends
+	Repeated bool
+}
+
+// Hours returns the number of hours of the market day of date: 23, 24 or 25
+func Hours(date time.Time) int {
+	return types.HoursInDay(date)
+}
+
+// Periods returns the number of quarter-hour periods of the market day of date: 92, 96
+// or 100
+func Periods(date time.Time) int {
+	return Hours(date) * types.QuartersPerHour
+}
+
+// Hour returns hour index of the market day of date, failing if the day has no such hour
+func Hour(date time.Time, index int) (Slot, error) {
+	if index < 1 || index > Hours(date) {
+		return Slot{}, types.NewOMIEError(types.ErrCodeInvalidData,
+			fmt.Sprintf("hour %d out of range (1-%d) on %s", index, Hours(date), date.Format("2006-01-02")), nil)
+	}
+	return newSlot(index, types.HourIndex(index).Start(date), time.Hour), nil
+}
+
+// Period returns quarter-hour period index of the market day of date, failing if the day
+// has no such period
+func Period(date time.Time, index int) (Slot, error) {
+	if index < 1 || index > Periods(date) {
+		return Slot{}, types.NewOMIEError(types.ErrCodeInvalidData,
+			fmt.Sprintf("period %d out of range (1-%d) on %s", index, Periods(date), date.Format("2006-01-02")), nil)
+	}
+	return newSlot(index, types.PeriodIndex(index).Start(date), 15*time.Minute), nil
+}
+
+// Day returns every hour of the market day of date in order
+func Day(date time.Time) []Slot {
+	slots := make([]Slot, Hours(date))
+	for i := range slots {
+		slots[i] = newSlot(i+1, types.HourIndex(i+1).Start(date), time.Hour)
+	}
+	return slots
+}
+
+// Locate returns the market day, as midnight UTC like the dates of parsed files, and the
+// hour index an instant falls in
+func Locate(instant time.Time) (date time.Time, hour int) {
+	date, elapsed := locate(instant)
+	return date, int(elapsed/time.Hour) + 1
+}
+
+// LocatePeriod returns the market day and the quarter-hour period index an instant
+// falls in, see Locate
+func LocatePeriod(instant time.Time) (date time.Time, period int) {
+	date, elapsed := locate(instant)
+	return date, int(elapsed/(15*time.Minute)) + 1
+}
+
+// locate returns the market day of instant and the time elapsed since it started
+func locate(instant time.Time) (time.Time, time.Duration) {
+	local := instant.In(types.Spain.Location())
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	return date, instant.Sub(types.HourIndex(1).Start(date))
+}
+
+// newSlot builds the slot of the given length starting at start
+func newSlot(index int, start time.Time, length time.Duration) Slot {
+	local := start.In(types.Spain.Location())
+	zone, offset := local.Zone()
+
+	// The end is shown in the zone of the start, so the first 02:00 hour of the day DST
+	// ends reads "02:00-03:00 CEST" rather than "02:00-02:00"
+	end := start.Add(length).In(time.FixedZone(zone, offset))
+
+	repeated := false
+	for _, other := range []time.Time{start.Add(-time.Hour), start.Add(time.Hour)} {
+		if other.In(types.Spain.Location()).Format("15:04") == local.Format("15:04") {
+			repeated = true
+		}
+	}
+
+	return Slot{
+		Index:    index,
+		Start:    start.UTC(),
+		End:      start.Add(length).UTC(),
+		Label:    fmt.Sprintf("%s-%s %s", local.Format("15:04"), end.Format("15:04"), zone),
+		Repeated: repeated,
+	}
+}
@@ -0,0 +1,85 @@
+package omietime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHour(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     time.Time
+		index    int
+		start    string // UTC
+		label    string
+		repeated bool
+	}{
+		{"regular day", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 15, "2024-01-15T13:00:00Z", "14:00-15:00 CET", false},
+		{"before DST starts", time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 2, "2024-03-31T00:00:00Z", "01:00-02:00 CET", false},
+		{"skipped hour", time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 3, "2024-03-31T01:00:00Z", "03:00-04:00 CEST", false},
+		{"first repeated hour", time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 3, "2022-10-30T00:00:00Z", "02:00-03:00 CEST", true},
+		{"second repeated hour", time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 4, "2022-10-30T01:00:00Z", "02:00-03:00 CET", true},
+		{"last hour of a long day", time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 25, "2022-10-30T22:00:00Z", "23:00-00:00 CET", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, err := Hour(tt.date, tt.index)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := slot.Start.Format(time.RFC3339); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if slot.End.Sub(slot.Start) != time.Hour {
+				t.Errorf("expected a one hour slot, got %s", slot.End.Sub(slot.Start))
+			}
+			if slot.Label != tt.label {
+				t.Errorf("label = %q, want %q", slot.Label, tt.label)
+			}
+			if slot.Repeated != tt.repeated {
+				t.Errorf("repeated = %v, want %v", slot.Repeated, tt.repeated)
+			}
+
+			date, hour := Locate(slot.Start.Add(30 * time.Minute))
+			if !date.Equal(tt.date) || hour != tt.index {
+				t.Errorf("Locate() = %s hour %d, want %s hour %d", date.Format("2006-01-02"), hour, tt.date.Format("2006-01-02"), tt.index)
+			}
+		})
+	}
+
+	if _, err := Hour(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 24); err == nil {
+		t.Error("expected an error for hour 24 on a 23-hour day")
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	date := time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC) // DST ends
+
+	if Periods(date) != 100 {
+		t.Fatalf("expected 100 periods, got %d", Periods(date))
+	}
+
+	slot, err := Period(date, 14) // Second quarter of the second 02:00 hour
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slot.Label != "02:15-02:30 CET" || !slot.Repeated {
+		t.Errorf("expected the repeated 02:15-02:30 CET period, got %+v", slot)
+	}
+	if _, period := LocatePeriod(slot.Start); period != 14 {
+		t.Errorf("LocatePeriod() = %d, want 14", period)
+	}
+}
+
+func TestDay(t *testing.T) {
+	slots := Day(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	if len(slots) != 23 {
+		t.Fatalf("expected 23 hours, got %d", len(slots))
+	}
+	for i := 1; i < len(slots); i++ {
+		if !slots[i].Start.Equal(slots[i-1].End) {
+			t.Errorf("hour %d doesn't start when hour %d ends", slots[i].Index, slots[i-1].Index)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package parsers
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// AggregatedCurveParser parses the daily aggregated supply/demand curve files (curva_pbc),
+// which hold the aggregated curves of every hour of a day without unit identity and are
+// much smaller than the files by unit. The layout is assumed to be that of the files by
+// unit without the unit column; it hasn't been checked against a published file yet.
+type AggregatedCurveParser struct{}
+
+// NewAggregatedCurveParser creates a new aggregated curve parser
+func NewAggregatedCurveParser() *AggregatedCurveParser {
+	return &AggregatedCurveParser{}
+}
+
+// ParseResponse parses aggregated curves from an HTTP response
+func (p *AggregatedCurveParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses aggregated curves from a file
+func (p *AggregatedCurveParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses aggregated curves from a reader, returning a *types.MarketCurveDay
+// with one Aggregated curve per hour (or period)
+func (p *AggregatedCurveParser) ParseReader(reader io.Reader) (interface{}, error) {
+	day, err := parseCurveDay(reader, types.Aggregated)
+	if err != nil {
+		return nil, err
+	}
+	return day, nil
+}
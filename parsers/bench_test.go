@@ -0,0 +1,121 @@
+package parsers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// loadFixture reads a testdata file into memory so benchmarks exclude disk I/O
+func loadFixture(b *testing.B, filename string) []byte {
+	b.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		b.Fatalf("failed to read fixture %s: %v", filename, err)
+	}
+	return data
+}
+
+func BenchmarkParseFloat(b *testing.B) {
+	inputs := []string{"6,694", "1.071,6", "24326,2", "  13631,0", "15.934", "3.14", ""}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			_, _ = ParseFloat(input)
+		}
+	}
+}
+
+func BenchmarkParseHour(b *testing.B) {
+	inputs := []string{"1", "13", " 24", "25"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			_, _ = ParseHour(input)
+		}
+	}
+}
+
+func BenchmarkSplitCSV(b *testing.B) {
+	line := "13/11/2020;1;1.432,0;;;6.088,9;2.405,9;3.191,6;7.371,1;25,7;3,7;6.292,4;;2.400,0;"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = SplitCSV(line)
+	}
+}
+
+func BenchmarkReadLines(b *testing.B) {
+	data := loadFixture(b, "../testdata/OfferAndDemandCurve_1_20090102.TXT")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadLines(NewISO88591Reader(bytes.NewReader(data))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseMarginalPrice(b *testing.B) {
+	fixtures := []struct {
+		name     string
+		filename string
+	}{
+		{"2006", "../testdata/PMD_20060101.txt"},
+		{"2009", "../testdata/PMD_20090601.txt"},
+		{"2022", "../testdata/PMD_20221030.txt"},
+	}
+
+	for _, fixture := range fixtures {
+		data := loadFixture(b, fixture.filename)
+
+		b.Run(fixture.name, func(b *testing.B) {
+			parser := NewMarginalPriceParser()
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.ParseReader(NewISO88591Reader(bytes.NewReader(data))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseTechnology(b *testing.B) {
+	data := loadFixture(b, "../testdata/EnergyByTechnology_9_20201113.TXT")
+	parser := NewEnergyByTechnologyParser()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseReader(NewISO88591Reader(bytes.NewReader(data))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseUnitOfferCurve(b *testing.B) {
+	// A day of offers by bidding unit runs to hundreds of thousands of lines
+	var file bytes.Buffer
+	file.WriteString("OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario incluyendo unidades;;;;\n")
+	file.WriteString("Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C);\n")
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&file, "%d;05/03/2024;MI;UNIT%d;V;1.010,0;%d,50;O;\n", i%24+1, i%500, i%180)
+	}
+	data := file.Bytes()
+	parser := NewUnitOfferCurveParser()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package parsers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/devuo/omiedata/internal/atomicfile"
+	"github.com/devuo/omiedata/types"
+)
+
+// ParserVersion identifies the behaviour of the parsers in this package. It is bumped
+// whenever a parser change alters the result produced for the same input file, which
+// invalidates every result cached by earlier versions.
+const ParserVersion = 15
+
+// ParseCache stores parsed results keyed by the SHA-256 of the raw file contents, so
+// identical files (re-downloaded, or present in several folders) are only parsed once.
+// Results are kept in memory and, when created with a directory, persisted to disk as
+// versioned JSON envelopes. Cached results are shared and must not be modified.
+type ParseCache struct {
+	mu      sync.RWMutex
+	entries map[string]interface{}
+	dir     string
+}
+
+// NewParseCache creates an in-memory parse cache
+func NewParseCache() *ParseCache {
+	return &ParseCache{entries: make(map[string]interface{})}
+}
+
+// NewDiskParseCache creates a parse cache that also persists results under dir
+func NewDiskParseCache(dir string) (*ParseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to create cache folder", err)
+	}
+
+	cache := NewParseCache()
+	cache.dir = dir
+	return cache, nil
+}
+
+// Get returns the cached result for key, if any
+func (c *ParseCache) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	result, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok || c.dir == "" {
+		return result, ok
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	result, err = types.Decode(data)
+	if err != nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.entries[key] = result
+	c.mu.Unlock()
+
+	return result, true
+}
+
+// Put stores a result under key
+func (c *ParseCache) Put(key string, result interface{}) {
+	c.mu.Lock()
+	c.entries[key] = result
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return
+	}
+
+	data, err := types.Encode(result)
+	if err != nil {
+		return // Result type can't be persisted, keep it in memory only
+	}
+
+	// Write atomically so readers never see partial entries
+	_ = atomicfile.WriteFile(c.path(key), data)
+}
+
+// Prune clears the in-memory entries and removes results persisted by other parser versions
+func (c *ParseCache) Prune() error {
+	c.mu.Lock()
+	c.entries = make(map[string]interface{})
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return nil
+	}
+
+	current := fmt.Sprintf("v%d", ParserVersion)
+	versionDirs, err := filepath.Glob(filepath.Join(c.dir, "*", "v*"))
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range versionDirs {
+		if filepath.Base(dir) == current {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return types.NewOMIEError(types.ErrCodeParse, "failed to prune cache", err)
+		}
+	}
+
+	return nil
+}
+
+// path returns the on-disk location for a cache key
+func (c *ParseCache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key)+".json")
+}
+
+// CachedParser wraps a Parser, serving results from a ParseCache when the same file
+// contents have been parsed before by a parser of the same type and configuration.
+// Results are shared by every caller parsing the same contents and must not be modified.
+type CachedParser struct {
+	parser Parser
+	cache  *ParseCache
+	name   string
+}
+
+// NewCachedParser creates a parser that caches the results of parser in cache
+func NewCachedParser(parser Parser, cache *ParseCache) *CachedParser {
+	name := fmt.Sprintf("%T", parser)
+	name = strings.TrimPrefix(name[strings.LastIndex(name, ".")+1:], "*")
+
+	return &CachedParser{
+		parser: parser,
+		cache:  cache,
+		name:   name,
+	}
+}
+
+// ParseResponse parses data from an HTTP response, using the cache when possible
+func (p *CachedParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to read response", err)
+	}
+
+	return p.parse(raw, func() (interface{}, error) {
+		clone := *resp
+		clone.Body = io.NopCloser(bytes.NewReader(raw))
+		return p.parser.ParseResponse(&clone)
+	})
+}
+
+// ParseFile parses data from a file, using the cache when possible
+func (p *CachedParser) ParseFile(filename string) (interface{}, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+
+	return p.parse(raw, func() (interface{}, error) {
+		return p.parser.ParseFile(filename)
+	})
+}
+
+// ParseReader parses data from any io.Reader, using the cache when possible
+func (p *CachedParser) ParseReader(reader io.Reader) (interface{}, error) {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to read data", err)
+	}
+
+	return p.parse(raw, func() (interface{}, error) {
+		return p.parser.ParseReader(bytes.NewReader(raw))
+	})
+}
+
+// configurable is implemented by parsers whose results depend on their settings, e.g.
+// the concepts a MarginalPriceParser loads
+type configurable interface {
+	// configuration describes every setting that changes the parsed results
+	configuration() string
+}
+
+// key returns the cache key for raw file contents. Keys of configurable parsers include a
+// hash of their configuration, so differently configured parsers sharing a cache don't
+// serve each other's results.
+func (p *CachedParser) key(raw []byte) string {
+	prefix := fmt.Sprintf("%s/v%d", p.name, ParserVersion)
+	if parser, ok := p.parser.(configurable); ok {
+		config := sha256.Sum256([]byte(parser.configuration()))
+		prefix += "/" + hex.EncodeToString(config[:8])
+	}
+
+	sum := sha256.Sum256(raw)
+	return prefix + "/" + hex.EncodeToString(sum[:])
+}
+
+// parse looks up the hash of raw in the cache, falling back to parseFn on a miss
+func (p *CachedParser) parse(raw []byte, parseFn func() (interface{}, error)) (interface{}, error) {
+	key := p.key(raw)
+
+	if result, ok := p.cache.Get(key); ok {
+		return result, nil
+	}
+
+	result, err := parseFn()
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache.Put(key, result)
+	return result, nil
+}
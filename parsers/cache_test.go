@@ -0,0 +1,134 @@
+package parsers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestCachedParser_MemoryCache(t *testing.T) {
+	cache := NewParseCache()
+	parser := NewCachedParser(NewMarginalPriceParser(), cache)
+
+	first, err := parser.ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := parser.ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("expected second parse to be served from cache")
+	}
+}
+
+func TestCachedParser_DiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewDiskParseCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	original, err := NewCachedParser(NewEnergyByTechnologyParser(), cache).ParseFile("../testdata/EnergyByTechnology_9_20201113.TXT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A fresh cache over the same folder must find the persisted result
+	reopened, err := NewDiskParseCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile("../testdata/EnergyByTechnology_9_20201113.TXT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parser := NewCachedParser(NewEnergyByTechnologyParser(), reopened)
+	key := parser.key(data)
+	cached, ok := reopened.Get(key)
+	if !ok {
+		t.Fatalf("expected persisted cache entry for %s", key)
+	}
+
+	if !original.(*types.TechnologyEnergyDay).Equal(cached.(*types.TechnologyEnergyDay), 1e-9) {
+		t.Errorf("persisted result differs: %v", types.Diff(original, cached, 1e-9))
+	}
+
+	// Entries written by other parser versions are removed by Prune
+	stale := filepath.Join(dir, "EnergyByTechnologyParser", "v0")
+	if err := os.MkdirAll(stale, 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := reopened.Prune(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected stale version folder to be pruned")
+	}
+
+	if _, ok := reopened.Get(key); !ok {
+		t.Errorf("expected current version entry to survive pruning")
+	}
+}
+
+func TestCachedParser_Configuration(t *testing.T) {
+	cache := NewParseCache()
+
+	all, err := NewCachedParser(NewMarginalPriceParser(), cache).ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A parser loading fewer concepts must not be served the result of the default parser
+	spain, err := NewCachedParser(NewMarginalPriceParser(types.PriceSpain), cache).ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spain == all {
+		t.Fatal("expected differently configured parsers to keep their results apart")
+	}
+	if prices := spain.(*types.MarginalPriceData); len(prices.SpainPrices) == 0 || len(prices.PortugalPrices) != 0 {
+		t.Errorf("expected only Spanish prices, got %d Spanish and %d Portuguese", len(prices.SpainPrices), len(prices.PortugalPrices))
+	}
+
+	data, err := os.ReadFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := map[string]string{}
+	strict := NewMarginalPriceParser()
+	strict.Strict = true
+	registered := NewMarginalPriceParser()
+	if err := registered.RegisterConcept("Precio marginal (EUR/MWh)", types.PriceSpain, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, parser := range map[string]Parser{
+		"default":    NewMarginalPriceParser(),
+		"spain":      NewMarginalPriceParser(types.PriceSpain),
+		"strict":     strict,
+		"registered": registered,
+	} {
+		key := NewCachedParser(parser, cache).key(data)
+		if other, ok := keys[key]; ok {
+			t.Errorf("%s and %s parsers share the cache key %s", name, other, key)
+		}
+		keys[key] = name
+	}
+
+	// Concepts are loaded whatever the order they were given in
+	reordered := NewCachedParser(NewMarginalPriceParser(types.PricePortugal, types.PriceSpain), cache)
+	if key := NewCachedParser(NewMarginalPriceParser(types.PriceSpain, types.PricePortugal), cache).key(data); reordered.key(data) != key {
+		t.Error("expected the order of the concepts to load not to change the cache key")
+	}
+}
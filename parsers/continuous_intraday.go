@@ -0,0 +1,172 @@
+package parsers
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// ContinuousIntradayParser parses the intraday continuous market (MIC) price files, which
+// hold the minimum, maximum and volume-weighted prices and the traded energy of every
+// delivery period of a day
+type ContinuousIntradayParser struct{}
+
+// NewContinuousIntradayParser creates a new intraday continuous market parser
+func NewContinuousIntradayParser() *ContinuousIntradayParser {
+	return &ContinuousIntradayParser{}
+}
+
+// ParseResponse parses intraday continuous market prices from an HTTP response
+func (p *ContinuousIntradayParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses intraday continuous market prices from a file
+func (p *ContinuousIntradayParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses intraday continuous market prices from a reader, returning a
+// *types.ContinuousIntradayDay. Columns are located by their headers, so their order
+// doesn't matter; when a header appears more than once the first column is used.
+func (p *ContinuousIntradayParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	date, err := scanCurveHeader(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, found := p.parseColumnHeaders(lines.Text())
+	for !found && lines.Scan() {
+		columns, found = p.parseColumnHeaders(lines.Text())
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		if lines.Line() < 3 {
+			return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+		}
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no period column found", nil)
+	}
+
+	result := &types.ContinuousIntradayDay{Date: date}
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+
+		price, err := p.parseDataLine(line, date, columns)
+		if err != nil {
+			continue // Skip invalid and trailing empty lines
+		}
+
+		if price.Period > types.MaxHourIndex {
+			result.Resolution = types.QuarterHourly
+		}
+		result.Prices = append(result.Prices, price)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if lines.Line() < 3 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
+	if len(result.Prices) == 0 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data found", nil)
+	}
+
+	sort.SliceStable(result.Prices, func(i, j int) bool {
+		return result.Prices[i].Period < result.Prices[j].Period
+	})
+
+	return result, nil
+}
+
+// continuousIntradayColumns holds the index of each known column, -1 if absent
+type continuousIntradayColumns struct {
+	period, min, max, weighted, energy int
+}
+
+// parseColumnHeaders finds the columns of the values in a line, reporting whether it
+// is the column headers line, the one naming the period column
+func (p *ContinuousIntradayParser) parseColumnHeaders(line string) (continuousIntradayColumns, bool) {
+	columns := continuousIntradayColumns{period: -1, min: -1, max: -1, weighted: -1, energy: -1}
+
+	for j, field := range SplitCSV(line) {
+		field = strings.ToLower(strings.TrimSpace(field))
+		switch {
+		case field == "hora" || field == "periodo":
+			setColumn(&columns.period, j)
+		case strings.Contains(field, "mínimo"):
+			setColumn(&columns.min, j)
+		case strings.Contains(field, "máximo"):
+			setColumn(&columns.max, j)
+		case strings.Contains(field, "medio"):
+			setColumn(&columns.weighted, j)
+		case strings.Contains(field, "energía"):
+			setColumn(&columns.energy, j)
+		}
+	}
+
+	return columns, columns.period != -1
+}
+
+// setColumn records index as the column of a header unless an earlier column had it
+func setColumn(column *int, index int) {
+	if *column == -1 {
+		*column = index
+	}
+}
+
+// parseDataLine parses a single delivery period, leaving NaN for missing values
+func (p *ContinuousIntradayParser) parseDataLine(line string, date time.Time, columns continuousIntradayColumns) (types.ContinuousIntradayPrice, error) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
+
+	fields := *fieldsPtr
+	if columns.period >= len(fields) {
+		return types.ContinuousIntradayPrice{}, types.NewOMIEError(types.ErrCodeParse, "insufficient fields", nil)
+	}
+
+	period, err := ParsePeriod(fields[columns.period])
+	if err != nil {
+		return types.ContinuousIntradayPrice{}, err
+	}
+
+	value := func(column int) float64 {
+		if column == -1 || column >= len(fields) {
+			return math.NaN()
+		}
+		v, err := ParseFloat(fields[column])
+		if err != nil {
+			return math.NaN()
+		}
+		return v
+	}
+
+	return types.ContinuousIntradayPrice{
+		Date:          date,
+		Period:        period.Int(),
+		MinPrice:      value(columns.min),
+		MaxPrice:      value(columns.max),
+		WeightedPrice: value(columns.weighted),
+		Energy:        value(columns.energy),
+	}, nil
+}
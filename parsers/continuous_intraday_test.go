@@ -0,0 +1,72 @@
+package parsers
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestContinuousIntradayParser_ParseReader(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :16/01/2024 - 00:15;;15/01/2024;Precios del mercado intradiario continuo;;;;
+;
+Fecha;Hora;Precio mínimo (EUR/MWh);Precio máximo (EUR/MWh);Precio medio ponderado (EUR/MWh);Energía negociada (MWh);
+15/01/2024;2;70,10;95,00;81,25;1.204,5;
+15/01/2024;1;65,00;90,50;78,40;1.530,2;
+15/01/2024;3;;;;0,0;
+;;;;;;
+`
+
+	result, err := NewContinuousIntradayParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day, ok := result.(*types.ContinuousIntradayDay)
+	if !ok {
+		t.Fatalf("expected *types.ContinuousIntradayDay, got %T", result)
+	}
+
+	if !day.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", day.Date)
+	}
+	if day.Resolution != types.Hourly {
+		t.Errorf("expected hourly resolution, got %v", day.Resolution)
+	}
+	if len(day.Prices) != 3 || day.Prices[0].Period != 1 || day.Prices[2].Period != 3 {
+		t.Fatalf("expected periods 1-3 in order, got %+v", day.Prices)
+	}
+
+	first := day.Prices[0]
+	if first.MinPrice != 65 || first.MaxPrice != 90.5 || first.WeightedPrice != 78.4 || first.Energy != 1530.2 {
+		t.Errorf("unexpected period 1: %+v", first)
+	}
+
+	// Periods without trades have no prices
+	if last := day.Prices[2]; !math.IsNaN(last.WeightedPrice) || last.Energy != 0 {
+		t.Errorf("expected NaN prices and zero energy for period 3, got %+v", last)
+	}
+}
+
+func TestContinuousIntradayParser_QuarterHourly(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :02/10/2025 - 00:15;;01/10/2025;Precios del mercado intradiario continuo;;;;
+;
+Periodo;Precio medio ponderado (EUR/MWh);Energía negociada (MWh);
+96;101,20;250,0;
+`
+
+	result, err := NewContinuousIntradayParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := result.(*types.ContinuousIntradayDay)
+	if day.Resolution != types.QuarterHourly {
+		t.Errorf("expected quarter-hourly resolution, got %v", day.Resolution)
+	}
+	if price := day.Prices[0]; price.Period != 96 || price.WeightedPrice != 101.2 || !math.IsNaN(price.MinPrice) {
+		t.Errorf("unexpected period 96: %+v", price)
+	}
+}
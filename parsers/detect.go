@@ -0,0 +1,121 @@
+package parsers
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// sniffSize is the number of bytes Detect inspects, enough for the title and column
+// headers of every supported format
+const sniffSize = 4096
+
+// Detect returns the parser for the file read from reader, recognized by its first
+// lines: the title of the OMIE files, the MARGINALPDBC marker or the column headers of
+// the OMIP settlement reports. Intraday session prices share the layout of the marginal
+// price files and get a MarginalPriceParser.
+//
+// Detect consumes the start of reader, so the file must be read again to be parsed; use
+// ParseDetected to detect and parse a single stream.
+func Detect(reader io.Reader) (Parser, error) {
+	header, err := io.ReadAll(io.LimitReader(reader, sniffSize))
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to read file header", err)
+	}
+	return detectHeader(header)
+}
+
+// DetectFile returns the parser for a file, see Detect
+func DetectFile(filename string) (Parser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return Detect(file)
+}
+
+// ParseDetected detects the format of the raw file read from reader and parses it,
+// decoding it like ParseResponse would
+func ParseDetected(reader io.Reader) (interface{}, error) {
+	buffered := bufio.NewReaderSize(reader, sniffSize)
+	header, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to read file header", err)
+	}
+
+	parser, err := detectHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseResponse(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(buffered)})
+}
+
+// detectHeader returns the parser for a file starting with header. Only ASCII keywords
+// are matched so the result doesn't depend on the encoding of the file.
+func detectHeader(header []byte) (Parser, error) {
+	lines := strings.Split(strings.ToLower(string(header)), "\n")
+	title := strings.TrimPrefix(strings.TrimSpace(lines[0]), "\ufeff")
+
+	switch {
+	case strings.HasPrefix(title, "marginalpdbc"):
+		return NewMarginalPDBCParser(), nil
+	case strings.HasPrefix(title, "omie") || strings.HasPrefix(title, "omel"):
+		if parser := detectTitle(title); parser != nil {
+			return parser, nil
+		}
+	}
+
+	for _, line := range lines {
+		if isFuturesHeader(line) {
+			return NewFuturesPriceParser(), nil
+		}
+	}
+
+	return nil, types.NewOMIEError(types.ErrCodeParse, "unrecognized file format", nil)
+}
+
+// detectTitle returns the parser for an OMIE file with the given lowercased title line,
+// or nil if the title isn't recognized
+func detectTitle(title string) Parser {
+	switch {
+	case strings.Contains(title, "curvas agregadas") && strings.Contains(title, "unidades"):
+		return NewUnitOfferCurveParser()
+	case strings.Contains(title, "curvas agregadas"):
+		return NewAggregatedCurveParser()
+	case strings.Contains(title, "mercado diario - hora") || strings.Contains(title, "mercado diario - periodo"):
+		return NewSupplyDemandCurveParser()
+	case strings.Contains(title, "tecnolog"):
+		return NewEnergyByTechnologyParser()
+	case strings.Contains(title, "interconexi"):
+		return NewInterconnectionParser()
+	case strings.Contains(title, "intradiario continuo"):
+		return NewContinuousIntradayParser()
+	case strings.Contains(title, "medias mensuales"):
+		return NewMonthlyPriceParser()
+	case strings.Contains(title, "precio"):
+		return NewMarginalPriceParser()
+	}
+	return nil
+}
+
+// isFuturesHeader reports whether a lowercased line holds the contract and settlement
+// price column headers of an OMIP report
+func isFuturesHeader(line string) bool {
+	var contract, price bool
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ';' || r == ',' }) {
+		name := strings.TrimSpace(field)
+		switch {
+		case name == "contract" || name == "instrument" || name == "contrato":
+			contract = true
+		case strings.Contains(name, "settlement") || strings.Contains(name, "reference price") || strings.HasPrefix(name, "pre"):
+			price = true
+		}
+	}
+	return contract && price
+}
@@ -0,0 +1,64 @@
+package parsers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   Parser
+	}{
+		{"marginal price", "OMEL - Mercado de electricidad;Fecha Emisión :31/05/2009 - 10:45;;01/06/2009;Precio del mercado diario (cent/kWh);;;;", &MarginalPriceParser{}},
+		{"intraday price", "OMIE - Mercado de electricidad;Fecha Emisión :13/01/2015 - 12:58;;02/01/2009;Precio del mercado intradiario (cent/kWh) - Sesión - Nº 2;;;;", &MarginalPriceParser{}},
+		{"energy by technology", "OMIE - Mercado de electricidad;Fecha Emisión :19/05/2021 - 20:01;; - Mercado Ibérico - 13/11/2020;Energía horaria por tecnologías (MWh);;;;", &EnergyByTechnologyParser{}},
+		{"hourly curve", "OMEL - Mercado de electricidad;Fecha Emisión :01/01/2009 - 10:55;;02/01/2009;Mercado diario - Hora 1;;;;", &SupplyDemandCurveParser{}},
+		{"aggregated curve", "OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario;;;;", &AggregatedCurveParser{}},
+		{"unit offer curve", "OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario incluyendo unidades;;;;", &UnitOfferCurveParser{}},
+		{"interconnection", "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Capacidad de interconexión y rentas de congestión;;;;", &InterconnectionParser{}},
+		{"continuous intraday", "OMIE - Mercado de electricidad;Fecha Emisión :16/01/2024 - 00:15;;15/01/2024;Precios del mercado intradiario continuo;;;;", &ContinuousIntradayParser{}},
+		{"monthly price", "OMIE - Mercado de electricidad;Fecha Emisión :01/02/2024 - 10:00;;31/01/2024;Medias mensuales del mercado diario;;", &MonthlyPriceParser{}},
+		{"marginalpdbc", "MARGINALPDBC;\n2024;01;15;1;74.50;74.50;", &MarginalPDBCParser{}},
+		{"futures", "OMIP - Settlement prices;15/01/2025\nContract;Last;Settlement price", &FuturesPriceParser{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := Detect(strings.NewReader(tt.header + "\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, want := fmt.Sprintf("%T", parser), fmt.Sprintf("%T", tt.want); got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+
+	if _, err := Detect(strings.NewReader("date,value\n2024-01-01,1\n")); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestParseDetected(t *testing.T) {
+	file, err := os.Open("../testdata/EnergyByTechnology_9_20201113.TXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	result, err := ParseDetected(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day, ok := result.(*types.TechnologyEnergyDay)
+	if !ok || len(day.Records) == 0 {
+		t.Errorf("expected energy by technology records, got %T", result)
+	}
+}
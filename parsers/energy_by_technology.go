@@ -1,10 +1,10 @@
 package parsers
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
@@ -12,17 +12,25 @@ import (
 )
 
 // EnergyByTechnologyParser parses energy by technology files
-type EnergyByTechnologyParser struct{}
+type EnergyByTechnologyParser struct {
+	// Strict fails parsing on rows and values that can't be parsed, which are otherwise
+	// skipped. Empty values and rows are still accepted.
+	Strict bool
+}
 
 // NewEnergyByTechnologyParser creates a new energy by technology parser
 func NewEnergyByTechnologyParser() *EnergyByTechnologyParser {
 	return &EnergyByTechnologyParser{}
 }
 
+// configuration describes the settings that change the parsed results, see CachedParser
+func (p *EnergyByTechnologyParser) configuration() string {
+	return fmt.Sprintf("strict=%t", p.Strict)
+}
+
 // ParseResponse parses energy by technology data from an HTTP response
 func (p *EnergyByTechnologyParser) ParseResponse(resp *http.Response) (interface{}, error) {
-	reader := NewISO88591Reader(resp.Body)
-	return p.ParseReader(reader)
+	return p.ParseReader(resp.Body)
 }
 
 // ParseFile parses energy by technology data from a file
@@ -33,65 +41,93 @@ func (p *EnergyByTechnologyParser) ParseFile(filename string) (interface{}, erro
 	}
 	defer file.Close()
 
-	reader := NewISO88591Reader(file)
-	return p.ParseReader(reader)
+	return p.ParseReader(file)
 }
 
 // ParseReader parses energy by technology data from a reader
 func (p *EnergyByTechnologyParser) ParseReader(reader io.Reader) (interface{}, error) {
-	lines, err := ReadLines(reader)
-	if err != nil {
-		return nil, err
-	}
+	lines := NewLineScanner(reader)
+	defer lines.Close()
 
-	if len(lines) < 3 {
+	if !lines.Scan() {
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
 		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
 	}
 
 	// Parse date and system from header
-	date, system, err := p.parseHeader(lines[0])
+	header := lines.Text()
+	date, system, err := p.parseHeader(header)
 	if err != nil {
 		return nil, err
 	}
 
-	// Find column headers line and parse column mapping
-	columnMapping, headerLineIndex := p.parseColumnHeaders(lines)
+	// Find column headers line and parse column mapping, which may be the first line
+	columnMapping := p.parseColumnHeaders(header)
+	for columnMapping == nil && lines.Scan() {
+		columnMapping = p.parseColumnHeaders(lines.Text())
+	}
 	if len(columnMapping) == 0 {
-		return nil, types.NewOMIEError(types.ErrCodeParse, "no technology columns found", nil)
+		return nil, p.missingColumns(lines)
 	}
 
 	// Parse data lines
 	var records []types.TechnologyEnergy
-	for i := headerLineIndex + 1; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
+	skipped := &skippedLines{strict: p.Strict}
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || isBlankRow(line) {
 			continue
 		}
 
-		record, err := p.parseDataLine(line, date, system, columnMapping)
+		record, err := p.parseDataLine(line, lines.Line(), date, system, columnMapping, skipped)
 		if err != nil {
-			continue // Skip invalid lines
+			// Skip invalid lines, unless strict
+			if err := skipped.skipRow(lines.Line(), line, err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		records = append(records, *record)
 	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
 
+	if lines.Line() < 3 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
 	if len(records) == 0 {
 		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data records found", nil)
 	}
 
 	return &types.TechnologyEnergyDay{
-		Date:    date,
-		System:  system,
-		Records: records,
+		Date:     date,
+		System:   system,
+		Records:  records,
+		Encoding: lines.Encoding(),
+		Warnings: skipped.warnings,
 	}, nil
 }
 
+// missingColumns returns the error of a file in which no column headers line was found
+// before the line lines stopped at
+func (p *EnergyByTechnologyParser) missingColumns(lines *LineScanner) error {
+	if err := lines.Err(); err != nil {
+		return err
+	}
+	if lines.Line() < 3 {
+		return types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
+	return types.NewOMIEError(types.ErrCodeParse, "no technology columns found", nil)
+}
+
 // parseHeader extracts date and system type from the header
 func (p *EnergyByTechnologyParser) parseHeader(headerLine string) (time.Time, types.SystemType, error) {
 	// Extract date
-	dateRegex := regexp.MustCompile(`\d{2}/\d{2}/\d{4}`)
-	dateMatches := dateRegex.FindAllString(headerLine, -1)
+	dateMatches := headerDateRegex.FindAllString(headerLine, -1)
 
 	if len(dateMatches) == 0 {
 		return time.Time{}, 0, types.NewOMIEError(types.ErrCodeParse, "no date found in header", nil)
@@ -113,32 +149,25 @@ func (p *EnergyByTechnologyParser) parseHeader(headerLine string) (time.Time, ty
 	return date, system, nil
 }
 
-// parseColumnHeaders finds and parses the column headers to create technology mapping
-func (p *EnergyByTechnologyParser) parseColumnHeaders(lines []string) (map[int]types.TechnologyType, int) {
-	for i, line := range lines {
-		fields := SplitCSV(line)
-		if len(fields) < 3 {
-			continue
-		}
-
-		// Check if this looks like a header line (contains technology names)
-		if p.containsTechnologyNames(fields) {
-			mapping := make(map[int]types.TechnologyType)
-
-			for j, field := range fields {
-				field = strings.TrimSpace(field)
-				// Only add to mapping if it's a recognized technology
-				if _, ok := isKnownTechnology(field); ok {
-					tech := types.TechnologyTypeFromSpanish(field)
-					mapping[j] = tech
-				}
-			}
+// parseColumnHeaders parses the column headers of a line to create technology mapping,
+// returning nil when the line isn't the column headers line
+func (p *EnergyByTechnologyParser) parseColumnHeaders(line string) map[int]types.TechnologyType {
+	fields := SplitCSV(line)
+	if len(fields) < 3 || !p.containsTechnologyNames(fields) {
+		return nil
+	}
 
-			return mapping, i
+	mapping := make(map[int]types.TechnologyType)
+	for j, field := range fields {
+		field = strings.TrimSpace(field)
+		// Only add to mapping if it's a recognized technology
+		if _, ok := isKnownTechnology(field); ok {
+			tech := types.TechnologyTypeFromSpanish(field)
+			mapping[j] = tech
 		}
 	}
 
-	return nil, -1
+	return mapping
 }
 
 // containsTechnologyNames checks if fields contain technology names
@@ -157,31 +186,35 @@ func (p *EnergyByTechnologyParser) containsTechnologyNames(fields []string) bool
 	return false
 }
 
+// knownTechnologies maps the column headers of technology files to technology types
+var knownTechnologies = map[string]types.TechnologyType{
+	"CARBÓN":                           types.Coal,
+	"FUEL-GAS":                         types.FuelGas,
+	"AUTOPRODUCTOR":                    types.SelfProducer,
+	"NUCLEAR":                          types.Nuclear,
+	"HIDRÁULICA":                       types.Hydro,
+	"CICLO COMBINADO":                  types.CombinedCycle,
+	"EÓLICA":                           types.Wind,
+	"SOLAR TÉRMICA":                    types.ThermalSolar,
+	"SOLAR FOTOVOLTAICA":               types.PhotovoltaicSolar,
+	"COGENERACIÓN/RESIDUOS/MINI HIDRA": types.Residuals,
+	"IMPORTACIÓN INTER.":               types.Import,
+	"IMPORTACIÓN INTER. SIN MIBEL":     types.ImportWithoutMIBEL,
+}
+
 // isKnownTechnology checks if a field name is a known technology
 func isKnownTechnology(field string) (types.TechnologyType, bool) {
-	knownTechs := map[string]types.TechnologyType{
-		"CARBÓN":                           types.Coal,
-		"FUEL-GAS":                         types.FuelGas,
-		"AUTOPRODUCTOR":                    types.SelfProducer,
-		"NUCLEAR":                          types.Nuclear,
-		"HIDRÁULICA":                       types.Hydro,
-		"CICLO COMBINADO":                  types.CombinedCycle,
-		"EÓLICA":                           types.Wind,
-		"SOLAR TÉRMICA":                    types.ThermalSolar,
-		"SOLAR FOTOVOLTAICA":               types.PhotovoltaicSolar,
-		"COGENERACIÓN/RESIDUOS/MINI HIDRA": types.Residuals,
-		"IMPORTACIÓN INTER.":               types.Import,
-		"IMPORTACIÓN INTER. SIN MIBEL":     types.ImportWithoutMIBEL,
-	}
-
-	tech, ok := knownTechs[field]
+	tech, ok := knownTechnologies[field]
 	return tech, ok
 }
 
 // parseDataLine parses a single data line
-func (p *EnergyByTechnologyParser) parseDataLine(line string, date time.Time, system types.SystemType, columnMapping map[int]types.TechnologyType) (*types.TechnologyEnergy, error) {
-	fields := SplitCSV(line)
-	if len(fields) < 3 {
+func (p *EnergyByTechnologyParser) parseDataLine(line string, number int, date time.Time, system types.SystemType, columnMapping map[int]types.TechnologyType, skipped *skippedLines) (*types.TechnologyEnergy, error) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
+
+	fields := *fieldsPtr
+	if len(fields) < 2 {
 		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient fields", nil)
 	}
 
@@ -194,7 +227,7 @@ func (p *EnergyByTechnologyParser) parseDataLine(line string, date time.Time, sy
 	// Create record
 	record := &types.TechnologyEnergy{
 		Date:   date,
-		Hour:   hour,
+		Hour:   hour.Int(),
 		System: system,
 	}
 
@@ -204,44 +237,17 @@ func (p *EnergyByTechnologyParser) parseDataLine(line string, date time.Time, sy
 			continue
 		}
 
-		value, err := ParseFloat(fields[colIndex])
+		value, err := ParseGroupedFloat(fields[colIndex]) // Energies in MWh, see ParseGroupedFloat
 		if err != nil {
+			reason := fmt.Sprintf("invalid value %q for %s", strings.TrimSpace(fields[colIndex]), techType)
+			if err := skipped.skip(number, line, reason, err); err != nil {
+				return nil, err
+			}
 			continue // Skip invalid values
 		}
 
-		// Assign to appropriate field
-		p.assignTechnologyValue(record, techType, value)
+		record.Set(techType, value)
 	}
 
 	return record, nil
 }
-
-// assignTechnologyValue assigns a value to the appropriate field in TechnologyEnergy
-func (p *EnergyByTechnologyParser) assignTechnologyValue(record *types.TechnologyEnergy, techType types.TechnologyType, value float64) {
-	switch techType {
-	case types.Coal:
-		record.Coal = value
-	case types.FuelGas:
-		record.FuelGas = value
-	case types.SelfProducer:
-		record.SelfProducer = value
-	case types.Nuclear:
-		record.Nuclear = value
-	case types.Hydro:
-		record.Hydro = value
-	case types.CombinedCycle:
-		record.CombinedCycle = value
-	case types.Wind:
-		record.Wind = value
-	case types.ThermalSolar:
-		record.SolarThermal = value
-	case types.PhotovoltaicSolar:
-		record.SolarPV = value
-	case types.Residuals:
-		record.Cogeneration = value
-	case types.Import:
-		record.ImportInt = value
-	case types.ImportWithoutMIBEL:
-		record.ImportNoMIBEL = value
-	}
-}
@@ -2,6 +2,7 @@ package parsers
 
 import (
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -199,7 +200,7 @@ func TestEnergyByTechnologyParser_ColumnMapping(t *testing.T) {
 	headerLine := "Fecha;Hora;CARBÓN;FUEL-GAS;AUTOPRODUCTOR;NUCLEAR;HIDRÁULICA;CICLO COMBINADO;EÓLICA;SOLAR TÉRMICA;SOLAR FOTOVOLTAICA;COGENERACIÓN/RESIDUOS/MINI HIDRA;IMPORTACIÓN INTER.;IMPORTACIÓN INTER. SIN MIBEL;"
 	fields := SplitCSV(headerLine)
 
-	mapping, _ := parser.parseColumnHeaders([]string{"", "", headerLine})
+	mapping := parser.parseColumnHeaders(headerLine)
 
 	expectedMappings := map[int]types.TechnologyType{
 		2:  types.Coal,
@@ -228,3 +229,33 @@ func TestEnergyByTechnologyParser_ColumnMapping(t *testing.T) {
 		}
 	}
 }
+
+func TestEnergyByTechnologyParser_Strict(t *testing.T) {
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :19/05/2021 - 20:01;; - Mercado Ibérico - 13/11/2020;Energía horaria por tecnologías (MWh);;;;\n\n" +
+		"Fecha;Hora;CARBÓN;NUCLEAR;EÓLICA;\n" +
+		"13/11/2020;1;1.180,0;6.088,9;5.765,7;\n" +
+		"13/11/2020;X;1.180,0;6.088,9;5.765,7;\n" +
+		";;;;;\n"
+
+	lenient, err := NewEnergyByTechnologyParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	day := lenient.(*types.TechnologyEnergyDay)
+	if len(day.Records) != 1 {
+		t.Errorf("expected the invalid row to be skipped, got %d records", len(day.Records))
+	}
+	if len(day.Warnings) != 1 || day.Warnings[0].Line != 5 || !strings.HasPrefix(day.Warnings[0].Text, "13/11/2020;X") {
+		t.Errorf("expected a warning for the invalid row on line 5, got %+v", day.Warnings)
+	}
+
+	strict := &EnergyByTechnologyParser{Strict: true}
+	_, err = strict.ParseReader(strings.NewReader(file))
+	if err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("expected an error pointing at line 5, got %v", err)
+	}
+
+	if _, err := strict.ParseFile("../testdata/EnergyByTechnology_9_20201113.TXT"); err != nil {
+		t.Errorf("unexpected error in strict mode: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package parsers
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// FuturesPriceParser parses OMIP settlement price reports exported as CSV. The columns
+// are located by their headers: the contract name ("Contract", "Instrument" or
+// "Contrato") and the settlement price ("Settlement", "Reference price" or "Preço de
+// referência"). Either commas or semicolons may separate the fields.
+//
+// The trading date is taken from a "Date"/"Trading date" column when present, otherwise
+// from the first date found above the column headers, in DD/MM/YYYY or YYYY-MM-DD form.
+type FuturesPriceParser struct{}
+
+// NewFuturesPriceParser creates a new futures settlement price parser
+func NewFuturesPriceParser() *FuturesPriceParser {
+	return &FuturesPriceParser{}
+}
+
+// ParseResponse parses settlement prices from an HTTP response. OMIP reports are UTF-8.
+func (p *FuturesPriceParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses settlement prices from a file
+func (p *FuturesPriceParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// futuresDateRegex matches the trading dates found in report headers and date columns
+var futuresDateRegex = regexp.MustCompile(`\d{2}/\d{2}/\d{4}|\d{4}-\d{2}-\d{2}`)
+
+// ParseReader parses settlement prices from a reader, returning a *types.FuturesSettlementDay
+// with the contracts whose name could be read. Contracts that aren't base or peak monthly,
+// quarterly or yearly futures (e.g. weekly or daily) are skipped.
+func (p *FuturesPriceParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	var tradingDate time.Time
+	contractColumn, priceColumn, dateColumn := -1, -1, -1
+	splitter := NewFieldSplitter(';')
+	found := false
+
+	for lines.Scan() {
+		line := lines.Text()
+		if !strings.Contains(line, ";") && strings.Contains(line, ",") {
+			splitter.Separator = ','
+		} else {
+			splitter.Separator = ';'
+		}
+
+		contractColumn, priceColumn, dateColumn = -1, -1, -1
+		for j, field := range splitter.Split(line) {
+			field = strings.ToLower(strings.TrimSpace(field))
+			switch {
+			case field == "contract" || field == "instrument" || field == "contrato":
+				contractColumn = j
+			case strings.Contains(field, "settlement") || strings.Contains(field, "reference price") || strings.Contains(field, "referência"):
+				priceColumn = j
+			case field == "date" || field == "trading date" || field == "data":
+				dateColumn = j
+			}
+		}
+
+		if contractColumn != -1 && priceColumn != -1 {
+			found = true
+			break
+		}
+
+		if tradingDate.IsZero() {
+			if match := futuresDateRegex.FindString(line); match != "" {
+				tradingDate, _ = parseFuturesDate(match)
+			}
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no contract and settlement price columns found", nil)
+	}
+
+	day := &types.FuturesSettlementDay{Date: tradingDate}
+	for lines.Scan() {
+		fields := splitter.Split(lines.Text())
+		if contractColumn >= len(fields) || priceColumn >= len(fields) {
+			continue
+		}
+
+		contract := strings.TrimSpace(fields[contractColumn])
+		load, period, start, ok := parseFuturesContract(contract)
+		if !ok {
+			continue
+		}
+
+		price, err := ParseFloat(fields[priceColumn])
+		if err != nil || !IsValidPriceValue(price) {
+			continue
+		}
+
+		date := tradingDate
+		if dateColumn != -1 && dateColumn < len(fields) {
+			if parsed, err := parseFuturesDate(strings.TrimSpace(fields[dateColumn])); err == nil {
+				date = parsed
+			}
+		}
+		if date.IsZero() {
+			return nil, types.NewOMIEError(types.ErrCodeParse, "no trading date found", nil)
+		}
+		if day.Date.IsZero() {
+			day.Date = date
+		}
+
+		day.Settlements = append(day.Settlements, types.FuturesSettlement{
+			TradingDate:   date,
+			Contract:      contract,
+			Load:          load,
+			Period:        period,
+			DeliveryStart: start,
+			Price:         price,
+		})
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(day.Settlements) == 0 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid settlement prices found", nil)
+	}
+
+	return day, nil
+}
+
+// parseFuturesDate parses a DD/MM/YYYY or YYYY-MM-DD date
+func parseFuturesDate(s string) (time.Time, error) {
+	if strings.Contains(s, "-") {
+		return time.Parse("2006-01-02", s)
+	}
+	return ParseDate(s)
+}
+
+var (
+	// futuresMonthRegex matches monthly delivery periods, e.g. "M Jan-25" or "Jan-25"
+	futuresMonthRegex = regexp.MustCompile(`(?i)\b([a-z]{3})-(\d{2})\b`)
+	// futuresQuarterRegex matches quarterly delivery periods, e.g. "Q1-25"
+	futuresQuarterRegex = regexp.MustCompile(`(?i)\bQ([1-4])-(\d{2})\b`)
+	// futuresYearRegex matches yearly delivery periods, e.g. "YR-25" or "Cal-25"
+	futuresYearRegex = regexp.MustCompile(`(?i)\b(?:YR|Y|Cal)-(\d{2})\b`)
+)
+
+// futuresMonths maps the English month abbreviations used in contract names
+var futuresMonths = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// parseFuturesContract reads the load profile and delivery period of a contract name such
+// as "FTB M Jan-25", "FTB Q1-25" or "SPEL Peak YR-26". The load is peak when the name says
+// so and base otherwise, since base contracts (FTB) are the ones usually listed by code.
+func parseFuturesContract(name string) (types.LoadProfile, types.DeliveryPeriod, time.Time, bool) {
+	load := types.BaseLoad
+	if strings.Contains(strings.ToLower(name), "peak") {
+		load = types.PeakLoad
+	}
+
+	if m := futuresQuarterRegex.FindStringSubmatch(name); m != nil {
+		quarter, _ := strconv.Atoi(m[1])
+		year, _ := strconv.Atoi(m[2])
+		return load, types.QuarterDelivery, time.Date(2000+year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, time.UTC), true
+	}
+
+	if m := futuresYearRegex.FindStringSubmatch(name); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		return load, types.YearDelivery, time.Date(2000+year, time.January, 1, 0, 0, 0, 0, time.UTC), true
+	}
+
+	if m := futuresMonthRegex.FindStringSubmatch(name); m != nil {
+		month, ok := futuresMonths[strings.ToLower(m[1])]
+		if !ok {
+			return "", "", time.Time{}, false
+		}
+		year, _ := strconv.Atoi(m[2])
+		return load, types.MonthDelivery, time.Date(2000+year, month, 1, 0, 0, 0, 0, time.UTC), true
+	}
+
+	return "", "", time.Time{}, false
+}
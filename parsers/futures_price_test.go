@@ -0,0 +1,102 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestFuturesPriceParser_ParseReader(t *testing.T) {
+	file := "OMIP - Settlement prices;15/01/2025\n" +
+		"Contract;Last;Settlement price\n" +
+		"FTB M Feb-25;;72,15\n" +
+		"FTB Q2-25;;55,40\n" +
+		"FTB YR-26;;63,00\n" +
+		"SPEL Peak M Feb-25;;80,10\n" +
+		"FTB WkEnd 18Jan25;;70,00\n"
+
+	result, err := NewFuturesPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day, ok := result.(*types.FuturesSettlementDay)
+	if !ok {
+		t.Fatalf("expected *types.FuturesSettlementDay, got %T", result)
+	}
+
+	if !day.Date.Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected trading date %v", day.Date)
+	}
+	if len(day.Settlements) != 4 {
+		t.Fatalf("expected 4 settlements, got %d", len(day.Settlements))
+	}
+
+	expected := []struct {
+		load   types.LoadProfile
+		period types.DeliveryPeriod
+		start  time.Time
+		price  float64
+	}{
+		{types.BaseLoad, types.MonthDelivery, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 72.15},
+		{types.BaseLoad, types.QuarterDelivery, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), 55.4},
+		{types.BaseLoad, types.YearDelivery, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 63},
+		{types.PeakLoad, types.MonthDelivery, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 80.1},
+	}
+	for i, want := range expected {
+		got := day.Settlements[i]
+		if got.Load != want.load || got.Period != want.period || !got.DeliveryStart.Equal(want.start) || got.Price != want.price {
+			t.Errorf("settlement %d (%s): got %s/%s/%v/%v", i, got.Contract, got.Load, got.Period, got.DeliveryStart, got.Price)
+		}
+	}
+}
+
+func TestFuturesPriceParser_DateColumn(t *testing.T) {
+	file := "Date,Instrument,Reference Price\n" +
+		"2025-01-16,FTB Cal-27,58.25\n"
+
+	result, err := NewFuturesPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := result.(*types.FuturesSettlementDay)
+	if !day.Date.Equal(time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected trading date %v", day.Date)
+	}
+	if len(day.Settlements) != 1 || day.Settlements[0].Period != types.YearDelivery || day.Settlements[0].Price != 58.25 {
+		t.Errorf("unexpected settlements %+v", day.Settlements)
+	}
+}
+
+func TestFuturesPriceParser_QuotedFields(t *testing.T) {
+	// Comma-separated reports quote the prices written with a decimal comma
+	file := "Date,Instrument,Reference Price\n" +
+		"2025-01-16,FTB Cal-27,\"1.058,25\",\n"
+
+	result, err := NewFuturesPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := result.(*types.FuturesSettlementDay)
+	if len(day.Settlements) != 1 || day.Settlements[0].Price != 1058.25 {
+		t.Errorf("unexpected settlements %+v", day.Settlements)
+	}
+}
+
+func TestFuturesPriceParser_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no columns": "OMIP;15/01/2025\nFTB M Feb-25;72,15\n",
+		"no date":    "Contract;Settlement price\nFTB M Feb-25;72,15\n",
+		"no prices":  "15/01/2025\nContract;Settlement price\nFTB M Feb-25;n.a.\n",
+	}
+
+	for name, file := range tests {
+		if _, err := NewFuturesPriceParser().ParseReader(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
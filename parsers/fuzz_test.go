@@ -0,0 +1,245 @@
+package parsers
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Run a target with e.g. go test -run='^$' -fuzz=FuzzParseFloat ./parsers. The seeds are
+// the lines of the fixtures, so the fuzzer starts from what the live files look like.
+
+// Fixtures whose lines seed the fuzz targets
+var (
+	fuzzPriceFixtures = []string{
+		"../testdata/PMD_20060101.txt",
+		"../testdata/PMD_20090601.txt",
+		"../testdata/PMD_20221030.txt",
+	}
+	fuzzTechnologyFixtures = []string{
+		"../testdata/EnergyByTechnology_9_20201113.TXT",
+	}
+)
+
+// fixtureLines returns the lines of the fixtures, decoded like the parsers decode them
+func fixtureLines(f *testing.F, filenames ...string) []string {
+	f.Helper()
+	var all []string
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatalf("failed to read fixture %s: %v", filename, err)
+		}
+		lines, err := ReadLines(NewISO88591Reader(bytes.NewReader(data)))
+		if err != nil {
+			f.Fatalf("failed to read lines of %s: %v", filename, err)
+		}
+		all = append(all, lines...)
+	}
+	return all
+}
+
+// addFields seeds f with every field of lines
+func addFields(f *testing.F, lines []string) {
+	for _, line := range lines {
+		for _, field := range SplitCSV(line) {
+			f.Add(field)
+		}
+	}
+}
+
+// sameFloat reports whether two results of a parse function are the same
+func sameFloat(a float64, errA error, b float64, errB error) bool {
+	if (errA == nil) != (errB == nil) {
+		return false
+	}
+	return errA != nil || a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func FuzzParseFloat(f *testing.F) {
+	for _, seed := range []string{"6,694", "1.071,6", "-3,5", "15.934.000", "3.14", "", " ", "1e308", "1,e5", ",", ".", "NaN", "-Inf", "0x1p3"} {
+		f.Add(seed)
+	}
+	addFields(f, fixtureLines(f, fuzzPriceFixtures...))
+
+	f.Fuzz(func(t *testing.T, s string) {
+		value, err := ParseFloat(s)
+		if strings.TrimSpace(s) == "" && (err != nil || !math.IsNaN(value)) {
+			t.Fatalf("ParseFloat(%q) = %v, %v, want NaN for a blank value", s, value, err)
+		}
+		if err == nil && strings.TrimSpace(s) != "" && (math.IsNaN(value) || math.IsInf(value, 0)) {
+			t.Fatalf("ParseFloat(%q) = %v, want a finite number or an error", s, value)
+		}
+
+		// Padding is part of the fields of every file
+		padded, paddedErr := ParseFloat(" " + s + "\t")
+		if !sameFloat(value, err, padded, paddedErr) {
+			t.Fatalf("ParseFloat(%q) = %v, %v but padded = %v, %v", s, value, err, padded, paddedErr)
+		}
+
+		grouped, groupedErr := ParseGroupedFloat(s)
+		if strings.Count(s, ".") != 1 || strings.Contains(s, ",") {
+			if !sameFloat(value, err, grouped, groupedErr) {
+				t.Fatalf("ParseGroupedFloat(%q) = %v, %v, want %v, %v", s, grouped, groupedErr, value, err)
+			}
+		}
+	})
+}
+
+func FuzzParseHour(f *testing.F) {
+	for _, seed := range []string{"1", "24", "25", "0", "26", " 3 ", "-1", "+2", "01", "", "1,0", "99999999999999999999"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		hour, err := ParseHour(s)
+		if err != nil {
+			return
+		}
+		if !hour.Valid() {
+			t.Fatalf("ParseHour(%q) = %d, want an hour in 1-25", s, hour)
+		}
+		if again, err := ParseHour(strconv.Itoa(hour.Int())); err != nil || again != hour {
+			t.Fatalf("ParseHour(%d) = %d, %v after ParseHour(%q)", hour, again, err, s)
+		}
+	})
+}
+
+func FuzzSplitCSV(f *testing.F) {
+	for _, seed := range []string{"", ";", ";;", "a", "a;b", ";a;", "13/11/2020;1;1.432,0;;;", `"a;b";c`, `"a""b";"c`, ` "a"x; ;`} {
+		f.Add(seed)
+	}
+	for _, line := range fixtureLines(f, append(fuzzPriceFixtures, fuzzTechnologyFixtures...)...) {
+		f.Add(line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		// Without quotes and trimming, fields are split at every separator
+		if plain := (&FieldSplitter{}).Split(line); !slices.Equal(plain, strings.Split(line, ";")) {
+			t.Fatalf("plain Split(%q) = %q", line, plain)
+		}
+
+		fields := SplitCSV(line)
+		if len(fields) == 0 || (len(fields) > 1 && strings.TrimSpace(fields[len(fields)-1]) == "") {
+			t.Fatalf("SplitCSV(%q) = %q, want trailing blank fields trimmed", line, fields)
+		}
+		if !strings.Contains(line, `"`) {
+			joined := strings.Join(fields, ";")
+			if !strings.HasPrefix(line, joined) || strings.TrimSpace(strings.ReplaceAll(line[len(joined):], ";", "")) != "" {
+				t.Fatalf("SplitCSV(%q) = %q, want the line up to its trailing separators", line, fields)
+			}
+		}
+
+		pooled := splitCSVPooled(line)
+		defer releaseFields(pooled)
+		if len(*pooled) != len(fields) {
+			t.Fatalf("splitCSVPooled(%q) has %d fields, SplitCSV %d", line, len(*pooled), len(fields))
+		}
+		for i := range fields {
+			if (*pooled)[i] != fields[i] {
+				t.Fatalf("splitCSVPooled(%q) field %d = %q, SplitCSV %q", line, i, (*pooled)[i], fields[i])
+			}
+		}
+	})
+}
+
+func FuzzMarginalPriceParseDataLine(f *testing.F) {
+	for _, line := range fixtureLines(f, fuzzPriceFixtures...) {
+		f.Add(line)
+		f.Add(line[:len(line)/2]) // Truncated, like a download cut short
+	}
+
+	date := time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
+	parser := NewMarginalPriceParser()
+	f.Fuzz(func(t *testing.T, line string) {
+		skipped := &skippedLines{}
+		record, err := parser.parseDataLine(line, 1, date, skipped)
+		if err != nil {
+			t.Fatalf("parseDataLine(%q) failed outside strict mode: %v", line, err)
+		}
+
+		if record != nil {
+			if !record.Date.Equal(date) || record.Concept == "" {
+				t.Fatalf("parseDataLine(%q) = %+v", line, record)
+			}
+			for index := range record.Values {
+				if index < 1 || index > types.MaxPeriodIndex {
+					t.Fatalf("parseDataLine(%q) has a value for period %d", line, index)
+				}
+			}
+		}
+
+		// Strict mode fails exactly on the lines with skipped values
+		_, strictErr := parser.parseDataLine(line, 1, date, &skippedLines{strict: true})
+		if (strictErr != nil) != (len(skipped.warnings) > 0) {
+			t.Fatalf("parseDataLine(%q) in strict mode = %v with %d values skipped otherwise", line, strictErr, len(skipped.warnings))
+		}
+	})
+}
+
+func FuzzEnergyByTechnologyParseDataLine(f *testing.F) {
+	parser := NewEnergyByTechnologyParser()
+	var columns map[int]types.TechnologyType
+	for _, line := range fixtureLines(f, fuzzTechnologyFixtures...) {
+		if mapping := parser.parseColumnHeaders(line); columns == nil && mapping != nil {
+			columns = mapping
+			continue
+		}
+		f.Add(line)
+		f.Add(line[:len(line)/2])
+	}
+	if len(columns) == 0 {
+		f.Fatal("no column headers in the fixtures")
+	}
+
+	date := time.Date(2020, 11, 13, 0, 0, 0, 0, time.UTC)
+	f.Fuzz(func(t *testing.T, line string) {
+		skipped := &skippedLines{}
+		record, err := parser.parseDataLine(line, 1, date, types.Iberian, columns, skipped)
+		if err != nil {
+			return
+		}
+		if !types.HourIndex(record.Hour).Valid() {
+			t.Fatalf("parseDataLine(%q) has hour %d", line, record.Hour)
+		}
+
+		_, strictErr := parser.parseDataLine(line, 1, date, types.Iberian, columns, &skippedLines{strict: true})
+		if (strictErr != nil) != (len(skipped.warnings) > 0) {
+			t.Fatalf("parseDataLine(%q) in strict mode = %v with %d values skipped otherwise", line, strictErr, len(skipped.warnings))
+		}
+	})
+}
+
+// FuzzParseReader feeds whole files, truncated or mangled by the fuzzer, to both parsers,
+// which must fail with an error rather than panic or return an empty result
+func FuzzParseReader(f *testing.F) {
+	for _, filename := range append(fuzzPriceFixtures, fuzzTechnologyFixtures...) {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatalf("failed to read fixture %s: %v", filename, err)
+		}
+		f.Add(data)
+		f.Add(data[:len(data)/2])
+		f.Add(data[:len(data)/10])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if parsed, err := NewMarginalPriceParser().ParseReader(bytes.NewReader(data)); err == nil {
+			if day := parsed.(*types.MarginalPriceData); day == nil || day.Date.IsZero() {
+				t.Fatalf("marginal price parser returned %+v without an error", parsed)
+			}
+		}
+		if parsed, err := NewEnergyByTechnologyParser().ParseReader(bytes.NewReader(data)); err == nil {
+			if day := parsed.(*types.TechnologyEnergyDay); day == nil || len(day.Records) == 0 {
+				t.Fatalf("energy by technology parser returned %+v without an error", parsed)
+			}
+		}
+	})
+}
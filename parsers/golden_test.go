@@ -0,0 +1,115 @@
+package parsers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// goldenPriceFile holds the values expected from a marginal price fixture, see
+// golden_values_test.go, regenerated with cmd/omie-fixtures
+type goldenPriceFile struct {
+	File       string
+	Date       string
+	Resolution string
+	Series     map[string]map[int]float64
+}
+
+// goldenTechnologyFile holds the values expected from an energy by technology fixture:
+// the total of every hour and the daily total of every technology
+type goldenTechnologyFile struct {
+	File         string
+	Date         string
+	System       string
+	Totals       map[int]float64
+	Technologies map[string]float64
+}
+
+// goldenEqual compares a parsed value with a golden one, written to 12 significant digits
+func goldenEqual(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+}
+
+func TestGoldenPrices(t *testing.T) {
+	for _, golden := range goldenPrices {
+		t.Run(golden.File, func(t *testing.T) {
+			parsed, err := NewMarginalPriceParser().ParseFile(golden.File)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			day := parsed.(*types.MarginalPriceData)
+			if got := day.Date.Format("2006-01-02"); got != golden.Date {
+				t.Errorf("date = %s, want %s", got, golden.Date)
+			}
+			if got := day.Resolution.String(); got != golden.Resolution {
+				t.Errorf("resolution = %s, want %s", got, golden.Resolution)
+			}
+
+			parsedSeries := make(map[string]bool)
+			for _, series := range day.Series() {
+				parsedSeries[series.Name] = true
+				want := golden.Series[series.Name]
+				present := 0
+				series.Values.ForEachHour(func(_ int, value float64) {
+					if !math.IsNaN(value) {
+						present++
+					}
+				})
+				if present != len(want) {
+					t.Errorf("%s has %d values, want %d", series.Name, present, len(want))
+					continue
+				}
+				for hour, value := range want {
+					if got := series.Values[hour]; !goldenEqual(got, value) {
+						t.Errorf("%s[%d] = %v, want %v", series.Name, hour, got, value)
+					}
+				}
+			}
+			for name := range golden.Series {
+				if !parsedSeries[name] {
+					t.Errorf("series %s is missing from the parsed day", name)
+				}
+			}
+		})
+	}
+}
+
+func TestGoldenTechnology(t *testing.T) {
+	for _, golden := range goldenTechnology {
+		t.Run(golden.File, func(t *testing.T) {
+			parsed, err := NewEnergyByTechnologyParser().ParseFile(golden.File)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			day := parsed.(*types.TechnologyEnergyDay)
+			if got := day.Date.Format("2006-01-02"); got != golden.Date {
+				t.Errorf("date = %s, want %s", got, golden.Date)
+			}
+			if got := day.System.String(); got != golden.System {
+				t.Errorf("system = %s, want %s", got, golden.System)
+			}
+			if len(day.Records) != len(golden.Totals) {
+				t.Fatalf("%d hours, want %d", len(day.Records), len(golden.Totals))
+			}
+
+			daily := make(map[string]float64)
+			for _, record := range day.Records {
+				if got := record.Total(); !goldenEqual(got, golden.Totals[record.Hour]) {
+					t.Errorf("total of hour %d = %v, want %v", record.Hour, got, golden.Totals[record.Hour])
+				}
+				for tech, value := range record.Values() {
+					daily[string(tech)] += value
+				}
+			}
+			for tech, want := range golden.Technologies {
+				if got := daily[tech]; !goldenEqual(got, want) {
+					t.Errorf("daily %s = %v, want %v", tech, got, want)
+				}
+			}
+			if len(daily) != len(golden.Technologies) {
+				t.Errorf("%d technologies, want %d", len(daily), len(golden.Technologies))
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package parsers
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// InterconnectionParser parses the Spain-Portugal interconnection files, which like the
+// marginal price files hold one concept per row and one column per hour:
+//
+//	Capacidad de interconexión de España a Portugal (MW);  3.000,0;  3.000,0;...
+//	Capacidad de interconexión de Portugal a España (MW);  3.400,0;  3.400,0;...
+//	Renta de congestión (EUR);      0,00;  1.250,40;...
+//
+// The concept labels and layout haven't been checked against a published file yet.
+type InterconnectionParser struct{}
+
+// NewInterconnectionParser creates a new interconnection parser
+func NewInterconnectionParser() *InterconnectionParser {
+	return &InterconnectionParser{}
+}
+
+// ParseResponse parses interconnection data from an HTTP response
+func (p *InterconnectionParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses interconnection data from a file
+func (p *InterconnectionParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses interconnection data from a reader, returning a *types.InterconnectionData
+func (p *InterconnectionParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	if !lines.Scan() {
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
+		return nil, types.NewOMIEError(types.ErrCodeParse, "empty file", nil)
+	}
+
+	date, err := p.parseDateFromHeader(lines.Text())
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewInterconnectionData(date)
+	found := false
+
+	for lines.Scan() {
+		fields := SplitCSV(lines.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		series := p.mapConcept(result, strings.TrimSpace(fields[0]))
+		if series == nil {
+			continue
+		}
+
+		for i, field := range fields[1:] {
+			if i >= types.MaxPeriodIndex {
+				break
+			}
+			if strings.TrimSpace(field) == "" {
+				continue
+			}
+
+			value, err := ParseFloat(field)
+			if err != nil {
+				continue // Skip invalid values
+			}
+
+			period := i + 1
+			if period > types.MaxHourIndex {
+				result.Resolution = types.QuarterHourly
+			}
+			series[period] = value
+			found = true
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data found", nil)
+	}
+
+	return result, nil
+}
+
+// parseDateFromHeader extracts the data date, the last date in the header
+func (p *InterconnectionParser) parseDateFromHeader(headerLine string) (time.Time, error) {
+	matches := headerDateRegex.FindAllString(headerLine, -1)
+	if len(matches) == 0 {
+		return time.Time{}, types.NewOMIEError(types.ErrCodeParse, "no date found in header", nil)
+	}
+	return ParseDate(matches[len(matches)-1])
+}
+
+// mapConcept returns the series a concept row fills, or nil for rows that aren't of
+// interest. The direction of a capacity is given by the system named first.
+func (p *InterconnectionParser) mapConcept(result *types.InterconnectionData, concept string) types.HourlyValues {
+	label := strings.ToLower(concept)
+
+	switch {
+	case strings.Contains(label, "renta"):
+		return result.CongestionRent
+	case strings.Contains(label, "capacidad"):
+		spain, portugal := strings.Index(label, "españa"), strings.Index(label, "portugal")
+		switch {
+		case spain == -1 || portugal == -1:
+			return nil
+		case spain < portugal:
+			return result.CapacitySpainToPortugal
+		default:
+			return result.CapacityPortugalToSpain
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestInterconnectionParser_ParseReader(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Capacidad de interconexión y rentas de congestión;;;;
+
+;1;2;3;
+Capacidad de interconexión de España a Portugal (MW);  3.000,0;  3.000,0;  2.800,0;
+Capacidad de interconexión de Portugal a España (MW);  3.400,0;  3.400,0;  3.400,0;
+Renta de congestión (EUR);      0,00;  1.250,40;      0,00;
+;;;;
+`
+
+	result, err := NewInterconnectionParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, ok := result.(*types.InterconnectionData)
+	if !ok {
+		t.Fatalf("expected *types.InterconnectionData, got %T", result)
+	}
+
+	if !data.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", data.Date)
+	}
+	if data.CapacitySpainToPortugal[3] != 2800 || data.CapacityPortugalToSpain[3] != 3400 {
+		t.Errorf("unexpected hour 3 capacities: ES->PT %v, PT->ES %v",
+			data.CapacitySpainToPortugal[3], data.CapacityPortugalToSpain[3])
+	}
+	if len(data.CongestionRent) != 3 || data.CongestionRent[2] != 1250.4 {
+		t.Errorf("unexpected congestion rent: %v", data.CongestionRent)
+	}
+}
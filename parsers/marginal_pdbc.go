@@ -0,0 +1,128 @@
+package parsers
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// MarginalPDBCParser parses the marginalpdbc files, a plain format with one row per hour
+// (or quarter-hour period) holding the Portuguese and Spanish day-ahead prices:
+//
+//	MARGINALPDBC;
+//	2024;01;15;1;74.50;74.50;
+//	...
+//	*
+type MarginalPDBCParser struct{}
+
+// NewMarginalPDBCParser creates a new marginalpdbc parser
+func NewMarginalPDBCParser() *MarginalPDBCParser {
+	return &MarginalPDBCParser{}
+}
+
+// ParseResponse parses marginalpdbc prices from an HTTP response
+func (p *MarginalPDBCParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses marginalpdbc prices from a file
+func (p *MarginalPDBCParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses marginalpdbc prices from a reader, returning a *types.MarginalPriceData
+// with only SpainPrices and PortugalPrices set
+func (p *MarginalPDBCParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	result := &types.MarginalPriceData{
+		SpainPrices:    make(types.HourlyValues, types.MaxHourIndex),
+		PortugalPrices: make(types.HourlyValues, types.MaxHourIndex),
+	}
+
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || line == "*" || strings.HasPrefix(strings.ToUpper(line), "MARGINALPDBC") {
+			continue
+		}
+
+		date, period, portugal, spain, err := p.parseDataLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Date.IsZero() {
+			result.Date = date
+		} else if !date.Equal(result.Date) {
+			return nil, types.NewOMIEError(types.ErrCodeParse, "file contains more than one date", nil)
+		}
+
+		if period > types.MaxHourIndex {
+			result.Resolution = types.QuarterHourly
+		}
+		result.PortugalPrices[period] = portugal
+		result.SpainPrices[period] = spain
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if result.Date.IsZero() {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data found", nil)
+	}
+
+	return result, nil
+}
+
+// parseDataLine parses a "year;month;day;period;portugal;spain;" row. Prices use a dot as
+// the decimal separator.
+func (p *MarginalPDBCParser) parseDataLine(line string) (time.Time, int, float64, float64, error) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
+
+	fields := *fieldsPtr
+	if len(fields) < 6 {
+		return time.Time{}, 0, 0, 0, types.NewOMIEError(types.ErrCodeParse, "insufficient fields in marginalpdbc line", nil)
+	}
+
+	var ymd [3]int
+	for i := range ymd {
+		value, err := strconv.Atoi(strings.TrimSpace(fields[i]))
+		if err != nil {
+			return time.Time{}, 0, 0, 0, types.NewOMIEError(types.ErrCodeParse, "invalid date in marginalpdbc line", err)
+		}
+		ymd[i] = value
+	}
+	date := time.Date(ymd[0], time.Month(ymd[1]), ymd[2], 0, 0, 0, 0, time.UTC)
+	if date.Year() != ymd[0] || int(date.Month()) != ymd[1] || date.Day() != ymd[2] {
+		return time.Time{}, 0, 0, 0, types.NewOMIEError(types.ErrCodeParse, "invalid date in marginalpdbc line", nil)
+	}
+
+	period, err := ParsePeriod(fields[3])
+	if err != nil {
+		return time.Time{}, 0, 0, 0, err
+	}
+
+	portugal, err := strconv.ParseFloat(strings.TrimSpace(fields[4]), 64)
+	if err != nil {
+		return time.Time{}, 0, 0, 0, types.NewOMIEError(types.ErrCodeParse, "invalid Portugal price", err)
+	}
+	spain, err := strconv.ParseFloat(strings.TrimSpace(fields[5]), 64)
+	if err != nil {
+		return time.Time{}, 0, 0, 0, types.NewOMIEError(types.ErrCodeParse, "invalid Spain price", err)
+	}
+
+	return date, period.Int(), portugal, spain, nil
+}
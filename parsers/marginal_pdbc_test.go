@@ -0,0 +1,76 @@
+package parsers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// marginalPDBCFile builds a marginalpdbc file for 15/01/2024 with the given number of periods
+func marginalPDBCFile(periods int) string {
+	var file strings.Builder
+	file.WriteString("MARGINALPDBC;\n")
+	for period := 1; period <= periods; period++ {
+		fmt.Fprintf(&file, "2024;01;15;%d;74.50;%d.25;\n", period, 60+period)
+	}
+	file.WriteString("*\n")
+	return file.String()
+}
+
+func TestMarginalPDBCParser_ParseReader(t *testing.T) {
+	result, err := NewMarginalPDBCParser().ParseReader(strings.NewReader(marginalPDBCFile(24)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, ok := result.(*types.MarginalPriceData)
+	if !ok {
+		t.Fatalf("expected *types.MarginalPriceData, got %T", result)
+	}
+
+	if !data.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", data.Date)
+	}
+	if data.Resolution != types.Hourly {
+		t.Errorf("expected hourly resolution, got %v", data.Resolution)
+	}
+	if len(data.SpainPrices) != 24 || len(data.PortugalPrices) != 24 {
+		t.Fatalf("expected 24 prices per system, got %d/%d", len(data.SpainPrices), len(data.PortugalPrices))
+	}
+	if data.PortugalPrices[3] != 74.5 || data.SpainPrices[3] != 63.25 {
+		t.Errorf("unexpected hour 3 prices: PT %v, ES %v", data.PortugalPrices[3], data.SpainPrices[3])
+	}
+}
+
+func TestMarginalPDBCParser_QuarterHourly(t *testing.T) {
+	result, err := NewMarginalPDBCParser().ParseReader(strings.NewReader(marginalPDBCFile(96)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := result.(*types.MarginalPriceData)
+	if data.Resolution != types.QuarterHourly {
+		t.Errorf("expected quarter-hourly resolution, got %v", data.Resolution)
+	}
+	if len(data.SpainPrices) != 96 || data.SpainPrices[96] != 156.25 {
+		t.Errorf("expected 96 periods ending at 156.25, got %d/%v", len(data.SpainPrices), data.SpainPrices[96])
+	}
+}
+
+func TestMarginalPDBCParser_Errors(t *testing.T) {
+	tests := map[string]string{
+		"empty":       "MARGINALPDBC;\n*\n",
+		"mixed dates": "MARGINALPDBC;\n2024;01;15;1;74.50;74.50;\n2024;01;16;2;74.50;74.50;\n*\n",
+		"bad price":   "MARGINALPDBC;\n2024;01;15;1;abc;74.50;\n*\n",
+		"bad date":    "MARGINALPDBC;\n2024;02;30;1;74.50;74.50;\n*\n",
+	}
+
+	for name, file := range tests {
+		if _, err := NewMarginalPDBCParser().ParseReader(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -1,33 +1,52 @@
 package parsers
 
 import (
+	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/devuo/omiedata/types"
 )
 
+// headerDateRegex matches the DD/MM/YYYY dates found in file headers
+var headerDateRegex = regexp.MustCompile(`\d{2}/\d{2}/\d{4}`)
+
 // MarginalPriceParser parses marginal price files
 type MarginalPriceParser struct {
+	// Strict fails parsing on rows and values that can't be parsed, which are otherwise
+	// skipped. Rows of concepts that aren't loaded are skipped either way.
+	Strict bool
+
 	conceptsToLoad []types.DataTypeInMarginalPriceFile
+	concepts       map[string]conceptMapping // Registered by lowercase label, see RegisterConcept
+}
+
+// builtInConcepts are the data types the parser fills fields of MarginalPriceData with
+var builtInConcepts = []types.DataTypeInMarginalPriceFile{
+	types.PriceSpain,
+	types.PricePortugal,
+	types.EnergyIberian,
+	types.EnergyIberianWithBilateral,
+	types.EnergyBuySpain,
+	types.EnergySellSpain,
+	types.AdjustmentPriceSpain,
+	types.AdjustmentPricePortugal,
+	types.ExportSpainToPortugal,
+	types.ExportPortugalToSpain,
 }
 
 // NewMarginalPriceParser creates a new marginal price parser
 func NewMarginalPriceParser(conceptsToLoad ...types.DataTypeInMarginalPriceFile) *MarginalPriceParser {
 	if len(conceptsToLoad) == 0 {
 		// Load all concepts by default
-		conceptsToLoad = []types.DataTypeInMarginalPriceFile{
-			types.PriceSpain,
-			types.PricePortugal,
-			types.EnergyIberian,
-			types.EnergyIberianWithBilateral,
-			types.EnergyBuySpain,
-			types.EnergySellSpain,
-		}
+		conceptsToLoad = slices.Clone(builtInConcepts)
 	}
 
 	return &MarginalPriceParser{
@@ -35,10 +54,67 @@ func NewMarginalPriceParser(conceptsToLoad ...types.DataTypeInMarginalPriceFile)
 	}
 }
 
+// Concept is a row label of the marginal price files mapped to a data type, as
+// registered with RegisterConcept
+type Concept struct {
+	Label      string                            // Row label, matched whatever its case
+	DataType   types.DataTypeInMarginalPriceFile // Built-in or custom data type the rows fill
+	Multiplier float64                           // Factor to EUR/MWh or MWh, 1 when zero
+}
+
+// RegisterConcept maps the rows labelled label, matched like the built-in labels whatever
+// their case, to dataType, multiplying their values by multiplier (1 when zero), e.g. to
+// read a label OMIE introduced after this version of the library:
+//
+//	parser.RegisterConcept("Precio marginal en el sistema ibérico (EUR/MWh)", types.PriceSpain, 1)
+//
+// A label of a built-in concept is mapped anew. Rows of a built-in data type are added to
+// its field of MarginalPriceData when the parser loads it, and rows of any other data
+// type, which is always loaded, to CustomConcepts. Register concepts before parsing, as
+// RegisterConcept isn't safe to call while the parser is in use.
+func (p *MarginalPriceParser) RegisterConcept(label string, dataType types.DataTypeInMarginalPriceFile, multiplier float64) error {
+	label = strings.TrimSpace(label)
+	if label == "" || dataType == "" {
+		return types.NewOMIEError(types.ErrCodeInvalidData, "concept label and data type are required", nil)
+	}
+	if math.IsNaN(multiplier) || math.IsInf(multiplier, 0) {
+		return types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("invalid multiplier %v for concept %q", multiplier, label), nil)
+	}
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	if p.concepts == nil {
+		p.concepts = make(map[string]conceptMapping)
+	}
+	p.concepts[strings.ToLower(label)] = conceptMapping{dataType: dataType, multiplier: multiplier}
+
+	if !slices.Contains(builtInConcepts, dataType) && !slices.Contains(p.conceptsToLoad, dataType) {
+		p.conceptsToLoad = append(p.conceptsToLoad, dataType)
+	}
+	return nil
+}
+
+// configuration describes the settings that change the parsed results, see CachedParser
+func (p *MarginalPriceParser) configuration() string {
+	loaded := make([]string, len(p.conceptsToLoad))
+	for i, concept := range p.conceptsToLoad {
+		loaded[i] = string(concept)
+	}
+	slices.Sort(loaded)
+
+	var config strings.Builder
+	fmt.Fprintf(&config, "strict=%t;load=%s", p.Strict, strings.Join(loaded, ","))
+	for _, label := range slices.Sorted(maps.Keys(p.concepts)) {
+		mapping := p.concepts[label]
+		fmt.Fprintf(&config, ";%s=%s*%g", label, mapping.dataType, mapping.multiplier)
+	}
+	return config.String()
+}
+
 // ParseResponse parses marginal price data from an HTTP response
 func (p *MarginalPriceParser) ParseResponse(resp *http.Response) (interface{}, error) {
-	reader := NewISO88591Reader(resp.Body)
-	return p.ParseReader(reader)
+	return p.ParseReader(resp.Body)
 }
 
 // ParseFile parses marginal price data from a file
@@ -49,23 +125,23 @@ func (p *MarginalPriceParser) ParseFile(filename string) (interface{}, error) {
 	}
 	defer file.Close()
 
-	reader := NewISO88591Reader(file)
-	return p.ParseReader(reader)
+	return p.ParseReader(file)
 }
 
 // ParseReader parses marginal price data from a reader
 func (p *MarginalPriceParser) ParseReader(reader io.Reader) (interface{}, error) {
-	lines, err := ReadLines(reader)
-	if err != nil {
-		return nil, err
-	}
+	lines := NewLineScanner(reader)
+	defer lines.Close()
 
-	if len(lines) == 0 {
+	if !lines.Scan() {
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
 		return nil, types.NewOMIEError(types.ErrCodeParse, "empty file", nil)
 	}
 
 	// Parse date from first line
-	date, err := p.parseDateFromHeader(lines[0])
+	date, err := p.parseDateFromHeader(lines.Text())
 	if err != nil {
 		return nil, err
 	}
@@ -75,26 +151,50 @@ func (p *MarginalPriceParser) ParseReader(reader io.Reader) (interface{}, error)
 	records := []types.MarginalPriceRecord{}
 
 	// Process all lines looking for data rows
-	for _, line := range lines[1:] { // Skip header line
+	skipped := &skippedLines{strict: p.Strict}
+	var format formatRows
+	for lines.Scan() {
+		line := lines.Text()
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		p.observeFormat(&format, line)
 
-		record, err := p.parseDataLine(line, date)
+		record, err := p.parseDataLine(line, lines.Line(), date, skipped)
 		if err != nil {
-			// Skip invalid lines but continue processing
+			// Skip invalid lines but continue processing, unless strict
+			if err := skipped.skipRow(lines.Line(), line, err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
 		if record != nil {
 			records = append(records, *record)
 			p.addRecordToResult(result, *record)
+		} else {
+			p.keepUnknownConcept(result, line)
 		}
 	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
 
-	if len(records) == 0 {
+	if len(records) == 0 && len(result.UnknownConcepts) == 0 {
 		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data found", nil)
 	}
+	result.Warnings = skipped.warnings
+	result.FormatVersion = format.version()
+	result.Encoding = lines.Encoding()
+
+	// Since the 15-minute MTU change files hold 92-100 periods instead of 23-25 hours
+	for _, record := range records {
+		for index := range record.Values {
+			if index > types.MaxHourIndex {
+				result.Resolution = types.QuarterHourly
+			}
+		}
+	}
 
 	return result, nil
 }
@@ -102,24 +202,29 @@ func (p *MarginalPriceParser) ParseReader(reader io.Reader) (interface{}, error)
 // parseDateFromHeader extracts the date from the header line
 func (p *MarginalPriceParser) parseDateFromHeader(headerLine string) (time.Time, error) {
 	// Use regex to find dates in DD/MM/YYYY format
-	dateRegex := regexp.MustCompile(`\d{2}/\d{2}/\d{4}`)
-	matches := dateRegex.FindAllString(headerLine, -1)
+	matches := headerDateRegex.FindAllString(headerLine, -1)
+
+	if len(matches) == 0 {
+		return time.Time{}, types.NewOMIEError(types.ErrCodeParse, "no date found in header", nil)
+	}
 
-	if len(matches) < 2 {
-		return time.Time{}, types.NewOMIEError(types.ErrCodeParse, "expected at least 2 dates in header", nil)
+	// Legacy OMEL headers are assumed to carry only the data date, without an emission
+	// date; no original file has confirmed it yet
+	if len(matches) == 1 {
+		return ParseDate(matches[0])
 	}
 
 	// The second date is the one we want (data date)
 	return ParseDate(matches[1])
 }
 
-// parseDataLine parses a single data line
-func (p *MarginalPriceParser) parseDataLine(line string, date time.Time) (*types.MarginalPriceRecord, error) {
-	fields := SplitCSV(line)
-	if len(fields) < 2 {
-		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient fields in line", nil)
-	}
+// parseDataLine parses a single data line, number being its line number in the file.
+// Values that can't be parsed are recorded in skipped.
+func (p *MarginalPriceParser) parseDataLine(line string, number int, date time.Time, skipped *skippedLines) (*types.MarginalPriceRecord, error) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
 
+	fields := *fieldsPtr
 	concept := strings.TrimSpace(fields[0])
 
 	// Map Spanish concepts to our enum types
@@ -141,20 +246,31 @@ func (p *MarginalPriceParser) parseDataLine(line string, date time.Time) (*types
 		return nil, nil
 	}
 
-	// Parse hourly values
-	values := make(map[int]float64)
+	// Energies only use dots as thousands separators, but without decimals the 2006-era
+	// files have a single one ("26.377" MWh) that ParseFloat would read as a decimal point
+	parse := ParseFloat
+	if energyConcepts[conceptType] || (!slices.Contains(builtInConcepts, conceptType) && isEnergyLabel(concept)) {
+		parse = ParseGroupedFloat
+	}
+
+	// Parse hourly (or quarter-hourly) values
+	values := make(types.HourlyValues, len(fields)-1)
 	for i, field := range fields[1:] {
-		if i >= 25 { // Maximum 25 hours (for DST)
+		if i >= types.MaxPeriodIndex { // Maximum 100 quarter-hours (for DST)
 			break
 		}
 
-		hour := i + 1 // Hours are 1-based
+		hour := i + 1 // Hours and periods are 1-based
 		if strings.TrimSpace(field) == "" {
 			continue // Skip empty values
 		}
 
-		value, err := ParseFloat(field)
+		value, err := parse(field)
 		if err != nil {
+			reason := fmt.Sprintf("invalid value %q for %s in hour %d", strings.TrimSpace(field), conceptType, hour)
+			if err := skipped.skip(number, line, reason, err); err != nil {
+				return nil, err
+			}
 			continue // Skip invalid values
 		}
 
@@ -169,35 +285,174 @@ func (p *MarginalPriceParser) parseDataLine(line string, date time.Time) (*types
 	}, nil
 }
 
-// mapConcept maps Spanish concept names to our enum types and returns multiplier
+// keepUnknownConcept adds the values of line to the UnknownConcepts of result when its
+// label isn't a known concept. Energies are read like the known ones, see parseDataLine,
+// and values that can't be parsed are left out.
+func (p *MarginalPriceParser) keepUnknownConcept(result *types.MarginalPriceData, line string) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
+
+	fields := *fieldsPtr
+	label := strings.TrimSpace(fields[0])
+	if label == "" {
+		return
+	}
+	if concept, _ := p.mapConcept(label); concept != "" {
+		return // Known but not loaded
+	}
+
+	parse := ParseFloat
+	if isEnergyLabel(label) {
+		parse = ParseGroupedFloat
+	}
+	values := make(types.HourlyValues)
+	for i, field := range fields[1:] {
+		if i >= types.MaxPeriodIndex {
+			break
+		}
+		if value, err := parse(field); err == nil && !math.IsNaN(value) {
+			values[i+1] = value
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	if result.UnknownConcepts == nil {
+		result.UnknownConcepts = make(map[string]types.HourlyValues)
+	}
+	if existing := result.UnknownConcepts[label]; existing != nil {
+		maps.Copy(existing, values)
+		return
+	}
+	result.UnknownConcepts[label] = values
+}
+
+// isEnergyLabel reports whether a concept label is of energies, in MWh, rather than of
+// prices in a currency per MWh or kWh
+func isEnergyLabel(label string) bool {
+	return strings.HasSuffix(label, "MWh)") && !strings.Contains(label, "/MWh")
+}
+
+// conceptMapping is the data type and unit multiplier for a concept row label
+type conceptMapping struct {
+	dataType   types.DataTypeInMarginalPriceFile
+	multiplier float64
+}
+
+// energyConcepts are the concepts measured in MWh
+var energyConcepts = map[types.DataTypeInMarginalPriceFile]bool{
+	types.EnergyIberian:              true,
+	types.EnergyIberianWithBilateral: true,
+	types.EnergyBuySpain:             true,
+	types.EnergySellSpain:            true,
+	types.ExportSpainToPortugal:      true,
+	types.ExportPortugalToSpain:      true,
+}
+
+// pesetasPerEuro is the fixed conversion rate used for the peseta prices of the oldest OMEL files
+const pesetasPerEuro = 166.386
+
+// conceptMap maps the Spanish concept labels found in marginal price files to data types
+var conceptMap = map[string]conceptMapping{
+	// Legacy OMEL format (Pta/kWh), used until the euro changeover. The labels are
+	// unverified: no original file of the peseta era has been checked yet.
+	"Precio marginal (Pta/kWh)": {types.PriceSpain, 1000 / pesetasPerEuro},
+	"Precio marginal (Pts/kWh)": {types.PriceSpain, 1000 / pesetasPerEuro},
+
+	// Old format (Cent/kWh) - multiply by 10 to get EUR/MWh
+	"Precio marginal (Cent/kWh)":                         {types.PriceSpain, 10.0},
+	"Precio marginal en el sistema español (Cent/kWh)":   {types.PriceSpain, 10.0},
+	"Precio marginal en el sistema portugués (Cent/kWh)": {types.PricePortugal, 10.0},
+
+	// New format (EUR/MWh)
+	"Precio marginal (EUR/MWh)":                         {types.PriceSpain, 1.0},
+	"Precio marginal en el sistema español (EUR/MWh)":   {types.PriceSpain, 1.0},
+	"Precio marginal en el sistema portugués (EUR/MWh)": {types.PricePortugal, 1.0},
+
+	// Adjustment prices of the gas price cap mechanism
+	"Precio de ajuste en el sistema español (EUR/MWh)":   {types.AdjustmentPriceSpain, 1.0},
+	"Precio de ajuste en el sistema portugués (EUR/MWh)": {types.AdjustmentPricePortugal, 1.0},
+
+	// Energy concepts
+	"Demanda+bombeos (MWh)": {types.EnergyIberian, 1.0},
+	"Energía en el programa resultante de la casación (MWh)":                       {types.EnergyIberian, 1.0},
+	"Energía total del mercado Ibérico (MWh)":                                      {types.EnergyIberian, 1.0},
+	"Energía total con bilaterales del mercado Ibérico (MWh)":                      {types.EnergyIberianWithBilateral, 1.0},
+	"Energía total de compra sistema español (MWh)":                                {types.EnergyBuySpain, 1.0},
+	"Energía total de venta sistema español (MWh)":                                 {types.EnergySellSpain, 1.0},
+	"Energía horaria sujeta al mecanismo de ajuste a los consumidores MIBEL (MWh)": {types.EnergyIberian, 1.0},
+
+	// Interconnection flows, labelled from the Spanish side
+	"Exportación de España a Portugal (MWh)":     {types.ExportSpainToPortugal, 1.0},
+	"Importación de España desde Portugal (MWh)": {types.ExportPortugalToSpain, 1.0},
+}
+
+// foldedConceptMap indexes conceptMap by lowercase label, for legacy OMEL files whose
+// labels would differ only in case (e.g. "cent/kWh", "PTA/kWh"), a layout not yet
+// checked against an original file
+var foldedConceptMap = func() map[string]conceptMapping {
+	folded := make(map[string]conceptMapping, len(conceptMap))
+	for label, mapping := range conceptMap {
+		folded[strings.ToLower(label)] = mapping
+	}
+	return folded
+}()
+
+// formatRows collects what the price rows of a file tell about its layout, see version
+type formatRows struct {
+	marginal, adjustment, portugal bool
+	multiplier                     float64 // Of the last marginal price row
+}
+
+// observeFormat records what a data row tells about the layout of its file
+func (p *MarginalPriceParser) observeFormat(rows *formatRows, line string) {
+	fieldsPtr := splitCSVPooled(line)
+	concept, multiplier := p.mapConcept(strings.TrimSpace((*fieldsPtr)[0]))
+	releaseFields(fieldsPtr)
+	switch concept {
+	case types.PriceSpain, types.PricePortugal:
+		rows.marginal = true
+		rows.multiplier = multiplier
+		rows.portugal = rows.portugal || concept == types.PricePortugal
+	case types.AdjustmentPriceSpain, types.AdjustmentPricePortugal:
+		rows.adjustment = true
+	}
+}
+
+// version identifies the layout of a file from the units and markets of its price rows
+func (f formatRows) version() types.FormatVersion {
+	switch {
+	case !f.marginal && f.adjustment:
+		return types.FormatAdjustment
+	case !f.marginal:
+		return ""
+	case f.multiplier == 1:
+		return types.FormatEuro
+	case f.multiplier == 10 && f.portugal:
+		return types.FormatCentDualMarket
+	case f.multiplier == 10:
+		return types.FormatCentSingleMarket
+	default:
+		return types.FormatLegacyPeseta
+	}
+}
+
+// mapConcept maps Spanish concept names to our enum types and returns multiplier, the
+// registered concepts taking precedence over the built-in ones
 func (p *MarginalPriceParser) mapConcept(concept string) (types.DataTypeInMarginalPriceFile, float64) {
-	conceptMap := map[string][2]interface{}{
-		// Old format (Cent/kWh) - multiply by 10 to get EUR/MWh
-		"Precio marginal (Cent/kWh)":                         {types.PriceSpain, 10.0},
-		"Precio marginal en el sistema español (Cent/kWh)":   {types.PriceSpain, 10.0},
-		"Precio marginal en el sistema portugués (Cent/kWh)": {types.PricePortugal, 10.0},
-
-		// New format (EUR/MWh)
-		"Precio marginal (EUR/MWh)":                         {types.PriceSpain, 1.0},
-		"Precio marginal en el sistema español (EUR/MWh)":   {types.PriceSpain, 1.0},
-		"Precio marginal en el sistema portugués (EUR/MWh)": {types.PricePortugal, 1.0},
-
-		// Adjustment prices (also map to Spain/Portugal prices)
-		"Precio de ajuste en el sistema español (EUR/MWh)":   {types.PriceSpain, 1.0},
-		"Precio de ajuste en el sistema portugués (EUR/MWh)": {types.PricePortugal, 1.0},
-
-		// Energy concepts
-		"Demanda+bombeos (MWh)": {types.EnergyIberian, 1.0},
-		"Energía en el programa resultante de la casación (MWh)":                       {types.EnergyIberian, 1.0},
-		"Energía total del mercado Ibérico (MWh)":                                      {types.EnergyIberian, 1.0},
-		"Energía total con bilaterales del mercado Ibérico (MWh)":                      {types.EnergyIberianWithBilateral, 1.0},
-		"Energía total de compra sistema español (MWh)":                                {types.EnergyBuySpain, 1.0},
-		"Energía total de venta sistema español (MWh)":                                 {types.EnergySellSpain, 1.0},
-		"Energía horaria sujeta al mecanismo de ajuste a los consumidores MIBEL (MWh)": {types.EnergyIberian, 1.0},
+	if len(p.concepts) > 0 {
+		if mapping, exists := p.concepts[strings.ToLower(concept)]; exists {
+			return mapping.dataType, mapping.multiplier
+		}
 	}
 
 	if mapping, exists := conceptMap[concept]; exists {
-		return mapping[0].(types.DataTypeInMarginalPriceFile), mapping[1].(float64)
+		return mapping.dataType, mapping.multiplier
+	}
+
+	if mapping, exists := foldedConceptMap[strings.ToLower(concept)]; exists {
+		return mapping.dataType, mapping.multiplier
 	}
 
 	return "", 0.0
@@ -230,5 +485,29 @@ func (p *MarginalPriceParser) addRecordToResult(result *types.MarginalPriceData,
 		for hour, value := range record.Values {
 			result.BilateralEnergy[hour] = value
 		}
+	case types.AdjustmentPriceSpain:
+		for hour, value := range record.Values {
+			result.SpainAdjustmentPrices[hour] = value
+		}
+	case types.AdjustmentPricePortugal:
+		for hour, value := range record.Values {
+			result.PortugalAdjustmentPrices[hour] = value
+		}
+	case types.ExportSpainToPortugal:
+		for hour, value := range record.Values {
+			result.ExportSpainToPortugal[hour] = value
+		}
+	case types.ExportPortugalToSpain:
+		for hour, value := range record.Values {
+			result.ExportPortugalToSpain[hour] = value
+		}
+	default: // Registered with a data type of its own
+		if result.CustomConcepts == nil {
+			result.CustomConcepts = make(map[types.DataTypeInMarginalPriceFile]types.HourlyValues)
+		}
+		if result.CustomConcepts[record.Concept] == nil {
+			result.CustomConcepts[record.Concept] = make(types.HourlyValues)
+		}
+		maps.Copy(result.CustomConcepts[record.Concept], record.Values)
 	}
 }
@@ -1,10 +1,15 @@
 package parsers
 
 import (
+	"fmt"
+	"io"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/text/encoding/charmap"
+
 	"github.com/devuo/omiedata/types"
 )
 
@@ -71,14 +76,10 @@ func TestMarginalPriceParser_ParseFile(t *testing.T) {
 				t.Errorf("expected date %v, got %v", tt.expectedDate, data.Date)
 			}
 
-			if len(data.SpainPrices) == 0 {
-				t.Errorf("should have Spain prices")
-			}
-
-			// Validate hour count
-			spainHours := len(data.SpainPrices)
-			if spainHours != tt.expectedHours {
-				t.Errorf("expected %d hours, got %d", tt.expectedHours, spainHours)
+			// Validate hour count across all series, since adjustment files have no marginal prices
+			hours := len(data.HoursSorted())
+			if hours != tt.expectedHours {
+				t.Errorf("expected %d hours, got %d", tt.expectedHours, hours)
 			}
 
 			t.Logf("Parsed data for %s with %d Spain prices",
@@ -125,12 +126,12 @@ func validate2006Format(t *testing.T, data *types.MarginalPriceData) {
 		t.Errorf("2006 format should not have Portugal prices, got %d", len(data.PortugalPrices))
 	}
 
-	// Should have energy data - currently parsed as decimal values
+	// Energies use a dot as thousands separator, without decimals
 	// From testdata: Energía en el programa resultante de la casación (MWh);  26.377;  26.070;...
 	expectedEnergy := map[int]float64{
-		1:  26.377, // Currently parsing as decimal, not thousands separator
-		2:  26.070, // Currently parsing as decimal
-		24: 25.373, // Currently parsing as decimal
+		1:  26377,
+		2:  26070,
+		24: 25373,
 	}
 
 	for hour, expectedEng := range expectedEnergy {
@@ -144,7 +145,7 @@ func validate2006Format(t *testing.T, data *types.MarginalPriceData) {
 		}
 
 		if !found {
-			t.Errorf("hour %d: expected energy value %.3f MWh not found in any energy field", hour, expectedEng)
+			t.Errorf("hour %d: expected energy value %.1f MWh not found in any energy field", hour, expectedEng)
 		}
 	}
 
@@ -212,36 +213,49 @@ func validate2009Format(t *testing.T, data *types.MarginalPriceData) {
 		}
 	}
 
+	// Interconnection flows by direction
+	// From testdata: Exportación de España a Portugal (MWh);   1071,6;   1079,8;...
+	if flow := data.ExportSpainToPortugal[1]; math.Abs(flow-1071.6) > 0.1 {
+		t.Errorf("hour 1 Spain to Portugal flow: expected 1071.6 MWh, got %.1f MWh", flow)
+	}
+	if len(data.ExportPortugalToSpain) != 24 || data.ExportPortugalToSpain[1] != 0 {
+		t.Errorf("expected 24 zero Portugal to Spain flows, got %v", data.ExportPortugalToSpain)
+	}
+
 	t.Logf("✓ 2009 format: dual market prices, energy data, market coupling")
 }
 
 func validate2022Format(t *testing.T, data *types.MarginalPriceData) {
-	// 2022 format: This file contains adjustment prices (EUR/MWh), all zeros
+	// 2022 format: This file contains adjustment prices (EUR/MWh), all zeros, and no marginal prices
 	// This is a DST change day with 25 hours
 	// From testdata: Precio de ajuste en el sistema español (EUR/MWh);     0,00;     0,00;...
 
+	// Adjustment prices must not masquerade as day-ahead prices
+	if len(data.SpainPrices) != 0 || len(data.PortugalPrices) != 0 {
+		t.Errorf("adjustment file should have no marginal prices, got %d Spain and %d Portugal",
+			len(data.SpainPrices), len(data.PortugalPrices))
+	}
+
 	// Validate DST day has 25 hours
-	if len(data.SpainPrices) != 25 {
-		t.Errorf("DST day should have 25 hours, got %d", len(data.SpainPrices))
+	if len(data.SpainAdjustmentPrices) != 25 {
+		t.Errorf("DST day should have 25 hours, got %d", len(data.SpainAdjustmentPrices))
+	}
+	if len(data.PortugalAdjustmentPrices) != 25 {
+		t.Errorf("DST day should have 25 Portugal hours, got %d", len(data.PortugalAdjustmentPrices))
 	}
 
 	// All adjustment prices should be 0.00 EUR/MWh
 	for hour := 1; hour <= 25; hour++ {
-		if price, exists := data.SpainPrices[hour]; !exists {
-			t.Errorf("missing Spain price for hour %d on DST day", hour)
+		if price, exists := data.SpainAdjustmentPrices[hour]; !exists {
+			t.Errorf("missing Spain adjustment price for hour %d on DST day", hour)
 		} else if price != 0.0 {
 			t.Errorf("hour %d adjustment price: expected 0.00 EUR/MWh, got %.2f EUR/MWh",
 				hour, price)
 		}
-	}
 
-	// Portugal prices should also be zero if present
-	if len(data.PortugalPrices) > 0 {
-		for hour := 1; hour <= 25; hour++ {
-			if price, exists := data.PortugalPrices[hour]; exists && price != 0.0 {
-				t.Errorf("hour %d Portugal adjustment price: expected 0.00 EUR/MWh, got %.2f EUR/MWh",
-					hour, price)
-			}
+		if price := data.PortugalAdjustmentPrices[hour]; price != 0.0 {
+			t.Errorf("hour %d Portugal adjustment price: expected 0.00 EUR/MWh, got %.2f EUR/MWh",
+				hour, price)
 		}
 	}
 
@@ -295,3 +309,471 @@ func TestMarginalPriceParser_EuropeanNumberFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGroupedFloat(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"26.377", 26377},        // 2006 energy, dot as thousands separator
+		{"  26.070", 26070},      // padded like in the files
+		{"1.071,6", 1071.6},      // thousands separator and comma decimal
+		{"15.934.000", 15934000}, // several thousands separators
+		{"24326,2", 24326.2},     // comma decimal only
+		{"292", 292},
+	}
+
+	for _, tc := range testCases {
+		result, err := ParseGroupedFloat(tc.input)
+		if err != nil {
+			t.Errorf("ParseGroupedFloat(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if math.Abs(result-tc.expected) > 0.001 {
+			t.Errorf("ParseGroupedFloat(%q): expected %.3f, got %.3f", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"-0,01", -0.01},            // negative prices, legal since 2024
+		{"  -12,50", -12.5},         // padded like in the files
+		{"-1.071,6", -1071.6},       // sign and thousands separator
+		{"+3,25", 3.25},             // explicit plus sign
+		{"\u22125,00", -5},          // minus sign character
+		{"\u00a042,50\u00a0", 42.5}, // non-breaking spaces as padding
+		{"1\u202f071,6", 1071.6},    // narrow no-break space as thousands separator
+		{"7\u2009087,2", 7087.2},    // thin space as thousands separator
+		{",5", 0.5},                 // no integer part
+		{"-,5", -0.5},
+		{"5,", 5}, // no decimals
+		{"15.934.000", 15934000},
+		{"3.14", 3.14},
+	}
+
+	for _, tc := range testCases {
+		result, err := ParseFloat(tc.input)
+		if err != nil {
+			t.Errorf("ParseFloat(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if math.Abs(result-tc.expected) > 1e-9 {
+			t.Errorf("ParseFloat(%q): expected %v, got %v", tc.input, tc.expected, result)
+		}
+	}
+
+	// Negative zero is zero
+	for _, input := range []string{"-0,00", "\u22120", "-0"} {
+		if result, err := ParseFloat(input); err != nil || result != 0 || math.Signbit(result) {
+			t.Errorf("ParseFloat(%q) = %v, %v, want 0", input, result, err)
+		}
+	}
+
+	for _, input := range []string{"--1", "1-", "+-1", "1,2,3", "NaN", "\u2212", "1 000", "1e5", "2,5E3"} {
+		if result, err := ParseFloat(input); err == nil {
+			t.Errorf("ParseFloat(%q) = %v, want an error", input, result)
+		}
+	}
+}
+
+func TestMarginalPriceParser_QuarterHourly(t *testing.T) {
+	// Build a file with 96 quarter-hour periods, as published since the 15-minute MTU change
+	var header, prices strings.Builder
+	for period := 1; period <= 96; period++ {
+		fmt.Fprintf(&header, ";%d", period)
+		fmt.Fprintf(&prices, ";%d,50", period)
+	}
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :30/09/2025 - 13:10;;01/10/2025;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		header.String() + ";\n" +
+		"Precio marginal en el sistema español (EUR/MWh)" + prices.String() + ";\n"
+
+	result, err := NewMarginalPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	data := result.(*types.MarginalPriceData)
+	if data.Resolution != types.QuarterHourly {
+		t.Errorf("expected quarter-hourly resolution, got %v", data.Resolution)
+	}
+	if len(data.SpainPrices) != 96 {
+		t.Errorf("expected 96 periods, got %d", len(data.SpainPrices))
+	}
+
+	// Period 6 is the second quarter of hour 2
+	period, _ := types.NewPeriodIndex(6)
+	if price, ok := data.PeriodValue(data.SpainPrices, period); !ok || price != 6.5 {
+		t.Errorf("expected price 6.5 for period 6, got %v (%v)", price, ok)
+	}
+
+	// Hourly files answer period queries with the value of the enclosing hour
+	hourly, err := NewMarginalPriceParser().ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	hourlyData := hourly.(*types.MarginalPriceData)
+	if hourlyData.Resolution != types.Hourly {
+		t.Errorf("expected hourly resolution, got %v", hourlyData.Resolution)
+	}
+	if price, _ := hourlyData.PeriodValue(hourlyData.SpainPrices, period); price != hourlyData.SpainPrices[2] {
+		t.Errorf("expected the hour 2 price for period 6, got %v", price)
+	}
+}
+
+func TestMarginalPriceParser_LegacyOMEL(t *testing.T) {
+	// No pre-2006 files are kept in testdata; these follow the assumed legacy OMEL layouts,
+	// unverified against original files: a header with only the data date, peseta prices
+	// before the euro changeover and labels whose case differs from the later files
+	tests := []struct {
+		name     string
+		file     string
+		date     time.Time
+		expected float64 // Hour 1 Spain price in EUR/MWh
+	}{
+		{
+			name: "1999 peseta prices",
+			file: "OMEL - Mercado de electricidad;;15/01/1999;Precio del mercado diario (Pta/kWh);;\n\n" +
+				";1;2;3;\n" +
+				"Precio marginal (Pta/kWh);  5,012;  4,500;  4,213;\n",
+			date:     time.Date(1999, 1, 15, 0, 0, 0, 0, time.UTC),
+			expected: 5.012 * 1000 / 166.386,
+		},
+		{
+			name: "2003 lowercase cent prices",
+			file: "OMEL - Mercado de electricidad;;01/03/2003;Precio del mercado diario (cent/kWh);;\n\n" +
+				";1;2;3;\n" +
+				"Precio marginal (cent/kWh);  2,850;  2,600;  2,410;\n",
+			date:     time.Date(2003, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: 28.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewMarginalPriceParser().ParseReader(strings.NewReader(tt.file))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			data := result.(*types.MarginalPriceData)
+			if !data.Date.Equal(tt.date) {
+				t.Errorf("expected date %v, got %v", tt.date, data.Date)
+			}
+			if len(data.SpainPrices) != 3 {
+				t.Errorf("expected 3 hours, got %d", len(data.SpainPrices))
+			}
+			if price := data.SpainPrices[1]; math.Abs(price-tt.expected) > 0.001 {
+				t.Errorf("hour 1: expected %.3f EUR/MWh, got %.3f EUR/MWh", tt.expected, price)
+			}
+		})
+	}
+}
+
+func TestMarginalPriceParser_Strict(t *testing.T) {
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		";1;2;3;\n" +
+		"Precio marginal en el sistema español (EUR/MWh);  74,50;  n/d;  70,10;\n"
+
+	lenient, err := NewMarginalPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	data := lenient.(*types.MarginalPriceData)
+	if len(data.SpainPrices) != 2 {
+		t.Errorf("expected the invalid value to be skipped, got %v", data.SpainPrices)
+	}
+	if len(data.Warnings) != 1 || data.Warnings[0].Line != 4 || !strings.Contains(data.Warnings[0].Reason, "n/d") {
+		t.Errorf("expected a warning for the invalid value on line 4, got %+v", data.Warnings)
+	}
+
+	strict := NewMarginalPriceParser()
+	strict.Strict = true
+	_, err = strict.ParseReader(strings.NewReader(file))
+	if err == nil || !strings.Contains(err.Error(), "line 4") || !strings.Contains(err.Error(), "n/d") {
+		t.Errorf("expected an error pointing at line 4 and the invalid value, got %v", err)
+	}
+
+	// The published files are well formed
+	for _, filename := range []string{"../testdata/PMD_20060101.txt", "../testdata/PMD_20090601.txt", "../testdata/PMD_20221030.txt"} {
+		if _, err := strict.ParseFile(filename); err != nil {
+			t.Errorf("%s: unexpected error in strict mode: %v", filename, err)
+		}
+	}
+}
+
+func TestMarginalPriceParser_FormatVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		file     string
+		want     types.FormatVersion
+	}{
+		{name: "2006 single market", filename: "../testdata/PMD_20060101.txt", want: types.FormatCentSingleMarket},
+		{name: "2009 dual market", filename: "../testdata/PMD_20090601.txt", want: types.FormatCentDualMarket},
+		{name: "2022 adjustment", filename: "../testdata/PMD_20221030.txt", want: types.FormatAdjustment},
+		{
+			name: "1999 pesetas",
+			file: "OMEL - Mercado de electricidad;;15/01/1999;Precio del mercado diario (Pta/kWh);;\n\n" +
+				";1;2;3;\n" +
+				"Precio marginal (Pta/kWh);  5,012;  4,500;  4,213;\n",
+			want: types.FormatLegacyPeseta,
+		},
+		{
+			name: "2024 euro",
+			file: "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+				";1;2;3;\n" +
+				"Precio marginal en el sistema español (EUR/MWh);  74,50;  72,00;  70,10;\n" +
+				"Precio marginal en el sistema portugués (EUR/MWh);  74,50;  72,00;  70,10;\n",
+			want: types.FormatEuro,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result interface{}
+			var err error
+			if tt.filename != "" {
+				result, err = NewMarginalPriceParser().ParseFile(tt.filename)
+			} else {
+				result, err = NewMarginalPriceParser().ParseReader(strings.NewReader(tt.file))
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			data := result.(*types.MarginalPriceData)
+			if data.FormatVersion != tt.want {
+				t.Errorf("expected format %q, got %q", tt.want, data.FormatVersion)
+			}
+			if got := data.FormatVersion.ExpectsPortugalPrices(); got != (len(data.PortugalPrices) > 0) {
+				t.Errorf("ExpectsPortugalPrices() = %v with %d Portugal prices", got, len(data.PortugalPrices))
+			}
+		})
+	}
+}
+
+func TestMarginalPriceParser_Encoding(t *testing.T) {
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		";1;2;3;\n" +
+		"Precio marginal en el sistema español (EUR/MWh);  74,50;  72,00;  70,10;\n" +
+		"Precio marginal en el sistema portugués (EUR/MWh);  74,50;  72,00;  70,10;\n"
+	latin1, err := charmap.ISO8859_1.NewEncoder().String(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want types.Encoding
+	}{
+		{name: "ISO-8859-1", file: latin1, want: types.EncodingLatin1},
+		{name: "UTF-8", file: file, want: types.EncodingUTF8},
+		{name: "UTF-8 with BOM", file: "\ufeff" + file, want: types.EncodingUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewMarginalPriceParser().ParseReader(strings.NewReader(tt.file))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			data := result.(*types.MarginalPriceData)
+			if data.Encoding != tt.want {
+				t.Errorf("expected encoding %q, got %q", tt.want, data.Encoding)
+			}
+			// The labels only match when the accents are decoded right
+			if len(data.SpainPrices) != 3 || len(data.PortugalPrices) != 3 {
+				t.Errorf("expected 3 prices of each country, got %v and %v", data.SpainPrices, data.PortugalPrices)
+			}
+		})
+	}
+
+	result, err := NewMarginalPriceParser().ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.(*types.MarginalPriceData).Encoding; got != types.EncodingLatin1 {
+		t.Errorf("expected the fixture to be %q, got %q", types.EncodingLatin1, got)
+	}
+}
+
+func TestNewDecodingReader(t *testing.T) {
+	// A character split by the end of the bytes inspected doesn't make UTF-8 invalid
+	split := "é" + strings.Repeat("a", encodingSniffSize-3) + "é;ñ"
+	reader, encoding := NewDecodingReader(strings.NewReader(split))
+	if encoding != types.EncodingUTF8 {
+		t.Errorf("expected %q, got %q", types.EncodingUTF8, encoding)
+	}
+	if decoded, err := io.ReadAll(reader); err != nil || string(decoded) != split {
+		t.Errorf("expected the text unchanged, got %v", err)
+	}
+
+	// ASCII alone is read as ISO-8859-1, like the accents that may come after it
+	latin1 := strings.Repeat("a", encodingSniffSize) + "\xe9"
+	reader, encoding = NewDecodingReader(strings.NewReader(latin1))
+	if encoding != types.EncodingLatin1 {
+		t.Errorf("expected %q, got %q", types.EncodingLatin1, encoding)
+	}
+	if decoded, err := io.ReadAll(reader); err != nil || !strings.HasSuffix(string(decoded), "aé") {
+		t.Errorf("expected the accent decoded, got %v", err)
+	}
+}
+
+func TestFieldSplitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		splitter *FieldSplitter
+		line     string
+		want     []string
+	}{
+		{"plain", NewFieldSplitter(';'), "a;1,5;b", []string{"a", "1,5", "b"}},
+		{"trailing separators", NewFieldSplitter(';'), "a;1,5;; ;", []string{"a", "1,5"}},
+		{"blank line", NewFieldSplitter(';'), ";;;", []string{""}},
+		{"empty columns kept", NewFieldSplitter(';'), "a;;b", []string{"a", "", "b"}},
+		{"quoted separator", NewFieldSplitter(';'), `"a;b";c`, []string{"a;b", "c"}},
+		{"doubled quote", NewFieldSplitter(';'), `"say ""hi""";c`, []string{`say "hi"`, "c"}},
+		{"padded quote", NewFieldSplitter(';'), `x; "7,5" ;y`, []string{"x", "7,5 ", "y"}},
+		{"unclosed quote", NewFieldSplitter(';'), `"a;b`, []string{`"a`, "b"}},
+		{"comma separator", NewFieldSplitter(','), `FTB,"72,15",`, []string{"FTB", "72,15"}},
+		{"zero value", &FieldSplitter{}, `"a";;`, []string{`"a"`, "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.splitter.Split(tt.line)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+
+	// Concept labels in quotes are read like plain ones
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		";1;2;3;\n" +
+		"\"Precio marginal en el sistema español (EUR/MWh)\";  74,50;  72,00;  70,10;;;\n"
+	result, err := NewMarginalPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if data := result.(*types.MarginalPriceData); len(data.SpainPrices) != 3 || data.FormatVersion != types.FormatEuro {
+		t.Errorf("expected 3 Spain prices of the euro format, got %v (%q)", data.SpainPrices, data.FormatVersion)
+	}
+}
+
+func TestMarginalPriceParser_UnknownConcepts(t *testing.T) {
+	result, err := NewMarginalPriceParser(types.PriceSpain).ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	data := result.(*types.MarginalPriceData)
+
+	// The Portuguese energies aren't mapped to a concept; the concepts not loaded aren't kept
+	if len(data.UnknownConcepts) != 2 {
+		t.Fatalf("expected the 2 Portuguese energy rows, got %v", data.UnknownConcepts)
+	}
+	buy := data.UnknownConcepts["Energía total de compra sistema portugués (MWh)"]
+	if len(buy) != 24 || buy[1] != 3999.6 {
+		t.Errorf("unexpected Portuguese buy energy %v", buy)
+	}
+	if len(data.SpainPrices) != 24 || len(data.IberianEnergy) != 0 {
+		t.Errorf("expected only the Spain prices loaded, got %d prices and %d energies", len(data.SpainPrices), len(data.IberianEnergy))
+	}
+
+	// Rows of new concepts are kept even when no known concept is left
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		";1;2;3;\n" +
+		"Precio de un concepto nuevo (EUR/MWh);  -1,50;  x;  70,10;\n" +
+		"Energía de un concepto nuevo (MWh);  26.377;;  1.071,6;\n"
+	result, err = NewMarginalPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	unknown := result.(*types.MarginalPriceData).UnknownConcepts
+	if prices := unknown["Precio de un concepto nuevo (EUR/MWh)"]; len(prices) != 2 || prices[1] != -1.5 || prices[3] != 70.1 {
+		t.Errorf("unexpected prices of the new concept %v", prices)
+	}
+	if energies := unknown["Energía de un concepto nuevo (MWh)"]; len(energies) != 2 || energies[1] != 26377 || energies[3] != 1071.6 {
+		t.Errorf("unexpected energies of the new concept %v", energies)
+	}
+
+	result, err = NewMarginalPriceParser().ParseFile("../testdata/PMD_20060101.txt")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if unknown := result.(*types.MarginalPriceData).UnknownConcepts; unknown != nil {
+		t.Errorf("expected no unknown concepts, got %v", unknown)
+	}
+}
+
+func TestMarginalPriceParser_RegisterConcept(t *testing.T) {
+	parser := NewMarginalPriceParser(types.PriceSpain)
+	if err := parser.RegisterConcept("energía total de compra sistema PORTUGUÉS (MWh)", "ENER_BUY_PT", 0); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	result, err := parser.ParseFile("../testdata/PMD_20090601.txt")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	data := result.(*types.MarginalPriceData)
+
+	// Labels match whatever their case, and data types of their own are always loaded
+	if buy := data.CustomConcepts["ENER_BUY_PT"]; len(buy) != 24 || buy[1] != 3999.6 {
+		t.Errorf("unexpected Portuguese buy energy %v", buy)
+	}
+	if _, unknown := data.UnknownConcepts["Energía total de compra sistema portugués (MWh)"]; unknown || len(data.UnknownConcepts) != 1 {
+		t.Errorf("expected only the Portuguese sell energy unknown, got %v", data.UnknownConcepts)
+	}
+
+	// A new label of a built-in concept fills its field, with the multiplier applied
+	file := "OMIE - Mercado de electricidad;Fecha Emisión :14/01/2024 - 13:05;;15/01/2024;Precio del mercado diario (EUR/MWh);;;;\n\n" +
+		";1;2;3;\n" +
+		"Precio marginal del sistema ibérico (Cent/kWh);  -0,15;  7,01;  7,02;\n"
+	parser = NewMarginalPriceParser()
+	if err := parser.RegisterConcept("Precio marginal del sistema ibérico (Cent/kWh)", types.PriceSpain, 10); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	result, err = parser.ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	data = result.(*types.MarginalPriceData)
+	if prices := data.SpainPrices; len(prices) != 3 || prices[1] != -1.5 || math.Abs(prices[3]-70.2) > 1e-9 {
+		t.Errorf("unexpected Spain prices %v", prices)
+	}
+	if data.UnknownConcepts != nil || data.CustomConcepts != nil {
+		t.Errorf("expected no unknown or custom concepts, got %v and %v", data.UnknownConcepts, data.CustomConcepts)
+	}
+
+	for _, tc := range []struct {
+		label      string
+		dataType   types.DataTypeInMarginalPriceFile
+		multiplier float64
+	}{
+		{" ", types.PriceSpain, 1},
+		{"Precio (EUR/MWh)", "", 1},
+		{"Precio (EUR/MWh)", types.PriceSpain, math.NaN()},
+	} {
+		if err := parser.RegisterConcept(tc.label, tc.dataType, tc.multiplier); err == nil {
+			t.Errorf("RegisterConcept(%q, %q, %v) succeeded", tc.label, tc.dataType, tc.multiplier)
+		}
+	}
+}
+
+func TestParseHour(t *testing.T) {
+	for input, want := range map[string]types.HourIndex{"1": 1, " 24 ": 24, "25": 25} {
+		if hour, err := ParseHour(input); err != nil || hour != want {
+			t.Errorf("ParseHour(%q) = %d, %v, want %d", input, hour, err, want)
+		}
+	}
+	for _, input := range []string{"", "0", "26", "x", "1,5"} {
+		if _, err := ParseHour(input); err == nil {
+			t.Errorf("ParseHour(%q) succeeded, want an error", input)
+		}
+	}
+}
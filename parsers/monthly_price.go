@@ -0,0 +1,149 @@
+package parsers
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// MonthlyPriceParser parses the monthly average price files (medias mensuales), which list
+// one value per concept row:
+//
+//	OMIE - Mercado de electricidad;Fecha Emisión :01/02/2024 - 10:00;;01/01/2024;Medias mensuales...
+//	Precio medio aritmético en el sistema español (EUR/MWh);  74,12;
+//	Precio máximo en el sistema español (EUR/MWh);  120,00;
+//	...
+//
+// The layout follows the concept rows of OMIE's daily files and hasn't been checked
+// against a published monthly file yet.
+type MonthlyPriceParser struct{}
+
+// NewMonthlyPriceParser creates a new monthly average price parser
+func NewMonthlyPriceParser() *MonthlyPriceParser {
+	return &MonthlyPriceParser{}
+}
+
+// ParseResponse parses monthly average prices from an HTTP response
+func (p *MonthlyPriceParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses monthly average prices from a file
+func (p *MonthlyPriceParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses monthly average prices from a reader, returning a
+// *types.MonthlyPriceSummary
+func (p *MonthlyPriceParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	if !lines.Scan() {
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
+		return nil, types.NewOMIEError(types.ErrCodeParse, "empty file", nil)
+	}
+
+	// The month is given by the last date of the header, after the emission date
+	dateMatches := headerDateRegex.FindAllString(lines.Text(), -1)
+	if len(dateMatches) == 0 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no date found in header", nil)
+	}
+	date, err := ParseDate(dateMatches[len(dateMatches)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	nan := math.NaN()
+	summary := &types.MonthlyPriceSummary{
+		Month:           time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()),
+		SpainAverage:    nan,
+		PortugalAverage: nan,
+		SpainMin:        nan,
+		SpainMax:        nan,
+		PortugalMin:     nan,
+		PortugalMax:     nan,
+		IberianEnergy:   nan,
+	}
+
+	found := false
+	for lines.Scan() {
+		fields := SplitCSV(lines.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		target, multiplier := p.mapConcept(summary, strings.TrimSpace(fields[0]))
+		if target == nil {
+			continue
+		}
+
+		value, err := ParseFloat(fields[1])
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+
+		*target = value * multiplier
+		found = true
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid data found", nil)
+	}
+
+	return summary, nil
+}
+
+// mapConcept returns the summary field a concept row fills and the multiplier converting
+// its value to EUR/MWh or MWh, or nil for rows that aren't of interest
+func (p *MonthlyPriceParser) mapConcept(summary *types.MonthlyPriceSummary, concept string) (*float64, float64) {
+	label := strings.ToLower(concept)
+
+	multiplier := 1.0
+	if strings.Contains(label, "cent/kwh") {
+		multiplier = 10.0
+	}
+
+	if strings.HasPrefix(label, "energía") {
+		return &summary.IberianEnergy, 1.0
+	}
+	if !strings.HasPrefix(label, "precio") {
+		return nil, 0
+	}
+
+	var average, min, max *float64
+	switch {
+	case strings.Contains(label, "español"):
+		average, min, max = &summary.SpainAverage, &summary.SpainMin, &summary.SpainMax
+	case strings.Contains(label, "portugués"):
+		average, min, max = &summary.PortugalAverage, &summary.PortugalMin, &summary.PortugalMax
+	default:
+		return nil, 0
+	}
+
+	switch {
+	case strings.Contains(label, "máximo"):
+		return max, multiplier
+	case strings.Contains(label, "mínimo"):
+		return min, multiplier
+	case strings.Contains(label, "medio"):
+		return average, multiplier
+	}
+	return nil, 0
+}
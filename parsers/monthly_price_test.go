@@ -0,0 +1,50 @@
+package parsers
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestMonthlyPriceParser_ParseReader(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :01/02/2024 - 10:00;;31/01/2024;Medias mensuales del mercado diario;;
+;
+Precio medio aritmético en el sistema español (EUR/MWh);    74,12;
+Precio máximo en el sistema español (EUR/MWh);   120,00;
+Precio mínimo en el sistema español (EUR/MWh);     1,05;
+Precio medio aritmético en el sistema portugués (Cent/kWh);    7,410;
+Energía total del mercado Ibérico (MWh); 19.034.512,3;
+;;
+`
+
+	result, err := NewMonthlyPriceParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary, ok := result.(*types.MonthlyPriceSummary)
+	if !ok {
+		t.Fatalf("expected *types.MonthlyPriceSummary, got %T", result)
+	}
+
+	if !summary.Month.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected January 2024, got %v", summary.Month)
+	}
+	if summary.SpainAverage != 74.12 || summary.SpainMax != 120 || summary.SpainMin != 1.05 {
+		t.Errorf("unexpected Spain values: %+v", summary)
+	}
+	if math.Abs(summary.PortugalAverage-74.1) > 1e-9 {
+		t.Errorf("expected Cent/kWh converted to 74.1 EUR/MWh, got %v", summary.PortugalAverage)
+	}
+	if summary.IberianEnergy != 19034512.3 {
+		t.Errorf("expected 19034512.3 MWh, got %v", summary.IberianEnergy)
+	}
+
+	// Concepts missing from the file are NaN
+	if !math.IsNaN(summary.PortugalMax) {
+		t.Errorf("expected NaN Portugal maximum, got %v", summary.PortugalMax)
+	}
+}
@@ -0,0 +1,217 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// SupplyDemandCurveParser parses the aggregated supply/demand curve files
+// (INT_CURVA_ACUM_UO_MIB), which hold the bids of a single hour
+type SupplyDemandCurveParser struct{}
+
+// NewSupplyDemandCurveParser creates a new supply/demand curve parser
+func NewSupplyDemandCurveParser() *SupplyDemandCurveParser {
+	return &SupplyDemandCurveParser{}
+}
+
+// ParseResponse parses curve data from an HTTP response
+func (p *SupplyDemandCurveParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses curve data from a file
+func (p *SupplyDemandCurveParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses curve data from a reader, returning a *types.MarketCurve
+func (p *SupplyDemandCurveParser) ParseReader(reader io.Reader) (interface{}, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	date, err := scanCurveHeader(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := &types.MarketCurve{Date: date, Aggregation: types.Aggregated}
+
+	// Data starts after the column headers line ("Hora;Fecha;Pais;Unidad;...")
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || isCurveColumnHeader(line) {
+			continue
+		}
+
+		hour, offerType, point, err := parseCurveLine(line)
+		if err != nil {
+			continue // Skip invalid and trailing empty lines
+		}
+
+		if curve.Hour == 0 {
+			curve.Hour = hour
+		} else if hour != curve.Hour {
+			return nil, types.NewOMIEError(types.ErrCodeParse, fmt.Sprintf("curve file mixes hours %d and %d", curve.Hour, hour), nil)
+		}
+
+		switch offerType {
+		case types.Sell:
+			curve.Supply = append(curve.Supply, point)
+		case types.Buy:
+			curve.Demand = append(curve.Demand, point)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if lines.Line() < 3 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
+	if len(curve.Supply) == 0 && len(curve.Demand) == 0 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid curve points found", nil)
+	}
+
+	return curve, nil
+}
+
+// parseCurveDay parses a whole-day curve file into a *types.MarketCurveDay with one curve
+// per hour (or period), in hour order. The file is read a line at a time, since files by
+// bidding unit run to hundreds of thousands of lines.
+func parseCurveDay(reader io.Reader, aggregation types.AggregationLevel) (*types.MarketCurveDay, error) {
+	lines := NewLineScanner(reader)
+	defer lines.Close()
+
+	date, err := scanCurveHeader(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	curves := make(map[int]*types.MarketCurve)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || isCurveColumnHeader(line) {
+			continue
+		}
+
+		hour, offerType, point, err := parseCurveLine(line)
+		if err != nil {
+			continue // Skip invalid and trailing empty lines
+		}
+
+		curve, ok := curves[hour]
+		if !ok {
+			curve = &types.MarketCurve{Date: date, Hour: hour, Aggregation: aggregation}
+			curves[hour] = curve
+		}
+
+		switch offerType {
+		case types.Sell:
+			curve.Supply = append(curve.Supply, point)
+		case types.Buy:
+			curve.Demand = append(curve.Demand, point)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if lines.Line() < 3 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
+	if len(curves) == 0 {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "no valid offers found", nil)
+	}
+
+	day := &types.MarketCurveDay{Date: date}
+	for _, curve := range curves {
+		day.Curves = append(day.Curves, *curve)
+	}
+	sort.Slice(day.Curves, func(i, j int) bool { return day.Curves[i].Hour < day.Curves[j].Hour })
+
+	return day, nil
+}
+
+// parseCurveLine parses a single bid line of a curve file, returning its hour (or
+// quarter-hour period in files published since the 15-minute MTU change):
+// Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C)
+func parseCurveLine(line string) (int, types.OfferType, types.MarketPoint, error) {
+	fieldsPtr := splitCSVPooled(line)
+	defer releaseFields(fieldsPtr)
+
+	fields := *fieldsPtr
+	if len(fields) < 7 { // The status may be left blank at the end of the line
+		return 0, "", types.MarketPoint{}, types.NewOMIEError(types.ErrCodeParse, "insufficient fields", nil)
+	}
+
+	hour, err := ParsePeriod(fields[0])
+	if err != nil {
+		return 0, "", types.MarketPoint{}, err
+	}
+
+	offerType := types.OfferType(strings.TrimSpace(fields[4]))
+	if offerType != types.Buy && offerType != types.Sell {
+		return 0, "", types.MarketPoint{}, types.NewOMIEError(types.ErrCodeParse, "unknown offer type", nil)
+	}
+
+	energy, err := ParseFloat(fields[5])
+	if err != nil {
+		return 0, "", types.MarketPoint{}, err
+	}
+
+	price, err := ParseFloat(fields[6])
+	if err != nil {
+		return 0, "", types.MarketPoint{}, err
+	}
+
+	point := types.MarketPoint{
+		Energy:   energy,
+		Price:    price,
+		UnitCode: strings.TrimSpace(fields[3]),
+	}
+	if len(fields) > 7 {
+		point.Matched = types.MatchedStatus(strings.TrimSpace(fields[7]))
+	}
+
+	return hour.Int(), offerType, point, nil
+}
+
+// parseCurveHeader extracts the market date from the header of a curve file, the last
+// date in the line after the emission date
+func parseCurveHeader(headerLine string) (time.Time, error) {
+	dateMatches := headerDateRegex.FindAllString(headerLine, -1)
+	if len(dateMatches) == 0 {
+		return time.Time{}, types.NewOMIEError(types.ErrCodeParse, "no date found in header", nil)
+	}
+	return ParseDate(dateMatches[len(dateMatches)-1])
+}
+
+// scanCurveHeader reads the first line of a curve file and returns its market date
+func scanCurveHeader(lines *LineScanner) (time.Time, error) {
+	if !lines.Scan() {
+		if err := lines.Err(); err != nil {
+			return time.Time{}, err
+		}
+		return time.Time{}, types.NewOMIEError(types.ErrCodeParse, "insufficient lines in file", nil)
+	}
+	return parseCurveHeader(lines.Text())
+}
+
+// isCurveColumnHeader reports whether a line is the column headers line of a curve
+// file, which starts with "Hora" or, since the 15-minute MTU change, "Periodo"
+func isCurveColumnHeader(line string) bool {
+	return strings.HasPrefix(line, "Hora;") || strings.HasPrefix(line, "Periodo;")
+}
@@ -0,0 +1,50 @@
+package parsers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestSupplyDemandCurveParser_ParseFile(t *testing.T) {
+	parser := NewSupplyDemandCurveParser()
+	result, err := parser.ParseFile("../testdata/OfferAndDemandCurve_1_20090102.TXT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	curve, ok := result.(*types.MarketCurve)
+	if !ok {
+		t.Fatalf("expected *types.MarketCurve, got %T", result)
+	}
+
+	expectedDate := time.Date(2009, 1, 2, 0, 0, 0, 0, time.UTC)
+	if !curve.Date.Equal(expectedDate) {
+		t.Errorf("expected date %v, got %v", expectedDate, curve.Date)
+	}
+	if curve.Hour != 1 {
+		t.Errorf("expected hour 1, got %d", curve.Hour)
+	}
+	if curve.Aggregation != types.Aggregated {
+		t.Errorf("expected aggregated curve, got %q", curve.Aggregation)
+	}
+
+	// 1100 offered + 627 matched sell points, 141 offered + 72 matched buy points
+	if len(curve.Supply) != 1727 {
+		t.Errorf("expected 1727 supply points, got %d", len(curve.Supply))
+	}
+	if len(curve.Demand) != 213 {
+		t.Errorf("expected 213 demand points, got %d", len(curve.Demand))
+	}
+
+	if matched := curve.MatchedOnly(); len(matched.Supply) != 627 || len(matched.Demand) != 72 {
+		t.Errorf("expected 627/72 matched points, got %d/%d", len(matched.Supply), len(matched.Demand))
+	}
+
+	// First line: 1;02/01/2009;MI;;C;3.922,0;18,030;O;
+	first := curve.Demand[0]
+	if first.Energy != 3922.0 || first.Price != 18.03 || first.Matched != types.Offered {
+		t.Errorf("unexpected first demand point: %+v", first)
+	}
+}
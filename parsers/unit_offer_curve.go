@@ -0,0 +1,44 @@
+package parsers
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// UnitOfferCurveParser parses the curva_pbc_uof files, which list every offer of the
+// day-ahead market by bidding unit for all the hours of a day
+type UnitOfferCurveParser struct{}
+
+// NewUnitOfferCurveParser creates a new unit offer curve parser
+func NewUnitOfferCurveParser() *UnitOfferCurveParser {
+	return &UnitOfferCurveParser{}
+}
+
+// ParseResponse parses unit offers from an HTTP response
+func (p *UnitOfferCurveParser) ParseResponse(resp *http.Response) (interface{}, error) {
+	return p.ParseReader(resp.Body)
+}
+
+// ParseFile parses unit offers from a file
+func (p *UnitOfferCurveParser) ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to open file", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader parses unit offers from a reader, returning a *types.MarketCurveDay with
+// one curve per hour (or period) and the unit code set on every point
+func (p *UnitOfferCurveParser) ParseReader(reader io.Reader) (interface{}, error) {
+	day, err := parseCurveDay(reader, types.UnitLevel)
+	if err != nil {
+		return nil, err
+	}
+	return day, nil
+}
@@ -0,0 +1,99 @@
+package parsers
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestUnitOfferCurveParser_ParseReader(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario incluyendo unidades;;;;
+;
+Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C);
+2;05/03/2024;MI;ACE3;V;1.010,0;0,00;O;
+1;05/03/2024;MI;ACE3;V;1.010,0;0,00;O;
+1;05/03/2024;MI;CTN1;V;300,5;65,20;O;
+1;05/03/2024;MI;ACE3;V;1.010,0;0,00;C;
+1;05/03/2024;MI;IBEC;C;2.500,0;180,30;O;
+;;;;;;;;
+`
+
+	result, err := NewUnitOfferCurveParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day, ok := result.(*types.MarketCurveDay)
+	if !ok {
+		t.Fatalf("expected *types.MarketCurveDay, got %T", result)
+	}
+
+	if !day.Date.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", day.Date)
+	}
+	if len(day.Curves) != 2 || day.Curves[0].Hour != 1 || day.Curves[1].Hour != 2 {
+		t.Fatalf("expected curves for hours 1 and 2 in order, got %d curves", len(day.Curves))
+	}
+
+	hour1 := day.Curves[0]
+	if hour1.Aggregation != types.UnitLevel {
+		t.Errorf("expected unit-level curve, got %q", hour1.Aggregation)
+	}
+	if len(hour1.Supply) != 3 || len(hour1.Demand) != 1 {
+		t.Errorf("expected 3 supply and 1 demand points, got %d/%d", len(hour1.Supply), len(hour1.Demand))
+	}
+	if point := hour1.Supply[1]; point.UnitCode != "CTN1" || point.Energy != 300.5 || point.Price != 65.2 {
+		t.Errorf("unexpected supply point: %+v", point)
+	}
+	if units := hour1.ByUnit(); len(units["ACE3"].Supply) != 2 {
+		t.Errorf("expected 2 points for ACE3, got %v", units["ACE3"])
+	}
+}
+
+func TestAggregatedCurveParser_ParseReader(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario;;;;
+;
+Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C);
+1;05/03/2024;MI;;V;12.010,0;0,00;O;
+1;05/03/2024;MI;;C;25.000,0;180,30;O;
+2;05/03/2024;MI;;V;11.500,0;0,00;C;
+`
+
+	result, err := NewAggregatedCurveParser().ParseReader(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := result.(*types.MarketCurveDay)
+	if len(day.Curves) != 2 {
+		t.Fatalf("expected 2 curves, got %d", len(day.Curves))
+	}
+	for _, curve := range day.Curves {
+		if curve.Aggregation != types.Aggregated {
+			t.Errorf("hour %d: expected aggregated curve, got %q", curve.Hour, curve.Aggregation)
+		}
+	}
+	if point := day.Curves[0].Supply[0]; point.Energy != 12010 || point.UnitCode != "" {
+		t.Errorf("unexpected supply point: %+v", point)
+	}
+}
+
+func TestUnitOfferCurveParser_ReadError(t *testing.T) {
+	file := `OMIE - Mercado de electricidad;Fecha Emisión :04/03/2024 - 14:05;;05/03/2024;Curvas agregadas de oferta y demanda del mercado diario incluyendo unidades;;;;
+Hora;Fecha;Pais;Unidad;Tipo Oferta;Energía Compra/Venta;Precio Compra/Venta;Ofertada (O)/Casada (C);
+1;05/03/2024;MI;ACE3;V;1.010,0;0,00;O;
+`
+	errCut := errors.New("connection reset")
+
+	// A file cut short mid-stream fails rather than parsing the lines read so far
+	_, err := NewUnitOfferCurveParser().ParseReader(io.MultiReader(strings.NewReader(file), iotest.ErrReader(errCut)))
+	var omieErr *types.OMIEError
+	if !errors.Is(err, errCut) || !errors.As(err, &omieErr) || omieErr.Code != types.ErrCodeParse {
+		t.Errorf("expected a parse error caused by the read error, got %v", err)
+	}
+}
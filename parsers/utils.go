@@ -2,11 +2,15 @@ package parsers
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"io"
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/transform"
@@ -14,33 +18,98 @@ import (
 	"github.com/devuo/omiedata/types"
 )
 
-// ParseFloat parses a European-formatted float (dot as thousands separator, comma as decimal separator)
+// ParseFloat parses a European-formatted float (dot as thousands separator, comma as decimal separator).
+// Signs may be written as "+", "-" or the "−" minus sign, and non-breaking and thin spaces
+// are ignored wherever they are, as padding or as thousands separators. A negative zero
+// like "-0,00" is read as zero.
 func ParseFloat(s string) (float64, error) {
-	if strings.TrimSpace(s) == "" {
+	s = cleanNumber(s)
+	if s == "" {
 		return math.NaN(), nil
 	}
+	if !isDecimal(s) {
+		// strconv would also read spellings no OMIE file uses, like "NaN", "Inf" or hex
+		return 0, types.NewOMIEError(types.ErrCodeParse, fmt.Sprintf("invalid number %q", s), nil)
+	}
 
-	s = strings.TrimSpace(s)
+	value, err := parseDecimal(s)
+	if value == 0 && err == nil {
+		return 0, nil // Not -0, which prints as "-0"
+	}
+	return value, err
+}
 
+// parseDecimal parses a number cleaned by cleanNumber
+func parseDecimal(s string) (float64, error) {
 	// Handle European format: 7.087,2 -> 7087.2
 	// Remove thousands separators (dots) and convert decimal separator (comma) to dot
-	lastCommaIndex := strings.LastIndex(s, ",")
-	if lastCommaIndex != -1 {
-		// Has comma - assume it's the decimal separator
-		beforeComma := strings.Replace(s[:lastCommaIndex], ".", "", -1) // Remove all dots before comma
-		afterComma := s[lastCommaIndex+1:]                              // Everything after comma
-		s = beforeComma + "." + afterComma                              // Combine with dot as decimal
-	} else {
+	lastCommaIndex := strings.LastIndexByte(s, ',')
+	if lastCommaIndex == -1 {
 		// No comma - might just be integer with thousands separators
-		// Check if it looks like a thousands-separated integer
-		if strings.Contains(s, ".") && len(strings.Split(s, ".")) > 2 {
-			// Multiple dots, likely thousands separators: 15.934 -> 15934
-			s = strings.Replace(s, ".", "", -1)
+		if strings.Count(s, ".") > 1 {
+			// Multiple dots, likely thousands separators: 15.934.000 -> 15934000
+			s = strings.ReplaceAll(s, ".", "")
 		}
 		// Single dot is treated as decimal separator (e.g., "3.14")
+		return strconv.ParseFloat(s, 64)
+	}
+
+	// Has comma - assume it's the decimal separator. The normalized number is built in
+	// a stack buffer so the common case doesn't allocate.
+	var buf [32]byte
+	normalized := buf[:0]
+	for i := 0; i < lastCommaIndex; i++ {
+		if s[i] != '.' { // Remove all dots before comma
+			normalized = append(normalized, s[i])
+		}
 	}
+	normalized = append(normalized, '.')
+	normalized = append(normalized, s[lastCommaIndex+1:]...)
 
-	return strconv.ParseFloat(s, 64)
+	return strconv.ParseFloat(string(normalized), 64)
+}
+
+// cleanNumber trims the padding around a number, dropping the non-breaking and thin
+// spaces in it and writing the minus sign as "-"
+func cleanNumber(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return strings.TrimSpace(strings.Map(func(r rune) rune {
+				switch r {
+				case '\u00a0', '\u2007', '\u2009', '\u202f': // No-break, figure, thin and narrow no-break spaces
+					return -1
+				case '\u2212':
+					return '-'
+				}
+				return r
+			}, s))
+		}
+	}
+	return strings.TrimSpace(s) // ASCII, the common case
+}
+
+// isDecimal reports whether s holds only the characters of a decimal number: digits,
+// separators and signs. OMIE files never write exponents, so "1e5" is rejected.
+func isDecimal(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9', c == '.', c == ',', c == '-', c == '+':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseGroupedFloat parses a European-formatted float in which a dot is always a
+// thousands separator, even a single one: "26.377" is 26377 rather than 26.377. Use it for
+// values known to be written this way, like the energies of the 2006-era files; ParseFloat
+// reads a single dot as a decimal point.
+func ParseGroupedFloat(s string) (float64, error) {
+	if !strings.Contains(s, ",") && strings.Count(s, ".") == 1 {
+		s = strings.Replace(s, ".", "", 1)
+	}
+	return ParseFloat(s)
 }
 
 // ParseDate parses a date in DD/MM/YYYY format
@@ -54,25 +123,294 @@ func NewISO88591Reader(r io.Reader) io.Reader {
 	return transform.NewReader(r, decoder)
 }
 
-// ReadLines reads all lines from a reader and returns them as a slice
-func ReadLines(reader io.Reader) ([]string, error) {
-	var lines []string
+// encodingSniffSize is the number of bytes NewDecodingReader inspects. The title line of
+// the OMIE files has accented words ("Emisión", "Ibérico"), so the encoding shows in it.
+const encodingSniffSize = 4096
+
+// utf8BOM is the byte order mark some UTF-8 files start with
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// NewDecodingReader creates a reader that decodes r to UTF-8 from the encoding detected
+// in its first bytes, which it returns: UTF-8 when r starts with a byte order mark, which
+// is dropped, or when the bytes are valid UTF-8 with some non-ASCII character, and
+// ISO-8859-1, the encoding of the OMIE files, otherwise. Text decoded already, e.g. by
+// NewISO88591Reader, is passed through as UTF-8.
+func NewDecodingReader(r io.Reader) (io.Reader, types.Encoding) {
+	head := make([]byte, encodingSniffSize)
+	n, err := io.ReadFull(r, head)
+	head = head[:n]
+
+	var rest io.Reader = r
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		rest = eofReader{}
+	} else if err != nil {
+		rest = errReader{err} // Fail the reads after the bytes read
+	}
+
+	if bytes.HasPrefix(head, utf8BOM) {
+		return io.MultiReader(bytes.NewReader(head[len(utf8BOM):]), rest), types.EncodingUTF8
+	}
+	reader := io.MultiReader(bytes.NewReader(head), rest)
+	if isUTF8Text(head, err == nil) {
+		return reader, types.EncodingUTF8
+	}
+	return NewISO88591Reader(reader), types.EncodingLatin1
+}
+
+// isUTF8Text reports whether head is valid UTF-8 holding some non-ASCII character. When
+// head was cut short, a character split at its end is left out.
+func isUTF8Text(head []byte, cut bool) bool {
+	if cut {
+		for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(head[i]) {
+				if !utf8.FullRune(head[i:]) {
+					head = head[:i]
+				}
+				break
+			}
+		}
+	}
+
+	ascii := true
+	for _, c := range head {
+		if c >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	return !ascii && utf8.Valid(head)
+}
+
+// eofReader is an io.Reader at its end
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
+
+// errReader is an io.Reader failing with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// scanBufferPool recycles the line buffers used by LineScanner across files
+var scanBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 64*1024)
+		return &buf
+	},
+}
+
+// fieldsPool recycles the field slices used while parsing data lines
+var fieldsPool = sync.Pool{
+	New: func() interface{} {
+		fields := make([]string, 0, 32)
+		return &fields
+	},
+}
+
+// LineScanner reads a file one line at a time, so parsers can handle every line as it
+// is read instead of holding the whole file in memory, like with ReadLines. Its buffer
+// comes from a pool shared across files and goes back to it on Close.
+type LineScanner struct {
+	scanner  *bufio.Scanner
+	buf      *[]byte
+	line     int
+	encoding types.Encoding
+}
+
+// NewLineScanner creates a scanner of the lines of reader, decoded to UTF-8 from the
+// encoding detected by NewDecodingReader
+func NewLineScanner(reader io.Reader) *LineScanner {
+	reader, encoding := NewDecodingReader(reader)
+	bufPtr := scanBufferPool.Get().(*[]byte)
 	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(*bufPtr, bufio.MaxScanTokenSize)
+	return &LineScanner{scanner: scanner, buf: bufPtr, encoding: encoding}
+}
+
+// Encoding returns the encoding the file was decoded from
+func (s *LineScanner) Encoding() types.Encoding {
+	return s.encoding
+}
+
+// Scan advances to the next line, returning false at the end of the file or on error
+func (s *LineScanner) Scan() bool {
+	if !s.scanner.Scan() {
+		return false
+	}
+	s.line++
+	return true
+}
+
+// Text returns the current line, without its line ending
+func (s *LineScanner) Text() string {
+	return s.scanner.Text()
+}
 
+// Line returns the number of the current line, from 1, or the number of lines read once
+// Scan returned false
+func (s *LineScanner) Line() int {
+	return s.line
+}
+
+// Err returns the error that stopped Scan, if any, as an ErrCodeParse error
+func (s *LineScanner) Err() error {
+	if err := s.scanner.Err(); err != nil {
+		return types.NewOMIEError(types.ErrCodeParse, "failed to read lines", err)
+	}
+	return nil
+}
+
+// Close returns the buffer of the scanner to the pool. The scanner can't be used after.
+func (s *LineScanner) Close() {
+	if s.buf != nil {
+		scanBufferPool.Put(s.buf)
+		s.buf = nil
+	}
+}
+
+// ReadLines reads all lines from a reader and returns them as a slice. Parsers read
+// large files with a LineScanner instead.
+func ReadLines(reader io.Reader) ([]string, error) {
+	scanner := NewLineScanner(reader)
+	defer scanner.Close()
+
+	lines := make([]string, 0, 64)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, types.NewOMIEError(types.ErrCodeParse, "failed to read lines", err)
+		return nil, err
 	}
 
 	return lines, nil
 }
 
-// SplitCSV splits a CSV line by semicolon separator
+// FieldSplitter splits the lines of delimited files into fields, tolerating what turns
+// up in OMIE files beyond plain separators: fields in quotes holding separators, and
+// stray separators ending a line, which would otherwise read as empty columns
+type FieldSplitter struct {
+	// Separator is the byte between fields, a semicolon when zero
+	Separator byte
+
+	// Quote starts fields in which separators are part of the value, up to the closing
+	// quote, a doubled quote standing for a quote; none when zero. A field whose quote
+	// isn't closed is read as is.
+	Quote byte
+
+	// TrimTrailing drops the blank fields at the end of a line, keeping the first field
+	TrimTrailing bool
+}
+
+// NewFieldSplitter creates a splitter of the fields separated by separator, with double
+// quotes and trailing separators trimmed
+func NewFieldSplitter(separator byte) *FieldSplitter {
+	return &FieldSplitter{Separator: separator, Quote: '"', TrimTrailing: true}
+}
+
+// omieFields splits the fields of the OMIE files
+var omieFields = NewFieldSplitter(';')
+
+// Split returns the fields of line, at least one
+func (s *FieldSplitter) Split(line string) []string {
+	return s.appendFields(make([]string, 0, strings.Count(line, string(s.separator()))+1), line)
+}
+
+// appendFields appends the fields of line to fields
+func (s *FieldSplitter) appendFields(fields []string, line string) []string {
+	separator := s.separator()
+	start := len(fields)
+	for {
+		field, rest, more := s.next(line, separator)
+		fields = append(fields, field)
+		if !more {
+			break
+		}
+		line = rest
+	}
+
+	if s.TrimTrailing {
+		for len(fields) > start+1 && strings.TrimSpace(fields[len(fields)-1]) == "" {
+			fields = fields[:len(fields)-1]
+		}
+	}
+	return fields
+}
+
+// separator returns the separator of the splitter
+func (s *FieldSplitter) separator() byte {
+	if s.Separator == 0 {
+		return ';'
+	}
+	return s.Separator
+}
+
+// next returns the first field of line and the rest of the line after its separator,
+// reporting whether there was a separator
+func (s *FieldSplitter) next(line string, separator byte) (string, string, bool) {
+	if s.Quote != 0 {
+		padding := len(line) - len(strings.TrimLeft(line, " \t"))
+		if padding < len(line) && line[padding] == s.Quote {
+			if field, rest, more, ok := s.quoted(line[padding+1:], separator); ok {
+				return field, rest, more
+			}
+		}
+	}
+
+	i := strings.IndexByte(line, separator)
+	if i == -1 {
+		return line, "", false
+	}
+	return line[:i], line[i+1:], true
+}
+
+// quoted returns the quoted field line starts with, after its opening quote, like next.
+// Text between the closing quote and the separator is kept. It reports false when the
+// quote isn't closed.
+func (s *FieldSplitter) quoted(line string, separator byte) (string, string, bool, bool) {
+	var field strings.Builder
+	for {
+		i := strings.IndexByte(line, s.Quote)
+		if i == -1 {
+			return "", "", false, false
+		}
+		if i+1 < len(line) && line[i+1] == s.Quote {
+			field.WriteString(line[:i+1])
+			line = line[i+2:]
+			continue
+		}
+		field.WriteString(line[:i])
+		line = line[i+1:]
+		break
+	}
+
+	i := strings.IndexByte(line, separator)
+	if i == -1 {
+		field.WriteString(line)
+		return field.String(), "", false, true
+	}
+	field.WriteString(line[:i])
+	return field.String(), line[i+1:], true, true
+}
+
+// SplitCSV splits a line of an OMIE file into its semicolon-separated fields, see
+// FieldSplitter
 func SplitCSV(line string) []string {
-	return strings.Split(line, ";")
+	return omieFields.Split(line)
+}
+
+// splitCSVPooled splits a line like SplitCSV into a slice borrowed from a pool. The
+// slice must not be retained after calling releaseFields.
+func splitCSVPooled(line string) *[]string {
+	fieldsPtr := fieldsPool.Get().(*[]string)
+	*fieldsPtr = omieFields.appendFields((*fieldsPtr)[:0], line)
+	return fieldsPtr
+}
+
+// releaseFields returns a slice obtained from splitCSVPooled to the pool
+func releaseFields(fieldsPtr *[]string) {
+	clear(*fieldsPtr) // Don't keep the line strings reachable from the pool
+	fieldsPool.Put(fieldsPtr)
 }
 
 // FindDatesInString finds dates in DD/MM/YYYY format in a string
@@ -94,7 +432,7 @@ func FindDatesInString(s string) []string {
 }
 
 // ParseHour parses hour value, handling 1-24 format
-func ParseHour(s string) (int, error) {
+func ParseHour(s string) (types.HourIndex, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, types.NewOMIEError(types.ErrCodeParse, "empty hour value", nil)
@@ -105,14 +443,33 @@ func ParseHour(s string) (int, error) {
 		return 0, types.NewOMIEError(types.ErrCodeParse, "invalid hour format", err)
 	}
 
-	if hour < 1 || hour > 25 { // Allow 25 for DST changes
+	if !types.HourIndex(hour).Valid() { // Allow 25 for DST changes
 		return 0, types.NewOMIEError(types.ErrCodeParse, "hour out of range (1-25)", nil)
 	}
 
-	return hour, nil
+	return types.HourIndex(hour), nil
+}
+
+// ParsePeriod parses a quarter-hour period index (1-100) as used since the 15-minute MTU change
+func ParsePeriod(s string) (types.PeriodIndex, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, types.NewOMIEError(types.ErrCodeParse, "empty period value", nil)
+	}
+
+	period, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, types.NewOMIEError(types.ErrCodeParse, "invalid period format", err)
+	}
+
+	if !types.PeriodIndex(period).Valid() {
+		return 0, types.NewOMIEError(types.ErrCodeParse, "period out of range (1-100)", nil)
+	}
+
+	return types.PeriodIndex(period), nil
 }
 
-// IsValidPriceValue checks if a price value is valid (not NaN or negative for prices)
+// IsValidPriceValue checks if a price value is valid: finite, negative prices included
 func IsValidPriceValue(value float64) bool {
 	return !math.IsNaN(value) && !math.IsInf(value, 0)
 }
@@ -121,3 +478,36 @@ func IsValidPriceValue(value float64) bool {
 func IsValidEnergyValue(value float64) bool {
 	return !math.IsNaN(value) && !math.IsInf(value, 0) && value >= 0
 }
+
+// skippedLines records the data rows and values a parser skips, as warnings or, in
+// strict mode, as the error to fail with
+type skippedLines struct {
+	strict   bool
+	warnings []types.ParseWarning
+}
+
+// skip records that line number (1-based) or part of it was skipped for reason. It
+// returns the error to fail with in strict mode and nil otherwise.
+func (s *skippedLines) skip(number int, line, reason string, err error) error {
+	line = strings.TrimSpace(line)
+	if s.strict {
+		return types.NewOMIEError(types.ErrCodeParse, fmt.Sprintf("malformed line %d %q: %s", number, line, reason), err)
+	}
+	s.warnings = append(s.warnings, types.ParseWarning{Line: number, Text: line, Reason: reason})
+	return nil
+}
+
+// skipRow is skip for a row that failed with err
+func (s *skippedLines) skipRow(number int, line string, err error) error {
+	reason := err.Error()
+	if omieErr, ok := err.(*types.OMIEError); ok {
+		reason = omieErr.Message
+	}
+	return s.skip(number, line, reason, err)
+}
+
+// isBlankRow reports whether a line holds only empty fields, like the ";;;;" rows that
+// end some files
+func isBlankRow(line string) bool {
+	return strings.Trim(line, "; \t\r") == ""
+}
@@ -0,0 +1,62 @@
+package schedule
+
+import "time"
+
+// Calendar tells when OMIE publishes the files of a delivery date: on the day before
+// delivery at the Publication time, or up to Late after it when the files are late. It
+// is also a Spec running at every publication.
+type Calendar struct {
+	Publication Daily
+	Late        time.Duration
+}
+
+var (
+	// DayAheadCalendar is the publication calendar of the day-ahead results, whose
+	// marginal prices are published around 13:00 to 14:00 for the next day
+	DayAheadCalendar = Calendar{Publication: DayAheadPublication, Late: PublicationRetry.For}
+
+	// TechnologyCalendar is the publication calendar of the energy by technology files
+	TechnologyCalendar = Calendar{Publication: TechnologyPublication, Late: PublicationRetry.For}
+)
+
+// Expected returns when the files of the delivery date are expected: at the publication
+// time of the day before
+func (c Calendar) Expected(delivery time.Time) time.Time {
+	return time.Date(delivery.Year(), delivery.Month(), delivery.Day()-1,
+		c.Publication.Hour, c.Publication.Minute, 0, 0, c.Publication.location())
+}
+
+// Deadline returns when the files of the delivery date should have been published even
+// when late. Looking for them after that is pointless until OMIE republishes.
+func (c Calendar) Deadline(delivery time.Time) time.Time {
+	return c.Expected(delivery).Add(c.Late)
+}
+
+// Published reports whether the files of the delivery date are expected to be out at
+// now, so callers don't request files that can't exist yet
+func (c Calendar) Published(delivery, now time.Time) bool {
+	return !now.Before(c.Expected(delivery))
+}
+
+// Delivery returns the delivery date of the files published on the day of at, the day
+// after in Madrid, as a UTC midnight like the importers expect
+func (c Calendar) Delivery(at time.Time) time.Time {
+	local := at.In(c.Publication.location())
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Latest returns the last delivery date whose files are expected to be out at now:
+// tomorrow from the publication time on, today before it
+func (c Calendar) Latest(now time.Time) time.Time {
+	delivery := c.Delivery(now)
+	if !c.Published(delivery, now) {
+		delivery = delivery.AddDate(0, 0, -1)
+	}
+	return delivery
+}
+
+// Next returns the first publication strictly after after, so a calendar can be the
+// Spec of the job importing its files
+func (c Calendar) Next(after time.Time) time.Time {
+	return c.Publication.Next(after)
+}
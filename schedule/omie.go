@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+// Publication times of OMIE files, in Madrid time. OMIE doesn't guarantee them, so
+// they include some margin after the usual publication.
+var (
+	// DayAheadPublication is when the day-ahead results for the next day are available
+	DayAheadPublication = Daily{Hour: 13, Minute: 30}
+
+	// TechnologyPublication is when the energy by technology files for the next day are available
+	TechnologyPublication = Daily{Hour: 14, Minute: 0}
+
+	// PublicationRetry is how the jobs importing published files retry when the files
+	// are late: every 10 minutes until the evening
+	PublicationRetry = Retry{Every: 10 * time.Minute, For: 6 * time.Hour}
+)
+
+// DayAheadPrices returns a job importing the next day's marginal prices when they are
+// published and passing them to handler, retrying with PublicationRetry until they
+// appear. Its Spec is DayAheadCalendar; set CatchUp to also import the latest published
+// day when the scheduler starts.
+func DayAheadPrices(importer *importers.MarginalPriceImporter, handler func(context.Context, *types.MarginalPriceData) error) Job {
+	return Job{
+		Name:  "day-ahead prices",
+		Spec:  DayAheadCalendar,
+		Retry: PublicationRetry,
+		Run: func(ctx context.Context, at time.Time) error {
+			data, err := importer.ImportDay(ctx, DayAheadCalendar.Delivery(at))
+			if err != nil {
+				return err
+			}
+			return handler(ctx, data)
+		},
+	}
+}
+
+// Technology returns a job importing the next day's energy by technology when it is
+// published and passing it to handler, retrying with PublicationRetry until it appears.
+// Its Spec is TechnologyCalendar.
+func Technology(importer *importers.EnergyByTechnologyImporter, handler func(context.Context, *types.TechnologyEnergyDay) error) Job {
+	return Job{
+		Name:  "energy by technology",
+		Spec:  TechnologyCalendar,
+		Retry: PublicationRetry,
+		Run: func(ctx context.Context, at time.Time) error {
+			data, err := importer.ImportDay(ctx, TechnologyCalendar.Delivery(at))
+			if err != nil {
+				return err
+			}
+			return handler(ctx, data)
+		},
+	}
+}
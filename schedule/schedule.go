@@ -0,0 +1,214 @@
+// Package schedule runs recurring jobs at OMIE publication times, turning the
+// importers into a small ingestion daemon
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Madrid is the time zone OMIE publishes its files in
+var Madrid = types.MarketLocation()
+
+// Spec decides when a job runs next
+type Spec interface {
+	// Next returns the first run time strictly after after
+	Next(after time.Time) time.Time
+}
+
+// Daily runs once a day at a wall-clock time in a location (Madrid when nil)
+type Daily struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+// Next returns the first occurrence of the daily time strictly after after
+func (d Daily) Next(after time.Time) time.Time {
+	loc := d.location()
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), d.Hour, d.Minute, 0, 0, loc)
+	if !next.After(after) {
+		// Build the next day from its calendar date so DST changes keep the wall-clock time
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, d.Hour, d.Minute, 0, 0, loc)
+	}
+	return next
+}
+
+// location returns the location of the daily time
+func (d Daily) location() *time.Location {
+	if d.Location == nil {
+		return Madrid
+	}
+	return d.Location
+}
+
+// previous returns the last time of spec at or before before, looking back two days, or
+// the zero time when spec runs less often
+func previous(spec Spec, before time.Time) time.Time {
+	var last time.Time
+	for at := spec.Next(before.Add(-48 * time.Hour)); !at.After(before); at = spec.Next(at) {
+		last = at
+	}
+	return last
+}
+
+// Job is a recurring task run by a Scheduler
+type Job struct {
+	Name  string
+	Spec  Spec
+	Run   func(ctx context.Context, at time.Time) error
+	Retry Retry
+
+	// CatchUp also runs the job when the scheduler starts, for its last scheduled time,
+	// e.g. so a daemon started after a publication imports the files right away rather
+	// than a day later. Its retries still stop Retry.For after that time.
+	CatchUp bool
+}
+
+// Retry runs a failed job again, e.g. while the files it imports aren't published yet.
+// Failures types.Classify finds permanent aren't retried. The zero value doesn't retry.
+type Retry struct {
+	Every time.Duration // Wait between attempts
+	For   time.Duration // How long after its scheduled time a run keeps being retried
+}
+
+// Scheduler runs jobs at the times given by their specs
+type Scheduler struct {
+	jobs    []Job
+	onError func(job Job, err error)
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job. Jobs must be added before calling Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// OnError sets a function called whenever a job returns an error, once its retries
+// are exhausted
+func (s *Scheduler) OnError(fn func(job Job, err error)) {
+	s.onError = fn
+}
+
+// Run runs the jobs at their scheduled times until ctx is cancelled, then waits for
+// the running jobs to return. A job still running when its next time comes is skipped
+// for that occurrence. Jobs set to CatchUp run right away for their last time.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	now := time.Now()
+	next := make([]time.Time, len(s.jobs))
+	running := make([]bool, len(s.jobs))
+	var mu sync.Mutex
+
+	// launch runs job i for its scheduled time at, unless it is still running
+	launch := func(i int, job Job, at time.Time) {
+		mu.Lock()
+		busy := running[i]
+		running[i] = true
+		mu.Unlock()
+		if busy {
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runWithRetry(ctx, job, at); err != nil && s.onError != nil {
+				s.onError(job, err)
+			}
+			mu.Lock()
+			running[i] = false
+			mu.Unlock()
+		}()
+	}
+
+	for i, job := range s.jobs {
+		next[i] = job.Spec.Next(now)
+		if job.CatchUp {
+			if at := previous(job.Spec, now); !at.IsZero() {
+				launch(i, job, at)
+			}
+		}
+	}
+
+	for {
+		if len(s.jobs) == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		// Sleep until the earliest job is due
+		earliest := 0
+		for i := range next {
+			if next[i].Before(next[earliest]) {
+				earliest = i
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next[earliest]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		now = time.Now()
+		for i, job := range s.jobs {
+			if next[i].After(now) {
+				continue
+			}
+			at := next[i]
+			next[i] = job.Spec.Next(now)
+			launch(i, job, at)
+		}
+	}
+}
+
+// runWithRetry runs job for its scheduled time at, retrying it as set by job.Retry, and
+// returns the error of the last attempt
+func runWithRetry(ctx context.Context, job Job, at time.Time) error {
+	deadline := at.Add(job.Retry.For)
+	for {
+		err := job.Run(ctx, at)
+		if err == nil || job.Retry.Every <= 0 || types.Classify(err) == types.ClassPermanent ||
+			time.Now().Add(job.Retry.Every).After(deadline) {
+			return err
+		}
+
+		timer := time.NewTimer(job.Retry.Every)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Multi returns a handler passing data to every handler in turn, e.g. to store imported
+// data in several places. All handlers run even if some fail, and their errors are
+// returned joined. A job retrying after a failed handler delivers the data to every
+// handler again, so handlers should be idempotent.
+func Multi[T any](handlers ...func(context.Context, T) error) func(context.Context, T) error {
+	return func(ctx context.Context, data T) error {
+		var errs []error
+		for _, handler := range handlers {
+			if err := handler(ctx, data); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDailyNext(t *testing.T) {
+	spec := Daily{Hour: 13, Minute: 30}
+
+	tests := []struct {
+		name  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "later the same day",
+			after: time.Date(2024, 1, 15, 9, 0, 0, 0, Madrid),
+			want:  time.Date(2024, 1, 15, 13, 30, 0, 0, Madrid),
+		},
+		{
+			name:  "exactly at the time moves to the next day",
+			after: time.Date(2024, 1, 15, 13, 30, 0, 0, Madrid),
+			want:  time.Date(2024, 1, 16, 13, 30, 0, 0, Madrid),
+		},
+		{
+			name:  "across the spring DST change",
+			after: time.Date(2024, 3, 30, 14, 0, 0, 0, Madrid),
+			want:  time.Date(2024, 3, 31, 13, 30, 0, 0, Madrid),
+		},
+		{
+			name:  "input in another zone",
+			after: time.Date(2024, 7, 1, 11, 0, 0, 0, time.UTC), // 13:00 in Madrid
+			want:  time.Date(2024, 7, 1, 13, 30, 0, 0, Madrid),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spec.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	delivery := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC) // Spring DST change
+
+	expected := DayAheadCalendar.Expected(delivery)
+	if want := time.Date(2024, 3, 30, 13, 30, 0, 0, Madrid); !expected.Equal(want) {
+		t.Errorf("Expected() = %s, want %s", expected, want)
+	}
+	if deadline := DayAheadCalendar.Deadline(delivery); !deadline.Equal(expected.Add(6 * time.Hour)) {
+		t.Errorf("Deadline() = %s, want 6 hours after %s", deadline, expected)
+	}
+	if DayAheadCalendar.Published(delivery, expected.Add(-time.Minute)) || !DayAheadCalendar.Published(delivery, expected) {
+		t.Error("expected the day to be published from its expected time on")
+	}
+
+	// Late evening UTC is already the next day in Madrid
+	at := time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)
+	if got, want := DayAheadCalendar.Delivery(at), time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Delivery(%s) = %s, want %s", at, got, want)
+	}
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 1, 15, 9, 0, 0, 0, Madrid), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 15, 13, 30, 0, 0, Madrid), time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 15, 23, 0, 0, 0, Madrid), time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := DayAheadCalendar.Latest(tt.now); !got.Equal(tt.want) {
+			t.Errorf("Latest(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+// soon runs a job once, shortly after the scheduler starts
+type soon struct{ once sync.Once }
+
+func (s *soon) Next(after time.Time) time.Time {
+	next := after.Add(24 * time.Hour)
+	s.once.Do(func() { next = after.Add(10 * time.Millisecond) })
+	return next
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	var attempts atomic.Int32
+	var failures []error
+	succeeded := make(chan struct{})
+
+	s := New()
+	s.Add(Job{
+		Name: "flaky",
+		Spec: &soon{},
+		Run: func(ctx context.Context, at time.Time) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("not published yet")
+			}
+			close(succeeded)
+			return nil
+		},
+		Retry: Retry{Every: 10 * time.Millisecond, For: time.Minute},
+	})
+	s.Add(Job{
+		Name: "broken",
+		Spec: &soon{},
+		Run: func(ctx context.Context, at time.Time) error {
+			return errors.New("always failing")
+		},
+		Retry: Retry{Every: 10 * time.Millisecond, For: 30 * time.Millisecond},
+	})
+
+	var mu sync.Mutex
+	s.OnError(func(job Job, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if job.Name != "broken" {
+			t.Errorf("unexpected error from %s: %v", job.Name, err)
+		}
+		failures = append(failures, err)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		<-succeeded
+		time.Sleep(100 * time.Millisecond) // Let the broken job exhaust its retries
+		cancel()
+	}()
+	s.Run(ctx)
+
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("expected 3 attempts of the flaky job, got %d", got)
+	}
+	if len(failures) != 1 {
+		t.Errorf("expected the broken job to report a single error, got %v", failures)
+	}
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	// Scheduled a minute ago, so next due tomorrow
+	last := time.Now().UTC().Add(-time.Minute).Truncate(time.Minute)
+	spec := Daily{Hour: last.Hour(), Minute: last.Minute(), Location: time.UTC}
+
+	ran := make(chan time.Time, 2)
+	s := New()
+	for _, catchUp := range []bool{true, false} {
+		s.Add(Job{
+			Spec:    spec,
+			CatchUp: catchUp,
+			Run: func(ctx context.Context, at time.Time) error {
+				ran <- at
+				return nil
+			},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	close(ran)
+	var runs []time.Time
+	for at := range ran {
+		runs = append(runs, at)
+	}
+	if len(runs) != 1 || !runs[0].Equal(last) {
+		t.Errorf("expected a single catch-up run for %s, got %v", last, runs)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	var calls []string
+	handler := Multi(
+		func(ctx context.Context, s string) error {
+			calls = append(calls, "a:"+s)
+			return errors.New("a failed")
+		},
+		func(ctx context.Context, s string) error {
+			calls = append(calls, "b:"+s)
+			return nil
+		},
+	)
+
+	err := handler(context.Background(), "x")
+	if err == nil || err.Error() != "a failed" {
+		t.Errorf("expected the error of the failed handler, got %v", err)
+	}
+	if len(calls) != 2 || calls[1] != "b:x" {
+		t.Errorf("expected every handler to run, got %v", calls)
+	}
+}
@@ -0,0 +1,222 @@
+// Package duckdb stores imported OMIE data in a DuckDB database for SQL analysis over
+// decades of market history, from Go or the DuckDB CLI. Days are appended through the
+// DuckDB appender, so backfills stay fast, and appending a day again replaces it.
+//
+// The driver links DuckDB through cgo, so building this package needs a C toolchain. The
+// package is a module of its own, keeping the bindings out of the builds of the rest of
+// the library.
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"math"
+	"strings"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+
+	"github.com/devuo/omiedata/store"
+	"github.com/devuo/omiedata/types"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Store appends OMIE data to the tables of a DuckDB database: prices, with the columns
+// date, hour, resolution, series and value, and technology_energy, with the columns
+// date, hour, system, technology and value. Missing values are NULL.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens, or creates, the database at path, or an in-memory database when path is
+// empty, and brings its schema up to date
+func Open(ctx context.Context, path string) (*Store, error) {
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to open "+path, err)
+	}
+
+	s, err := New(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New creates a store on an open DuckDB database, bringing its schema up to date
+func New(ctx context.Context, db *sql.DB) (*Store, error) {
+	migrations, err := store.LoadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Migrate(ctx, db, store.DuckDB, migrations); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// DB returns the underlying database, to run SQL over the stored data
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AppendPrices appends days of marginal prices, replacing the stored values of the same
+// dates. Missing values are skipped.
+func (s *Store) AppendPrices(ctx context.Context, days ...*types.MarginalPriceData) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	where, args := inDates(days, func(day *types.MarginalPriceData) time.Time { return day.Date })
+	return s.appendRows(ctx, "prices", where, args, func(appendRow func(...driver.Value) error) error {
+		for _, day := range days {
+			resolution := types.Hourly
+			if day.Resolution == types.QuarterHourly {
+				resolution = types.QuarterHourly
+			}
+			for _, hour := range day.HoursSorted() {
+				for _, series := range day.Series() {
+					value, ok := series.Values[hour]
+					if !ok || math.IsNaN(value) {
+						continue
+					}
+					if err := appendRow(calendarDate(day.Date), int32(hour), resolution.String(), series.Name, value); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// AppendTechnology appends days of energy by technology, replacing the stored values of
+// the same dates and systems
+func (s *Store) AppendTechnology(ctx context.Context, days ...*types.TechnologyEnergyDay) error {
+	bySystem := make(map[types.SystemType][]*types.TechnologyEnergyDay)
+	for _, day := range days {
+		bySystem[day.System] = append(bySystem[day.System], day)
+	}
+
+	for system, days := range bySystem {
+		where, args := inDates(days, func(day *types.TechnologyEnergyDay) time.Time { return day.Date })
+		where = "system = ? AND " + where
+		args = append([]interface{}{system.String()}, args...)
+
+		err := s.appendRows(ctx, "technology_energy", where, args, func(appendRow func(...driver.Value) error) error {
+			for _, day := range days {
+				var err error
+				day.ForEachHour(func(record types.TechnologyEnergy) {
+					for _, tech := range types.Technologies() {
+						if err != nil {
+							return
+						}
+						var value driver.Value
+						if v, ok := record.Value(tech); ok {
+							value = v
+						}
+						err = appendRow(calendarDate(day.Date), int32(record.Hour), system.String(), strings.ToLower(string(tech)), value)
+					}
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastPriceDate returns the latest date with stored prices, to append the days after it.
+// It returns the zero time when there are none.
+func (s *Store) LastPriceDate(ctx context.Context) (time.Time, error) {
+	return s.lastDate(ctx, "SELECT MAX(date) FROM prices")
+}
+
+// LastTechnologyDate returns the latest date with stored energy by technology of system,
+// or the zero time when there is none
+func (s *Store) LastTechnologyDate(ctx context.Context, system types.SystemType) (time.Time, error) {
+	return s.lastDate(ctx, "SELECT MAX(date) FROM technology_energy WHERE system = ?", system.String())
+}
+
+// lastDate runs a query returning a single, possibly NULL, date
+func (s *Store) lastDate(ctx context.Context, query string, args ...interface{}) (time.Time, error) {
+	var date sql.NullTime
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&date); err != nil {
+		return time.Time{}, types.NewOMIEError(types.ErrCodeStorage, "failed to query the last date", err)
+	}
+	return date.Time, nil
+}
+
+// appendRows deletes the rows of table matching where and appends the rows passed by
+// fill, all in one transaction
+func (s *Store) appendRows(ctx context.Context, table, where string, args []interface{}, fill func(appendRow func(...driver.Value) error) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to connect", err)
+	}
+	defer conn.Close()
+
+	fail := func(err error) error {
+		conn.ExecContext(context.Background(), "ROLLBACK")
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to append to "+table, err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN TRANSACTION"); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to append to "+table, err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+where, args...); err != nil {
+		return fail(err)
+	}
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		appender, err := duckdb.NewAppenderFromConn(driverConn.(driver.Conn), "", table)
+		if err != nil {
+			return err
+		}
+		if err := fill(appender.AppendRow); err != nil {
+			appender.Close()
+			return err
+		}
+		return appender.Close()
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// inDates returns a condition matching the dates of days and its arguments
+func inDates[T any](days []T, date func(T) time.Time) (string, []interface{}) {
+	placeholders := make([]string, len(days))
+	args := make([]interface{}, len(days))
+	for i, day := range days {
+		placeholders[i] = "?"
+		args[i] = calendarDate(date(day))
+	}
+	return "date IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// calendarDate returns the calendar date of t at midnight UTC, as DuckDB stores DATE
+// values
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
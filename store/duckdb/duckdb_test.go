@@ -0,0 +1,91 @@
+package duckdb
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestAppendPrices(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "omie.duckdb"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	first := types.NewMarginalPriceData(date)
+	first.SpainPrices[1] = 45.5
+	first.SpainPrices[2] = 40
+	first.PortugalPrices[1] = math.NaN()
+	second := types.NewMarginalPriceData(date.AddDate(0, 0, 1))
+	second.SpainPrices[1] = 50
+
+	if err := s.AppendPrices(ctx, first, second); err != nil {
+		t.Fatalf("AppendPrices() error: %v", err)
+	}
+
+	// Appending a day again replaces it
+	first.SpainPrices[1] = 46
+	if err := s.AppendPrices(ctx, first); err != nil {
+		t.Fatalf("AppendPrices() error: %v", err)
+	}
+
+	var count int
+	var total float64
+	row := s.DB().QueryRowContext(ctx, "SELECT COUNT(*), SUM(value) FROM prices WHERE series = 'spain_prices'")
+	if err := row.Scan(&count, &total); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if count != 3 || total != 46+40+50 {
+		t.Errorf("expected 3 Spain prices summing 136, got %d summing %v", count, total)
+	}
+
+	last, err := s.LastPriceDate(ctx)
+	if err != nil || !last.Equal(date.AddDate(0, 0, 1)) {
+		t.Errorf("LastPriceDate() = %v, %v", last, err)
+	}
+}
+
+func TestAppendTechnology(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, "")
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if last, err := s.LastTechnologyDate(ctx, types.Spain); err != nil || !last.IsZero() {
+		t.Fatalf("expected no date in an empty store, got %v, %v", last, err)
+	}
+
+	spain := &types.TechnologyEnergyDay{Date: date, System: types.Spain, Records: []types.TechnologyEnergy{
+		{Date: date, Hour: 1, System: types.Spain, Wind: 100, Coal: math.NaN()},
+	}}
+	portugal := &types.TechnologyEnergyDay{Date: date, System: types.Portugal, Records: []types.TechnologyEnergy{
+		{Date: date, Hour: 1, System: types.Portugal, Wind: 30},
+	}}
+	if err := s.AppendTechnology(ctx, spain, portugal); err != nil {
+		t.Fatalf("AppendTechnology() error: %v", err)
+	}
+	if err := s.AppendTechnology(ctx, spain); err != nil {
+		t.Fatalf("AppendTechnology() error: %v", err)
+	}
+
+	var rows, nulls int
+	var wind float64
+	row := s.DB().QueryRowContext(ctx, `SELECT COUNT(*), COUNT(*) - COUNT(value), SUM(value) FILTER (WHERE technology = 'wind')
+		FROM technology_energy`)
+	if err := row.Scan(&rows, &nulls, &wind); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if rows != 24 || nulls != 1 || wind != 130 {
+		t.Errorf("expected 24 rows with 1 null and 130 MWh of wind, got %d, %d, %v", rows, nulls, wind)
+	}
+}
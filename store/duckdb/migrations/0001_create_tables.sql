@@ -0,0 +1,18 @@
+-- Marginal prices, one row per series and hour (or period) with the layout of the
+-- Parquet export, so both can be queried together
+CREATE TABLE prices (
+    date DATE NOT NULL,
+    hour INTEGER NOT NULL,
+    resolution VARCHAR NOT NULL,
+    series VARCHAR NOT NULL,
+    value DOUBLE
+);
+
+-- Energy by technology, one row per system, hour and technology
+CREATE TABLE technology_energy (
+    date DATE NOT NULL,
+    hour INTEGER NOT NULL,
+    system VARCHAR NOT NULL,
+    technology VARCHAR NOT NULL,
+    value DOUBLE
+);
@@ -0,0 +1,194 @@
+// Package store contains shared building blocks for the database storage sinks,
+// such as the versioned schema migrations applied when a sink is opened.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Dialect identifies the SQL flavour of the database a sink writes to
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+	DuckDB
+)
+
+// Placeholder returns the bind parameter syntax for the n-th (1-based) argument
+func (d Dialect) Placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// migrationsTable records which migrations have been applied to a database
+const migrationsTable = "schema_migrations"
+
+// LoadMigrations reads the migrations stored in dir of fsys (typically an embed.FS).
+// Files must be named "<version>_<name>.sql", e.g. "0001_create_prices.sql"; other
+// files are ignored. Versions must be unique and are returned in ascending order.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read migrations", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		versionPart, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionPart)
+		if err != nil || version <= 0 {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, "invalid migration file name "+entry.Name(), err)
+		}
+
+		if other, exists := seen[version]; exists {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("duplicate migration version %d (%s, %s)", version, other, entry.Name()), nil)
+		}
+		seen[version] = entry.Name()
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, "failed to read migration "+entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			SQL:     string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// CurrentVersion returns the highest migration version applied to db, or 0 for a fresh database
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	row := db.QueryRowContext(ctx, "SELECT MAX(version) FROM "+migrationsTable)
+	if err := row.Scan(&version); err != nil {
+		return 0, types.NewOMIEError(types.ErrCodeStorage, "failed to read schema version", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+// Migrate brings the schema of db up to date by applying, in order, every migration
+// newer than the current version. Each migration runs in its own transaction together
+// with its bookkeeping row, so an interrupted upgrade resumes from the last applied step.
+func Migrate(ctx context.Context, db *sql.DB, dialect Dialect, migrations []Migration) error {
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, dialect, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureMigrationsTable creates the bookkeeping table if it doesn't exist yet
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+migrationsTable+` (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL
+	)`)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to create migrations table", err)
+	}
+	return nil
+}
+
+// applyMigration runs a single migration and records it as applied
+func applyMigration(ctx context.Context, db *sql.DB, dialect Dialect, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, "failed to start migration", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(m.SQL) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return types.NewOMIEError(types.ErrCodeStorage, fmt.Sprintf("migration %d (%s) failed", m.Version, m.Name), err)
+		}
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (%s, %s)", migrationsTable, dialect.Placeholder(1), dialect.Placeholder(2))
+	if _, err := tx.ExecContext(ctx, insert, m.Version, m.Name); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, fmt.Sprintf("failed to record migration %d", m.Version), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, fmt.Sprintf("failed to commit migration %d", m.Version), err)
+	}
+
+	return nil
+}
+
+// splitStatements splits a migration script on semicolons at the end of a line, since
+// not every driver accepts several statements in a single Exec call
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteByte('\n')
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
@@ -0,0 +1,45 @@
+-- Days of marginal prices and their values, one row per series and hour (or period)
+CREATE TABLE price_days (
+    date TEXT PRIMARY KEY,
+    resolution TEXT NOT NULL,
+    format_version TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE prices (
+    date TEXT NOT NULL REFERENCES price_days (date),
+    series TEXT NOT NULL,
+    hour INTEGER NOT NULL,
+    value REAL,
+    PRIMARY KEY (date, series, hour)
+);
+
+-- Energy by technology, one row per system, hour and technology
+CREATE TABLE technology_energy (
+    date TEXT NOT NULL,
+    system TEXT NOT NULL,
+    hour INTEGER NOT NULL,
+    technology TEXT NOT NULL,
+    value REAL,
+    PRIMARY KEY (system, date, hour, technology)
+);
+
+-- Supply/demand curves, one row per hour and one per point in file order
+CREATE TABLE curves (
+    date TEXT NOT NULL,
+    hour INTEGER NOT NULL,
+    aggregation TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (date, hour)
+);
+
+CREATE TABLE curve_points (
+    date TEXT NOT NULL,
+    hour INTEGER NOT NULL,
+    side TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    matched TEXT NOT NULL,
+    unit_code TEXT NOT NULL DEFAULT '',
+    energy REAL,
+    price REAL,
+    PRIMARY KEY (date, hour, side, position),
+    FOREIGN KEY (date, hour) REFERENCES curves (date, hour)
+);
@@ -0,0 +1,6 @@
+-- Dates imported by incremental syncs, one row per dataset and date, see importers.Journal
+CREATE TABLE journal (
+    dataset TEXT NOT NULL,
+    date TEXT NOT NULL,
+    PRIMARY KEY (dataset, date)
+);
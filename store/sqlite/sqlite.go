@@ -0,0 +1,402 @@
+// Package sqlite stores imported OMIE data in a local SQLite database, a queryable archive
+// of market history that needs no database server. The schema is created and upgraded by
+// the migrations of the store package when the database is opened.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"math"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, registered as "sqlite"
+
+	"github.com/devuo/omiedata/store"
+	"github.com/devuo/omiedata/types"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// dateLayout is the layout of the date columns, which sort chronologically as text
+const dateLayout = "2006-01-02"
+
+// Store reads and writes OMIE data in a SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens, or creates, the database at path and brings its schema up to date
+func Open(ctx context.Context, path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to open "+path, err)
+	}
+
+	s, err := New(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New creates a store on an open SQLite database, bringing its schema up to date
+func New(ctx context.Context, db *sql.DB) (*Store, error) {
+	migrations, err := store.LoadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Migrate(ctx, db, store.SQLite, migrations); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// DB returns the underlying database, e.g. for custom queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SavePrices saves days of marginal prices, replacing any previously saved data of the
+// same dates
+func (s *Store) SavePrices(ctx context.Context, days ...*types.MarginalPriceData) error {
+	return s.inTx(ctx, "failed to save prices", func(tx *sql.Tx) error {
+		for _, day := range days {
+			date := day.Date.Format(dateLayout)
+			if err := exec(ctx, tx, "DELETE FROM prices WHERE date = ?", date); err != nil {
+				return err
+			}
+
+			resolution := types.Hourly
+			if day.Resolution == types.QuarterHourly {
+				resolution = types.QuarterHourly
+			}
+			if err := exec(ctx, tx, `INSERT INTO price_days (date, resolution, format_version) VALUES (?, ?, ?)
+				ON CONFLICT (date) DO UPDATE SET resolution = excluded.resolution, format_version = excluded.format_version`,
+				date, resolution.String(), string(day.FormatVersion)); err != nil {
+				return err
+			}
+
+			for _, series := range day.Series() {
+				for hour, value := range series.Values {
+					if err := exec(ctx, tx, "INSERT INTO prices (date, series, hour, value) VALUES (?, ?, ?, ?)",
+						date, series.Name, hour, nullFloat(value)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SaveTechnology saves days of energy by technology, replacing any previously saved data
+// of the same dates and systems
+func (s *Store) SaveTechnology(ctx context.Context, days ...*types.TechnologyEnergyDay) error {
+	return s.inTx(ctx, "failed to save energy by technology", func(tx *sql.Tx) error {
+		for _, day := range days {
+			date := day.Date.Format(dateLayout)
+			if err := exec(ctx, tx, "DELETE FROM technology_energy WHERE system = ? AND date = ?", day.System.String(), date); err != nil {
+				return err
+			}
+
+			for _, record := range day.Records {
+				for _, tech := range types.Technologies() {
+					if err := exec(ctx, tx, "INSERT INTO technology_energy (date, system, hour, technology, value) VALUES (?, ?, ?, ?, ?)",
+						date, day.System.String(), record.Hour, string(tech), nullFloat(record.Nullable(tech).Float64())); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SaveCurves saves days of supply/demand curves, replacing any previously saved curves of
+// the same dates
+func (s *Store) SaveCurves(ctx context.Context, days ...*types.MarketCurveDay) error {
+	return s.inTx(ctx, "failed to save curves", func(tx *sql.Tx) error {
+		for _, day := range days {
+			date := day.Date.Format(dateLayout)
+			if err := exec(ctx, tx, "DELETE FROM curve_points WHERE date = ?", date); err != nil {
+				return err
+			}
+			if err := exec(ctx, tx, "DELETE FROM curves WHERE date = ?", date); err != nil {
+				return err
+			}
+
+			for _, curve := range day.Curves {
+				if err := exec(ctx, tx, "INSERT INTO curves (date, hour, aggregation) VALUES (?, ?, ?)",
+					date, curve.Hour, string(curve.Aggregation)); err != nil {
+					return err
+				}
+				for _, side := range []struct {
+					name   string
+					points []types.MarketPoint
+				}{{"supply", curve.Supply}, {"demand", curve.Demand}} {
+					for position, point := range side.points {
+						if err := exec(ctx, tx, `INSERT INTO curve_points (date, hour, side, position, matched, unit_code, energy, price)
+							VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+							date, curve.Hour, side.name, position, string(point.Matched), point.UnitCode,
+							nullFloat(point.Energy), nullFloat(point.Price)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Prices returns the saved days of marginal prices from start to end, both included, in
+// date order
+func (s *Store) Prices(ctx context.Context, start, end time.Time) ([]*types.MarginalPriceData, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT d.date, d.resolution, d.format_version, p.series, p.hour, p.value
+		FROM price_days d LEFT JOIN prices p ON p.date = d.date
+		WHERE d.date BETWEEN ? AND ? ORDER BY d.date`, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to query prices", err)
+	}
+	defer rows.Close()
+
+	var days []*types.MarginalPriceData
+	var current *types.MarginalPriceData
+	var series map[string]types.HourlyValues
+
+	for rows.Next() {
+		var date, resolution, formatVersion string
+		var name sql.NullString
+		var hour sql.NullInt64
+		var value sql.NullFloat64
+		if err := rows.Scan(&date, &resolution, &formatVersion, &name, &hour, &value); err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read prices", err)
+		}
+
+		if current == nil || current.Date.Format(dateLayout) != date {
+			parsed, err := time.Parse(dateLayout, date)
+			if err != nil {
+				return nil, types.NewOMIEError(types.ErrCodeInvalidData, "invalid date "+date, err)
+			}
+			current = types.NewMarginalPriceData(parsed)
+			if err := current.Resolution.UnmarshalText([]byte(resolution)); err != nil {
+				return nil, err
+			}
+			current.FormatVersion = types.FormatVersion(formatVersion)
+			series = make(map[string]types.HourlyValues)
+			for _, s := range current.Series() {
+				series[s.Name] = s.Values
+			}
+			days = append(days, current)
+		}
+
+		if values, ok := series[name.String]; ok && hour.Valid {
+			values[int(hour.Int64)] = floatOrNaN(value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read prices", err)
+	}
+
+	return days, nil
+}
+
+// Technology returns the saved days of energy by technology of a system from start to
+// end, both included, in date order with records in hour order
+func (s *Store) Technology(ctx context.Context, system types.SystemType, start, end time.Time) ([]*types.TechnologyEnergyDay, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT date, hour, technology, value FROM technology_energy
+		WHERE system = ? AND date BETWEEN ? AND ? ORDER BY date, hour`,
+		system.String(), start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to query energy by technology", err)
+	}
+	defer rows.Close()
+
+	var days []*types.TechnologyEnergyDay
+	for rows.Next() {
+		var date, tech string
+		var hour int
+		var value sql.NullFloat64
+		if err := rows.Scan(&date, &hour, &tech, &value); err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read energy by technology", err)
+		}
+
+		parsed, err := time.Parse(dateLayout, date)
+		if err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, "invalid date "+date, err)
+		}
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(parsed) {
+			days = append(days, &types.TechnologyEnergyDay{Date: parsed, System: system})
+		}
+
+		day := days[len(days)-1]
+		if n := len(day.Records); n == 0 || day.Records[n-1].Hour != hour {
+			day.Records = append(day.Records, newTechnologyRecord(parsed, hour, system))
+		}
+		day.Records[len(day.Records)-1].Set(types.TechnologyType(tech), floatOrNaN(value))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read energy by technology", err)
+	}
+
+	return days, nil
+}
+
+// Curves returns the saved days of supply/demand curves from start to end, both included,
+// in date order with curves in hour order and points in file order
+func (s *Store) Curves(ctx context.Context, start, end time.Time) ([]*types.MarketCurveDay, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT c.date, c.hour, c.aggregation, p.side, p.matched, p.unit_code, p.energy, p.price
+		FROM curves c LEFT JOIN curve_points p ON p.date = c.date AND p.hour = c.hour
+		WHERE c.date BETWEEN ? AND ? ORDER BY c.date, c.hour, p.side DESC, p.position`,
+		start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to query curves", err)
+	}
+	defer rows.Close()
+
+	var days []*types.MarketCurveDay
+	for rows.Next() {
+		var date, aggregation string
+		var hour int
+		var side, matched, unitCode sql.NullString
+		var energy, price sql.NullFloat64
+		if err := rows.Scan(&date, &hour, &aggregation, &side, &matched, &unitCode, &energy, &price); err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read curves", err)
+		}
+
+		parsed, err := time.Parse(dateLayout, date)
+		if err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeInvalidData, "invalid date "+date, err)
+		}
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(parsed) {
+			days = append(days, &types.MarketCurveDay{Date: parsed})
+		}
+
+		day := days[len(days)-1]
+		if n := len(day.Curves); n == 0 || day.Curves[n-1].Hour != hour {
+			day.Curves = append(day.Curves, types.MarketCurve{
+				Date:        parsed,
+				Hour:        hour,
+				Aggregation: types.AggregationLevel(aggregation),
+			})
+		}
+		if !side.Valid {
+			continue // Curve without points
+		}
+
+		curve := &day.Curves[len(day.Curves)-1]
+		point := types.MarketPoint{
+			Energy:   floatOrNaN(energy),
+			Price:    floatOrNaN(price),
+			Matched:  types.MatchedStatus(matched.String),
+			UnitCode: unitCode.String,
+		}
+		if side.String == "supply" {
+			curve.Supply = append(curve.Supply, point)
+		} else {
+			curve.Demand = append(curve.Demand, point)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read curves", err)
+	}
+
+	return days, nil
+}
+
+// Missing returns the ranges of start..end not recorded in the journal for dataset, in
+// date order, so the store can keep the importers.Journal of the syncs filling it
+func (s *Store) Missing(ctx context.Context, dataset string, start, end time.Time) ([]types.DateRange, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT date FROM journal WHERE dataset = ? AND date BETWEEN ? AND ? ORDER BY date",
+		dataset, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read journal", err)
+	}
+	defer rows.Close()
+
+	var recorded []types.DateRange
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read journal", err)
+		}
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read journal", err)
+		}
+		recorded = append(recorded, types.SingleDay(date))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewOMIEError(types.ErrCodeStorage, "failed to read journal", err)
+	}
+
+	return types.DateRange{Start: start, End: end}.Subtract(recorded), nil
+}
+
+// Record marks dates as imported for dataset in the journal
+func (s *Store) Record(ctx context.Context, dataset string, dates ...time.Time) error {
+	return s.inTx(ctx, "failed to write journal", func(tx *sql.Tx) error {
+		for _, date := range dates {
+			if err := exec(ctx, tx, "INSERT INTO journal (dataset, date) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				dataset, date.Format(dateLayout)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction, committing it when fn succeeds
+func (s *Store) inTx(ctx context.Context, message string, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, message, err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, message, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return types.NewOMIEError(types.ErrCodeStorage, message, err)
+	}
+	return nil
+}
+
+// exec runs a statement in tx
+func exec(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) error {
+	_, err := tx.ExecContext(ctx, strings.TrimSpace(query), args...)
+	return err
+}
+
+// nullFloat stores missing values (NaN) as NULL
+func nullFloat(value float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: value, Valid: !math.IsNaN(value) && !math.IsInf(value, 0)}
+}
+
+// floatOrNaN reads NULL back as a missing value
+func floatOrNaN(value sql.NullFloat64) float64 {
+	if !value.Valid {
+		return math.NaN()
+	}
+	return value.Float64
+}
+
+// newTechnologyRecord returns a record with every technology missing, filled in as the
+// rows of its hour are read
+func newTechnologyRecord(date time.Time, hour int, system types.SystemType) types.TechnologyEnergy {
+	record := types.TechnologyEnergy{Date: date, Hour: hour, System: system}
+	for _, tech := range types.Technologies() {
+		record.Set(tech, math.NaN())
+	}
+	return record
+}
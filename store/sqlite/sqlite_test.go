@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/importers"
+	"github.com/devuo/omiedata/types"
+)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "omie.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSavePrices(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	first := types.NewMarginalPriceData(date)
+	first.SpainPrices[1] = 45.5
+	first.SpainPrices[2] = 40
+	first.PortugalPrices[1] = math.NaN()
+	first.FormatVersion = types.FormatEuro
+
+	second := types.NewMarginalPriceData(date.AddDate(0, 0, 1))
+	second.Resolution = types.QuarterHourly
+	second.IberianEnergy[96] = 25000
+
+	if err := s.SavePrices(ctx, first, second); err != nil {
+		t.Fatalf("SavePrices() error: %v", err)
+	}
+
+	// Saving a day again replaces its values
+	delete(first.SpainPrices, 2)
+	if err := s.SavePrices(ctx, first); err != nil {
+		t.Fatalf("SavePrices() error: %v", err)
+	}
+
+	days, err := s.Prices(ctx, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Prices() error: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(days))
+	}
+	if changes := types.Diff(first, days[0], 0); len(changes) > 0 {
+		t.Errorf("first day changed: %v", changes)
+	}
+	if changes := types.Diff(second, days[1], 0); len(changes) > 0 {
+		t.Errorf("second day changed: %v", changes)
+	}
+
+	days, err = s.Prices(ctx, date.AddDate(0, 0, 1), date.AddDate(0, 0, 5))
+	if err != nil || len(days) != 1 {
+		t.Errorf("expected only the second day, got %d days, error %v", len(days), err)
+	}
+}
+
+func TestSaveTechnology(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	day := &types.TechnologyEnergyDay{Date: date, System: types.Spain}
+	for hour := 1; hour <= 3; hour++ {
+		day.Records = append(day.Records, types.TechnologyEnergy{
+			Date: date, Hour: hour, System: types.Spain, Wind: float64(hour * 100), Coal: math.NaN(),
+		})
+	}
+	other := &types.TechnologyEnergyDay{Date: date, System: types.Portugal,
+		Records: []types.TechnologyEnergy{{Date: date, Hour: 1, System: types.Portugal, Hydro: 50}}}
+
+	if err := s.SaveTechnology(ctx, day, other); err != nil {
+		t.Fatalf("SaveTechnology() error: %v", err)
+	}
+
+	days, err := s.Technology(ctx, types.Spain, date, date)
+	if err != nil {
+		t.Fatalf("Technology() error: %v", err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("expected 1 day, got %d", len(days))
+	}
+	if changes := types.Diff(day, days[0], 0); len(changes) > 0 {
+		t.Errorf("day changed: %v", changes)
+	}
+}
+
+func TestSaveCurves(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	day := &types.MarketCurveDay{Date: date, Curves: []types.MarketCurve{
+		{
+			Date: date, Hour: 1, Aggregation: types.UnitLevel,
+			Supply: []types.MarketPoint{{Energy: 100, Price: -0.5, Matched: types.Matched, UnitCode: "A"}, {Energy: 50, Price: 10, Matched: types.Offered}},
+			Demand: []types.MarketPoint{{Energy: 80, Price: 180, Matched: types.Matched}},
+		},
+		{Date: date, Hour: 2},
+	}}
+
+	if err := s.SaveCurves(ctx, day); err != nil {
+		t.Fatalf("SaveCurves() error: %v", err)
+	}
+	if err := s.SaveCurves(ctx, day); err != nil {
+		t.Fatalf("saving again failed: %v", err)
+	}
+
+	days, err := s.Curves(ctx, date, date)
+	if err != nil {
+		t.Fatalf("Curves() error: %v", err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("expected 1 day, got %d", len(days))
+	}
+	if changes := types.Diff(day, days[0], 0); len(changes) > 0 {
+		t.Errorf("day changed: %v", changes)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	var _ importers.Journal = s
+
+	if err := s.Record(ctx, "prices", day(1), day(2), day(4), day(2)); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	missing, err := s.Missing(ctx, "prices", day(1), day(5))
+	if err != nil {
+		t.Fatalf("Missing() error: %v", err)
+	}
+	if len(missing) != 2 || missing[0].String() != "2024-01-03..2024-01-03" || missing[1].String() != "2024-01-05..2024-01-05" {
+		t.Errorf("expected the 3rd and 5th missing, got %v", missing)
+	}
+
+	missing, err = s.Missing(ctx, "curves", day(1), day(5))
+	if err != nil {
+		t.Fatalf("Missing() error: %v", err)
+	}
+	if len(missing) != 1 || missing[0].Days() != 5 {
+		t.Errorf("expected the whole range missing for another dataset, got %v", missing)
+	}
+}
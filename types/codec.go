@@ -0,0 +1,89 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// SchemaVersion is the version of the JSON wire format produced by this package.
+// It only changes when a field is renamed, removed or changes meaning; adding
+// new fields keeps the version, so older readers can skip what they don't know.
+const SchemaVersion = 1
+
+// Envelope wraps a serialized value with its kind and the schema version it was
+// written with, so persisted data can be decoded safely by later library versions
+type Envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Kind          string          `json:"kind"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// kinds maps the kind names used in envelopes to constructors of the matching type
+var kinds = map[string]func() interface{}{
+	"MarginalPriceData":   func() interface{} { return new(MarginalPriceData) },
+	"MarginalPriceRecord": func() interface{} { return new(MarginalPriceRecord) },
+	"TechnologyEnergy":    func() interface{} { return new(TechnologyEnergy) },
+	"TechnologyEnergyDay": func() interface{} { return new(TechnologyEnergyDay) },
+	"MarketPoint":         func() interface{} { return new(MarketPoint) },
+	"MarketCurve":         func() interface{} { return new(MarketCurve) },
+	"MarketCurveDay":      func() interface{} { return new(MarketCurveDay) },
+	"IntradayPrice":       func() interface{} { return new(IntradayPrice) },
+	"IntradaySession":     func() interface{} { return new(IntradaySession) },
+
+	"ContinuousIntradayPrice": func() interface{} { return new(ContinuousIntradayPrice) },
+	"ContinuousIntradayDay":   func() interface{} { return new(ContinuousIntradayDay) },
+	"MonthlyPriceSummary":     func() interface{} { return new(MonthlyPriceSummary) },
+	"InterconnectionData":     func() interface{} { return new(InterconnectionData) },
+	"FuturesSettlement":       func() interface{} { return new(FuturesSettlement) },
+	"FuturesSettlementDay":    func() interface{} { return new(FuturesSettlementDay) },
+	"HourlyValues":            func() interface{} { return new(HourlyValues) },
+	"DateRange":               func() interface{} { return new(DateRange) },
+}
+
+// Encode serializes a data type from this package into a versioned envelope
+func Encode(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.PkgPath() != reflect.TypeOf(Envelope{}).PkgPath() || kinds[t.Name()] == nil {
+		return nil, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("cannot encode %T", v), nil)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, NewOMIEError(ErrCodeInvalidData, "failed to encode "+t.Name(), err)
+	}
+
+	return json.Marshal(Envelope{
+		SchemaVersion: SchemaVersion,
+		Kind:          t.Name(),
+		Data:          data,
+	})
+}
+
+// Decode deserializes a versioned envelope produced by Encode, returning a pointer
+// to the encoded type (e.g. *MarginalPriceData)
+func Decode(data []byte) (interface{}, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, NewOMIEError(ErrCodeInvalidData, "invalid envelope", err)
+	}
+
+	if env.SchemaVersion < 1 || env.SchemaVersion > SchemaVersion {
+		return nil, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("unsupported schema version %d", env.SchemaVersion), nil)
+	}
+
+	newValue, ok := kinds[env.Kind]
+	if !ok {
+		return nil, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("unknown kind %q", env.Kind), nil)
+	}
+
+	v := newValue()
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return nil, NewOMIEError(ErrCodeInvalidData, "failed to decode "+env.Kind, err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	date := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	prices := NewMarginalPriceData(date)
+	prices.SpainPrices[1] = 45.5
+	prices.PortugalPrices[25] = -1.25
+
+	energy := TechnologyEnergy{Date: date, Hour: 3, System: Spain, Wind: 1500, ImportNoMIBEL: -20}
+	point := MarketPoint{Energy: 100, Price: -0.5, Matched: Matched}
+	intraday := IntradayPrice{Date: date, Session: Session2, Hour: 5, SpainPrice: 50, PortugalPrice: 51}
+
+	for _, value := range []interface{}{
+		prices,
+		MarginalPriceRecord{Date: date, Concept: PriceSpain, Values: map[int]float64{1: 3, 2: 4}},
+		energy,
+		&TechnologyEnergyDay{Date: date, System: Spain, Records: []TechnologyEnergy{energy}},
+		point,
+		MarketCurve{Date: date, Hour: 3, Supply: []MarketPoint{point}, Demand: []MarketPoint{}},
+		intraday,
+		IntradaySession{Date: date, Session: Session2, Prices: []IntradayPrice{intraday}},
+	} {
+		payload, err := Encode(value)
+		if err != nil {
+			t.Fatalf("Encode(%T) error: %v", value, err)
+		}
+		decoded, err := Decode(payload)
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", payload, err)
+		}
+
+		want := reflect.ValueOf(value)
+		if want.Kind() == reflect.Ptr {
+			want = want.Elem()
+		}
+		got := reflect.ValueOf(decoded)
+		if got.Kind() != reflect.Ptr || got.Elem().Type() != want.Type() {
+			t.Fatalf("Decode(%s) returned %T, want *%s", payload, decoded, want.Type())
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), want.Interface()) {
+			t.Errorf("round trip of %T changed it to %+v\n%s", value, got.Elem().Interface(), payload)
+		}
+	}
+}
+
+func TestEncodeDecodeNaN(t *testing.T) {
+	payload, err := Encode(TechnologyEnergy{Hour: 1, System: Iberian, Coal: math.NaN(), Wind: 10})
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	if !strings.Contains(string(payload), `"coal":null`) {
+		t.Errorf("expected a missing value as null in %s", payload)
+	}
+
+	decoded, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if energy := decoded.(*TechnologyEnergy); !math.IsNaN(energy.Coal) || energy.Wind != 10 {
+		t.Errorf("unexpected round trip %+v", energy)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, value := range []interface{}{nil, 42, "text", time.Time{}, Envelope{}} {
+		if _, err := Encode(value); err == nil {
+			t.Errorf("Encode(%T) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for name, payload := range map[string]string{
+		"invalid JSON":   `{`,
+		"no version":     `{"kind":"MarketPoint","data":{}}`,
+		"future version": `{"schema_version":99,"kind":"MarketPoint","data":{}}`,
+		"unknown kind":   `{"schema_version":1,"kind":"Unknown","data":{}}`,
+		"invalid data":   `{"schema_version":1,"kind":"MarketPoint","data":{"matched":"X"}}`,
+	} {
+		if _, err := Decode([]byte(payload)); err == nil {
+			t.Errorf("%s: Decode(%s) succeeded, want an error", name, payload)
+		}
+	}
+}
+
+func TestTextMarshalers(t *testing.T) {
+	system := Portugal
+	session := Session3
+	technology := Wind
+	concept := PriceSpain
+	offer := Sell
+	status := Offered
+
+	for _, value := range []interface {
+		encoding.TextMarshaler
+		encoding.TextUnmarshaler
+	}{&system, &session, &technology, &concept, &offer, &status} {
+		text, err := value.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v) error: %v", value, err)
+		}
+		decoded := reflect.New(reflect.TypeOf(value).Elem())
+		if err := decoded.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+			t.Fatalf("UnmarshalText(%q) error: %v", text, err)
+		}
+		if !reflect.DeepEqual(decoded.Interface(), value) {
+			t.Errorf("text round trip of %q gave %v", text, decoded.Elem().Interface())
+		}
+	}
+
+	// System types are also read from the codes of the OMIE file names
+	var parsed SystemType
+	if err := parsed.UnmarshalText([]byte("9")); err != nil || parsed != Iberian {
+		t.Errorf("UnmarshalText(9) = %v, %v, want IBERIAN", parsed, err)
+	}
+
+	invalid := []struct {
+		value encoding.TextUnmarshaler
+		text  string
+	}{
+		{new(SystemType), "FRANCE"},
+		{new(SessionType), "7"},
+		{new(TechnologyType), ""},
+		{new(DataTypeInMarginalPriceFile), ""},
+		{new(OfferType), "X"},
+		{new(MatchedStatus), "X"},
+	}
+	for _, tc := range invalid {
+		if err := tc.value.UnmarshalText([]byte(tc.text)); err == nil {
+			t.Errorf("%T.UnmarshalText(%q) succeeded, want an error", tc.value, tc.text)
+		}
+	}
+	if _, err := SystemType(5).MarshalText(); err == nil {
+		t.Error("expected an error marshalling an unknown system type")
+	}
+	if _, err := json.Marshal(map[string]SessionType{"session": 0}); err == nil {
+		t.Error("expected an error marshalling an unknown session")
+	}
+}
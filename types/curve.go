@@ -0,0 +1,97 @@
+package types
+
+// IsMatched reports whether the point belongs to the matched curve
+func (p MarketPoint) IsMatched() bool {
+	return p.Matched == Matched
+}
+
+// IsOffered reports whether the point belongs to the offered curve
+func (p MarketPoint) IsOffered() bool {
+	return p.Matched == Offered
+}
+
+// Filter returns a copy of the curve keeping only the supply and demand points for which keep returns true
+func (c MarketCurve) Filter(keep func(MarketPoint) bool) MarketCurve {
+	filtered := c
+	filtered.Supply = filterPoints(c.Supply, keep)
+	filtered.Demand = filterPoints(c.Demand, keep)
+	return filtered
+}
+
+// MatchedOnly returns a copy of the curve with only the matched points
+func (c MarketCurve) MatchedOnly() MarketCurve {
+	return c.Filter(MarketPoint.IsMatched)
+}
+
+// OfferedOnly returns a copy of the curve with only the offered points
+func (c MarketCurve) OfferedOnly() MarketCurve {
+	return c.Filter(MarketPoint.IsOffered)
+}
+
+// ByUnit splits the curve by bidding unit, returning one curve per unit code. Points
+// from files that don't identify units are grouped under the empty code.
+func (c MarketCurve) ByUnit() map[string]MarketCurve {
+	units := make(map[string]MarketCurve)
+	for _, point := range c.Supply {
+		unit := units[point.UnitCode]
+		unit.Supply = append(unit.Supply, point)
+		units[point.UnitCode] = unit
+	}
+	for _, point := range c.Demand {
+		unit := units[point.UnitCode]
+		unit.Demand = append(unit.Demand, point)
+		units[point.UnitCode] = unit
+	}
+
+	for code, unit := range units {
+		unit.Date = c.Date
+		unit.Hour = c.Hour
+		unit.Aggregation = c.Aggregation
+		units[code] = unit
+	}
+	return units
+}
+
+// ByUnit splits every curve of the day by bidding unit, returning each unit's curves in hour order
+func (d *MarketCurveDay) ByUnit() map[string][]MarketCurve {
+	units := make(map[string][]MarketCurve)
+	for _, curve := range d.Curves {
+		for code, unit := range curve.ByUnit() {
+			units[code] = append(units[code], unit)
+		}
+	}
+	return units
+}
+
+// MatchedOnly returns a copy of the day with only the matched points of every curve
+func (d *MarketCurveDay) MatchedOnly() *MarketCurveDay {
+	return d.filter(MarketCurve.MatchedOnly)
+}
+
+// OfferedOnly returns a copy of the day with only the offered points of every curve
+func (d *MarketCurveDay) OfferedOnly() *MarketCurveDay {
+	return d.filter(MarketCurve.OfferedOnly)
+}
+
+// filter returns a copy of the day with fn applied to every curve
+func (d *MarketCurveDay) filter(fn func(MarketCurve) MarketCurve) *MarketCurveDay {
+	filtered := &MarketCurveDay{
+		Date:   d.Date,
+		Curves: make([]MarketCurve, len(d.Curves)),
+	}
+	for i, curve := range d.Curves {
+		filtered.Curves[i] = fn(curve)
+	}
+	return filtered
+}
+
+// filterPoints returns the points for which keep returns true, in their original order
+func filterPoints(points []MarketPoint, keep func(MarketPoint) bool) []MarketPoint {
+	var kept []MarketPoint
+	for _, point := range points {
+		if keep(point) {
+			kept = append(kept, point)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarketCurveMatchedOnly(t *testing.T) {
+	curve := MarketCurve{
+		Date: time.Date(2009, 1, 2, 0, 0, 0, 0, time.UTC),
+		Hour: 1,
+		Supply: []MarketPoint{
+			{Energy: 100, Price: 10, Matched: Offered},
+			{Energy: 80, Price: 10, Matched: Matched},
+			{Energy: 50, Price: 20, Matched: Offered},
+		},
+		Demand: []MarketPoint{
+			{Energy: 200, Price: 180, Matched: Matched},
+		},
+	}
+
+	matched := curve.MatchedOnly()
+	if len(matched.Supply) != 1 || matched.Supply[0].Energy != 80 {
+		t.Errorf("Expected only the matched supply point, got %v", matched.Supply)
+	}
+	if len(matched.Demand) != 1 {
+		t.Errorf("Expected the matched demand point, got %v", matched.Demand)
+	}
+
+	offered := curve.OfferedOnly()
+	if len(offered.Supply) != 2 || len(offered.Demand) != 0 {
+		t.Errorf("Expected 2 offered supply points and no demand, got %v / %v", offered.Supply, offered.Demand)
+	}
+
+	if len(curve.Supply) != 3 {
+		t.Error("Filtering must not modify the original curve")
+	}
+
+	day := &MarketCurveDay{Date: curve.Date, Curves: []MarketCurve{curve}}
+	if got := day.MatchedOnly(); len(got.Curves) != 1 || len(got.Curves[0].Supply) != 1 {
+		t.Errorf("Unexpected matched day: %v", got)
+	}
+}
+
+func TestMarketCurveByUnit(t *testing.T) {
+	curve := MarketCurve{
+		Hour: 3,
+		Supply: []MarketPoint{
+			{Energy: 100, Price: 10, Matched: Matched, UnitCode: "ACE3"},
+			{Energy: 50, Price: 20, Matched: Matched, UnitCode: "ABO1"},
+			{Energy: 25, Price: 30, Matched: Offered, UnitCode: "ACE3"},
+		},
+		Demand: []MarketPoint{
+			{Energy: 200, Price: 180, Matched: Matched, UnitCode: "IBEC"},
+		},
+	}
+
+	units := curve.ByUnit()
+	if len(units) != 3 {
+		t.Fatalf("Expected 3 units, got %d", len(units))
+	}
+	if got := units["ACE3"]; len(got.Supply) != 2 || got.Hour != 3 {
+		t.Errorf("Unexpected curve for ACE3: %v", got)
+	}
+	if got := units["IBEC"]; len(got.Supply) != 0 || len(got.Demand) != 1 {
+		t.Errorf("Unexpected curve for IBEC: %v", got)
+	}
+}
@@ -2,27 +2,86 @@ package types
 
 import "time"
 
-// MarginalPriceData contains the marginal prices and energy data for a specific date
+// MarginalPriceData contains the marginal prices and energy data for a specific date.
+// The maps are keyed by hour (1-25) for hourly data, or by quarter-hour period (1-100)
+// when Resolution is QuarterHourly; use PeriodValue to query either by period.
 type MarginalPriceData struct {
 	Date            time.Time
-	SpainPrices     map[int]float64 // hour (1-24) -> EUR/MWh
-	PortugalPrices  map[int]float64 // hour (1-24) -> EUR/MWh
-	SpainBuyEnergy  map[int]float64 // hour (1-24) -> MWh
-	SpainSellEnergy map[int]float64 // hour (1-24) -> MWh
-	IberianEnergy   map[int]float64 // hour (1-24) -> MWh
-	BilateralEnergy map[int]float64 // hour (1-24) -> MWh
+	Resolution      Resolution
+	SpainPrices     HourlyValues // hour or period -> EUR/MWh
+	PortugalPrices  HourlyValues // hour or period -> EUR/MWh
+	SpainBuyEnergy  HourlyValues // hour or period -> MWh
+	SpainSellEnergy HourlyValues // hour or period -> MWh
+	IberianEnergy   HourlyValues // hour or period -> MWh
+	BilateralEnergy HourlyValues // hour or period -> MWh
+
+	// Adjustment prices of the MIBEL gas price cap mechanism, published in place of the
+	// marginal prices in the adjustment files of 2022-2023
+	SpainAdjustmentPrices    HourlyValues // hour or period -> EUR/MWh
+	PortugalAdjustmentPrices HourlyValues // hour or period -> EUR/MWh
+
+	// Scheduled flows over the Spain-Portugal interconnection by direction, found in the
+	// 2009-era files
+	ExportSpainToPortugal HourlyValues // hour or period -> MWh
+	ExportPortugalToSpain HourlyValues // hour or period -> MWh
+
+	// UnknownConcepts holds the rows of concepts the parser doesn't know, e.g. ones added
+	// by OMIE after this version, by their label in the file, with the values as
+	// published. It is nil when every row was known.
+	UnknownConcepts map[string]HourlyValues
+
+	// CustomConcepts holds the rows of the concepts registered with data types of their
+	// own, see MarginalPriceParser.RegisterConcept. It is nil when none was found.
+	CustomConcepts map[DataTypeInMarginalPriceFile]HourlyValues
+
+	// FormatVersion is the layout of the file the data was parsed from, e.g. to know
+	// whether Portugal prices are expected
+	FormatVersion FormatVersion
+
+	// Encoding is the character encoding of the file the data was parsed from
+	Encoding Encoding
+
+	// Warnings lists the rows and values skipped while parsing
+	Warnings []ParseWarning
 }
 
 // NewMarginalPriceData creates a new MarginalPriceData with initialized maps
 func NewMarginalPriceData(date time.Time) *MarginalPriceData {
 	return &MarginalPriceData{
 		Date:            date,
-		SpainPrices:     make(map[int]float64),
-		PortugalPrices:  make(map[int]float64),
-		SpainBuyEnergy:  make(map[int]float64),
-		SpainSellEnergy: make(map[int]float64),
-		IberianEnergy:   make(map[int]float64),
-		BilateralEnergy: make(map[int]float64),
+		SpainPrices:     make(HourlyValues),
+		PortugalPrices:  make(HourlyValues),
+		SpainBuyEnergy:  make(HourlyValues),
+		SpainSellEnergy: make(HourlyValues),
+		IberianEnergy:   make(HourlyValues),
+		BilateralEnergy: make(HourlyValues),
+
+		SpainAdjustmentPrices:    make(HourlyValues),
+		PortugalAdjustmentPrices: make(HourlyValues),
+
+		ExportSpainToPortugal: make(HourlyValues),
+		ExportPortugalToSpain: make(HourlyValues),
+	}
+}
+
+// InterconnectionData contains the available capacity and the congestion rent of the
+// Spain-Portugal interconnection for a specific date. Like MarginalPriceData, the maps are
+// keyed by hour, or by quarter-hour period when Resolution is QuarterHourly.
+type InterconnectionData struct {
+	Date                    time.Time
+	Resolution              Resolution
+	CapacitySpainToPortugal HourlyValues // hour or period -> MW
+	CapacityPortugalToSpain HourlyValues // hour or period -> MW
+	CongestionRent          HourlyValues // hour or period -> EUR
+}
+
+// NewInterconnectionData creates a new InterconnectionData with initialized maps
+func NewInterconnectionData(date time.Time) *InterconnectionData {
+	return &InterconnectionData{
+		Date:                    date,
+		CapacitySpainToPortugal: make(HourlyValues),
+		CapacityPortugalToSpain: make(HourlyValues),
+		CongestionRent:          make(HourlyValues),
 	}
 }
 
@@ -45,19 +104,24 @@ type TechnologyEnergy struct {
 	ImportNoMIBEL float64 // MWh
 }
 
-// MarketPoint represents a single point in the supply/demand curve
+// MarketPoint represents a single point in the supply/demand curve. Curve files list
+// every offer twice: once as offered (O), forming the full bid curve, and once as
+// matched (C), forming the curve left after the market clearing. OMIE doesn't publish
+// complex-condition flags in curve files, so they aren't available per point.
 type MarketPoint struct {
-	Energy  float64       // MWh
-	Price   float64       // EUR/MWh
-	Matched MatchedStatus // Offered (O) or Matched (C)
+	Energy   float64       // MWh
+	Price    float64       // EUR/MWh
+	Matched  MatchedStatus // Offered (O) or Matched (C)
+	UnitCode string        // Bidding unit (Unidad), empty when the file doesn't identify it
 }
 
 // MarketCurve contains the supply and demand curves for a specific hour
 type MarketCurve struct {
-	Date   time.Time
-	Hour   int
-	Supply []MarketPoint // Sell offers (Tipo "V")
-	Demand []MarketPoint // Buy offers (Tipo "C")
+	Date        time.Time
+	Hour        int
+	Aggregation AggregationLevel // What the points represent, empty if unknown
+	Supply      []MarketPoint    // Sell offers (Tipo "V")
+	Demand      []MarketPoint    // Buy offers (Tipo "C")
 }
 
 // IntradayPrice contains intraday session prices
@@ -71,11 +135,51 @@ type IntradayPrice struct {
 	PortugalEnergy float64 // MWh
 }
 
+// ContinuousIntradayPrice contains the results of the intraday continuous market (MIC)
+// for a single delivery period
+type ContinuousIntradayPrice struct {
+	Date          time.Time
+	Period        int     // Hour (1-25), or quarter-hour period (1-100) when the day is QuarterHourly
+	MinPrice      float64 // EUR/MWh
+	MaxPrice      float64 // EUR/MWh
+	WeightedPrice float64 // Volume-weighted average price, EUR/MWh
+	Energy        float64 // Traded energy, MWh
+}
+
+// MonthlyPriceSummary contains the monthly averages published by OMIE for the day-ahead
+// market. Values missing from the file are NaN.
+type MonthlyPriceSummary struct {
+	Month           time.Time // First day of the month
+	SpainAverage    float64   // Arithmetic mean of the hourly prices, EUR/MWh
+	PortugalAverage float64   // Arithmetic mean of the hourly prices, EUR/MWh
+	SpainMin        float64   // EUR/MWh
+	SpainMax        float64   // EUR/MWh
+	PortugalMin     float64   // EUR/MWh
+	PortugalMax     float64   // EUR/MWh
+	IberianEnergy   float64   // Energy matched in the month, MWh
+}
+
+// FuturesSettlement is the settlement price of an OMIP futures contract on a trading day
+type FuturesSettlement struct {
+	TradingDate   time.Time
+	Contract      string         // Contract name as published, e.g. "FTB M Jan-25"
+	Load          LoadProfile    // Base or peak
+	Period        DeliveryPeriod // Month, quarter or year
+	DeliveryStart time.Time      // First day of the delivery period
+	Price         float64        // EUR/MWh
+}
+
+// FuturesSettlementDay contains the settlement prices of every listed contract for a trading day
+type FuturesSettlementDay struct {
+	Date        time.Time
+	Settlements []FuturesSettlement // In the order of the file
+}
+
 // MarginalPriceRecord represents a single record from marginal price file parsing
 type MarginalPriceRecord struct {
 	Date    time.Time
 	Concept DataTypeInMarginalPriceFile
-	Values  map[int]float64 // hour -> value
+	Values  HourlyValues // hour -> value
 }
 
 // TechnologyEnergyDay contains all technology energy data for a single day
@@ -83,6 +187,20 @@ type TechnologyEnergyDay struct {
 	Date    time.Time
 	System  SystemType
 	Records []TechnologyEnergy // One record per hour
+
+	// Encoding is the character encoding of the file the data was parsed from
+	Encoding Encoding
+
+	// Warnings lists the rows and values skipped while parsing
+	Warnings []ParseWarning
+}
+
+// ParseWarning describes a data row, or a value in it, that a parser skipped because it
+// couldn't be parsed
+type ParseWarning struct {
+	Line   int    // 1-based line number in the file
+	Text   string // The line, trimmed
+	Reason string
 }
 
 // MarketCurveDay contains all market curves for a single day
@@ -91,6 +209,13 @@ type MarketCurveDay struct {
 	Curves []MarketCurve // One curve per hour
 }
 
+// ContinuousIntradayDay contains the intraday continuous market results for a single day
+type ContinuousIntradayDay struct {
+	Date       time.Time
+	Resolution Resolution
+	Prices     []ContinuousIntradayPrice // One price per delivery period, in period order
+}
+
 // IntradaySession contains all prices for a single intraday session
 type IntradaySession struct {
 	Date    time.Time
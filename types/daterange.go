@@ -0,0 +1,132 @@
+package types
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+	"time"
+)
+
+// DateRange is a range of market days including both Start and End, so a range with
+// Start equal to End covers a single day. Only the calendar dates of Start and End are
+// compared; the days yielded keep the time of day and location of Start.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewDateRange returns the range of days from start to end, both included, failing if
+// either date is zero or end is before start
+func NewDateRange(start, end time.Time) (DateRange, error) {
+	r := DateRange{Start: start, End: end}
+	if err := r.Validate(); err != nil {
+		return DateRange{}, err
+	}
+	return r, nil
+}
+
+// SingleDay returns the range covering only date
+func SingleDay(date time.Time) DateRange {
+	return DateRange{Start: date, End: date}
+}
+
+// Validate checks that neither date is zero and that End isn't before Start
+func (r DateRange) Validate() error {
+	if r.Start.IsZero() || r.End.IsZero() {
+		return NewOMIEError(ErrCodeInvalidDate, "date range with a zero date", nil)
+	}
+	if calendarDate(r.End).Before(calendarDate(r.Start)) {
+		return NewOMIEError(ErrCodeInvalidDate, fmt.Sprintf("date range end %s is before start %s",
+			r.End.Format("2006-01-02"), r.Start.Format("2006-01-02")), nil)
+	}
+	return nil
+}
+
+// Days returns the number of days in the range, 0 if End is before Start
+func (r DateRange) Days() int {
+	days := 0
+	for range r.All() {
+		days++
+	}
+	return days
+}
+
+// Contains reports whether the calendar date of date is within the range
+func (r DateRange) Contains(date time.Time) bool {
+	day := calendarDate(date)
+	return !day.Before(calendarDate(r.Start)) && !day.After(calendarDate(r.End))
+}
+
+// All yields every day of the range in order, starting at Start
+func (r DateRange) All() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		end := calendarDate(r.End)
+		for date := r.Start; !calendarDate(date).After(end); date = date.AddDate(0, 0, 1) {
+			if !yield(date) {
+				return
+			}
+		}
+	}
+}
+
+// Subtract returns the days of the range not covered by any of ranges, as ranges of
+// calendar dates at midnight UTC in date order, e.g. the dates of a sync that weren't
+// imported before
+func (r DateRange) Subtract(ranges []DateRange) []DateRange {
+	var gaps []DateRange
+	next, end := calendarDate(r.Start), calendarDate(r.End)
+	for _, covered := range MergeDateRanges(ranges) {
+		if next.After(end) {
+			break
+		}
+		if calendarDate(covered.End).Before(next) {
+			continue
+		}
+		if start := calendarDate(covered.Start); start.After(next) {
+			gapEnd := start.AddDate(0, 0, -1)
+			if gapEnd.After(end) {
+				gapEnd = end
+			}
+			gaps = append(gaps, DateRange{Start: next, End: gapEnd})
+		}
+		next = calendarDate(covered.End).AddDate(0, 0, 1)
+	}
+	if !next.After(end) {
+		gaps = append(gaps, DateRange{Start: next, End: end})
+	}
+	return gaps
+}
+
+// MergeDateRanges sorts ranges by date and merges the ones that overlap or follow each
+// other, so a set of days is kept as few ranges as possible
+func MergeDateRanges(ranges []DateRange) []DateRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]DateRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return calendarDate(sorted[i].Start).Before(calendarDate(sorted[j].Start)) })
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if calendarDate(r.Start).After(calendarDate(last.End).AddDate(0, 0, 1)) {
+			merged = append(merged, r)
+			continue
+		}
+		if calendarDate(r.End).After(calendarDate(last.End)) {
+			last.End = r.End
+		}
+	}
+	return merged
+}
+
+// String returns the range as "2006-01-02..2006-01-02"
+func (r DateRange) String() string {
+	return r.Start.Format("2006-01-02") + ".." + r.End.Format("2006-01-02")
+}
+
+// calendarDate returns the calendar date of t as midnight UTC, for comparing dates
+// regardless of time of day and location
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewDateRange(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		wantErr bool
+	}{
+		{"single day", start, start, false},
+		{"week", start, start.AddDate(0, 0, 6), false},
+		{"end before start", start, start.AddDate(0, 0, -1), true},
+		{"zero start", time.Time{}, start, true},
+		{"zero end", start, time.Time{}, true},
+		{"same day, earlier time", start.Add(12 * time.Hour), start, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDateRange(tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDateRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var omieErr *OMIEError
+			if err != nil && (!errors.As(err, &omieErr) || omieErr.Code != ErrCodeInvalidDate) {
+				t.Errorf("expected an %s error, got %v", ErrCodeInvalidDate, err)
+			}
+		})
+	}
+}
+
+func TestDateRangeAll(t *testing.T) {
+	start := time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)
+	r := DateRange{Start: start, End: start.AddDate(0, 0, 2)}
+
+	var days []string
+	for date := range r.All() {
+		days = append(days, date.Format("2006-01-02"))
+	}
+
+	want := []string{"2024-02-28", "2024-02-29", "2024-03-01"}
+	if len(days) != len(want) {
+		t.Fatalf("expected %v, got %v", want, days)
+	}
+	for i := range want {
+		if days[i] != want[i] {
+			t.Errorf("day %d = %s, want %s", i, days[i], want[i])
+		}
+	}
+
+	if r.Days() != 3 {
+		t.Errorf("Days() = %d, want 3", r.Days())
+	}
+	if !r.Contains(start.AddDate(0, 0, 2).Add(23*time.Hour)) || r.Contains(start.AddDate(0, 0, 3)) {
+		t.Error("expected Contains to include the end date and nothing after it")
+	}
+	if (DateRange{Start: start, End: start.AddDate(0, 0, -1)}).Days() != 0 {
+		t.Error("expected an inverted range to have no days")
+	}
+}
+
+func TestDateRangeSubtract(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	done := MergeDateRanges([]DateRange{SingleDay(day(8)), {Start: day(1), End: day(3)}, SingleDay(day(5)), SingleDay(day(7))})
+	if len(done) != 3 {
+		t.Fatalf("expected 1-3, 5 and 7-8 to merge into 3 ranges, got %v", done)
+	}
+
+	gaps := DateRange{Start: day(2), End: day(10)}.Subtract(done)
+	want := []string{"2024-01-04..2024-01-04", "2024-01-06..2024-01-06", "2024-01-09..2024-01-10"}
+	if len(gaps) != len(want) {
+		t.Fatalf("expected gaps %v, got %v", want, gaps)
+	}
+	for i := range want {
+		if gaps[i].String() != want[i] {
+			t.Errorf("gap %d = %s, want %s", i, gaps[i], want[i])
+		}
+	}
+
+	if gaps := SingleDay(day(2)).Subtract(done); len(gaps) != 0 {
+		t.Errorf("expected no gaps in a covered day, got %v", gaps)
+	}
+	if gaps := SingleDay(day(2)).Subtract(nil); len(gaps) != 1 || gaps[0].Days() != 1 {
+		t.Errorf("expected the whole range without ranges to subtract, got %v", gaps)
+	}
+}
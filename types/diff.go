@@ -0,0 +1,232 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Change describes a single difference found by Diff. Old is nil for values that
+// were added and New is nil for values that were removed
+type Change struct {
+	Path string // Location of the value, e.g. "SpainPrices[3]" or "Records[2].Wind"
+	Old  interface{}
+	New  interface{}
+}
+
+// String returns a human-readable description of the change
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(value)"
+	}
+
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("%s: added %v", path, formatDiffValue(c.New))
+	case c.New == nil:
+		return fmt.Sprintf("%s: removed %v", path, formatDiffValue(c.Old))
+	default:
+		return fmt.Sprintf("%s: %v -> %v", path, formatDiffValue(c.Old), formatDiffValue(c.New))
+	}
+}
+
+// Diff compares two values and returns the list of changes needed to go from a to b.
+// Floats are compared with the given absolute tolerance and NaN (missing data) is
+// considered equal to NaN. Map entries are reported in ascending key order.
+func Diff[T any](a, b T, tolerance float64) []Change {
+	var changes []Change
+	diffValues(&changes, "", reflect.ValueOf(a), reflect.ValueOf(b), tolerance)
+	return changes
+}
+
+// diffValues appends the differences between a and b to changes
+func diffValues(changes *[]Change, path string, a, b reflect.Value, tolerance float64) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			*changes = append(*changes, Change{Path: path, Old: interfaceOf(a), New: interfaceOf(b)})
+		}
+		return
+	}
+
+	if a.Type() == reflect.TypeOf(time.Time{}) {
+		if !a.Interface().(time.Time).Equal(b.Interface().(time.Time)) {
+			*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				*changes = append(*changes, Change{Path: path, Old: interfaceOf(a), New: interfaceOf(b)})
+			}
+			return
+		}
+		diffValues(changes, path, a.Elem(), b.Elem(), tolerance)
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			diffValues(changes, joinPath(path, field.Name), a.Field(i), b.Field(i), tolerance)
+		}
+
+	case reflect.Map:
+		for _, key := range sortedMapKeys(a, b) {
+			keyPath := fmt.Sprintf("%s[%v]", path, key.Interface())
+			av, bv := a.MapIndex(key), b.MapIndex(key)
+			switch {
+			case !av.IsValid():
+				*changes = append(*changes, Change{Path: keyPath, New: bv.Interface()})
+			case !bv.IsValid():
+				*changes = append(*changes, Change{Path: keyPath, Old: av.Interface()})
+			default:
+				diffValues(changes, keyPath, av, bv, tolerance)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			indexPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= a.Len():
+				*changes = append(*changes, Change{Path: indexPath, New: b.Index(i).Interface()})
+			case i >= b.Len():
+				*changes = append(*changes, Change{Path: indexPath, Old: a.Index(i).Interface()})
+			default:
+				diffValues(changes, indexPath, a.Index(i), b.Index(i), tolerance)
+			}
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if !floatsEqual(a.Float(), b.Float(), tolerance) {
+			*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+	}
+}
+
+// floatsEqual compares two floats within tolerance, treating NaN as equal to NaN
+func floatsEqual(a, b, tolerance float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= tolerance
+}
+
+// sortedMapKeys returns the union of the keys of two maps in ascending order
+func sortedMapKeys(a, b reflect.Value) []reflect.Value {
+	seen := make(map[interface{}]bool)
+	var keys []reflect.Value
+	for _, m := range []reflect.Value{a, b} {
+		for _, key := range m.MapKeys() {
+			if !seen[key.Interface()] {
+				seen[key.Interface()] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := keys[i], keys[j]
+		switch ki.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return ki.Int() < kj.Int()
+		case reflect.String:
+			return ki.String() < kj.String()
+		default:
+			return fmt.Sprint(ki.Interface()) < fmt.Sprint(kj.Interface())
+		}
+	})
+
+	return keys
+}
+
+// joinPath appends a field name to a path
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// interfaceOf returns the value as an interface, or nil for invalid or nil values
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// formatDiffValue formats a value for Change.String
+func formatDiffValue(v interface{}) string {
+	switch value := v.(type) {
+	case time.Time:
+		return value.Format("2006-01-02")
+	case float64:
+		return fmt.Sprintf("%g", value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// Equal reports whether both values hold the same data, comparing floats within tolerance
+func (d *MarginalPriceData) Equal(other *MarginalPriceData, tolerance float64) bool {
+	return len(Diff(d, other, tolerance)) == 0
+}
+
+// Equal reports whether both records hold the same data, comparing floats within tolerance
+func (r MarginalPriceRecord) Equal(other MarginalPriceRecord, tolerance float64) bool {
+	return len(Diff(r, other, tolerance)) == 0
+}
+
+// Equal reports whether both records hold the same data, comparing floats within tolerance
+func (e TechnologyEnergy) Equal(other TechnologyEnergy, tolerance float64) bool {
+	return len(Diff(e, other, tolerance)) == 0
+}
+
+// Equal reports whether both days hold the same data, comparing floats within tolerance
+func (d *TechnologyEnergyDay) Equal(other *TechnologyEnergyDay, tolerance float64) bool {
+	return len(Diff(d, other, tolerance)) == 0
+}
+
+// Equal reports whether both points hold the same data, comparing floats within tolerance
+func (p MarketPoint) Equal(other MarketPoint, tolerance float64) bool {
+	return len(Diff(p, other, tolerance)) == 0
+}
+
+// Equal reports whether both curves hold the same data, comparing floats within tolerance
+func (c MarketCurve) Equal(other MarketCurve, tolerance float64) bool {
+	return len(Diff(c, other, tolerance)) == 0
+}
+
+// Equal reports whether both days hold the same data, comparing floats within tolerance
+func (d *MarketCurveDay) Equal(other *MarketCurveDay, tolerance float64) bool {
+	return len(Diff(d, other, tolerance)) == 0
+}
+
+// Equal reports whether both prices hold the same data, comparing floats within tolerance
+func (p IntradayPrice) Equal(other IntradayPrice, tolerance float64) bool {
+	return len(Diff(p, other, tolerance)) == 0
+}
+
+// Equal reports whether both sessions hold the same data, comparing floats within tolerance
+func (s *IntradaySession) Equal(other *IntradaySession, tolerance float64) bool {
+	return len(Diff(s, other, tolerance)) == 0
+}
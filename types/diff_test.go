@@ -0,0 +1,71 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDiff_MarginalPriceData(t *testing.T) {
+	date := time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC)
+
+	a := NewMarginalPriceData(date)
+	a.SpainPrices[1] = 100.0
+	a.SpainPrices[2] = 90.0
+	a.PortugalPrices[1] = math.NaN()
+
+	b := NewMarginalPriceData(date)
+	b.SpainPrices[1] = 100.004
+	b.SpainPrices[2] = 95.5
+	b.SpainPrices[25] = 80.0
+	b.PortugalPrices[1] = math.NaN()
+
+	changes := Diff(a, b, 0.01)
+
+	expected := []string{
+		"SpainPrices[2]: 90 -> 95.5",
+		"SpainPrices[25]: added 80",
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+
+	for i, change := range changes {
+		if change.String() != expected[i] {
+			t.Errorf("change %d: expected %q, got %q", i, expected[i], change.String())
+		}
+	}
+
+	if a.Equal(b, 0.01) {
+		t.Errorf("expected data to differ")
+	}
+
+	if !a.Equal(a, 0) {
+		t.Errorf("expected data to equal itself")
+	}
+}
+
+func TestDiff_TechnologyEnergyDay(t *testing.T) {
+	a := &TechnologyEnergyDay{
+		System:  Iberian,
+		Records: []TechnologyEnergy{{Hour: 1, Wind: 7371.1, Coal: math.NaN()}},
+	}
+	b := &TechnologyEnergyDay{
+		System:  Iberian,
+		Records: []TechnologyEnergy{{Hour: 1, Wind: 7371.1, Coal: 10}, {Hour: 2}},
+	}
+
+	changes := Diff(a, b, 0.1)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %v", len(changes), changes)
+	}
+
+	if changes[0].Path != "Records[0].Coal" {
+		t.Errorf("expected path Records[0].Coal, got %s", changes[0].Path)
+	}
+
+	if changes[1].Path != "Records[1]" || changes[1].Old != nil {
+		t.Errorf("expected added Records[1], got %v", changes[1])
+	}
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+)
+
+// Nullable is a float that may be missing, an alternative to the NaN sentinels of the
+// data types that is safe to sum and to encode. It marshals to JSON as null when missing.
+type Nullable struct {
+	Value float64
+	Valid bool // Valid is false when the value is missing
+}
+
+// NullableOf returns value as a Nullable, missing when it's NaN
+func NullableOf(value float64) Nullable {
+	return Nullable{Value: value, Valid: !math.IsNaN(value)}
+}
+
+// Or returns the value, or fallback when it's missing
+func (n Nullable) Or(fallback float64) float64 {
+	if !n.Valid {
+		return fallback
+	}
+	return n.Value
+}
+
+// Float64 returns the value, or NaN when it's missing
+func (n Nullable) Float64() float64 {
+	return n.Or(math.NaN())
+}
+
+// MarshalJSON implements json.Marshaler
+func (n Nullable) MarshalJSON() ([]byte, error) {
+	return jsonFloat(n.Float64()).MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *Nullable) UnmarshalJSON(data []byte) error {
+	var f jsonFloat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*n = NullableOf(float64(f))
+	return nil
+}
+
+// technologies lists the technologies of TechnologyEnergy in field order
+var technologies = []TechnologyType{
+	Coal, FuelGas, SelfProducer, Nuclear, Hydro, CombinedCycle,
+	Wind, ThermalSolar, PhotovoltaicSolar, Residuals, Import, ImportWithoutMIBEL,
+}
+
+// Technologies returns the technologies of TechnologyEnergy in field order
+func Technologies() []TechnologyType {
+	return append([]TechnologyType(nil), technologies...)
+}
+
+// field returns the field holding the energy of tech, or nil if there's none
+func (e *TechnologyEnergy) field(tech TechnologyType) *float64 {
+	switch tech {
+	case Coal:
+		return &e.Coal
+	case FuelGas:
+		return &e.FuelGas
+	case SelfProducer:
+		return &e.SelfProducer
+	case Nuclear:
+		return &e.Nuclear
+	case Hydro:
+		return &e.Hydro
+	case CombinedCycle:
+		return &e.CombinedCycle
+	case Wind:
+		return &e.Wind
+	case ThermalSolar:
+		return &e.SolarThermal
+	case PhotovoltaicSolar:
+		return &e.SolarPV
+	case Residuals:
+		return &e.Cogeneration
+	case Import:
+		return &e.ImportInt
+	case ImportWithoutMIBEL:
+		return &e.ImportNoMIBEL
+	}
+	return nil
+}
+
+// Set sets the energy of tech, ignoring technologies TechnologyEnergy has no field for
+func (e *TechnologyEnergy) Set(tech TechnologyType, value float64) {
+	if field := e.field(tech); field != nil {
+		*field = value
+	}
+}
+
+// Value returns the energy of tech and whether it's present, i.e. known and not NaN
+func (e TechnologyEnergy) Value(tech TechnologyType) (float64, bool) {
+	n := e.Nullable(tech)
+	return n.Value, n.Valid
+}
+
+// ValueOrZero returns the energy of tech, or zero when it's missing
+func (e TechnologyEnergy) ValueOrZero(tech TechnologyType) float64 {
+	return e.Nullable(tech).Or(0)
+}
+
+// Nullable returns the energy of tech as a Nullable, missing when it's NaN or tech has
+// no field in TechnologyEnergy
+func (e TechnologyEnergy) Nullable(tech TechnologyType) Nullable {
+	field := e.field(tech)
+	if field == nil {
+		return Nullable{}
+	}
+	return NullableOf(*field)
+}
+
+// Values returns the energies present in the record by technology, leaving out the
+// missing ones
+func (e TechnologyEnergy) Values() map[TechnologyType]float64 {
+	values := make(map[TechnologyType]float64, len(technologies))
+	for _, tech := range technologies {
+		if value, ok := e.Value(tech); ok {
+			values[tech] = value
+		}
+	}
+	return values
+}
+
+// Total returns the energy summed over every technology, including imports. Missing
+// values (NaN) are skipped; NaN is only returned when every technology is missing.
+func (e TechnologyEnergy) Total() float64 {
+	total := 0.0
+	present := false
+	for _, tech := range technologies {
+		if value, ok := e.Value(tech); ok {
+			total += value
+			present = true
+		}
+	}
+
+	if !present {
+		return math.NaN()
+	}
+	return total
+}
+
+// renewables lists the technologies counted as renewable by default
+var renewables = []TechnologyType{Hydro, Wind, ThermalSolar, PhotovoltaicSolar}
+
+// RenewableTechnologies returns the technologies RenewableShare counts as renewable by
+// default: hydro, wind, solar thermal and solar PV. Cogeneration, waste and mini-hydro
+// are reported together by OMIE, so they aren't counted.
+func RenewableTechnologies() []TechnologyType {
+	return append([]TechnologyType(nil), renewables...)
+}
+
+// RenewableShare returns the share (0-1) of the energy generated in the hour that comes
+// from renewable technologies, those given or RenewableTechnologies when none is.
+// Imports aren't generation, so they are left out of both sides. Missing values are
+// skipped; NaN is returned when no generation is present.
+func (e TechnologyEnergy) RenewableShare(renewable ...TechnologyType) float64 {
+	generated, fromRenewables := e.generation(renewable)
+	if generated <= 0 {
+		return math.NaN()
+	}
+	return fromRenewables / generated
+}
+
+// generation returns the energy generated in the hour, without imports, and the part of
+// it coming from the renewable technologies, RenewableTechnologies when none is given
+func (e TechnologyEnergy) generation(renewable []TechnologyType) (generated, fromRenewables float64) {
+	if len(renewable) == 0 {
+		renewable = renewables
+	}
+	for _, tech := range technologies {
+		value, ok := e.Value(tech)
+		if !ok || tech == Import || tech == ImportWithoutMIBEL {
+			continue
+		}
+		generated += value
+		if slices.Contains(renewable, tech) {
+			fromRenewables += value
+		}
+	}
+	return generated, fromRenewables
+}
+
+// RenewableShare returns the share (0-1) of the energy generated over the day that comes
+// from renewable technologies, weighting every hour by its generation, see
+// TechnologyEnergy.RenewableShare
+func (d *TechnologyEnergyDay) RenewableShare(renewable ...TechnologyType) float64 {
+	var generated, fromRenewables float64
+	for _, record := range d.Records {
+		hourGenerated, hourFromRenewables := record.generation(renewable)
+		generated += hourGenerated
+		fromRenewables += hourFromRenewables
+	}
+	if generated <= 0 {
+		return math.NaN()
+	}
+	return fromRenewables / generated
+}
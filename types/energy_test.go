@@ -0,0 +1,76 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestTechnologyEnergyValue(t *testing.T) {
+	record := TechnologyEnergy{Hour: 1, Coal: math.NaN(), Wind: 1500, Nuclear: 7000}
+
+	if _, ok := record.Value(Coal); ok {
+		t.Error("expected coal to be missing")
+	}
+	if value, ok := record.Value(Wind); !ok || value != 1500 {
+		t.Errorf("Value(Wind) = %v, %v, want 1500, true", value, ok)
+	}
+	if record.ValueOrZero(Coal) != 0 {
+		t.Errorf("ValueOrZero(Coal) = %v, want 0", record.ValueOrZero(Coal))
+	}
+	if _, ok := record.Value(TechnologyType("UNKNOWN")); ok {
+		t.Error("expected an unknown technology to be missing")
+	}
+
+	values := record.Values()
+	if _, ok := values[Coal]; ok || values[Nuclear] != 7000 {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	record.Set(Coal, 200)
+	if record.Coal != 200 {
+		t.Errorf("Set(Coal) left %v", record.Coal)
+	}
+}
+
+func TestNullableJSON(t *testing.T) {
+	payload, err := json.Marshal([]Nullable{NullableOf(1.5), NullableOf(math.NaN())})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(payload) != "[1.5,null]" {
+		t.Errorf("unexpected JSON %s", payload)
+	}
+
+	var decoded []Nullable
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded[0].Valid || decoded[0].Value != 1.5 || decoded[1].Valid {
+		t.Errorf("unexpected values %+v", decoded)
+	}
+}
+
+func TestRenewableShare(t *testing.T) {
+	nan := math.NaN()
+	day := TechnologyEnergyDay{Records: []TechnologyEnergy{
+		{Hour: 1, Coal: nan, Nuclear: 6000, Wind: 3000, Hydro: 1000, ImportInt: 500, ImportNoMIBEL: nan},
+		{Hour: 2, Coal: nan, Nuclear: 6000, Wind: 9000, SolarPV: 5000, ImportInt: 2000},
+	}}
+
+	if share := day.Records[0].RenewableShare(); share != 0.4 {
+		t.Errorf("RenewableShare() = %v, want 0.4 leaving imports out", share)
+	}
+	if share := day.Records[0].RenewableShare(Wind, Nuclear); share != 0.9 {
+		t.Errorf("RenewableShare(Wind, Nuclear) = %v, want 0.9", share)
+	}
+	if share := day.RenewableShare(); share != 0.6 {
+		t.Errorf("day RenewableShare() = %v, want 0.6 weighted by generation", share)
+	}
+
+	missing := TechnologyEnergy{Coal: nan, FuelGas: nan, SelfProducer: nan, Nuclear: nan, Hydro: nan, CombinedCycle: nan,
+		Wind: nan, SolarThermal: nan, SolarPV: nan, Cogeneration: nan, ImportInt: 100, ImportNoMIBEL: nan}
+	if share := missing.RenewableShare(); !math.IsNaN(share) {
+		t.Errorf("expected NaN without generation, got %v", share)
+	}
+}
@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
 // SystemType represents the different market systems
 type SystemType int
 
@@ -23,6 +29,45 @@ func (s SystemType) String() string {
 	}
 }
 
+// Location returns the local time zone of the system: Europe/Lisbon for Portugal and
+// Europe/Madrid, the time zone of the market, otherwise
+func (s SystemType) Location() *time.Location {
+	if s == Portugal {
+		return portugalLocation
+	}
+	return marketLocation
+}
+
+// MarshalText implements encoding.TextMarshaler. The zero value encodes as an empty string
+func (s SystemType) MarshalText() ([]byte, error) {
+	switch s {
+	case 0:
+		return []byte{}, nil
+	case Spain, Portugal, Iberian:
+		return []byte(s.String()), nil
+	default:
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown system type "+strconv.Itoa(int(s)), nil)
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either the
+// name ("SPAIN") or the numeric code used in OMIE file names ("1")
+func (s *SystemType) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(strings.TrimSpace(string(text))) {
+	case "":
+		*s = 0
+	case "SPAIN", "1":
+		*s = Spain
+	case "PORTUGAL", "2":
+		*s = Portugal
+	case "IBERIAN", "9":
+		*s = Iberian
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown system type "+strconv.Quote(string(text)), nil)
+	}
+	return nil
+}
+
 // TechnologyType represents different energy generation technologies
 type TechnologyType string
 
@@ -105,6 +150,20 @@ func TechnologyTypeFromSpanish(spanish string) TechnologyType {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler
+func (t TechnologyType) MarshalText() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *TechnologyType) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return NewOMIEError(ErrCodeInvalidData, "empty technology type", nil)
+	}
+	*t = TechnologyType(text)
+	return nil
+}
+
 // DataTypeInMarginalPriceFile represents the different data types in marginal price files
 type DataTypeInMarginalPriceFile string
 
@@ -115,6 +174,55 @@ const (
 	EnergyIberianWithBilateral DataTypeInMarginalPriceFile = "ENER_IB_BILLAT"
 	EnergyBuySpain             DataTypeInMarginalPriceFile = "ENER_BUY_SP"
 	EnergySellSpain            DataTypeInMarginalPriceFile = "ENER_SELL_SP"
+
+	// Adjustment prices of the gas price cap mechanism (2022-2023), not day-ahead prices
+	AdjustmentPriceSpain    DataTypeInMarginalPriceFile = "ADJ_PRICE_SP"
+	AdjustmentPricePortugal DataTypeInMarginalPriceFile = "ADJ_PRICE_PT"
+
+	// Scheduled flows over the Spain-Portugal interconnection
+	ExportSpainToPortugal DataTypeInMarginalPriceFile = "EXP_SP_PT"
+	ExportPortugalToSpain DataTypeInMarginalPriceFile = "EXP_PT_SP"
+)
+
+// MarshalText implements encoding.TextMarshaler
+func (d DataTypeInMarginalPriceFile) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *DataTypeInMarginalPriceFile) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return NewOMIEError(ErrCodeInvalidData, "empty marginal price data type", nil)
+	}
+	*d = DataTypeInMarginalPriceFile(text)
+	return nil
+}
+
+// FormatVersion identifies the layout of a marginal price file, which changed with the
+// currency, the units and the markets it covers. The empty version is unknown.
+type FormatVersion string
+
+const (
+	FormatLegacyPeseta     FormatVersion = "LEGACY_PESETA"      // OMEL files with Pta/kWh prices, before the euro changeover
+	FormatCentSingleMarket FormatVersion = "CENT_SINGLE_MARKET" // Cent/kWh prices of the Spanish market only, before MIBEL
+	FormatCentDualMarket   FormatVersion = "CENT_DUAL_MARKET"   // Cent/kWh prices of the Spanish and Portuguese markets
+	FormatEuro             FormatVersion = "EURO"               // EUR/MWh prices, the current layout
+	FormatAdjustment       FormatVersion = "ADJUSTMENT"         // Adjustment prices of the gas price cap mechanism (2022-2023)
+)
+
+// ExpectsPortugalPrices reports whether files of this version publish Portuguese
+// marginal prices
+func (v FormatVersion) ExpectsPortugalPrices() bool {
+	return v == FormatCentDualMarket || v == FormatEuro
+}
+
+// Encoding is the character encoding of a file as detected by the parsers. The empty
+// encoding is unknown.
+type Encoding string
+
+const (
+	EncodingLatin1 Encoding = "ISO-8859-1" // Of the files OMIE has always published
+	EncodingUTF8   Encoding = "UTF-8"      // Of some newer endpoints, with or without a byte order mark
 )
 
 // SessionType represents intraday market sessions
@@ -129,6 +237,24 @@ const (
 	Session6 SessionType = 6
 )
 
+// MarshalText implements encoding.TextMarshaler
+func (s SessionType) MarshalText() ([]byte, error) {
+	if s < Session1 || s > Session6 {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown intraday session "+strconv.Itoa(int(s)), nil)
+	}
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *SessionType) UnmarshalText(text []byte) error {
+	n, err := strconv.Atoi(strings.TrimSpace(string(text)))
+	if err != nil || SessionType(n) < Session1 || SessionType(n) > Session6 {
+		return NewOMIEError(ErrCodeInvalidData, "unknown intraday session "+strconv.Quote(string(text)), err)
+	}
+	*s = SessionType(n)
+	return nil
+}
+
 // OfferType represents market offer types
 type OfferType string
 
@@ -137,6 +263,25 @@ const (
 	Sell OfferType = "V" // Venta/Supply
 )
 
+// MarshalText implements encoding.TextMarshaler
+func (o OfferType) MarshalText() ([]byte, error) {
+	if o != "" && o != Buy && o != Sell {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown offer type "+strconv.Quote(string(o)), nil)
+	}
+	return []byte(o), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (o *OfferType) UnmarshalText(text []byte) error {
+	switch OfferType(text) {
+	case "", Buy, Sell:
+		*o = OfferType(text)
+		return nil
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown offer type "+strconv.Quote(string(text)), nil)
+	}
+}
+
 // MatchedStatus represents whether an offer was matched
 type MatchedStatus string
 
@@ -144,3 +289,104 @@ const (
 	Offered MatchedStatus = "O" // Ofertada
 	Matched MatchedStatus = "C" // Casada
 )
+
+// MarshalText implements encoding.TextMarshaler
+func (m MatchedStatus) MarshalText() ([]byte, error) {
+	if m != "" && m != Offered && m != Matched {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown matched status "+strconv.Quote(string(m)), nil)
+	}
+	return []byte(m), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *MatchedStatus) UnmarshalText(text []byte) error {
+	switch MatchedStatus(text) {
+	case "", Offered, Matched:
+		*m = MatchedStatus(text)
+		return nil
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown matched status "+strconv.Quote(string(text)), nil)
+	}
+}
+
+// AggregationLevel tells what the points of a curve represent
+type AggregationLevel string
+
+const (
+	Aggregated AggregationLevel = "aggregated" // Steps of the aggregated curves, without unit identity
+	UnitLevel  AggregationLevel = "unit"       // Offers of individual bidding units
+)
+
+// MarshalText implements encoding.TextMarshaler
+func (a AggregationLevel) MarshalText() ([]byte, error) {
+	if a != "" && a != Aggregated && a != UnitLevel {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown aggregation level "+strconv.Quote(string(a)), nil)
+	}
+	return []byte(a), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *AggregationLevel) UnmarshalText(text []byte) error {
+	switch AggregationLevel(text) {
+	case "", Aggregated, UnitLevel:
+		*a = AggregationLevel(text)
+		return nil
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown aggregation level "+strconv.Quote(string(text)), nil)
+	}
+}
+
+// LoadProfile is the delivery profile of a futures contract
+type LoadProfile string
+
+const (
+	BaseLoad LoadProfile = "base" // Every hour of the delivery period
+	PeakLoad LoadProfile = "peak" // Weekday daytime hours only
+)
+
+// MarshalText implements encoding.TextMarshaler
+func (l LoadProfile) MarshalText() ([]byte, error) {
+	if l != "" && l != BaseLoad && l != PeakLoad {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown load profile "+strconv.Quote(string(l)), nil)
+	}
+	return []byte(l), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (l *LoadProfile) UnmarshalText(text []byte) error {
+	switch LoadProfile(text) {
+	case "", BaseLoad, PeakLoad:
+		*l = LoadProfile(text)
+		return nil
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown load profile "+strconv.Quote(string(text)), nil)
+	}
+}
+
+// DeliveryPeriod is the length of the delivery period of a futures contract
+type DeliveryPeriod string
+
+const (
+	MonthDelivery   DeliveryPeriod = "month"
+	QuarterDelivery DeliveryPeriod = "quarter"
+	YearDelivery    DeliveryPeriod = "year"
+)
+
+// MarshalText implements encoding.TextMarshaler
+func (p DeliveryPeriod) MarshalText() ([]byte, error) {
+	if p != "" && p != MonthDelivery && p != QuarterDelivery && p != YearDelivery {
+		return nil, NewOMIEError(ErrCodeInvalidData, "unknown delivery period "+strconv.Quote(string(p)), nil)
+	}
+	return []byte(p), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *DeliveryPeriod) UnmarshalText(text []byte) error {
+	switch DeliveryPeriod(text) {
+	case "", MonthDelivery, QuarterDelivery, YearDelivery:
+		*p = DeliveryPeriod(text)
+		return nil
+	default:
+		return NewOMIEError(ErrCodeInvalidData, "unknown delivery period "+strconv.Quote(string(text)), nil)
+	}
+}
@@ -1,6 +1,12 @@
 package types
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
 
 // OMIEError represents a custom error type for the OMIE library
 type OMIEError struct {
@@ -38,4 +44,111 @@ const (
 	ErrCodeNotFound    = "NOT_FOUND"
 	ErrCodeNetwork     = "NETWORK_ERROR"
 	ErrCodeEncoding    = "ENCODING_ERROR"
+	ErrCodeStorage     = "STORAGE_ERROR"
+	ErrCodeUnavailable = "UPSTREAM_UNAVAILABLE"
+)
+
+// ErrorClass tells whether a failed download is worth trying again, and when
+type ErrorClass string
+
+// Error classes returned by Classify
+const (
+	ClassTransient    ErrorClass = "TRANSIENT"     // Network errors, 5xx and 429 responses: retry soon
+	ClassNotPublished ErrorClass = "NOT_PUBLISHED" // 404 responses: the file may be published later
+	ClassPermanent    ErrorClass = "PERMANENT"     // Other 4xx responses and invalid files: retrying won't help
 )
+
+// HTTPError is an unsuccessful response from omie.es. It is the cause of the NOT_FOUND
+// and NETWORK_ERROR errors of failed downloads.
+type HTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration // Wait asked for by the Retry-After header, zero without one
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// Class returns the class of the response status
+func (e *HTTPError) Class() ErrorClass {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ClassNotPublished
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests, e.StatusCode >= 500:
+		return ClassTransient
+	default:
+		return ClassPermanent
+	}
+}
+
+// Classify returns the class of the failure of a single date, e.g. to decide whether to
+// import it again later. The status of an HTTPError in the chain decides; otherwise
+// the innermost error code does, and errors without a code, like connection failures,
+// are transient. It returns "" for a nil error.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Class()
+	}
+
+	class := ClassTransient
+	for ; err != nil; err = errors.Unwrap(err) {
+		omieErr, ok := err.(*OMIEError)
+		if !ok {
+			continue
+		}
+		switch omieErr.Code {
+		case ErrCodeNotFound:
+			class = ClassNotPublished
+		case ErrCodeParse, ErrCodeInvalidData, ErrCodeInvalidDate, ErrCodeEncoding:
+			class = ClassPermanent
+		case ErrCodeNetwork, ErrCodeUnavailable:
+			class = ClassTransient
+		}
+	}
+	return class
+}
+
+// ErrorCode returns the innermost error code in the chain of err, the most specific
+// one, e.g. NOT_FOUND rather than the DOWNLOAD_ERROR wrapping it, or "" when err has
+// none
+func ErrorCode(err error) string {
+	code := ""
+	for ; err != nil; err = errors.Unwrap(err) {
+		if omieErr, ok := err.(*OMIEError); ok {
+			code = omieErr.Code
+		}
+	}
+	return code
+}
+
+// MultiError aggregates the failures of a batch operation, e.g. every date of an import
+// that failed. It unwraps to all of them, so errors.Is and errors.As match any cause.
+type MultiError struct {
+	Message string
+	Errors  []error
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%s with %d errors: %s", e.Message, len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// JoinErrors returns a *MultiError holding errs, or nil when there are none
+func JoinErrors(message string, errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{Message: message, Errors: errs}
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJoinErrors(t *testing.T) {
+	if err := JoinErrors("download completed", nil); err != nil {
+		t.Fatalf("Expected nil for no errors, got %v", err)
+	}
+
+	notFound := NewOMIEError(ErrCodeNotFound, "data not available", nil)
+	parse := NewOMIEError(ErrCodeParse, "no valid data found", io.ErrUnexpectedEOF)
+	err := JoinErrors("download completed", []error{notFound, parse})
+
+	if !strings.Contains(err.Error(), "2 errors") || !strings.Contains(err.Error(), "data not available") || !strings.Contains(err.Error(), "no valid data found") {
+		t.Errorf("Expected every failure in the message, got %q", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("Expected errors.Is to reach the second failure's cause")
+	}
+
+	var omieErr *OMIEError
+	if !errors.As(err, &omieErr) || omieErr != notFound {
+		t.Errorf("Expected errors.As to find the first failure, got %v", omieErr)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ""},
+		{"not published", NewOMIEError(ErrCodeDownload, "failed after 1 attempts",
+			NewOMIEError(ErrCodeNotFound, "data not available", &HTTPError{StatusCode: 404})), ClassNotPublished},
+		{"server error", NewOMIEError(ErrCodeNetwork, "unexpected response", &HTTPError{StatusCode: 502}), ClassTransient},
+		{"rate limited", &HTTPError{StatusCode: 429}, ClassTransient},
+		{"forbidden", NewOMIEError(ErrCodeNetwork, "unexpected response", &HTTPError{StatusCode: 403}), ClassPermanent},
+		{"connection failure", NewOMIEError(ErrCodeDownload, "failed after 4 attempts", io.ErrUnexpectedEOF), ClassTransient},
+		{"invalid file", NewOMIEError(ErrCodeParse, "no valid data found", nil), ClassPermanent},
+		{"no code", errors.New("connection refused"), ClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	wrapped := fmt.Errorf("importing: %w", NewOMIEError(ErrCodeDownload, "failed after 1 attempts",
+		NewOMIEError(ErrCodeNotFound, "data not available", &HTTPError{StatusCode: 404})))
+	if got := ErrorCode(wrapped); got != ErrCodeNotFound {
+		t.Errorf("ErrorCode() = %q, want %q", got, ErrCodeNotFound)
+	}
+	if got := ErrorCode(errors.New("connection refused")); got != "" {
+		t.Errorf("ErrorCode() = %q, want none", got)
+	}
+}
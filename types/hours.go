@@ -0,0 +1,123 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// HourlyValues maps an hour index (1-based, up to 25 on DST days) to a value
+type HourlyValues map[int]float64
+
+// HoursSorted returns the hours present in ascending order
+func (v HourlyValues) HoursSorted() []int {
+	hours := make([]int, 0, len(v))
+	for hour := range v {
+		hours = append(hours, hour)
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// ForEachHour calls fn for every hour present, in ascending hour order
+func (v HourlyValues) ForEachHour(fn func(hour int, value float64)) {
+	for _, hour := range v.HoursSorted() {
+		fn(hour, v[hour])
+	}
+}
+
+// Series is a named series of MarginalPriceData, see MarginalPriceData.Series
+type Series struct {
+	Name   string // Name of the field in the JSON wire format, e.g. "spain_prices"
+	Values HourlyValues
+}
+
+// Series returns every series of the data in field order, including the empty ones
+func (d *MarginalPriceData) Series() []Series {
+	return []Series{
+		{"spain_prices", d.SpainPrices},
+		{"portugal_prices", d.PortugalPrices},
+		{"spain_buy_energy", d.SpainBuyEnergy},
+		{"spain_sell_energy", d.SpainSellEnergy},
+		{"iberian_energy", d.IberianEnergy},
+		{"bilateral_energy", d.BilateralEnergy},
+		{"spain_adjustment_prices", d.SpainAdjustmentPrices},
+		{"portugal_adjustment_prices", d.PortugalAdjustmentPrices},
+		{"export_spain_to_portugal", d.ExportSpainToPortugal},
+		{"export_portugal_to_spain", d.ExportPortugalToSpain},
+	}
+}
+
+// HoursSorted returns every hour that has a value in any of the series, in ascending order
+func (d *MarginalPriceData) HoursSorted() []int {
+	seen := make(map[int]bool)
+	var hours []int
+	for _, series := range d.Series() {
+		for hour := range series.Values {
+			if !seen[hour] {
+				seen[hour] = true
+				hours = append(hours, hour)
+			}
+		}
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// ForEachHour calls fn for every hour returned by HoursSorted, in ascending order
+func (d *MarginalPriceData) ForEachHour(fn func(hour int)) {
+	for _, hour := range d.HoursSorted() {
+		fn(hour)
+	}
+}
+
+// HoursSorted returns the hours of the records in ascending order
+func (d *TechnologyEnergyDay) HoursSorted() []int {
+	hours := make([]int, 0, len(d.Records))
+	for _, record := range d.Records {
+		hours = append(hours, record.Hour)
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// ForEachHour calls fn for every record in ascending hour order, regardless of
+// the order the records appear in the file
+func (d *TechnologyEnergyDay) ForEachHour(fn func(record TechnologyEnergy)) {
+	records := make([]TechnologyEnergy, len(d.Records))
+	copy(records, d.Records)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Hour < records[j].Hour
+	})
+
+	for _, record := range records {
+		fn(record)
+	}
+}
+
+// TimeForHour returns the instant hour h of the data's day starts, in Europe/Madrid. For
+// quarter-hourly data h is a period. DST days are handled, see HourIndex.Start.
+func (d *MarginalPriceData) TimeForHour(h int) time.Time {
+	return marketMidnight(d.Date).Add(time.Duration(h-1) * d.Resolution.Duration())
+}
+
+// TimeForHourIn returns TimeForHour in the local time of system, e.g. Europe/Lisbon for
+// Portugal. OMIE hours are in Spanish time for both countries.
+func (d *MarginalPriceData) TimeForHourIn(h int, system SystemType) time.Time {
+	return d.TimeForHour(h).In(system.Location())
+}
+
+// Time returns the instant the record's hour starts, in the local time of its system
+func (e TechnologyEnergy) Time() time.Time {
+	return HourIndex(e.Hour).Start(e.Date).In(e.System.Location())
+}
+
+// PeriodValue returns the value of one of the data's series (e.g. d.SpainPrices) for a
+// quarter-hour period. Hourly data holds one value for the four periods of each hour.
+func (d *MarginalPriceData) PeriodValue(series HourlyValues, period PeriodIndex) (float64, bool) {
+	index := period.Int()
+	if d.Resolution == Hourly {
+		index = period.Hour().Int()
+	}
+	value, ok := series[index]
+	return value, ok
+}
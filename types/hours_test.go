@@ -0,0 +1,62 @@
+package types
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHourlyValuesSorted(t *testing.T) {
+	values := HourlyValues{25: 3, 1: 1, 10: 2, 2: 1.5}
+	if hours := values.HoursSorted(); !slices.Equal(hours, []int{1, 2, 10, 25}) {
+		t.Errorf("HoursSorted() = %v", hours)
+	}
+
+	var visited []float64
+	values.ForEachHour(func(hour int, value float64) {
+		visited = append(visited, value)
+	})
+	if !slices.Equal(visited, []float64{1, 1.5, 2, 3}) {
+		t.Errorf("ForEachHour() visited %v", visited)
+	}
+
+	if hours := HourlyValues(nil).HoursSorted(); len(hours) != 0 {
+		t.Errorf("HoursSorted() of nil = %v", hours)
+	}
+}
+
+func TestMarginalPriceDataHoursSorted(t *testing.T) {
+	data := NewMarginalPriceData(time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC))
+	data.SpainPrices[3] = 40
+	data.SpainPrices[1] = 41
+	data.PortugalPrices[25] = 42
+	data.IberianEnergy[2] = 1000
+	data.IberianEnergy[3] = 1100
+
+	if hours := data.HoursSorted(); !slices.Equal(hours, []int{1, 2, 3, 25}) {
+		t.Errorf("HoursSorted() = %v, want every hour of any series once", hours)
+	}
+
+	var visited []int
+	data.ForEachHour(func(hour int) { visited = append(visited, hour) })
+	if !slices.Equal(visited, []int{1, 2, 3, 25}) {
+		t.Errorf("ForEachHour() visited %v", visited)
+	}
+}
+
+func TestTechnologyEnergyDayForEachHour(t *testing.T) {
+	day := &TechnologyEnergyDay{Records: []TechnologyEnergy{{Hour: 3, Wind: 30}, {Hour: 1, Wind: 10}, {Hour: 2, Wind: 20}}}
+
+	if hours := day.HoursSorted(); !slices.Equal(hours, []int{1, 2, 3}) {
+		t.Errorf("HoursSorted() = %v", hours)
+	}
+
+	var wind []float64
+	day.ForEachHour(func(record TechnologyEnergy) { wind = append(wind, record.Wind) })
+	if !slices.Equal(wind, []float64{10, 20, 30}) {
+		t.Errorf("ForEachHour() visited %v", wind)
+	}
+	if day.Records[0].Hour != 3 {
+		t.Error("ForEachHour() reordered the records of the day")
+	}
+}
@@ -0,0 +1,844 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// jsonDateLayout is the layout used for dates in the JSON wire format
+const jsonDateLayout = "2006-01-02"
+
+// jsonDate encodes a date as "YYYY-MM-DD", or null for the zero time
+type jsonDate time.Time
+
+func (d jsonDate) MarshalJSON() ([]byte, error) {
+	t := time.Time(d)
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(t.Format(jsonDateLayout))), nil
+}
+
+func (d *jsonDate) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = jsonDate(time.Time{})
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return NewOMIEError(ErrCodeInvalidData, "invalid date", err)
+	}
+
+	t, err := time.Parse(jsonDateLayout, s)
+	if err != nil {
+		return NewOMIEError(ErrCodeInvalidData, "invalid date", err)
+	}
+
+	*d = jsonDate(t)
+	return nil
+}
+
+// jsonFloat encodes NaN and infinite values (missing data) as null
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return []byte("null"), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*f = jsonFloat(math.NaN())
+		return nil
+	}
+
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return NewOMIEError(ErrCodeInvalidData, "invalid number", err)
+	}
+
+	*f = jsonFloat(v)
+	return nil
+}
+
+// jsonHourly encodes an hour -> value map as an object with keys in
+// ascending numeric order and null for missing values
+type jsonHourly map[int]float64
+
+func (h jsonHourly) MarshalJSON() ([]byte, error) {
+	hours := make([]int, 0, len(h))
+	for hour := range h {
+		hours = append(hours, hour)
+	}
+	sort.Ints(hours)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, hour := range hours {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Quote(strconv.Itoa(hour)))
+		buf.WriteByte(':')
+
+		value, _ := jsonFloat(h[hour]).MarshalJSON()
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (h *jsonHourly) UnmarshalJSON(data []byte) error {
+	var raw map[string]jsonFloat
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	values := make(jsonHourly, len(raw))
+	for key, value := range raw {
+		hour, err := strconv.Atoi(key)
+		if err != nil {
+			return NewOMIEError(ErrCodeInvalidData, "invalid hour key "+strconv.Quote(key), err)
+		}
+		values[hour] = float64(value)
+	}
+
+	*h = values
+	return nil
+}
+
+// toMap returns the values as HourlyValues, never nil
+func (h jsonHourly) toMap() HourlyValues {
+	if h == nil {
+		return make(HourlyValues)
+	}
+	return HourlyValues(h)
+}
+
+// MarshalJSON implements json.Marshaler, see jsonHourly
+func (v HourlyValues) MarshalJSON() ([]byte, error) {
+	return jsonHourly(v).MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (v *HourlyValues) UnmarshalJSON(data []byte) error {
+	var h jsonHourly
+	if err := h.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*v = h.toMap()
+	return nil
+}
+
+type marginalPriceDataJSON struct {
+	Date            jsonDate   `json:"date"`
+	Resolution      Resolution `json:"resolution,omitempty"`
+	SpainPrices     jsonHourly `json:"spain_prices"`
+	PortugalPrices  jsonHourly `json:"portugal_prices"`
+	SpainBuyEnergy  jsonHourly `json:"spain_buy_energy"`
+	SpainSellEnergy jsonHourly `json:"spain_sell_energy"`
+	IberianEnergy   jsonHourly `json:"iberian_energy"`
+	BilateralEnergy jsonHourly `json:"bilateral_energy"`
+
+	SpainAdjustmentPrices    jsonHourly `json:"spain_adjustment_prices,omitempty"`
+	PortugalAdjustmentPrices jsonHourly `json:"portugal_adjustment_prices,omitempty"`
+
+	ExportSpainToPortugal jsonHourly `json:"export_spain_to_portugal,omitempty"`
+	ExportPortugalToSpain jsonHourly `json:"export_portugal_to_spain,omitempty"`
+
+	UnknownConcepts map[string]HourlyValues                      `json:"unknown_concepts,omitempty"`
+	CustomConcepts  map[DataTypeInMarginalPriceFile]HourlyValues `json:"custom_concepts,omitempty"`
+
+	FormatVersion FormatVersion  `json:"format_version,omitempty"`
+	Encoding      Encoding       `json:"encoding,omitempty"`
+	Warnings      []ParseWarning `json:"warnings,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d MarginalPriceData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marginalPriceDataJSON{
+		Date:            jsonDate(d.Date),
+		Resolution:      d.Resolution,
+		SpainPrices:     jsonHourly(d.SpainPrices),
+		PortugalPrices:  jsonHourly(d.PortugalPrices),
+		SpainBuyEnergy:  jsonHourly(d.SpainBuyEnergy),
+		SpainSellEnergy: jsonHourly(d.SpainSellEnergy),
+		IberianEnergy:   jsonHourly(d.IberianEnergy),
+		BilateralEnergy: jsonHourly(d.BilateralEnergy),
+
+		SpainAdjustmentPrices:    jsonHourly(d.SpainAdjustmentPrices),
+		PortugalAdjustmentPrices: jsonHourly(d.PortugalAdjustmentPrices),
+
+		ExportSpainToPortugal: jsonHourly(d.ExportSpainToPortugal),
+		ExportPortugalToSpain: jsonHourly(d.ExportPortugalToSpain),
+
+		UnknownConcepts: d.UnknownConcepts,
+		CustomConcepts:  d.CustomConcepts,
+
+		FormatVersion: d.FormatVersion,
+		Encoding:      d.Encoding,
+		Warnings:      d.Warnings,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *MarginalPriceData) UnmarshalJSON(data []byte) error {
+	var w marginalPriceDataJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = MarginalPriceData{
+		Date:            time.Time(w.Date),
+		Resolution:      w.Resolution,
+		SpainPrices:     w.SpainPrices.toMap(),
+		PortugalPrices:  w.PortugalPrices.toMap(),
+		SpainBuyEnergy:  w.SpainBuyEnergy.toMap(),
+		SpainSellEnergy: w.SpainSellEnergy.toMap(),
+		IberianEnergy:   w.IberianEnergy.toMap(),
+		BilateralEnergy: w.BilateralEnergy.toMap(),
+
+		SpainAdjustmentPrices:    w.SpainAdjustmentPrices.toMap(),
+		PortugalAdjustmentPrices: w.PortugalAdjustmentPrices.toMap(),
+
+		ExportSpainToPortugal: w.ExportSpainToPortugal.toMap(),
+		ExportPortugalToSpain: w.ExportPortugalToSpain.toMap(),
+
+		UnknownConcepts: w.UnknownConcepts,
+		CustomConcepts:  w.CustomConcepts,
+
+		FormatVersion: w.FormatVersion,
+		Encoding:      w.Encoding,
+		Warnings:      w.Warnings,
+	}
+	return nil
+}
+
+type parseWarningJSON struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (w ParseWarning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(parseWarningJSON(w))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (w *ParseWarning) UnmarshalJSON(data []byte) error {
+	var v parseWarningJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*w = ParseWarning(v)
+	return nil
+}
+
+type interconnectionDataJSON struct {
+	Date                    jsonDate   `json:"date"`
+	Resolution              Resolution `json:"resolution,omitempty"`
+	CapacitySpainToPortugal jsonHourly `json:"capacity_spain_to_portugal"`
+	CapacityPortugalToSpain jsonHourly `json:"capacity_portugal_to_spain"`
+	CongestionRent          jsonHourly `json:"congestion_rent"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d InterconnectionData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(interconnectionDataJSON{
+		Date:                    jsonDate(d.Date),
+		Resolution:              d.Resolution,
+		CapacitySpainToPortugal: jsonHourly(d.CapacitySpainToPortugal),
+		CapacityPortugalToSpain: jsonHourly(d.CapacityPortugalToSpain),
+		CongestionRent:          jsonHourly(d.CongestionRent),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *InterconnectionData) UnmarshalJSON(data []byte) error {
+	var w interconnectionDataJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = InterconnectionData{
+		Date:                    time.Time(w.Date),
+		Resolution:              w.Resolution,
+		CapacitySpainToPortugal: w.CapacitySpainToPortugal.toMap(),
+		CapacityPortugalToSpain: w.CapacityPortugalToSpain.toMap(),
+		CongestionRent:          w.CongestionRent.toMap(),
+	}
+	return nil
+}
+
+type technologyEnergyJSON struct {
+	Date          jsonDate   `json:"date"`
+	Hour          int        `json:"hour"`
+	System        SystemType `json:"system"`
+	Coal          jsonFloat  `json:"coal"`
+	FuelGas       jsonFloat  `json:"fuel_gas"`
+	SelfProducer  jsonFloat  `json:"self_producer"`
+	Nuclear       jsonFloat  `json:"nuclear"`
+	Hydro         jsonFloat  `json:"hydro"`
+	CombinedCycle jsonFloat  `json:"combined_cycle"`
+	Wind          jsonFloat  `json:"wind"`
+	SolarThermal  jsonFloat  `json:"solar_thermal"`
+	SolarPV       jsonFloat  `json:"solar_pv"`
+	Cogeneration  jsonFloat  `json:"cogeneration"`
+	ImportInt     jsonFloat  `json:"import_int"`
+	ImportNoMIBEL jsonFloat  `json:"import_no_mibel"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (e TechnologyEnergy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(technologyEnergyJSON{
+		Date:          jsonDate(e.Date),
+		Hour:          e.Hour,
+		System:        e.System,
+		Coal:          jsonFloat(e.Coal),
+		FuelGas:       jsonFloat(e.FuelGas),
+		SelfProducer:  jsonFloat(e.SelfProducer),
+		Nuclear:       jsonFloat(e.Nuclear),
+		Hydro:         jsonFloat(e.Hydro),
+		CombinedCycle: jsonFloat(e.CombinedCycle),
+		Wind:          jsonFloat(e.Wind),
+		SolarThermal:  jsonFloat(e.SolarThermal),
+		SolarPV:       jsonFloat(e.SolarPV),
+		Cogeneration:  jsonFloat(e.Cogeneration),
+		ImportInt:     jsonFloat(e.ImportInt),
+		ImportNoMIBEL: jsonFloat(e.ImportNoMIBEL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (e *TechnologyEnergy) UnmarshalJSON(data []byte) error {
+	var w technologyEnergyJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*e = TechnologyEnergy{
+		Date:          time.Time(w.Date),
+		Hour:          w.Hour,
+		System:        w.System,
+		Coal:          float64(w.Coal),
+		FuelGas:       float64(w.FuelGas),
+		SelfProducer:  float64(w.SelfProducer),
+		Nuclear:       float64(w.Nuclear),
+		Hydro:         float64(w.Hydro),
+		CombinedCycle: float64(w.CombinedCycle),
+		Wind:          float64(w.Wind),
+		SolarThermal:  float64(w.SolarThermal),
+		SolarPV:       float64(w.SolarPV),
+		Cogeneration:  float64(w.Cogeneration),
+		ImportInt:     float64(w.ImportInt),
+		ImportNoMIBEL: float64(w.ImportNoMIBEL),
+	}
+	return nil
+}
+
+type marketPointJSON struct {
+	Energy   jsonFloat     `json:"energy"`
+	Price    jsonFloat     `json:"price"`
+	Matched  MatchedStatus `json:"matched"`
+	UnitCode string        `json:"unit_code,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p MarketPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marketPointJSON{
+		Energy:   jsonFloat(p.Energy),
+		Price:    jsonFloat(p.Price),
+		Matched:  p.Matched,
+		UnitCode: p.UnitCode,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *MarketPoint) UnmarshalJSON(data []byte) error {
+	var w marketPointJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*p = MarketPoint{
+		Energy:   float64(w.Energy),
+		Price:    float64(w.Price),
+		Matched:  w.Matched,
+		UnitCode: w.UnitCode,
+	}
+	return nil
+}
+
+type marketCurveJSON struct {
+	Date        jsonDate         `json:"date"`
+	Hour        int              `json:"hour"`
+	Aggregation AggregationLevel `json:"aggregation,omitempty"`
+	Supply      []MarketPoint    `json:"supply"`
+	Demand      []MarketPoint    `json:"demand"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (c MarketCurve) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marketCurveJSON{
+		Date:        jsonDate(c.Date),
+		Hour:        c.Hour,
+		Aggregation: c.Aggregation,
+		Supply:      nonNil(c.Supply),
+		Demand:      nonNil(c.Demand),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *MarketCurve) UnmarshalJSON(data []byte) error {
+	var w marketCurveJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*c = MarketCurve{
+		Date:        time.Time(w.Date),
+		Hour:        w.Hour,
+		Aggregation: w.Aggregation,
+		Supply:      w.Supply,
+		Demand:      w.Demand,
+	}
+	return nil
+}
+
+type intradayPriceJSON struct {
+	Date           jsonDate  `json:"date"`
+	Session        int       `json:"session"`
+	Hour           int       `json:"hour"`
+	SpainPrice     jsonFloat `json:"spain_price"`
+	PortugalPrice  jsonFloat `json:"portugal_price"`
+	SpainEnergy    jsonFloat `json:"spain_energy"`
+	PortugalEnergy jsonFloat `json:"portugal_energy"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p IntradayPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(intradayPriceJSON{
+		Date:           jsonDate(p.Date),
+		Session:        int(p.Session),
+		Hour:           p.Hour,
+		SpainPrice:     jsonFloat(p.SpainPrice),
+		PortugalPrice:  jsonFloat(p.PortugalPrice),
+		SpainEnergy:    jsonFloat(p.SpainEnergy),
+		PortugalEnergy: jsonFloat(p.PortugalEnergy),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *IntradayPrice) UnmarshalJSON(data []byte) error {
+	var w intradayPriceJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*p = IntradayPrice{
+		Date:           time.Time(w.Date),
+		Session:        SessionType(w.Session),
+		Hour:           w.Hour,
+		SpainPrice:     float64(w.SpainPrice),
+		PortugalPrice:  float64(w.PortugalPrice),
+		SpainEnergy:    float64(w.SpainEnergy),
+		PortugalEnergy: float64(w.PortugalEnergy),
+	}
+	return nil
+}
+
+type marginalPriceRecordJSON struct {
+	Date    jsonDate                    `json:"date"`
+	Concept DataTypeInMarginalPriceFile `json:"concept"`
+	Values  jsonHourly                  `json:"values"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (r MarginalPriceRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marginalPriceRecordJSON{
+		Date:    jsonDate(r.Date),
+		Concept: r.Concept,
+		Values:  jsonHourly(r.Values),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *MarginalPriceRecord) UnmarshalJSON(data []byte) error {
+	var w marginalPriceRecordJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*r = MarginalPriceRecord{
+		Date:    time.Time(w.Date),
+		Concept: w.Concept,
+		Values:  w.Values.toMap(),
+	}
+	return nil
+}
+
+type technologyEnergyDayJSON struct {
+	Date     jsonDate           `json:"date"`
+	System   SystemType         `json:"system"`
+	Records  []TechnologyEnergy `json:"records"`
+	Encoding Encoding           `json:"encoding,omitempty"`
+	Warnings []ParseWarning     `json:"warnings,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d TechnologyEnergyDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(technologyEnergyDayJSON{
+		Date:     jsonDate(d.Date),
+		System:   d.System,
+		Records:  nonNil(d.Records),
+		Encoding: d.Encoding,
+		Warnings: d.Warnings,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *TechnologyEnergyDay) UnmarshalJSON(data []byte) error {
+	var w technologyEnergyDayJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = TechnologyEnergyDay{
+		Date:     time.Time(w.Date),
+		System:   w.System,
+		Records:  w.Records,
+		Encoding: w.Encoding,
+		Warnings: w.Warnings,
+	}
+	return nil
+}
+
+type marketCurveDayJSON struct {
+	Date   jsonDate      `json:"date"`
+	Curves []MarketCurve `json:"curves"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d MarketCurveDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(marketCurveDayJSON{
+		Date:   jsonDate(d.Date),
+		Curves: nonNil(d.Curves),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *MarketCurveDay) UnmarshalJSON(data []byte) error {
+	var w marketCurveDayJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = MarketCurveDay{
+		Date:   time.Time(w.Date),
+		Curves: w.Curves,
+	}
+	return nil
+}
+
+type intradaySessionJSON struct {
+	Date    jsonDate        `json:"date"`
+	Session int             `json:"session"`
+	Prices  []IntradayPrice `json:"prices"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s IntradaySession) MarshalJSON() ([]byte, error) {
+	return json.Marshal(intradaySessionJSON{
+		Date:    jsonDate(s.Date),
+		Session: int(s.Session),
+		Prices:  nonNil(s.Prices),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *IntradaySession) UnmarshalJSON(data []byte) error {
+	var w intradaySessionJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*s = IntradaySession{
+		Date:    time.Time(w.Date),
+		Session: SessionType(w.Session),
+		Prices:  w.Prices,
+	}
+	return nil
+}
+
+type continuousIntradayPriceJSON struct {
+	Date          jsonDate  `json:"date"`
+	Period        int       `json:"period"`
+	MinPrice      jsonFloat `json:"min_price"`
+	MaxPrice      jsonFloat `json:"max_price"`
+	WeightedPrice jsonFloat `json:"weighted_price"`
+	Energy        jsonFloat `json:"energy"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p ContinuousIntradayPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(continuousIntradayPriceJSON{
+		Date:          jsonDate(p.Date),
+		Period:        p.Period,
+		MinPrice:      jsonFloat(p.MinPrice),
+		MaxPrice:      jsonFloat(p.MaxPrice),
+		WeightedPrice: jsonFloat(p.WeightedPrice),
+		Energy:        jsonFloat(p.Energy),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *ContinuousIntradayPrice) UnmarshalJSON(data []byte) error {
+	var w continuousIntradayPriceJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*p = ContinuousIntradayPrice{
+		Date:          time.Time(w.Date),
+		Period:        w.Period,
+		MinPrice:      float64(w.MinPrice),
+		MaxPrice:      float64(w.MaxPrice),
+		WeightedPrice: float64(w.WeightedPrice),
+		Energy:        float64(w.Energy),
+	}
+	return nil
+}
+
+type continuousIntradayDayJSON struct {
+	Date       jsonDate                  `json:"date"`
+	Resolution Resolution                `json:"resolution,omitempty"`
+	Prices     []ContinuousIntradayPrice `json:"prices"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d ContinuousIntradayDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(continuousIntradayDayJSON{
+		Date:       jsonDate(d.Date),
+		Resolution: d.Resolution,
+		Prices:     nonNil(d.Prices),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *ContinuousIntradayDay) UnmarshalJSON(data []byte) error {
+	var w continuousIntradayDayJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = ContinuousIntradayDay{
+		Date:       time.Time(w.Date),
+		Resolution: w.Resolution,
+		Prices:     w.Prices,
+	}
+	return nil
+}
+
+type monthlyPriceSummaryJSON struct {
+	Month           jsonDate  `json:"month"`
+	SpainAverage    jsonFloat `json:"spain_average"`
+	PortugalAverage jsonFloat `json:"portugal_average"`
+	SpainMin        jsonFloat `json:"spain_min"`
+	SpainMax        jsonFloat `json:"spain_max"`
+	PortugalMin     jsonFloat `json:"portugal_min"`
+	PortugalMax     jsonFloat `json:"portugal_max"`
+	IberianEnergy   jsonFloat `json:"iberian_energy"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s MonthlyPriceSummary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(monthlyPriceSummaryJSON{
+		Month:           jsonDate(s.Month),
+		SpainAverage:    jsonFloat(s.SpainAverage),
+		PortugalAverage: jsonFloat(s.PortugalAverage),
+		SpainMin:        jsonFloat(s.SpainMin),
+		SpainMax:        jsonFloat(s.SpainMax),
+		PortugalMin:     jsonFloat(s.PortugalMin),
+		PortugalMax:     jsonFloat(s.PortugalMax),
+		IberianEnergy:   jsonFloat(s.IberianEnergy),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *MonthlyPriceSummary) UnmarshalJSON(data []byte) error {
+	var w monthlyPriceSummaryJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*s = MonthlyPriceSummary{
+		Month:           time.Time(w.Month),
+		SpainAverage:    float64(w.SpainAverage),
+		PortugalAverage: float64(w.PortugalAverage),
+		SpainMin:        float64(w.SpainMin),
+		SpainMax:        float64(w.SpainMax),
+		PortugalMin:     float64(w.PortugalMin),
+		PortugalMax:     float64(w.PortugalMax),
+		IberianEnergy:   float64(w.IberianEnergy),
+	}
+	return nil
+}
+
+type futuresSettlementJSON struct {
+	TradingDate   jsonDate       `json:"trading_date"`
+	Contract      string         `json:"contract"`
+	Load          LoadProfile    `json:"load"`
+	Period        DeliveryPeriod `json:"period"`
+	DeliveryStart jsonDate       `json:"delivery_start"`
+	Price         jsonFloat      `json:"price"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s FuturesSettlement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(futuresSettlementJSON{
+		TradingDate:   jsonDate(s.TradingDate),
+		Contract:      s.Contract,
+		Load:          s.Load,
+		Period:        s.Period,
+		DeliveryStart: jsonDate(s.DeliveryStart),
+		Price:         jsonFloat(s.Price),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *FuturesSettlement) UnmarshalJSON(data []byte) error {
+	var w futuresSettlementJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*s = FuturesSettlement{
+		TradingDate:   time.Time(w.TradingDate),
+		Contract:      w.Contract,
+		Load:          w.Load,
+		Period:        w.Period,
+		DeliveryStart: time.Time(w.DeliveryStart),
+		Price:         float64(w.Price),
+	}
+	return nil
+}
+
+type futuresSettlementDayJSON struct {
+	Date        jsonDate            `json:"date"`
+	Settlements []FuturesSettlement `json:"settlements"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (d FuturesSettlementDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(futuresSettlementDayJSON{
+		Date:        jsonDate(d.Date),
+		Settlements: nonNil(d.Settlements),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *FuturesSettlementDay) UnmarshalJSON(data []byte) error {
+	var w futuresSettlementDayJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*d = FuturesSettlementDay{
+		Date:        time.Time(w.Date),
+		Settlements: w.Settlements,
+	}
+	return nil
+}
+
+type dateRangeJSON struct {
+	Start jsonDate `json:"start"`
+	End   jsonDate `json:"end"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (r DateRange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dateRangeJSON{Start: jsonDate(r.Start), End: jsonDate(r.End)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *DateRange) UnmarshalJSON(data []byte) error {
+	var w dateRangeJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*r = DateRange{Start: time.Time(w.Start), End: time.Time(w.End)}
+	return nil
+}
+
+type changeJSON struct {
+	Path string          `json:"path"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Old and New are left out when nil, and encoded
+// as null when they are missing (NaN) values.
+func (c Change) MarshalJSON() ([]byte, error) {
+	w := changeJSON{Path: c.Path}
+	for _, v := range []struct {
+		value interface{}
+		raw   *json.RawMessage
+	}{{c.Old, &w.Old}, {c.New, &w.New}} {
+		if v.value == nil {
+			continue
+		}
+		if f, ok := v.value.(float64); ok {
+			v.value = jsonFloat(f)
+		}
+		raw, err := json.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		*v.raw = raw
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Values are decoded as encoding/json does into
+// an interface{}, so numbers come back as float64 and null as NaN.
+func (c *Change) UnmarshalJSON(data []byte) error {
+	var w changeJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	*c = Change{Path: w.Path}
+	for _, v := range []struct {
+		raw   json.RawMessage
+		value *interface{}
+	}{{w.Old, &c.Old}, {w.New, &c.New}} {
+		switch {
+		case v.raw == nil:
+		case bytes.Equal(v.raw, []byte("null")):
+			*v.value = math.NaN()
+		default:
+			if err := json.Unmarshal(v.raw, v.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nonNil returns an empty slice instead of nil so lists always encode as []
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip encodes v into an envelope, decodes it back and reports any difference
+func roundTrip[T any](t *testing.T, v T) {
+	t.Helper()
+
+	payload, err := Encode(v)
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	decoded, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	got, ok := decoded.(*T)
+	if !ok {
+		t.Fatalf("Decode() returned %T, want *%T", decoded, v)
+	}
+	if changes := Diff(v, *got, 0); len(changes) > 0 {
+		t.Errorf("round trip changed %d values, first: %s\n%s", len(changes), changes[0], payload)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	date := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	nan := math.NaN()
+
+	prices := NewMarginalPriceData(date)
+	prices.SpainPrices[1] = 45.5
+	prices.SpainPrices[10] = nan
+	prices.PortugalPrices[2] = -1.25
+	prices.FormatVersion = FormatEuro
+	prices.Encoding = EncodingUTF8
+	prices.UnknownConcepts = map[string]HourlyValues{"Cuantía unitaria del ajuste (EUR/MWh)": {1: 0, 2: 12.5}}
+	prices.CustomConcepts = map[DataTypeInMarginalPriceFile]HourlyValues{"SYSTEM_PRICE": {1: 41.2}}
+	prices.Warnings = []ParseWarning{{Line: 4, Text: "Precio;x", Reason: "invalid value"}}
+
+	interconnection := NewInterconnectionData(date)
+	interconnection.Resolution = QuarterHourly
+	interconnection.CongestionRent[96] = 1200
+
+	energy := TechnologyEnergy{Date: date, Hour: 3, System: Spain, Wind: 1500, Coal: nan, ImportNoMIBEL: -20}
+	point := MarketPoint{Energy: 100, Price: -0.5, Matched: Matched, UnitCode: "UNIT1"}
+	curve := MarketCurve{Date: date, Hour: 3, Aggregation: Aggregated, Supply: []MarketPoint{point}, Demand: []MarketPoint{}}
+	intraday := IntradayPrice{Date: date, Session: Session2, Hour: 5, SpainPrice: 50, PortugalPrice: nan}
+	continuous := ContinuousIntradayPrice{Date: date, Period: 7, MinPrice: 10, MaxPrice: 90, WeightedPrice: 55, Energy: nan}
+	settlement := FuturesSettlement{TradingDate: date, Contract: "FTB M Apr-24", Load: BaseLoad, Period: MonthDelivery, DeliveryStart: date.AddDate(0, 0, 1), Price: 38}
+
+	t.Run("MarginalPriceData", func(t *testing.T) { roundTrip(t, *prices) })
+	t.Run("MarginalPriceRecord", func(t *testing.T) {
+		roundTrip(t, MarginalPriceRecord{Date: date, Concept: PriceSpain, Values: HourlyValues{1: 3, 2: nan}})
+	})
+	t.Run("InterconnectionData", func(t *testing.T) { roundTrip(t, *interconnection) })
+	t.Run("TechnologyEnergy", func(t *testing.T) { roundTrip(t, energy) })
+	t.Run("TechnologyEnergyDay", func(t *testing.T) {
+		roundTrip(t, TechnologyEnergyDay{Date: date, System: Spain, Records: []TechnologyEnergy{energy}})
+	})
+	t.Run("MarketPoint", func(t *testing.T) { roundTrip(t, point) })
+	t.Run("MarketCurve", func(t *testing.T) { roundTrip(t, curve) })
+	t.Run("MarketCurveDay", func(t *testing.T) { roundTrip(t, MarketCurveDay{Date: date, Curves: []MarketCurve{curve}}) })
+	t.Run("IntradayPrice", func(t *testing.T) { roundTrip(t, intraday) })
+	t.Run("IntradaySession", func(t *testing.T) {
+		roundTrip(t, IntradaySession{Date: date, Session: Session2, Prices: []IntradayPrice{intraday}})
+	})
+	t.Run("ContinuousIntradayPrice", func(t *testing.T) { roundTrip(t, continuous) })
+	t.Run("ContinuousIntradayDay", func(t *testing.T) {
+		roundTrip(t, ContinuousIntradayDay{Date: date, Resolution: Hourly, Prices: []ContinuousIntradayPrice{continuous}})
+	})
+	t.Run("MonthlyPriceSummary", func(t *testing.T) {
+		roundTrip(t, MonthlyPriceSummary{Month: date.AddDate(0, 0, -30), SpainAverage: 20, PortugalAverage: nan, IberianEnergy: 1e7})
+	})
+	t.Run("FuturesSettlement", func(t *testing.T) { roundTrip(t, settlement) })
+	t.Run("FuturesSettlementDay", func(t *testing.T) {
+		roundTrip(t, FuturesSettlementDay{Date: date, Settlements: []FuturesSettlement{settlement}})
+	})
+	t.Run("HourlyValues", func(t *testing.T) { roundTrip(t, HourlyValues{1: 1, 2: nan, 25: 3}) })
+	t.Run("DateRange", func(t *testing.T) { roundTrip(t, DateRange{Start: date, End: date.AddDate(0, 1, 0)}) })
+}
+
+func TestHourlyValuesJSON(t *testing.T) {
+	payload, err := json.Marshal(HourlyValues{10: 2, 2: math.NaN(), 1: 0.5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"1":0.5,"2":null,"10":2}`; string(payload) != want {
+		t.Errorf("got %s, want %s", payload, want)
+	}
+
+	var values HourlyValues
+	if err := json.Unmarshal([]byte(`{"x":1}`), &values); err == nil {
+		t.Error("expected an error for a non-numeric hour")
+	}
+}
+
+func TestChangeJSON(t *testing.T) {
+	changes := []Change{
+		{Path: "SpainPrices[3]", Old: 40.0, New: math.NaN()},
+		{Path: "Records[2]", New: "added"},
+	}
+
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `[{"path":"SpainPrices[3]","old":40,"new":null},{"path":"Records[2]","new":"added"}]`
+	if string(payload) != want {
+		t.Errorf("got %s, want %s", payload, want)
+	}
+
+	var decoded []Change
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded[0].Old != 40.0 || !math.IsNaN(decoded[0].New.(float64)) || decoded[1].Old != nil {
+		t.Errorf("unexpected changes %v", decoded)
+	}
+}
+
+func TestMarginalPriceDataJSONSchema(t *testing.T) {
+	data := NewMarginalPriceData(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	data.SpainPrices[1] = 45.5
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"date":"2024-01-15"`, `"spain_prices":{"1":45.5}`, `"portugal_prices":{}`} {
+		if !strings.Contains(string(payload), want) {
+			t.Errorf("expected %s in %s", want, payload)
+		}
+	}
+}
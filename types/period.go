@@ -0,0 +1,184 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// MaxHourIndex is the highest hour index, reached on the 25-hour day when DST ends
+	MaxHourIndex = 25
+
+	// MaxPeriodIndex is the highest quarter-hour index, reached on the 100-period day when DST ends
+	MaxPeriodIndex = 4 * MaxHourIndex
+
+	// QuartersPerHour is the number of 15-minute periods in an hour
+	QuartersPerHour = 4
+)
+
+// marketLocation is the time zone OMIE market days and hours are defined in
+var marketLocation = loadLocation("Europe/Madrid", time.FixedZone("CET", 60*60))
+
+// portugalLocation is the local time zone of the Portuguese market, an hour behind
+var portugalLocation = loadLocation("Europe/Lisbon", time.FixedZone("WET", 0))
+
+// loadLocation loads a time zone, falling back to a zone without DST when the system
+// has no tz database
+func loadLocation(name string, fallback *time.Location) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// MarketLocation returns the time zone OMIE market days and hours are defined in,
+// Europe/Madrid, or CET when the system has no tz database
+func MarketLocation() *time.Location {
+	return marketLocation
+}
+
+// marketMidnight returns the instant the market day of date starts. Only the calendar
+// date of date is used.
+func marketMidnight(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, marketLocation)
+}
+
+// HoursInDay returns the number of hours of a market day: 23 when DST starts, 25 when
+// it ends and 24 otherwise. Only the calendar date of date is used.
+func HoursInDay(date time.Time) int {
+	start := marketMidnight(date)
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, marketLocation)
+	return int(end.Sub(start) / time.Hour)
+}
+
+// HourIndex is a 1-based hour of the day as used in OMIE files (1-23, 1-24 or 1-25
+// depending on DST transitions)
+type HourIndex int
+
+// NewHourIndex validates and returns an hour index in the range 1-25
+func NewHourIndex(hour int) (HourIndex, error) {
+	h := HourIndex(hour)
+	if !h.Valid() {
+		return 0, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("hour %d out of range (1-%d)", hour, MaxHourIndex), nil)
+	}
+	return h, nil
+}
+
+// Valid reports whether the index is within 1-25
+func (h HourIndex) Valid() bool {
+	return h >= 1 && h <= MaxHourIndex
+}
+
+// Int returns the index as a plain int
+func (h HourIndex) Int() int {
+	return int(h)
+}
+
+// Start returns the instant this hour starts on the market day of date, in Europe/Madrid.
+// Hours count from midnight, so when DST ends hours 3 and 4 both start at 02:00 local
+// time, first in CEST and then in CET.
+func (h HourIndex) Start(date time.Time) time.Time {
+	return marketMidnight(date).Add(time.Duration(h-1) * time.Hour)
+}
+
+// FirstPeriod returns the first quarter-hour period within this hour
+func (h HourIndex) FirstPeriod() PeriodIndex {
+	return PeriodIndex((int(h)-1)*QuartersPerHour + 1)
+}
+
+// Periods returns the four quarter-hour periods within this hour
+func (h HourIndex) Periods() [QuartersPerHour]PeriodIndex {
+	first := h.FirstPeriod()
+	return [QuartersPerHour]PeriodIndex{first, first + 1, first + 2, first + 3}
+}
+
+// PeriodIndex is a 1-based quarter-hour period of the day (1-92, 1-96 or 1-100
+// depending on DST transitions), as used since the 15-minute MTU change
+type PeriodIndex int
+
+// NewPeriodIndex validates and returns a quarter-hour period index in the range 1-100
+func NewPeriodIndex(period int) (PeriodIndex, error) {
+	p := PeriodIndex(period)
+	if !p.Valid() {
+		return 0, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("period %d out of range (1-%d)", period, MaxPeriodIndex), nil)
+	}
+	return p, nil
+}
+
+// Valid reports whether the index is within 1-100
+func (p PeriodIndex) Valid() bool {
+	return p >= 1 && p <= MaxPeriodIndex
+}
+
+// Int returns the index as a plain int
+func (p PeriodIndex) Int() int {
+	return int(p)
+}
+
+// Start returns the instant this period starts on the market day of date, in
+// Europe/Madrid, see HourIndex.Start
+func (p PeriodIndex) Start(date time.Time) time.Time {
+	return marketMidnight(date).Add(time.Duration(p-1) * 15 * time.Minute)
+}
+
+// Hour returns the hour index this period belongs to
+func (p PeriodIndex) Hour() HourIndex {
+	return HourIndex((int(p)-1)/QuartersPerHour + 1)
+}
+
+// Quarter returns the position of the period within its hour (1-4)
+func (p PeriodIndex) Quarter() int {
+	return (int(p)-1)%QuartersPerHour + 1
+}
+
+// Resolution is the length of the periods a file's values refer to
+type Resolution int
+
+const (
+	Hourly        Resolution = iota // One value per hour (1-25), used before the 15-minute MTU change
+	QuarterHourly                   // One value per quarter-hour period (1-100)
+)
+
+// String returns the string representation of Resolution
+func (r Resolution) String() string {
+	switch r {
+	case Hourly:
+		return "HOURLY"
+	case QuarterHourly:
+		return "QUARTER_HOURLY"
+	default:
+		return fmt.Sprintf("Resolution(%d)", int(r))
+	}
+}
+
+// Duration returns the length of a single period
+func (r Resolution) Duration() time.Duration {
+	if r == QuarterHourly {
+		return 15 * time.Minute
+	}
+	return time.Hour
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r Resolution) MarshalText() ([]byte, error) {
+	switch r {
+	case Hourly, QuarterHourly:
+		return []byte(r.String()), nil
+	default:
+		return nil, NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("unknown resolution %d", int(r)), nil)
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (r *Resolution) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "", "HOURLY":
+		*r = Hourly
+	case "QUARTER_HOURLY":
+		*r = QuarterHourly
+	default:
+		return NewOMIEError(ErrCodeInvalidData, fmt.Sprintf("unknown resolution %q", text), nil)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHourIndex(t *testing.T) {
+	for _, hour := range []int{1, 24, 25} {
+		if h, err := NewHourIndex(hour); err != nil || h.Int() != hour {
+			t.Errorf("NewHourIndex(%d) = %d, %v", hour, h, err)
+		}
+	}
+	for _, hour := range []int{-1, 0, 26} {
+		if _, err := NewHourIndex(hour); err == nil {
+			t.Errorf("NewHourIndex(%d) succeeded, want an error", hour)
+		}
+	}
+
+	if periods := HourIndex(2).Periods(); periods != [QuartersPerHour]PeriodIndex{5, 6, 7, 8} {
+		t.Errorf("Periods() of hour 2 = %v", periods)
+	}
+	if first := HourIndex(25).FirstPeriod(); first != 97 {
+		t.Errorf("FirstPeriod() of hour 25 = %d, want 97", first)
+	}
+}
+
+func TestNewPeriodIndex(t *testing.T) {
+	for _, period := range []int{1, 96, MaxPeriodIndex} {
+		if p, err := NewPeriodIndex(period); err != nil || p.Int() != period {
+			t.Errorf("NewPeriodIndex(%d) = %d, %v", period, p, err)
+		}
+	}
+	for _, period := range []int{0, MaxPeriodIndex + 1} {
+		if _, err := NewPeriodIndex(period); err == nil {
+			t.Errorf("NewPeriodIndex(%d) succeeded, want an error", period)
+		}
+	}
+
+	tests := []struct {
+		period  PeriodIndex
+		hour    HourIndex
+		quarter int
+	}{
+		{1, 1, 1},
+		{4, 1, 4},
+		{5, 2, 1},
+		{96, 24, 4},
+		{100, 25, 4},
+	}
+	for _, tc := range tests {
+		if hour, quarter := tc.period.Hour(), tc.period.Quarter(); hour != tc.hour || quarter != tc.quarter {
+			t.Errorf("period %d is quarter %d of hour %d, want quarter %d of hour %d", tc.period, quarter, hour, tc.quarter, tc.hour)
+		}
+		if tc.hour.Periods()[tc.quarter-1] != tc.period {
+			t.Errorf("hour %d quarter %d isn't period %d", tc.hour, tc.quarter, tc.period)
+		}
+	}
+}
+
+func TestHoursInDay(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want int
+	}{
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 24},
+		{time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 23},  // DST starts
+		{time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC), 25}, // DST ends
+	}
+
+	for _, tt := range tests {
+		if got := HoursInDay(tt.date); got != tt.want {
+			t.Errorf("HoursInDay(%s) = %d, want %d", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestHourStart(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		hour HourIndex
+		want string // UTC
+	}{
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 1, "2024-01-14T23:00:00Z"},
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 15, "2024-01-15T13:00:00Z"},
+		{time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 3, "2024-03-31T01:00:00Z"},   // 03:00 CEST, 02:00 is skipped
+		{time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 3, "2022-10-30T00:00:00Z"},  // 02:00 CEST
+		{time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 4, "2022-10-30T01:00:00Z"},  // 02:00 CET
+		{time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC), 25, "2022-10-30T22:00:00Z"}, // 23:00 CET
+	}
+
+	for _, tt := range tests {
+		if got := tt.hour.Start(tt.date).UTC().Format(time.RFC3339); got != tt.want {
+			t.Errorf("hour %d of %s starts at %s, want %s", tt.hour, tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestTimeForHour(t *testing.T) {
+	data := NewMarginalPriceData(time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC))
+
+	spain := data.TimeForHour(15)
+	if got := spain.Format("15:04 MST"); got != "13:00 CET" {
+		t.Errorf("expected hour 15 to start at 13:00 CET on the day DST ends, got %s", got)
+	}
+	portugal := data.TimeForHourIn(15, Portugal)
+	if !portugal.Equal(spain) || portugal.Format("15:04") != "12:00" {
+		t.Errorf("expected the same instant at 12:00 in Lisbon, got %s", portugal)
+	}
+
+	data.Resolution = QuarterHourly
+	if got := data.TimeForHour(5).Format("15:04 MST"); got != "01:00 CEST" {
+		t.Errorf("expected period 5 to start at 01:00 CEST, got %s", got)
+	}
+
+	record := TechnologyEnergy{Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Hour: 1, System: Portugal}
+	if got := record.Time().Format("2006-01-02 15:04"); got != "2024-01-14 23:00" {
+		t.Errorf("expected hour 1 to start at 23:00 the day before in Lisbon, got %s", got)
+	}
+}
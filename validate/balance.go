@@ -0,0 +1,89 @@
+// Package validate provides consistency checks for parsed OMIE data
+package validate
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Violation describes a value that failed a validation check
+type Violation struct {
+	Rule    string
+	Date    time.Time
+	Hour    int // Hour, or quarter-hour period, of the value; 0 for checks of a whole day
+	Message string
+}
+
+// String returns a human-readable description of the violation
+func (v Violation) String() string {
+	if v.Hour == 0 {
+		return fmt.Sprintf("%s %s: %s", v.Rule, v.Date.Format("2006-01-02"), v.Message)
+	}
+	return fmt.Sprintf("%s %s hour %d: %s", v.Rule, v.Date.Format("2006-01-02"), v.Hour, v.Message)
+}
+
+// RuleEnergyBalance identifies violations reported by EnergyBalance
+const RuleEnergyBalance = "energy_balance"
+
+// EnergyBalance cross-checks, hour by hour, the generation summed over all technologies
+// against the matched energy of the marginal price file for the same day. The Iberian
+// system is compared with the total Iberian energy and Spain with the Spanish sell
+// energy; the price file carries no Portuguese totals. Hours whose relative deviation
+// exceeds tolerance (e.g. 0.02 for 2%) are returned as violations, and hours missing
+// from either file are skipped.
+func EnergyBalance(prices *types.MarginalPriceData, technology *types.TechnologyEnergyDay, tolerance float64) ([]Violation, error) {
+	if prices == nil || technology == nil {
+		return nil, types.NewOMIEError(types.ErrCodeInvalidData, "energy balance needs both price and technology data", nil)
+	}
+
+	if !sameDay(prices.Date, technology.Date) {
+		return nil, types.NewOMIEError(types.ErrCodeInvalidDate, fmt.Sprintf("price data for %s doesn't match technology data for %s",
+			prices.Date.Format("2006-01-02"), technology.Date.Format("2006-01-02")), nil)
+	}
+
+	var matched types.HourlyValues
+	switch technology.System {
+	case types.Iberian:
+		matched = prices.IberianEnergy
+	case types.Spain:
+		matched = prices.SpainSellEnergy
+	default:
+		return nil, types.NewOMIEError(types.ErrCodeInvalidData, fmt.Sprintf("no matched energy available for system %s", technology.System), nil)
+	}
+
+	var violations []Violation
+	for _, record := range technology.Records {
+		expected, ok := matched[record.Hour]
+		generated := record.Total()
+		if !ok || math.IsNaN(expected) || math.IsNaN(generated) {
+			continue
+		}
+
+		deviation := math.Abs(generated - expected)
+		if expected != 0 {
+			deviation /= math.Abs(expected)
+		}
+
+		if deviation > tolerance {
+			violations = append(violations, Violation{
+				Rule: RuleEnergyBalance,
+				Date: technology.Date,
+				Hour: record.Hour,
+				Message: fmt.Sprintf("technology total %.1f MWh deviates %.1f%% from matched energy %.1f MWh",
+					generated, deviation*100, expected),
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// sameDay reports whether two times fall on the same calendar date
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+func TestEnergyBalance(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	prices := types.NewMarginalPriceData(date)
+	prices.IberianEnergy[1] = 1000
+	prices.IberianEnergy[2] = 1000
+	prices.IberianEnergy[3] = 1000
+
+	record := func(hour int, wind float64) types.TechnologyEnergy {
+		return types.TechnologyEnergy{Date: date, Hour: hour, System: types.Iberian, Nuclear: 500, Wind: wind, Coal: math.NaN()}
+	}
+	technology := &types.TechnologyEnergyDay{
+		Date:   date,
+		System: types.Iberian,
+		Records: []types.TechnologyEnergy{
+			record(1, 505), // 0.5% off
+			record(2, 600), // 10% off
+			record(3, 500),
+			record(4, 500), // Not in the price file
+		},
+	}
+
+	violations, err := EnergyBalance(prices, technology, 0.02)
+	if err != nil {
+		t.Fatalf("EnergyBalance failed: %v", err)
+	}
+
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %v", len(violations), violations)
+	}
+	if violations[0].Hour != 2 || violations[0].Rule != RuleEnergyBalance {
+		t.Errorf("Unexpected violation: %v", violations[0])
+	}
+}
+
+func TestEnergyBalanceErrors(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	prices := types.NewMarginalPriceData(date)
+
+	if _, err := EnergyBalance(prices, &types.TechnologyEnergyDay{Date: date.AddDate(0, 0, 1), System: types.Iberian}, 0.02); err == nil {
+		t.Error("Expected error for mismatched dates")
+	}
+
+	if _, err := EnergyBalance(prices, &types.TechnologyEnergyDay{Date: date, System: types.Portugal}, 0.02); err == nil {
+		t.Error("Expected error for system without matched energy")
+	}
+}
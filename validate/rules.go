@@ -0,0 +1,239 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devuo/omiedata/types"
+)
+
+// Rule names of the violations reported by the rules of this package
+const (
+	RuleHourCount         = "hour_count"
+	RulePriceBounds       = "price_bounds"
+	RuleNonNegativeEnergy = "non_negative_energy"
+	RuleDuplicateHours    = "duplicate_hours"
+)
+
+// Rule is a check of a parsed day, e.g. a *types.MarginalPriceData. Check returns the
+// violations found, and nothing for data of types the rule doesn't apply to.
+type Rule struct {
+	Name  string
+	Check func(data interface{}) []Violation
+}
+
+// RuleSet is a set of rules checked together, e.g. by an importer after parsing every
+// file, see importers.ImportOptions.Validation
+type RuleSet []Rule
+
+// DefaultRules returns the rules every day published by OMIE is expected to pass: hour
+// counts matching the calendar day, prices within the limits of the day-ahead market on
+// their date, non-negative energies and no duplicate hours
+func DefaultRules() RuleSet {
+	return RuleSet{HourCount(), MarketPriceBounds(), NonNegativeEnergy(), UniqueHours()}
+}
+
+// Check runs every rule on data, returning their violations in rule order
+func (s RuleSet) Check(data interface{}) []Violation {
+	var violations []Violation
+	for _, rule := range s {
+		violations = append(violations, rule.Check(data)...)
+	}
+	return violations
+}
+
+// Validate runs every rule on data, returning an *Error holding the violations when
+// there are any
+func (s RuleSet) Validate(data interface{}) error {
+	if violations := s.Check(data); len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// Error is the error of data that failed validation
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return fmt.Sprintf("%d validation violations: %s", len(e.Violations), strings.Join(messages, "; "))
+}
+
+// HourCount checks that days have as many hours as their calendar day: 23 when DST
+// starts, 25 when it ends and 24 otherwise, or four times as many quarter-hour periods.
+// It applies to marginal prices and technology mixes.
+func HourCount() Rule {
+	return Rule{Name: RuleHourCount, Check: func(data interface{}) []Violation {
+		switch d := data.(type) {
+		case *types.MarginalPriceData:
+			expected := types.HoursInDay(d.Date)
+			unit := "hours"
+			if d.Resolution == types.QuarterHourly {
+				expected *= types.QuartersPerHour
+				unit = "periods"
+			}
+			return countViolations(d.Date, d.HoursSorted(), expected, unit)
+		case *types.TechnologyEnergyDay:
+			return countViolations(d.Date, d.HoursSorted(), types.HoursInDay(d.Date), "hours")
+		}
+		return nil
+	}}
+}
+
+// PriceBounds checks that the Spanish and Portuguese marginal prices are within min
+// and max, in EUR/MWh
+func PriceBounds(min, max float64) Rule {
+	return Rule{Name: RulePriceBounds, Check: func(data interface{}) []Violation {
+		d, ok := data.(*types.MarginalPriceData)
+		if !ok {
+			return nil
+		}
+		return priceViolations(d, PriceLimits{Min: min, Max: max}, "")
+	}}
+}
+
+// MarketPriceBounds checks that the Spanish and Portuguese marginal prices are within
+// the limits of the day-ahead market on their date, see PriceLimitsOn. Prices outside
+// them can't have cleared, so they are most likely values misread by the parser.
+func MarketPriceBounds() Rule {
+	return Rule{Name: RulePriceBounds, Check: func(data interface{}) []Violation {
+		d, ok := data.(*types.MarginalPriceData)
+		if !ok {
+			return nil
+		}
+		return priceViolations(d, PriceLimitsOn(d.Date), ", the market limits of the day, likely misread")
+	}}
+}
+
+// PriceLimits are the minimum and maximum clearing prices of the day-ahead market from
+// a date on, in EUR/MWh
+type PriceLimits struct {
+	From     time.Time
+	Min, Max float64
+}
+
+// marketPriceLimits lists the limits of the Iberian day-ahead market in date order
+var marketPriceLimits = []PriceLimits{
+	{From: time.Time{}, Min: 0, Max: 180.3},                                    // OMIE's own limits
+	{From: time.Date(2014, 5, 13, 0, 0, 0, 0, time.UTC), Min: -500, Max: 3000}, // Coupling with the rest of Europe
+	{From: time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC), Min: -500, Max: 4000}, // First raise of the harmonised maximum
+}
+
+// PriceLimitsOn returns the limits of the day-ahead market on date: 0 to 180.30 EUR/MWh
+// until the Iberian market coupled with the rest of Europe in May 2014, -500 to 3000
+// EUR/MWh since, and -500 to 4000 EUR/MWh since the harmonised maximum was first raised
+// in May 2022
+func PriceLimitsOn(date time.Time) PriceLimits {
+	limits := marketPriceLimits[0]
+	for _, era := range marketPriceLimits[1:] {
+		if date.Before(era.From) {
+			break
+		}
+		limits = era
+	}
+	return limits
+}
+
+// priceViolations reports the Spanish and Portuguese prices of d outside limits, with
+// note appended to the messages
+func priceViolations(d *types.MarginalPriceData, limits PriceLimits, note string) []Violation {
+	var violations []Violation
+	for _, series := range []struct {
+		name   string
+		prices types.HourlyValues
+	}{{"Spain", d.SpainPrices}, {"Portugal", d.PortugalPrices}} {
+		series.prices.ForEachHour(func(hour int, price float64) {
+			if price < limits.Min || price > limits.Max {
+				violations = append(violations, Violation{Rule: RulePriceBounds, Date: d.Date, Hour: hour,
+					Message: fmt.Sprintf("%s price %.2f EUR/MWh outside %.2f to %.2f%s", series.name, price, limits.Min, limits.Max, note)})
+			}
+		})
+	}
+	return violations
+}
+
+// NonNegativeEnergy checks that the matched energies of marginal prices and the
+// generation of technology mixes aren't negative. Imports are left out, as exports
+// may be reported as negative imports.
+func NonNegativeEnergy() Rule {
+	return Rule{Name: RuleNonNegativeEnergy, Check: func(data interface{}) []Violation {
+		var violations []Violation
+		negative := func(date time.Time, hour int, name string, value float64) {
+			violations = append(violations, Violation{Rule: RuleNonNegativeEnergy, Date: date, Hour: hour,
+				Message: fmt.Sprintf("%s energy %.1f MWh is negative", name, value)})
+		}
+
+		switch d := data.(type) {
+		case *types.MarginalPriceData:
+			for _, series := range d.Series() {
+				if !strings.HasSuffix(series.Name, "_energy") {
+					continue
+				}
+				series.Values.ForEachHour(func(hour int, value float64) {
+					if value < 0 {
+						negative(d.Date, hour, series.Name, value)
+					}
+				})
+			}
+		case *types.TechnologyEnergyDay:
+			for _, record := range d.Records {
+				for _, tech := range types.Technologies() {
+					value, ok := record.Value(tech)
+					if ok && value < 0 && tech != types.Import && tech != types.ImportWithoutMIBEL {
+						negative(d.Date, record.Hour, string(tech), value)
+					}
+				}
+			}
+		}
+		return violations
+	}}
+}
+
+// UniqueHours checks that no hour appears twice in a technology mix or a day of curves
+func UniqueHours() Rule {
+	return Rule{Name: RuleDuplicateHours, Check: func(data interface{}) []Violation {
+		var date time.Time
+		var hours []int
+		switch d := data.(type) {
+		case *types.TechnologyEnergyDay:
+			date = d.Date
+			for _, record := range d.Records {
+				hours = append(hours, record.Hour)
+			}
+		case *types.MarketCurveDay:
+			date = d.Date
+			for _, curve := range d.Curves {
+				hours = append(hours, curve.Hour)
+			}
+		}
+
+		var violations []Violation
+		seen := make(map[int]int)
+		for _, hour := range hours {
+			if seen[hour]++; seen[hour] == 2 {
+				violations = append(violations, Violation{Rule: RuleDuplicateHours, Date: date, Hour: hour,
+					Message: "hour appears more than once"})
+			}
+		}
+		return violations
+	}}
+}
+
+// countViolations reports a day whose sorted hours aren't 1 to expected
+func countViolations(date time.Time, hours []int, expected int, unit string) []Violation {
+	complete := len(hours) == expected
+	for i := 0; complete && i < len(hours); i++ {
+		complete = hours[i] == i+1
+	}
+	if complete {
+		return nil
+	}
+	return []Violation{{Rule: RuleHourCount, Date: date,
+		Message: fmt.Sprintf("%d %s found, %s 1 to %d expected on this day", len(hours), unit, unit, expected)}}
+}
@@ -0,0 +1,105 @@
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devuo/omiedata/parsers"
+	"github.com/devuo/omiedata/types"
+)
+
+func TestDefaultRulesOnFixtures(t *testing.T) {
+	fixtures := []struct {
+		file   string
+		parser parsers.Parser
+	}{
+		{"../testdata/PMD_20060101.txt", parsers.NewMarginalPriceParser()},
+		{"../testdata/PMD_20090601.txt", parsers.NewMarginalPriceParser()},
+		{"../testdata/PMD_20221030.txt", parsers.NewMarginalPriceParser()},
+		{"../testdata/EnergyByTechnology_9_20201113.TXT", parsers.NewEnergyByTechnologyParser()},
+	}
+	for _, fixture := range fixtures {
+		data, err := fixture.parser.ParseFile(fixture.file)
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.file, err)
+		}
+		if violations := DefaultRules().Check(data); len(violations) > 0 {
+			t.Errorf("%s: unexpected violations %v", fixture.file, violations)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	date := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC) // DST starts, 23 hours
+
+	prices := types.NewMarginalPriceData(date)
+	for hour := 1; hour <= 24; hour++ {
+		prices.SpainPrices[hour] = 50
+	}
+	prices.PortugalPrices[7] = 5000
+	prices.SpainSellEnergy[3] = -10
+
+	technology := &types.TechnologyEnergyDay{Date: date, System: types.Spain}
+	for hour := 1; hour <= 23; hour++ {
+		technology.Records = append(technology.Records, types.TechnologyEnergy{Date: date, Hour: hour, Wind: 100, ImportInt: -50})
+	}
+	technology.Records[4].Hour = 4 // Hour 5 is missing and hour 4 is there twice
+
+	tests := []struct {
+		name string
+		data interface{}
+		want []Violation
+	}{
+		{"prices", prices, []Violation{
+			{Rule: RuleHourCount, Date: date},
+			{Rule: RulePriceBounds, Date: date, Hour: 7},
+			{Rule: RuleNonNegativeEnergy, Date: date, Hour: 3},
+		}},
+		{"technology", technology, []Violation{
+			{Rule: RuleHourCount, Date: date},
+			{Rule: RuleDuplicateHours, Date: date, Hour: 4},
+		}},
+		{"other data", &types.FuturesSettlementDay{Date: date}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := DefaultRules().Check(tt.data)
+			if len(violations) != len(tt.want) {
+				t.Fatalf("Expected %d violations, got %v", len(tt.want), violations)
+			}
+			for i, want := range tt.want {
+				got := violations[i]
+				if got.Rule != want.Rule || !got.Date.Equal(want.Date) || got.Hour != want.Hour || got.Message == "" {
+					t.Errorf("Violation %d = %v, want rule %s hour %d", i, got, want.Rule, want.Hour)
+				}
+			}
+		})
+	}
+}
+
+func TestMarketPriceBounds(t *testing.T) {
+	tests := []struct {
+		date  time.Time
+		price float64
+		valid bool
+	}{
+		{time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC), 180.3, true},
+		{time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC), 200, false},
+		{time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC), -1, false},
+		{time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), -1, true},
+		{time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), 3500, false},
+		{time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), 3500, true},
+	}
+	for _, tt := range tests {
+		prices := types.NewMarginalPriceData(tt.date)
+		prices.SpainPrices[1] = tt.price
+		violations := MarketPriceBounds().Check(prices)
+		if valid := len(violations) == 0; valid != tt.valid {
+			t.Errorf("%s at %.2f EUR/MWh: expected valid=%v, got %v", tt.date.Format("2006-01-02"), tt.price, tt.valid, violations)
+		}
+	}
+
+	if limits := PriceLimitsOn(time.Date(2014, 5, 13, 0, 0, 0, 0, time.UTC)); limits.Max != 3000 || limits.Min != -500 {
+		t.Errorf("Expected the coupled market limits from 2014-05-13, got %+v", limits)
+	}
+}